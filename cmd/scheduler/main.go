@@ -2,19 +2,28 @@ package main
 
 import (
 	"os"
-	"log"
+
+	"github.com/spf13/cobra"
 	"k8s.io/component-base/cli"
+	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/cmd/kube-scheduler/app"
 	"my-scheduler-plugins/pkg/plugins"
 )
 
+// options register the out-of-tree plugins with the scheduler.
+var options = []app.Option{
+	app.WithPlugin(plugins.Register()),
+}
+
+// newSchedulerCommand returns the kube-scheduler command with the plugins
+// registered.
+func newSchedulerCommand() *cobra.Command {
+	return app.NewSchedulerCommand(options...)
+}
+
 func main() {
 	// Register custom plugins to the scheduler framework.
-	log.Printf("custom-scheduler starts!\n")
-	command := app.NewSchedulerCommand(
-		app.WithPlugin(plugins.Name, plugins.New),
-	)
-
-	code := cli.Run(command)
+	klog.InfoS("custom-scheduler starts")
+	code := cli.Run(newSchedulerCommand())
 	os.Exit(code)
 }
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+
+	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+	"my-scheduler-plugins/pkg/plugins"
+)
+
+func TestNewSchedulerCommand(t *testing.T) {
+	command := newSchedulerCommand()
+	if err := command.ParseFlags([]string{"--config=/etc/kubernetes/scheduler-config.yaml", "--v=4", "--leader-elect=false"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value := command.Flags().Lookup("config").Value.String(); value != "/etc/kubernetes/scheduler-config.yaml" {
+		t.Errorf("expected the config flag to be parsed, got %q", value)
+	}
+
+	registry := frameworkruntime.Registry{}
+	for _, option := range options {
+		if err := option(registry); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, ok := registry[plugins.Name]; !ok {
+		t.Errorf("expected %s in the registry, got %v", plugins.Name, registry)
+	}
+}
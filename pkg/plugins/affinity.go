@@ -0,0 +1,39 @@
+package plugins
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// addGroupAffinity blends the normalized scores with a bonus for the members
+// of the pod's group already on each node, weighted by groupAffinityWeight.
+// With spreadGroup the members are a penalty instead. Nodes the pod doesn't
+// fit on keep the minimum score.
+func (cs *CustomScheduler) addGroupAffinity(scores framework.NodeScoreList, unfit map[string]bool, memberNodes map[string]int) {
+	for i := range scores {
+		if unfit[scores[i].Name] {
+			continue
+		}
+		affinity := int64(memberNodes[scores[i].Name]) * cs.groupAffinityBonus
+		if affinity > framework.MaxNodeScore {
+			affinity = framework.MaxNodeScore
+		}
+		if cs.spreadGroup {
+			affinity = framework.MaxNodeScore - affinity
+		}
+		scores[i].Score = (scores[i].Score*(100-cs.groupAffinityWeight) + affinity*cs.groupAffinityWeight) / 100
+	}
+}
+
+// countNodeMembers returns the number of other live members of the pod's
+// group on the node, including assumed ones.
+func (cs *CustomScheduler) countNodeMembers(pod *v1.Pod, group string, nodeInfo *framework.NodeInfo) int {
+	count := 0
+	for _, podInfo := range nodeInfo.Pods {
+		p := podInfo.Pod
+		if isActivePod(p) && podKey(p) != podKey(pod) && cs.inGroup(p, pod.Namespace, group) {
+			count++
+		}
+	}
+	return count
+}
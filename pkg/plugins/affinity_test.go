@@ -0,0 +1,69 @@
+package plugins
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_Score_GroupAffinity(t *testing.T) {
+	makePod := func(name, group string) *v1.Pod {
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{}}}
+		if group != "" {
+			pod.Labels["podGroup"] = group
+		}
+		return pod
+	}
+	// both nodes have the same memory left: node1 runs a member of g1 and
+	// node2 a pod of another group
+	newNodeInfos := func() []*framework.NodeInfo {
+		node1, node2 := makeNodeInfo("node1", 1000, 1<<30), makeNodeInfo("node2", 1000, 2<<30)
+		node1.AddPod(makePod("member", "g1"))
+		node2.AddPod(makePod("other", "g2"))
+		node2.Requested.Memory = 1 << 30
+		return []*framework.NodeInfo{node1, node2}
+	}
+	tests := []struct {
+		name   string
+		pod    *v1.Pod
+		weight int64
+		want   map[string]int64
+	}{
+		{
+			name: "affinity is disabled",
+			pod:  makePod("incoming", "g1"),
+			want: map[string]int64{"node1": 100, "node2": 100},
+		},
+		{
+			name:   "members attract the pod",
+			pod:    makePod("incoming", "g1"),
+			weight: 50,
+			want:   map[string]int64{"node1": 55, "node2": 50},
+		},
+		{
+			name:   "pod outside of a group",
+			pod:    makePod("incoming", ""),
+			weight: 50,
+			want:   map[string]int64{"node1": 50, "node2": 50},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeInfos := newNodeInfos()
+			cs := &CustomScheduler{
+				handle:              newTestFrameworkWithNodes(t, nil, nodeInfos),
+				scoreMode:           mostMode,
+				groupLabelKey:       groupNameLabel,
+				groupAffinityWeight: tt.weight,
+				groupAffinityBonus:  10,
+			}
+			for _, score := range scoreNodes(t, cs, tt.pod, nodeInfos) {
+				if score.Score != tt.want[score.Name] {
+					t.Errorf("expected node %s to score %d, got %d", score.Name, tt.want[score.Name], score.Score)
+				}
+			}
+		})
+	}
+}
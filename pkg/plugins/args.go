@@ -0,0 +1,393 @@
+package plugins
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+	v1helper "k8s.io/kubernetes/pkg/apis/core/v1/helper"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"sigs.k8s.io/yaml"
+)
+
+// SchemeGroupVersion is the group and version CustomSchedulerArgs are
+// registered under.
+var SchemeGroupVersion = schema.GroupVersion{Group: "scheduler.nthu.io", Version: "v1"}
+
+var (
+	// SchemeBuilder registers CustomSchedulerArgs and their defaults.
+	SchemeBuilder = runtime.NewSchemeBuilder(addKnownTypes, addDefaultingFuncs)
+	// AddToScheme adds CustomSchedulerArgs to a scheme.
+	AddToScheme = SchemeBuilder.AddToScheme
+
+	scheme = runtime.NewScheme()
+)
+
+func init() {
+	utilruntime.Must(AddToScheme(scheme))
+}
+
+func addKnownTypes(s *runtime.Scheme) error {
+	s.AddKnownTypes(SchemeGroupVersion, &CustomSchedulerArgs{})
+	return nil
+}
+
+func addDefaultingFuncs(s *runtime.Scheme) error {
+	s.AddTypeDefaultingFunc(&CustomSchedulerArgs{}, func(obj interface{}) {
+		SetDefaultsCustomSchedulerArgs(obj.(*CustomSchedulerArgs))
+	})
+	return nil
+}
+
+// SetDefaultsCustomSchedulerArgs fills in the unset arguments and turns the
+// mode into its canonical name.
+func SetDefaultsCustomSchedulerArgs(args *CustomSchedulerArgs) {
+	if args.Mode == "" {
+		klog.V(2).InfoS("No mode given, using the default", "mode", leastMode)
+		args.Mode = leastMode
+	}
+	args.Mode = canonicalScoreMode(args.Mode)
+	if args.DryRunMode != "" {
+		args.DryRunMode = canonicalScoreMode(args.DryRunMode)
+	}
+	for class, mode := range args.ModeByQoS {
+		args.ModeByQoS[class] = canonicalScoreMode(mode)
+	}
+	for i := range args.ScoreComponents {
+		args.ScoreComponents[i].Name = canonicalComponentName(args.ScoreComponents[i].Name)
+	}
+	if args.PermitWaitingTimeSeconds == 0 {
+		args.PermitWaitingTimeSeconds = defaultPermitWaitingTimeSeconds
+	}
+	if args.GroupBackoffSeconds == 0 {
+		args.GroupBackoffSeconds = defaultGroupBackoffSeconds
+	}
+	if args.BestEffortGraceSeconds == 0 {
+		args.BestEffortGraceSeconds = defaultBestEffortGraceSeconds
+	}
+	if args.CacheSyncTimeoutSeconds == nil {
+		timeout := defaultCacheSyncTimeoutSeconds
+		args.CacheSyncTimeoutSeconds = &timeout
+	}
+	if args.GroupLabelKey == "" {
+		args.GroupLabelKey = groupNameLabel
+	}
+	if args.MinAvailableLabelKey == "" {
+		args.MinAvailableLabelKey = minAvailableLabel
+	}
+	if args.MinAvailableAnnotationKey == "" {
+		args.MinAvailableAnnotationKey = minAvailableAnnotation
+	}
+	if args.MaxAvailableLabelKey == "" {
+		args.MaxAvailableLabelKey = maxAvailableLabel
+	}
+	if args.MaxMinAvailable == 0 {
+		args.MaxMinAvailable = defaultMaxMinAvailable
+	}
+	if args.GangCountPolicy == "" {
+		args.GangCountPolicy = gangCountCreated
+	}
+	if args.ConflictPolicy == "" {
+		args.ConflictPolicy = conflictReject
+	}
+	if args.DefaultMemoryRequest == "" {
+		args.DefaultMemoryRequest = defaultMemoryRequestValue
+	}
+	if args.DomainTopologyKey == "" {
+		args.DomainTopologyKey = v1.LabelTopologyZone
+	}
+	if args.GroupAffinityBonus == 0 {
+		args.GroupAffinityBonus = defaultGroupAffinityBonus
+	}
+	if len(args.Resources) == 0 {
+		args.Resources = append([]ResourceSpec(nil), defaultResources...)
+	}
+	if args.UsageRefreshSeconds == 0 {
+		args.UsageRefreshSeconds = defaultUsageRefreshSeconds
+	}
+	if args.UsageStaleSeconds == 0 {
+		args.UsageStaleSeconds = defaultUsageStaleSeconds
+	}
+	if args.MemoryPressurePenalty == nil {
+		penalty := defaultMemoryPressurePenalty
+		args.MemoryPressurePenalty = &penalty
+	}
+	if args.DiskPressurePenalty == nil {
+		penalty := defaultDiskPressurePenalty
+		args.DiskPressurePenalty = &penalty
+	}
+	if args.CapacityPolicy == "" {
+		args.CapacityPolicy = capacityRequests
+	}
+	if args.ScoreBasis == "" {
+		args.ScoreBasis = basisAbsolute
+	}
+	if args.NormalizationStrategy == "" {
+		args.NormalizationStrategy = normalizeMinMax
+	}
+	if args.EnableGangFilter == nil {
+		enabled := true
+		args.EnableGangFilter = &enabled
+	}
+	if args.EnableScoring == nil {
+		enabled := true
+		args.EnableScoring = &enabled
+	}
+	if args.ConfigMapRef != nil && args.ConfigMapRef.Key == "" {
+		args.ConfigMapRef.Key = defaultConfigMapKey
+	}
+}
+
+// DefaultArgs returns the arguments the plugin runs with when none are given.
+func DefaultArgs() *CustomSchedulerArgs {
+	args := &CustomSchedulerArgs{}
+	SetDefaultsCustomSchedulerArgs(args)
+	return args
+}
+
+// ValidateCustomSchedulerArgs checks defaulted arguments.
+func ValidateCustomSchedulerArgs(args *CustomSchedulerArgs) error {
+	if err := validateMode(args.Mode, args); err != nil {
+		return fmt.Errorf("invalid mode, %v", err)
+	}
+	for class, mode := range args.ModeByQoS {
+		switch v1.PodQOSClass(class) {
+		case v1.PodQOSGuaranteed, v1.PodQOSBurstable, v1.PodQOSBestEffort:
+		default:
+			return fmt.Errorf("invalid modeByQoS, unknown QoS class %s", class)
+		}
+		if err := validateMode(mode, args); err != nil {
+			return fmt.Errorf("invalid modeByQoS for %s, %v", class, err)
+		}
+	}
+	if err := validateShape(args.Shape); err != nil {
+		return fmt.Errorf("invalid shape, %w", err)
+	}
+	if err := validateScoreComponents(args.ScoreComponents); err != nil {
+		return fmt.Errorf("invalid scoreComponents, %w", err)
+	}
+	if m := args.DryRunMode; m != "" && m != leastMode && m != mostMode {
+		return fmt.Errorf("invalid dryRunMode, got %s", m)
+	}
+	if args.PermitWaitingTimeSeconds < 0 {
+		return fmt.Errorf("invalid permitWaitingTimeSeconds, got %d", args.PermitWaitingTimeSeconds)
+	}
+	if args.GroupBackoffSeconds < 0 {
+		return fmt.Errorf("invalid groupBackoffSeconds, got %d", args.GroupBackoffSeconds)
+	}
+	if args.MaxGroupAttempts < 0 {
+		return fmt.Errorf("invalid maxGroupAttempts, got %d", args.MaxGroupAttempts)
+	}
+	if args.MaxConcurrentGroupsPerNamespace < 0 {
+		return fmt.Errorf("invalid maxConcurrentGroupsPerNamespace, got %d", args.MaxConcurrentGroupsPerNamespace)
+	}
+	if args.MaxConcurrentReleasingGroups < 0 {
+		return fmt.Errorf("invalid maxConcurrentReleasingGroups, got %d", args.MaxConcurrentReleasingGroups)
+	}
+	if args.BestEffortGraceSeconds < 0 {
+		return fmt.Errorf("invalid bestEffortGraceSeconds, got %d", args.BestEffortGraceSeconds)
+	}
+	if *args.CacheSyncTimeoutSeconds < 0 {
+		return fmt.Errorf("invalid cacheSyncTimeoutSeconds, got %d", *args.CacheSyncTimeoutSeconds)
+	}
+	if args.MaxMinAvailable < 0 {
+		return fmt.Errorf("invalid maxMinAvailable, got %d", args.MaxMinAvailable)
+	}
+	if args.GangCountPolicy != gangCountCreated && args.GangCountPolicy != gangCountAssigned {
+		return fmt.Errorf("invalid gangCountPolicy, got %s", args.GangCountPolicy)
+	}
+	if args.GangTimeoutSeconds < 0 {
+		return fmt.Errorf("invalid gangTimeoutSeconds, got %d", args.GangTimeoutSeconds)
+	}
+	if p := args.ConflictPolicy; p != conflictReject && p != conflictMax && p != conflictMin {
+		return fmt.Errorf("invalid conflictPolicy, got %s", p)
+	}
+	if args.ResourceName != "" {
+		resourceName := v1.ResourceName(args.ResourceName)
+		if !v1helper.IsExtendedResourceName(resourceName) && !v1helper.IsHugePageResourceName(resourceName) {
+			return fmt.Errorf("invalid resourceName, got %s", resourceName)
+		}
+		if args.Mode != leastMode && args.Mode != mostMode {
+			return fmt.Errorf("invalid resourceName, mode %s doesn't score on it", args.Mode)
+		}
+	}
+	if quantity, err := resource.ParseQuantity(args.DefaultMemoryRequest); err != nil || quantity.Sign() < 0 {
+		return fmt.Errorf("invalid defaultMemoryRequest, got %s", args.DefaultMemoryRequest)
+	}
+	if args.GroupAffinityWeight < 0 || args.GroupAffinityWeight > 100 {
+		return fmt.Errorf("invalid groupAffinityWeight, got %d", args.GroupAffinityWeight)
+	}
+	if args.GroupAffinityBonus < 0 {
+		return fmt.Errorf("invalid groupAffinityBonus, got %d", args.GroupAffinityBonus)
+	}
+	seen := sets.New[string]()
+	for _, r := range args.Resources {
+		if r.Name == "" || seen.Has(r.Name) {
+			return fmt.Errorf("invalid resources, got name %q", r.Name)
+		}
+		if r.Weight <= 0 {
+			return fmt.Errorf("invalid resources, got weight %d for %s", r.Weight, r.Name)
+		}
+		seen.Insert(r.Name)
+	}
+	if p := *args.MemoryPressurePenalty; p < 0 || p > framework.MaxNodeScore {
+		return fmt.Errorf("invalid memoryPressurePenalty, got %d", p)
+	}
+	if p := *args.DiskPressurePenalty; p < 0 || p > framework.MaxNodeScore {
+		return fmt.Errorf("invalid diskPressurePenalty, got %d", p)
+	}
+	if args.CapacityPolicy != capacityRequests && args.CapacityPolicy != capacityLimits {
+		return fmt.Errorf("invalid capacityPolicy, got %s", args.CapacityPolicy)
+	}
+	if args.ScoreBasis != basisAbsolute && args.ScoreBasis != basisFraction {
+		return fmt.Errorf("invalid scoreBasis, got %s", args.ScoreBasis)
+	}
+	switch args.NormalizationStrategy {
+	case normalizeMinMax, normalizeZScore, normalizePercentile:
+	default:
+		return fmt.Errorf("invalid normalizationStrategy, got %s", args.NormalizationStrategy)
+	}
+	if args.UsageRefreshSeconds < 0 {
+		return fmt.Errorf("invalid usageRefreshSeconds, got %d", args.UsageRefreshSeconds)
+	}
+	if args.UsageStaleSeconds < 0 {
+		return fmt.Errorf("invalid usageStaleSeconds, got %d", args.UsageStaleSeconds)
+	}
+	if args.SpreadGroup && args.GroupAffinityWeight == 0 {
+		return fmt.Errorf("invalid groupAffinityWeight, spreadGroup needs a positive weight")
+	}
+	if !*args.EnableGangFilter && !*args.EnableScoring {
+		return fmt.Errorf("invalid args, enableGangFilter and enableScoring can't both be false")
+	}
+	if _, _, err := parseMemorySafetyMargin(args.MemorySafetyMargin); err != nil {
+		return fmt.Errorf("invalid memorySafetyMargin, %w", err)
+	}
+	if args.NodeHeadroomBytes < 0 {
+		return fmt.Errorf("invalid nodeHeadroomBytes, got %d", args.NodeHeadroomBytes)
+	}
+	if ref := args.ConfigMapRef; ref != nil && (ref.Namespace == "" || ref.Name == "") {
+		return fmt.Errorf("invalid configMapRef, namespace and name are required, got %q and %q", ref.Namespace, ref.Name)
+	}
+	if len(args.TierBonus) > 0 && args.TierLabelKey == "" {
+		return fmt.Errorf("invalid tierBonus, tierLabelKey is required")
+	}
+	for tier, bonus := range args.TierBonus {
+		if bonus < 0 || bonus > framework.MaxNodeScore {
+			return fmt.Errorf("invalid tierBonus for tier %q, must be between 0 and %d, got %d", tier, framework.MaxNodeScore, bonus)
+		}
+	}
+	if args.TierLabelKey != "" {
+		if errs := validation.IsQualifiedName(args.TierLabelKey); len(errs) != 0 {
+			return fmt.Errorf("invalid key %q: %s", args.TierLabelKey, strings.Join(errs, "; "))
+		}
+	}
+	for _, key := range []string{args.GroupLabelKey, args.MinAvailableLabelKey, args.MinAvailableAnnotationKey, args.MaxAvailableLabelKey, args.DomainTopologyKey} {
+		if errs := validation.IsQualifiedName(key); len(errs) != 0 {
+			return fmt.Errorf("invalid key %q: %s", key, strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+// validateMode checks that the mode is known and that the arguments it needs
+// are set.
+func validateMode(mode string, args *CustomSchedulerArgs) error {
+	switch {
+	case mode == randomMode && !args.AllowRandomMode:
+		return fmt.Errorf("%s needs allowRandomMode", mode)
+	case !isScoreMode(mode) && mode != randomMode:
+		return fmt.Errorf("got %s", mode)
+	case mode == shapedMode && len(args.Shape) == 0:
+		return fmt.Errorf("%s needs a shape", mode)
+	case mode == compositeMode && totalWeight(args.ScoreComponents) == 0:
+		return fmt.Errorf("%s needs scoreComponents", mode)
+	}
+	return nil
+}
+
+// decodeArgs returns the defaulted arguments of the plugin. They are either
+// typed, or JSON or YAML the scheduler doesn't know the type of, which is
+// decoded strictly so that misspelled fields are reported.
+func decodeArgs(obj runtime.Object) (*CustomSchedulerArgs, error) {
+	var args *CustomSchedulerArgs
+	switch o := obj.(type) {
+	case nil:
+		klog.V(2).InfoS("No args given, using the defaults")
+		args = &CustomSchedulerArgs{}
+	case *CustomSchedulerArgs:
+		args = o.DeepCopy()
+	case *runtime.Unknown:
+		args = &CustomSchedulerArgs{}
+		if err := yaml.UnmarshalStrict(o.Raw, args); err != nil {
+			return nil, fmt.Errorf("invalid args of %d bytes: %w", len(o.Raw), err)
+		}
+	default:
+		return nil, fmt.Errorf("invalid args, got type %T", obj)
+	}
+	scheme.Default(args)
+	return args, nil
+}
+
+// DeepCopyInto copies the arguments into out.
+func (in *CustomSchedulerArgs) DeepCopyInto(out *CustomSchedulerArgs) {
+	*out = *in
+	if in.Resources != nil {
+		out.Resources = append([]ResourceSpec(nil), in.Resources...)
+	}
+	if in.Shape != nil {
+		out.Shape = append([]ShapePoint(nil), in.Shape...)
+	}
+	if in.ScoreComponents != nil {
+		out.ScoreComponents = append([]ScoreComponent(nil), in.ScoreComponents...)
+	}
+	for _, p := range []**int64{&out.MemoryPressurePenalty, &out.DiskPressurePenalty, &out.RandomSeed, &out.CacheSyncTimeoutSeconds} {
+		if *p != nil {
+			value := **p
+			*p = &value
+		}
+	}
+	for _, p := range []**bool{&out.EnableGangFilter, &out.EnableScoring} {
+		if *p != nil {
+			value := **p
+			*p = &value
+		}
+	}
+	if in.ConfigMapRef != nil {
+		ref := *in.ConfigMapRef
+		out.ConfigMapRef = &ref
+	}
+	if in.ModeByQoS != nil {
+		out.ModeByQoS = make(map[string]string, len(in.ModeByQoS))
+		for class, mode := range in.ModeByQoS {
+			out.ModeByQoS[class] = mode
+		}
+	}
+	if in.TierBonus != nil {
+		out.TierBonus = make(map[string]int64, len(in.TierBonus))
+		for tier, bonus := range in.TierBonus {
+			out.TierBonus[tier] = bonus
+		}
+	}
+}
+
+// DeepCopy returns a copy of the arguments.
+func (in *CustomSchedulerArgs) DeepCopy() *CustomSchedulerArgs {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomSchedulerArgs)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject implements runtime.Object.
+func (in *CustomSchedulerArgs) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
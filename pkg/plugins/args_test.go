@@ -0,0 +1,186 @@
+package plugins
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestNew_ArgsEncodings(t *testing.T) {
+	tests := []struct {
+		name     string
+		obj      runtime.Object
+		wantMode string
+		// wantErr is part of the expected error, if any
+		wantErr string
+	}{
+		{
+			name:     "typed args",
+			obj:      &CustomSchedulerArgs{Mode: mostMode, ConflictPolicy: conflictMax},
+			wantMode: mostMode,
+		},
+		{
+			name:     "raw JSON",
+			obj:      &runtime.Unknown{Raw: []byte(`{"mode": "Most", "conflictPolicy": "Max"}`)},
+			wantMode: mostMode,
+		},
+		{
+			name:     "raw YAML",
+			obj:      &runtime.Unknown{Raw: []byte("mode: Most\nconflictPolicy: Max\n")},
+			wantMode: mostMode,
+		},
+		{
+			name:     "raw JSON with type meta",
+			obj:      &runtime.Unknown{Raw: []byte(`{"apiVersion": "scheduler.nthu.io/v1", "kind": "CustomSchedulerArgs", "mode": "Most", "conflictPolicy": "Max"}`)},
+			wantMode: mostMode,
+		},
+		{
+			name:    "misspelled field",
+			obj:     &runtime.Unknown{Raw: []byte(`{"moed": "Most"}`)},
+			wantErr: `unknown field "moed"`,
+		},
+		{
+			name:    "malformed JSON",
+			obj:     &runtime.Unknown{Raw: []byte(`{"mode": "Most"`)},
+			wantErr: "invalid args",
+		},
+		{
+			name:    "field of the wrong type",
+			obj:     &runtime.Unknown{Raw: []byte(`{"mode": "Most", "permitWaitingTimeSeconds": "60"}`)},
+			wantErr: "invalid args",
+		},
+		{
+			name:    "invalid typed args",
+			obj:     &CustomSchedulerArgs{Mode: mostMode, ConflictPolicy: "Avg"},
+			wantErr: "invalid conflictPolicy",
+		},
+		{
+			name:    "unexpected type",
+			obj:     &v1.Pod{},
+			wantErr: "invalid args, got type",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.obj, newTestFrameworkWithPods(t, nil))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			cs := p.(*CustomScheduler)
+			if cs.scoreMode != tt.wantMode || cs.conflictPolicy != conflictMax {
+				t.Errorf("expected mode %s and conflict policy %s, got %s and %s", tt.wantMode, conflictMax, cs.scoreMode, cs.conflictPolicy)
+			}
+			// the other arguments are defaulted
+			if cs.permitWaitingTime.Seconds() != float64(defaultPermitWaitingTimeSeconds) || cs.groupLabelKey != groupNameLabel {
+				t.Errorf("expected the defaults, got a waiting time of %v and group label %q", cs.permitWaitingTime, cs.groupLabelKey)
+			}
+		})
+	}
+}
+
+func TestNew_TypedArgsNotModified(t *testing.T) {
+	args := &CustomSchedulerArgs{Mode: mostMode}
+	if _, err := New(args, newTestFrameworkWithPods(t, nil)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if args.GroupLabelKey != "" || args.MemoryPressurePenalty != nil {
+		t.Errorf("expected the configured args to stay as they are, got %+v", args)
+	}
+}
+
+func TestAddToScheme(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := AddToScheme(s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	gvks, _, err := s.ObjectKinds(&CustomSchedulerArgs{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(gvks) != 1 || gvks[0] != SchemeGroupVersion.WithKind("CustomSchedulerArgs") {
+		t.Errorf("expected the args to be registered as %v, got %v", SchemeGroupVersion.WithKind("CustomSchedulerArgs"), gvks)
+	}
+	args := &CustomSchedulerArgs{Mode: mostMode}
+	s.Default(args)
+	if args.DiskPressurePenalty == nil || *args.DiskPressurePenalty != defaultDiskPressurePenalty {
+		t.Errorf("expected the scheme to default the args, got %+v", args)
+	}
+}
+
+func TestNew_ArgsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  runtime.Object
+		// wantErr is part of the expected error, if any
+		wantErr string
+		// wantWrapped is set when the decode error is wrapped
+		wantWrapped bool
+	}{
+		{
+			name: "nil args",
+		},
+		{
+			name: "valid args",
+			obj:  &runtime.Unknown{Raw: []byte(`{"mode": "Most"}`)},
+		},
+		{
+			name:        "invalid JSON",
+			obj:         &runtime.Unknown{Raw: []byte(`{"mode": "Most"`)},
+			wantErr:     "invalid args of 15 bytes",
+			wantWrapped: true,
+		},
+		{
+			name:    "invalid mode",
+			obj:     &runtime.Unknown{Raw: []byte(`{"mode": "Fastest"}`)},
+			wantErr: "invalid mode, got Fastest",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.obj, newTestFrameworkWithPods(t, nil))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if wrapped := errors.Unwrap(err) != nil; wrapped != tt.wantWrapped {
+				t.Errorf("expected the error to be wrapped: %v, got %v", tt.wantWrapped, err)
+			}
+		})
+	}
+}
+
+func TestDefaultArgs(t *testing.T) {
+	args := DefaultArgs()
+	if err := ValidateCustomSchedulerArgs(args); err != nil {
+		t.Fatalf("expected the defaults to be valid, got %v", err)
+	}
+	if args.Mode != leastMode || args.PermitWaitingTimeSeconds != defaultPermitWaitingTimeSeconds || args.DefaultMemoryRequest != defaultMemoryRequestValue {
+		t.Errorf("expected the default mode, waiting time and memory request, got %+v", args)
+	}
+
+	// New without args and with an empty mode uses the same arguments
+	for _, obj := range []runtime.Object{nil, &runtime.Unknown{Raw: []byte(`{"mode": ""}`)}} {
+		p, err := New(obj, newTestFrameworkWithPods(t, nil))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		cs := p.(*CustomScheduler)
+		if cs.scoreMode != args.Mode || cs.memoryPressurePenalty != *args.MemoryPressurePenalty || cs.conflictPolicy != args.ConflictPolicy {
+			t.Errorf("expected New(%v) to run with the default args, got mode %s", obj, cs.scoreMode)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package plugins
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// assumedMemberTTL bounds how long a reserved member is remembered. It only
+// matters when neither Unreserve nor the pod's deletion clears the entry.
+const assumedMemberTTL = 5 * time.Minute
+
+// assumedMember is a member of a group that this scheduler reserved on a node
+// but that the pod informer may not show bound yet.
+type assumedMember struct {
+	key      string
+	nodeName string
+	expires  time.Time
+}
+
+// assume records the pod as reserved on the node.
+func (cs *CustomScheduler) assume(key string, pod *v1.Pod, nodeName string) {
+	cs.updateGroup(key, func(gs *groupState) {
+		if gs.assumed == nil {
+			gs.assumed = make(map[types.UID]assumedMember)
+		}
+		gs.assumed[pod.UID] = assumedMember{
+			key:      podKey(pod),
+			nodeName: nodeName,
+			expires:  cs.now().Add(assumedMemberTTL),
+		}
+	})
+}
+
+// forget drops the pod from the reserved members of the group.
+func (cs *CustomScheduler) forget(key string, uid types.UID) {
+	cs.updateGroup(key, func(gs *groupState) {
+		delete(gs.assumed, uid)
+	})
+}
+
+// assumedMembers returns the reserved members of the group that haven't
+// expired, dropping the expired ones on the way.
+func (cs *CustomScheduler) assumedMembers(key string) map[types.UID]assumedMember {
+	members := make(map[types.UID]assumedMember)
+	cs.updateGroup(key, func(gs *groupState) {
+		now := cs.now()
+		for uid, member := range gs.assumed {
+			if !member.expires.After(now) {
+				delete(gs.assumed, uid)
+				continue
+			}
+			members[uid] = member
+		}
+	})
+	return members
+}
+
+// hasAssumed reports whether any reserved member of the group hasn't expired.
+func (gs *groupState) hasAssumed(now time.Time) bool {
+	for _, member := range gs.assumed {
+		if member.expires.After(now) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package plugins
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// balancedScore prefers the nodes whose CPU and memory utilization, with the
+// pod's requests placed on them, are closest to each other.
+func balancedScore(requests v1.ResourceList, nodeInfo *framework.NodeInfo) int64 {
+	cpu := utilization(nodeInfo.Requested.MilliCPU+requests.Cpu().MilliValue(), nodeInfo.Allocatable.MilliCPU)
+	memory := utilization(nodeInfo.Requested.Memory+requests.Memory().Value(), nodeInfo.Allocatable.Memory)
+
+	// the standard deviation of two fractions is half their distance
+	std := (cpu - memory) / 2
+	if std < 0 {
+		std = -std
+	}
+	return int64((1 - std) * float64(framework.MaxNodeScore))
+}
+
+// utilization returns the requested fraction of a resource, capped at 1. A
+// node without any of the resource counts as fully used.
+func utilization(requested, allocatable int64) float64 {
+	if allocatable <= 0 || requested >= allocatable {
+		return 1
+	}
+	return float64(requested) / float64(allocatable)
+}
+
+// balancedSubScorer scores the nodes in the Balanced mode.
+type balancedSubScorer struct{}
+
+func (balancedSubScorer) subScore(s *preScoreState, nodeInfo *framework.NodeInfo) int64 {
+	return balancedScore(s.requests, nodeInfo)
+}
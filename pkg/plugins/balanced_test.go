@@ -0,0 +1,88 @@
+package plugins
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_Score_Balanced(t *testing.T) {
+	makePod := func(name, cpu, memory string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: v1.PodSpec{Containers: []v1.Container{{
+				Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse(cpu),
+					v1.ResourceMemory: resource.MustParse(memory),
+				}},
+			}}},
+		}
+	}
+	makeNode := func(name string, milliCPU, memory int64, pods ...*v1.Pod) *framework.NodeInfo {
+		nodeInfo := makeNodeInfo(name, milliCPU, memory)
+		for _, p := range pods {
+			nodeInfo.AddPod(p)
+		}
+		return nodeInfo
+	}
+
+	tests := []struct {
+		name      string
+		pod       *v1.Pod
+		nodeInfos []*framework.NodeInfo
+		want      map[string]int64
+	}{
+		{
+			name: "evenly used node beats a skewed one",
+			pod:  makePod("incoming", "1", "1Gi"),
+			nodeInfos: []*framework.NodeInfo{
+				// 50% CPU and 50% memory with the pod
+				makeNode("even", 4000, 4<<30, makePod("p0", "1", "1Gi")),
+				// 50% CPU but 100% memory with the pod
+				makeNode("memory-bound", 4000, 2<<30, makePod("p1", "1", "1Gi")),
+			},
+			want: map[string]int64{"even": 100, "memory-bound": 75},
+		},
+		{
+			name: "pod evens out a skewed node",
+			pod:  makePod("incoming", "2", "0"),
+			nodeInfos: []*framework.NodeInfo{
+				// 75% CPU and 75% memory with the pod
+				makeNode("cpu-hungry", 4000, 4<<30, makePod("p0", "1", "3Gi")),
+				// 50% CPU and 0% memory with the pod
+				makeNode("empty", 4000, 4<<30),
+			},
+			want: map[string]int64{"cpu-hungry": 100, "empty": 75},
+		},
+		{
+			name: "node without allocatable CPU",
+			pod:  makePod("incoming", "0", "1Gi"),
+			nodeInfos: []*framework.NodeInfo{
+				// CPU counts as fully used, 50% memory with the pod
+				makeNode("no-cpu", 0, 2<<30),
+			},
+			want: map[string]int64{"no-cpu": 75},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:    newTestFrameworkWithNodes(t, nil, tt.nodeInfos),
+				scoreMode: balancedMode,
+			}
+			for node, want := range tt.want {
+				got, status := cs.Score(context.Background(), nil, tt.pod, node)
+				if !status.IsSuccess() {
+					t.Fatalf("unexpected error: %v", status)
+				}
+				if got != want {
+					t.Errorf("expected node %s to score %d, got %d", node, want, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package plugins
+
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// gangPolicyLabel picks how strictly the group of a pod is held to
+// minAvailable: gangPolicyStrict, the default, holds every member until the
+// group is complete, while gangPolicyBestEffort lets the members of a group
+// that stalled schedule on their own, for jobs that can start with fewer
+// pods and scale up.
+const (
+	gangPolicyLabel      string = "gangPolicy"
+	gangPolicyStrict     string = "strict"
+	gangPolicyBestEffort string = "besteffort"
+)
+
+// bestEffortReleased reports whether the pod's group is best effort and
+// didn't reach minAvailable within the grace period since its oldest member
+// was created, so that its members are no longer held back. The grace period
+// is shared by the whole group, so members created late don't get their own.
+// Groups whose creation time is unknown are never released.
+func (cs *CustomScheduler) bestEffortReleased(pod *v1.Pod, group string) bool {
+	if !strings.EqualFold(pod.Labels[gangPolicyLabel], gangPolicyBestEffort) {
+		return false
+	}
+	createdAt := cs.groupCreationTime(cs.groupKey(pod.Namespace, group), pod.Namespace, group, pod.CreationTimestamp.Time)
+	if createdAt.IsZero() {
+		return false
+	}
+	return !cs.now().Before(createdAt.Add(cs.bestEffortGrace))
+}
+
+// recordBestEffortRelease emits an event on the first member of the group
+// released on its own, explaining why the group is no longer held back.
+func (cs *CustomScheduler) recordBestEffortRelease(pod *v1.Pod, group string, members, minAvailable int) {
+	first := false
+	cs.updateGroup(cs.groupKey(pod.Namespace, group), func(gs *groupState) {
+		first = !gs.bestEffortReleased
+		gs.bestEffortReleased = true
+	})
+	if !first {
+		return
+	}
+	klog.V(2).InfoS("Best-effort group stalled, scheduling its members on their own", "pod", klog.KObj(pod), "group", group, "members", members, "minAvailable", minAvailable)
+	if cs.eventRecorder == nil {
+		return
+	}
+	cs.eventRecorder.Eventf(pod, nil, v1.EventTypeWarning, "GangDowngraded", "Scheduling",
+		"Group '%s' has %d of the %d pods it needs after %v, scheduling its members on their own", group, members, minAvailable, cs.bestEffortGrace)
+}
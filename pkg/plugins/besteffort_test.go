@@ -0,0 +1,103 @@
+package plugins
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+func TestCustomScheduler_BestEffortGang(t *testing.T) {
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	const grace = 5 * time.Minute
+	makePod := func(name, policy string, createdAt time.Time) *v1.Pod {
+		p := makeGangPod(name, "g1", 4)
+		p.CreationTimestamp = metav1.NewTime(createdAt)
+		if policy != "" {
+			p.Labels[gangPolicyLabel] = policy
+		}
+		return p
+	}
+	tests := []struct {
+		name    string
+		policy  string
+		elapsed time.Duration
+		want    framework.Code
+	}{
+		{name: "strict by default", elapsed: time.Hour, want: framework.Unschedulable},
+		{name: "strict", policy: gangPolicyStrict, elapsed: time.Hour, want: framework.Unschedulable},
+		{name: "best effort within the grace period", policy: gangPolicyBestEffort, elapsed: grace - time.Second, want: framework.Unschedulable},
+		{name: "best effort at the end of the grace period", policy: gangPolicyBestEffort, elapsed: grace, want: framework.Success},
+		{name: "best effort after the grace period", policy: "BestEffort", elapsed: grace + time.Minute, want: framework.Success},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the second member is created late, but the grace period runs
+			// from the creation of the first
+			first := makePod("pod1", tt.policy, created)
+			late := makePod("pod2", tt.policy, created.Add(grace-10*time.Second))
+			fakeClock := testingclock.NewFakeClock(created.Add(tt.elapsed))
+			recorder := events.NewFakeRecorder(10)
+			cs := &CustomScheduler{
+				handle:               newTestFrameworkWithPods(t, []*v1.Pod{first, late}),
+				groupLabelKey:        groupNameLabel,
+				minAvailableLabelKey: minAvailableLabel,
+				groups:               make(map[string]*groupState),
+				clock:                fakeClock,
+				eventRecorder:        recorder,
+				bestEffortGrace:      grace,
+			}
+			_, status := cs.PreFilter(context.Background(), framework.NewCycleState(), late)
+			if status.Code() != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, status)
+			}
+			if tt.want != framework.Success {
+				return
+			}
+
+			// the downgrade is explained once, on the first released member
+			if _, status := cs.PreFilter(context.Background(), framework.NewCycleState(), first); !status.IsSuccess() {
+				t.Errorf("expected the other member to be released too, got %v", status)
+			}
+			downgrades := 0
+			for len(recorder.Events) > 0 {
+				if event := <-recorder.Events; strings.Contains(event, "GangDowngraded") {
+					downgrades++
+				}
+			}
+			if downgrades != 1 {
+				t.Errorf("expected 1 GangDowngraded event, got %d", downgrades)
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_Permit_BestEffortGang(t *testing.T) {
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	pod := makeGangPod("pod1", "g1", 3)
+	pod.CreationTimestamp = metav1.NewTime(created)
+	pod.Labels[gangPolicyLabel] = gangPolicyBestEffort
+	fakeClock := testingclock.NewFakeClock(created.Add(time.Minute))
+	cs := &CustomScheduler{
+		handle:               newTestFrameworkWithPods(t, []*v1.Pod{pod}),
+		groupLabelKey:        groupNameLabel,
+		minAvailableLabelKey: minAvailableLabel,
+		groups:               make(map[string]*groupState),
+		clock:                fakeClock,
+		permitWaitingTime:    time.Minute,
+		bestEffortGrace:      5 * time.Minute,
+	}
+	if status, _ := cs.Permit(context.Background(), framework.NewCycleState(), pod, "node1"); !status.IsWait() {
+		t.Errorf("expected the pod to wait within the grace period, got %v", status)
+	}
+	fakeClock.Step(5 * time.Minute)
+	if status, _ := cs.Permit(context.Background(), framework.NewCycleState(), pod, "node1"); !status.IsSuccess() {
+		t.Errorf("expected the pod to be allowed after the grace period, got %v", status)
+	}
+}
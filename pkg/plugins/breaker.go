@@ -0,0 +1,83 @@
+package plugins
+
+import (
+	"time"
+)
+
+// maxBreakerBackoff caps how long a tripped circuit breaker holds a group.
+const maxBreakerBackoff = 30 * time.Minute
+
+// recordGroupFailure counts a failed scheduling attempt of the group and trips
+// its circuit breaker after maxGroupAttempts failures in a row. Each trip in
+// a row holds the group twice as long as the one before, starting from the
+// group backoff. It returns how long the group is held, or 0 if the breaker
+// didn't trip.
+func (cs *CustomScheduler) recordGroupFailure(namespace, group string) time.Duration {
+	if cs.maxGroupAttempts <= 0 {
+		return 0
+	}
+	var backoff time.Duration
+	var trips int
+	cs.updateGroup(cs.groupKey(namespace, group), func(gs *groupState) {
+		gs.failedAttempts++
+		if gs.failedAttempts < cs.maxGroupAttempts {
+			return
+		}
+		gs.failedAttempts = 0
+		gs.breakerTrips++
+		trips = gs.breakerTrips
+		backoff = breakerBackoff(cs.groupBackoff, trips)
+		gs.breakerOpenUntil = cs.now().Add(backoff)
+	})
+	if backoff > 0 {
+		groupBreakerTrips.WithLabelValues(cs.groupMetricLabels(namespace, group)...).Set(float64(trips))
+	}
+	return backoff
+}
+
+// breakerBackoff returns how long the breaker holds a group on its trips-th
+// trip in a row.
+func breakerBackoff(base time.Duration, trips int) time.Duration {
+	if base <= 0 {
+		base = time.Duration(defaultGroupBackoffSeconds) * time.Second
+	}
+	backoff := base
+	for i := 1; i < trips && backoff < maxBreakerBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBreakerBackoff {
+		backoff = maxBreakerBackoff
+	}
+	return backoff
+}
+
+// breakerOpenUntil returns when the tripped breaker of the group lets it be
+// scheduled again, or the zero time if the breaker isn't open.
+func (cs *CustomScheduler) breakerOpenUntil(key string) time.Time {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	gs := cs.groups[key]
+	if gs == nil || !gs.breakerOpenUntil.After(cs.now()) {
+		return time.Time{}
+	}
+	return gs.breakerOpenUntil
+}
+
+// resetBreaker closes the breaker of the group and forgets its failures.
+func (cs *CustomScheduler) resetBreaker(namespace, group string) {
+	tripped := false
+	cs.updateGroup(cs.groupKey(namespace, group), func(gs *groupState) {
+		tripped = gs.breakerTrips > 0
+		gs.resetBreaker()
+	})
+	if tripped {
+		groupBreakerTrips.WithLabelValues(cs.groupMetricLabels(namespace, group)...).Set(0)
+	}
+}
+
+func (gs *groupState) resetBreaker() {
+	gs.failedAttempts = 0
+	gs.breakerTrips = 0
+	gs.breakerOpenUntil = time.Time{}
+}
@@ -0,0 +1,131 @@
+package plugins
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/component-base/metrics/testutil"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+func TestCustomScheduler_GroupBreaker(t *testing.T) {
+	RegisterMetrics()
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	pod := makeGangPod("pod0", "g1", 1)
+	cs := &CustomScheduler{
+		handle:               newTestFrameworkWithPods(t, []*v1.Pod{pod}),
+		groupLabelKey:        groupNameLabel,
+		minAvailableLabelKey: minAvailableLabel,
+		groupBackoff:         10 * time.Second,
+		maxGroupAttempts:     2,
+		clock:                fakeClock,
+	}
+	ctx := context.Background()
+	preFilter := func() framework.Code {
+		t.Helper()
+		_, status := cs.PreFilter(ctx, nil, pod)
+		return status.Code()
+	}
+	fail := func() {
+		t.Helper()
+		cs.PostFilter(ctx, nil, pod, framework.NodeToStatusMap{"node1": framework.NewStatus(framework.Unschedulable)})
+	}
+	trips := func() float64 {
+		t.Helper()
+		value, err := testutil.GetGaugeMetricValue(groupBreakerTrips.WithLabelValues("", "g1"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return value
+	}
+
+	// a single failure only blocks the group for the group backoff
+	fail()
+	if code := preFilter(); code != framework.Unschedulable {
+		t.Fatalf("expected the group to be blocked after one failure, got %v", code)
+	}
+	fakeClock.Step(11 * time.Second)
+	if code := preFilter(); code != framework.Success {
+		t.Fatalf("expected the group to be retried after the group backoff, got %v", code)
+	}
+
+	// every trip in a row holds the group twice as long
+	for i, backoff := range []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second} {
+		fail()
+		if i > 0 {
+			fail()
+		}
+		if code := preFilter(); code != framework.UnschedulableAndUnresolvable {
+			t.Fatalf("trip %d: expected the breaker to hold the group, got %v", i+1, code)
+		}
+		if got := trips(); got != float64(i+1) {
+			t.Errorf("trip %d: expected the trips metric to be %d, got %v", i+1, i+1, got)
+		}
+		fakeClock.Step(backoff - time.Second)
+		if code := preFilter(); code != framework.UnschedulableAndUnresolvable {
+			t.Fatalf("trip %d: expected the breaker to hold the group for %v, got %v", i+1, backoff, code)
+		}
+		fakeClock.Step(2 * time.Second)
+		if code := preFilter(); code != framework.Success {
+			t.Fatalf("trip %d: expected the breaker to let the group go after %v, got %v", i+1, backoff, code)
+		}
+	}
+
+	// a new node resets the breaker
+	fail()
+	fail()
+	cs.onNodeAdd(nil)
+	if code := preFilter(); code != framework.Success {
+		t.Errorf("expected a new node to reset the breaker, got %v", code)
+	}
+	if got := trips(); got != 0 {
+		t.Errorf("expected the trips metric to be reset, got %v", got)
+	}
+
+	// so does binding a member, and the next trip starts over
+	fail()
+	fail()
+	cs.PostBind(ctx, nil, pod, "node1")
+	if got := trips(); got != 0 {
+		t.Errorf("expected a bound member to reset the breaker, got %v trips", got)
+	}
+	fail()
+	fail()
+	fakeClock.Step(11 * time.Second)
+	if code := preFilter(); code != framework.Success {
+		t.Errorf("expected the first trip after a reset to last the group backoff, got %v", code)
+	}
+
+	// attempts the plugin's own Filter rejected on some nodes don't count
+	for i := 0; i < 3; i++ {
+		cs.PostFilter(ctx, nil, pod, framework.NodeToStatusMap{
+			"node1": framework.NewStatus(framework.Unschedulable),
+			"node2": framework.NewStatus(framework.Unschedulable).WithFailedPlugin(Name),
+		})
+	}
+	fakeClock.Step(11 * time.Second)
+	if code := preFilter(); code != framework.Success {
+		t.Errorf("expected the plugin's own rejections not to trip the breaker, got %v", code)
+	}
+}
+
+func TestBreakerBackoff(t *testing.T) {
+	tests := []struct {
+		base  time.Duration
+		trips int
+		want  time.Duration
+	}{
+		{base: 30 * time.Second, trips: 1, want: 30 * time.Second},
+		{base: 30 * time.Second, trips: 3, want: 2 * time.Minute},
+		{base: 30 * time.Second, trips: 100, want: maxBreakerBackoff},
+		{base: 0, trips: 2, want: time.Duration(defaultGroupBackoffSeconds) * 2 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := breakerBackoff(tt.base, tt.trips); got != tt.want {
+			t.Errorf("breakerBackoff(%v, %d): expected %v, got %v", tt.base, tt.trips, tt.want, got)
+		}
+	}
+}
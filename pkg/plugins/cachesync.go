@@ -0,0 +1,36 @@
+package plugins
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// cacheSync tracks whether the pod informer has synced. The scheduler starts
+// the informers only after creating the plugins, so New can't wait for them.
+// Until the cache is synced, or the timeout passed, PreFilter holds gang
+// members back, since it would count their groups from a partial cache.
+type cacheSync struct {
+	hasSynced cache.InformerSynced
+	deadline  time.Time
+	// once logs when the timeout passes before the cache is synced.
+	once sync.Once
+}
+
+// podCacheSynced reports whether PreFilter may count groups from the pod
+// informer. Plugins without a tracked informer are always synced.
+func (cs *CustomScheduler) podCacheSynced() bool {
+	s := cs.podsSynced
+	if s == nil || s.hasSynced() {
+		return true
+	}
+	if cs.now().Before(s.deadline) {
+		return false
+	}
+	s.once.Do(func() {
+		klog.InfoS("Pod cache didn't sync in time, counting groups from it anyway")
+	})
+	return true
+}
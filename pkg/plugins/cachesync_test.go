@@ -0,0 +1,74 @@
+package plugins
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/component-base/metrics/testutil"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+func TestCustomScheduler_PodCacheSync(t *testing.T) {
+	pods := []*v1.Pod{makeGangPod("pod0", "g1", 2), makeGangPod("pod1", "g1", 2)}
+	// the informer of the test framework is never run, so it never syncs
+	fh := newTestFrameworkWithPods(t, pods)
+	timeout := int64(30)
+	p, err := New(&CustomSchedulerArgs{Mode: leastMode, CacheSyncTimeoutSeconds: &timeout}, fh)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cs := p.(*CustomScheduler)
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	cs.clock = fakeClock
+	cs.podsSynced.deadline = fakeClock.Now().Add(30 * time.Second)
+
+	ctx := context.Background()
+	rejections := func() float64 {
+		t.Helper()
+		value, err := testutil.GetCounterMetricValue(preFilterRejections.WithLabelValues(rejectionCacheNotSynced))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return value
+	}
+	before := rejections()
+	_, status := cs.PreFilter(ctx, nil, pods[0])
+	if status.Code() != framework.Unschedulable || status.Message() != "pod cache not synced yet" {
+		t.Fatalf("expected the pod to be retried until the cache syncs, got %v", status)
+	}
+	if got := rejections() - before; got != 1 {
+		t.Errorf("expected the rejection to be counted once, got %v", got)
+	}
+	if _, status := cs.PreFilter(ctx, nil, &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "lonely"}}); !status.IsSuccess() {
+		t.Errorf("expected pods outside of a gang to be scheduled right away, got %v", status)
+	}
+
+	// the cache syncs
+	cs.podsSynced.hasSynced = func() bool { return true }
+	if _, status := cs.PreFilter(ctx, nil, pods[0]); !status.IsSuccess() {
+		t.Errorf("expected the complete group to be admitted once the cache is synced, got %v", status)
+	}
+
+	// or it never does, and the timeout passes
+	cs.podsSynced.hasSynced = func() bool { return false }
+	fakeClock.Step(31 * time.Second)
+	if _, status := cs.PreFilter(ctx, nil, pods[0]); !status.IsSuccess() {
+		t.Errorf("expected the complete group to be admitted after the timeout, got %v", status)
+	}
+}
+
+func TestCustomScheduler_PodCacheSyncDisabled(t *testing.T) {
+	pods := []*v1.Pod{makeGangPod("pod0", "g1", 2), makeGangPod("pod1", "g1", 2)}
+	timeout := int64(0)
+	p, err := New(&CustomSchedulerArgs{Mode: leastMode, CacheSyncTimeoutSeconds: &timeout}, newTestFrameworkWithPods(t, pods))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, status := p.(*CustomScheduler).PreFilter(context.Background(), nil, pods[0]); !status.IsSuccess() {
+		t.Errorf("expected a zero timeout not to wait for the cache, got %v", status)
+	}
+}
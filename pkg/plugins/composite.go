@@ -0,0 +1,175 @@
+package plugins
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// ScoreComponent is a sub-score of the Composite mode and its weight. The
+// free modes, such as Most or LeastCPU, score the free fraction of their
+// resource, GroupLocality the members of the pod's group on the node, Tier
+// the bonus of the node's tier and ImageLocality the bytes of the pod's
+// images already on the node. A component weighing 0 is disabled.
+type ScoreComponent struct {
+	Name   string `json:"name"`
+	Weight int64  `json:"weight"`
+}
+
+const (
+	componentGroupLocality string = "GroupLocality"
+	componentTier          string = "Tier"
+)
+
+// canonicalComponentName returns the canonical name of a component, ignoring
+// case like the modes do.
+func canonicalComponentName(name string) string {
+	for _, known := range []string{componentGroupLocality, componentTier, componentImageLocality} {
+		if strings.EqualFold(name, known) {
+			return known
+		}
+	}
+	return canonicalScoreMode(name)
+}
+
+// subScorer scores a node for a score mode, or for one component of the
+// Composite mode. The scores of the components are between MinNodeScore and
+// MaxNodeScore, and so are those of the modes NormalizeScore doesn't rescale.
+type subScorer interface {
+	subScore(s *preScoreState, nodeInfo *framework.NodeInfo) int64
+}
+
+// validateScoreComponents checks that the components are known and that
+// their weights aren't negative.
+func validateScoreComponents(components []ScoreComponent) error {
+	for i, c := range components {
+		if !isScoreComponent(c.Name) {
+			return fmt.Errorf("name of component %d is unknown, got %s", i, c.Name)
+		}
+		if c.Weight < 0 {
+			return fmt.Errorf("weight of component %s must not be negative, got %d", c.Name, c.Weight)
+		}
+	}
+	return nil
+}
+
+// totalWeight sums the weights of the components. The Composite mode needs a
+// positive one.
+func totalWeight(components []ScoreComponent) int64 {
+	var total int64
+	for _, c := range components {
+		total += c.Weight
+	}
+	return total
+}
+
+// componentWeight returns the weight of the component, 0 when it isn't
+// configured.
+func (cs *CustomScheduler) componentWeight(name string) int64 {
+	var weight int64
+	for _, c := range cs.scoreComponents {
+		if c.Name == name {
+			weight += c.Weight
+		}
+	}
+	return weight
+}
+
+// isScoreComponent reports whether the name is that of a component.
+func isScoreComponent(name string) bool {
+	if _, ok := freeModes[name]; ok {
+		return true
+	}
+	return name == componentGroupLocality || name == componentTier || name == componentImageLocality
+}
+
+// componentScorer returns the sub-scorer of a component, or nil when the name
+// is unknown. The group locality bonus and spread follow the group affinity
+// arguments.
+func (cs *CustomScheduler) componentScorer(name string) subScorer {
+	if resourceName, ok := freeModes[name]; ok {
+		return freeSubScorer{cs: cs, mode: name, resourceName: resourceName, invert: invertsScores(name)}
+	}
+	switch name {
+	case componentGroupLocality:
+		return localitySubScorer{bonus: cs.groupAffinityBonus, spread: cs.spreadGroup}
+	case componentTier:
+		return tierSubScorer{}
+	case componentImageLocality:
+		return imageLocalitySubScorer{}
+	}
+	return nil
+}
+
+// compositeSubScorer scores the nodes in the Composite mode.
+type compositeSubScorer struct {
+	cs *CustomScheduler
+}
+
+// subScore combines the sub-scores of the node by their weighted average,
+// which stays in the node score range so that NormalizeScore only has to
+// clamp it.
+func (c compositeSubScorer) subScore(s *preScoreState, nodeInfo *framework.NodeInfo) int64 {
+	var score, weights int64
+	for _, component := range c.cs.scoreComponents {
+		if component.Weight == 0 {
+			continue
+		}
+		score += component.Weight * c.cs.componentScorer(component.Name).subScore(s, nodeInfo)
+		weights += component.Weight
+	}
+	if weights == 0 {
+		return framework.MinNodeScore
+	}
+	return clampScore(score / weights)
+}
+
+// freeSubScorer scores the percentage of the allocatable amount of a
+// resource left on the node once the pod is placed, or the percentage used
+// when inverted. What is left honors the capacity policy, the actual usage
+// and the headroom like the free modes do. Nodes the pod doesn't fit on
+// score the minimum either way.
+type freeSubScorer struct {
+	cs           *CustomScheduler
+	mode         string
+	resourceName v1.ResourceName
+	invert       bool
+}
+
+func (f freeSubScorer) subScore(s *preScoreState, nodeInfo *framework.NodeInfo) int64 {
+	free, allocatable, fits := f.cs.nodeLeft(s, nodeInfo, f.mode, f.resourceName)
+	if !fits || allocatable <= 0 {
+		return framework.MinNodeScore
+	}
+	score := free * framework.MaxNodeScore / allocatable
+	if f.invert {
+		return framework.MaxNodeScore - score
+	}
+	return score
+}
+
+// localitySubScorer scores the members of the pod's group already on the
+// node, each worth bonus points up to the maximum. With spread, the nodes
+// without members score the most instead.
+type localitySubScorer struct {
+	bonus  int64
+	spread bool
+}
+
+func (l localitySubScorer) subScore(s *preScoreState, nodeInfo *framework.NodeInfo) int64 {
+	score := clampScore(int64(s.memberNodes[nodeInfo.Node().Name]) * l.bonus)
+	if l.spread {
+		return framework.MaxNodeScore - score
+	}
+	return score
+}
+
+// tierSubScorer scores the bonus of the node's tier, which is only known when
+// PreScore ran.
+type tierSubScorer struct{}
+
+func (tierSubScorer) subScore(s *preScoreState, nodeInfo *framework.NodeInfo) int64 {
+	return clampScore(s.nodeBonuses[nodeInfo.Node().Name])
+}
@@ -0,0 +1,121 @@
+package plugins
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestSubScorers(t *testing.T) {
+	// a quarter of the node's memory and half of its CPU are requested
+	nodeInfo := makeNodeInfo("node1", 4000, 8<<30)
+	nodeInfo.Requested.Memory = 2 << 30
+	nodeInfo.Requested.MilliCPU = 2000
+	s := &preScoreState{
+		requests:    v1.ResourceList{v1.ResourceMemory: resource.MustParse("2Gi")},
+		memberNodes: map[string]int{"node1": 3},
+		nodeBonuses: map[string]int64{"node1": 40},
+	}
+	tests := []struct {
+		name      string
+		component string
+		bonus     int64
+		spread    bool
+		state     *preScoreState
+		want      int64
+	}{
+		{name: "most memory", component: mostMode, state: s, want: 50},
+		{name: "least memory", component: leastMode, state: s, want: 50},
+		{name: "most cpu", component: mostCPUMode, state: s, want: 50},
+		{name: "least cpu", component: leastCPUMode, state: s, want: 50},
+		{
+			// the memory limits of the pods replace their requests
+			name:      "most memory by limits",
+			component: mostMode,
+			state: &preScoreState{
+				requests:         s.requests,
+				memoryLimit:      2 << 30,
+				nodeMemoryLimits: map[string]int64{"node1": 4 << 30},
+			},
+			want: 25,
+		},
+		{
+			name:      "pod doesn't fit",
+			component: leastMode,
+			state:     &preScoreState{requests: v1.ResourceList{v1.ResourceMemory: resource.MustParse("7Gi")}},
+			want:      framework.MinNodeScore,
+		},
+		{name: "group locality", component: componentGroupLocality, bonus: 10, state: s, want: 30},
+		{name: "group locality capped", component: componentGroupLocality, bonus: 50, state: s, want: framework.MaxNodeScore},
+		{name: "group locality spread", component: componentGroupLocality, bonus: 10, spread: true, state: s, want: 70},
+		{name: "pod outside of a group", component: componentGroupLocality, bonus: 10, state: &preScoreState{}, want: framework.MinNodeScore},
+		{name: "tier", component: componentTier, state: s, want: 40},
+		{name: "no tier", component: componentTier, state: &preScoreState{}, want: framework.MinNodeScore},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{groupAffinityBonus: tt.bonus, spreadGroup: tt.spread}
+			scorer := cs.componentScorer(tt.component)
+			if got := scorer.subScore(tt.state, nodeInfo); got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_Score_CompositeMode(t *testing.T) {
+	makeNode := func(name string, requested int64, tier string) *framework.NodeInfo {
+		ni := makeNodeInfo(name, 4000, 4<<30)
+		ni.Requested.Memory = requested
+		if tier != "" {
+			ni.Node().Labels = map[string]string{"tier": tier}
+		}
+		return ni
+	}
+	nodeInfos := []*framework.NodeInfo{
+		makeNode("empty", 0, ""),
+		makeNode("half-gold", 2<<30, "gold"),
+		makeNode("three-quarters-silver", 3<<30, "silver"),
+		makeNode("full-gold", 4<<30, "gold"),
+	}
+	cs := &CustomScheduler{
+		handle:          newTestFrameworkWithNodes(t, nil, nodeInfos),
+		scoreMode:       compositeMode,
+		scoreComponents: []ScoreComponent{{Name: mostMode, Weight: 3}, {Name: componentTier, Weight: 1}},
+		tierLabelKey:    "tier",
+		tierBonus:       map[string]int64{"gold": 100, "silver": 60},
+		// the Composite mode weighs the group affinity itself
+		groupAffinityWeight: 1,
+	}
+	// (3 × free percentage + tier bonus) / 4, which NormalizeScore keeps
+	want := map[string]int64{"empty": 75, "half-gold": 62, "three-quarters-silver": 33, "full-gold": 25}
+	for _, score := range scoreNodes(t, cs, &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod"}}, nodeInfos) {
+		if score.Score != want[score.Name] {
+			t.Errorf("expected node %s to score %d, got %d", score.Name, want[score.Name], score.Score)
+		}
+	}
+}
+
+func TestCustomScheduler_PodScoreMode_Composite(t *testing.T) {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{scoreModeAnnotation: "composite"}}}
+	if mode := (&CustomScheduler{scoreMode: leastMode}).podScoreMode(pod); mode != leastMode {
+		t.Errorf("expected pods not to pick the Composite mode without components, got %s", mode)
+	}
+	cs := &CustomScheduler{scoreMode: leastMode, scoreComponents: []ScoreComponent{{Name: mostMode, Weight: 1}}}
+	if mode := cs.podScoreMode(pod); mode != compositeMode {
+		t.Errorf("expected pods to pick the Composite mode with components, got %s", mode)
+	}
+}
+
+func TestSetDefaultsCustomSchedulerArgs_ScoreComponents(t *testing.T) {
+	args := &CustomSchedulerArgs{ScoreComponents: []ScoreComponent{{Name: "mostallocated"}, {Name: "grouplocality"}, {Name: "TIER"}}}
+	SetDefaultsCustomSchedulerArgs(args)
+	for i, want := range []string{mostMode, componentGroupLocality, componentTier} {
+		if got := args.ScoreComponents[i].Name; got != want {
+			t.Errorf("expected component %d to be %s, got %s", i, want, got)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package plugins
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// groupAdmission limits how many groups of a namespace schedule at once, so
+// that the members of many groups submitted together don't interleave and
+// starve each other. A complete group is admitted by PreFilter and stays
+// active until none of its live members is left unscheduled. Groups waiting
+// for a slot are queued, and the oldest queued groups are admitted first.
+type groupAdmission struct {
+	mu sync.Mutex
+	// active and queued are keyed by namespace, and then by group key.
+	active map[string]map[string]admittedGroup
+	queued map[string]map[string]admittedGroup
+}
+
+// admittedGroup is a group taking or waiting for a slot of its namespace.
+type admittedGroup struct {
+	namespace    string
+	group        string
+	minAvailable int
+	createdAt    time.Time
+}
+
+func newGroupAdmission() *groupAdmission {
+	return &groupAdmission{
+		active: make(map[string]map[string]admittedGroup),
+		queued: make(map[string]map[string]admittedGroup),
+	}
+}
+
+// admitGroup reports whether the pod's group may schedule now. It returns an
+// Unschedulable status when its namespace already has as many active groups
+// as allowed, or when older groups are waiting for the free slots.
+func (cs *CustomScheduler) admitGroup(pod *v1.Pod, group string, minAvailable int) *framework.Status {
+	a := cs.admission
+	if a == nil {
+		return nil
+	}
+	namespace := pod.Namespace
+	key := cs.groupKey(namespace, group)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	active, queued := namespaceGroups(a.active, namespace), namespaceGroups(a.queued, namespace)
+	defer a.updateMetrics(namespace)
+	if _, ok := active[key]; ok {
+		return nil
+	}
+	g, ok := queued[key]
+	if !ok {
+		g = admittedGroup{
+			namespace:    namespace,
+			group:        group,
+			minAvailable: minAvailable,
+			createdAt:    cs.groupCreationTime(key, namespace, group, pod.CreationTimestamp.Time),
+		}
+	}
+	older := 0
+	for k, other := range queued {
+		if k != key && queuedBefore(other, g) {
+			older++
+		}
+	}
+	if free := cs.maxConcurrentGroups - len(active); older < free {
+		delete(queued, key)
+		active[key] = g
+		klog.V(4).InfoS("Group admitted to schedule", "namespace", namespace, "group", group, "activeGroups", len(active))
+		return nil
+	}
+	queued[key] = g
+	return framework.NewStatus(framework.Unschedulable, fmt.Sprintf("group '%s' waits for one of the %d groups scheduling in namespace %s", group, len(active), namespace))
+}
+
+// queuedBefore reports whether group a is admitted before group b: the older
+// group first, and by name between groups created at the same time.
+func queuedBefore(a, b admittedGroup) bool {
+	if !a.createdAt.Equal(b.createdAt) {
+		return a.createdAt.Before(b.createdAt)
+	}
+	return a.group < b.group
+}
+
+// namespaceGroups returns the groups of the namespace, adding the namespace
+// when it has none yet.
+func namespaceGroups(groups map[string]map[string]admittedGroup, namespace string) map[string]admittedGroup {
+	g := groups[namespace]
+	if g == nil {
+		g = make(map[string]admittedGroup)
+		groups[namespace] = g
+	}
+	return g
+}
+
+// pruneAdmission drops the group once it has no live member left to
+// schedule, because they were scheduled or deleted, freeing its slot or its
+// place in the queue. A queued group left with fewer live members than
+// minAvailable is dropped too, as PreFilter rejects it before it could take a
+// slot. It is called when a member of the group is bound, finishes or is
+// deleted.
+func (cs *CustomScheduler) pruneAdmission(namespace, group string) {
+	a := cs.admission
+	if a == nil {
+		return
+	}
+	key := cs.groupKey(namespace, group)
+	pods, err := cs.groupManager().Members(namespace, group)
+	if err != nil {
+		klog.ErrorS(err, "Failed to list pods of group", "group", group)
+		return
+	}
+	unscheduled, members := cs.hasUnscheduledMembers(key, pods), countActivePods(pods)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, active := a.active[namespace][key]
+	g, queued := a.queued[namespace][key]
+	switch {
+	case active && !unscheduled:
+		delete(a.active[namespace], key)
+	case queued && (!unscheduled || members < g.minAvailable):
+		delete(a.queued[namespace], key)
+	default:
+		return
+	}
+	klog.V(4).InfoS("Group no longer needs a slot", "namespace", namespace, "group", group)
+	a.updateMetrics(namespace)
+}
+
+// updateMetrics records the number of active and queued groups of the
+// namespace, dropping the namespace once it has no group left. The caller
+// must hold a.mu.
+func (a *groupAdmission) updateMetrics(namespace string) {
+	if len(a.active[namespace]) == 0 && len(a.queued[namespace]) == 0 {
+		delete(a.active, namespace)
+		delete(a.queued, namespace)
+		namespaceActiveGroups.DeleteLabelValues(namespace)
+		namespaceQueuedGroups.DeleteLabelValues(namespace)
+		return
+	}
+	namespaceActiveGroups.WithLabelValues(namespace).Set(float64(len(a.active[namespace])))
+	namespaceQueuedGroups.WithLabelValues(namespace).Set(float64(len(a.queued[namespace])))
+}
+
+// hasUnscheduledMembers reports whether a live member of the group among pods
+// is neither bound nor reserved on a node.
+func (cs *CustomScheduler) hasUnscheduledMembers(key string, pods []*v1.Pod) bool {
+	assumed := cs.assumedMembers(key)
+	for _, p := range pods {
+		if _, reserved := assumed[p.UID]; isActivePod(p) && p.Spec.NodeName == "" && !reserved {
+			return true
+		}
+	}
+	return false
+}
+
+// activeGroups returns the names of the active groups of the namespace,
+// oldest first.
+func (a *groupAdmission) activeGroups(namespace string) []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var groups []admittedGroup
+	for _, g := range a.active[namespace] {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return queuedBefore(groups[i], groups[j]) })
+	names := make([]string, 0, len(groups))
+	for _, g := range groups {
+		names = append(names, g.group)
+	}
+	return names
+}
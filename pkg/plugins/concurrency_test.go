@@ -0,0 +1,149 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/component-base/metrics/testutil"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_MaxConcurrentGroups(t *testing.T) {
+	RegisterMetrics()
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	groups := map[string][]*v1.Pod{}
+	var pods []*v1.Pod
+	for i, group := range []string{"g1", "g2", "g3"} {
+		for j := 0; j < 2; j++ {
+			p := makeGangPod(fmt.Sprintf("%s-pod%d", group, j), group, 2)
+			p.Namespace = "default"
+			p.CreationTimestamp = metav1.NewTime(created.Add(time.Duration(i) * time.Minute))
+			groups[group] = append(groups[group], p)
+			pods = append(pods, p)
+		}
+	}
+	h := newTestFrameworkWithPods(t, pods)
+	store := h.SharedInformerFactory().Core().V1().Pods().Informer().GetStore()
+	cs := &CustomScheduler{
+		handle:               h,
+		groupLabelKey:        groupNameLabel,
+		minAvailableLabelKey: minAvailableLabel,
+		groups:               make(map[string]*groupState),
+		maxConcurrentGroups:  1,
+		admission:            newGroupAdmission(),
+	}
+	preFilter := func(group string, want framework.Code) {
+		t.Helper()
+		if _, status := cs.PreFilter(context.Background(), framework.NewCycleState(), groups[group][0]); status.Code() != want {
+			t.Errorf("group %s: expected %v, got %v", group, want, status)
+		}
+	}
+	gauges := func(wantActive, wantQueued float64) {
+		t.Helper()
+		active, _ := testutil.GetGaugeMetricValue(namespaceActiveGroups.WithLabelValues("default"))
+		queued, _ := testutil.GetGaugeMetricValue(namespaceQueuedGroups.WithLabelValues("default"))
+		if active != wantActive || queued != wantQueued {
+			t.Errorf("expected %v active and %v queued groups, got %v and %v", wantActive, wantQueued, active, queued)
+		}
+	}
+
+	// the first group takes the only slot, the others queue behind it
+	preFilter("g1", framework.Success)
+	preFilter("g3", framework.Unschedulable)
+	preFilter("g2", framework.Unschedulable)
+	// members of the admitted group keep passing
+	preFilter("g1", framework.Success)
+	gauges(1, 2)
+
+	// the slot is freed once every member of g1 is bound, and goes to the
+	// oldest waiting group even when a younger one asks first
+	for _, p := range groups["g1"] {
+		bound := p.DeepCopy()
+		bound.Spec.NodeName = "node1"
+		store.Update(bound)
+		cs.onPodUpdate(p, bound)
+	}
+	gauges(0, 2)
+	preFilter("g3", framework.Unschedulable)
+	preFilter("g2", framework.Success)
+	gauges(1, 1)
+	if got := cs.admission.activeGroups("default"); !reflect.DeepEqual(got, []string{"g2"}) {
+		t.Errorf("expected g2 to be active, got %v", got)
+	}
+
+	// deleting the active group frees the slot too
+	for _, p := range groups["g2"] {
+		store.Delete(p)
+		cs.onPodDelete(p)
+	}
+	preFilter("g3", framework.Success)
+	gauges(1, 0)
+
+	// the gauges of a namespace are dropped once it has no group left
+	for _, p := range groups["g3"] {
+		store.Delete(p)
+		cs.onPodDelete(p)
+	}
+	if err := testutil.GatherAndCompare(legacyregistry.DefaultGatherer, strings.NewReader(""), metricsSubsystem+"_namespace_active_groups", metricsSubsystem+"_namespace_queued_groups"); err != nil {
+		t.Errorf("expected the gauges of the namespace to be dropped: %v", err)
+	}
+
+	// other namespaces have slots of their own
+	other := makeGangPod("other", "g1", 1)
+	other.Namespace = "team-b"
+	store.Add(other)
+	if _, status := cs.PreFilter(context.Background(), framework.NewCycleState(), other); !status.IsSuccess() {
+		t.Errorf("expected a group of another namespace to be admitted, got %v", status)
+	}
+}
+
+func TestCustomScheduler_MaxConcurrentGroups_IncompleteQueuedGroup(t *testing.T) {
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	makePod := func(name, group string, age time.Duration) *v1.Pod {
+		p := makeGangPod(name, group, 2)
+		p.Namespace = "default"
+		p.CreationTimestamp = metav1.NewTime(created.Add(-age))
+		return p
+	}
+	pods := []*v1.Pod{
+		makePod("active1", "active", 3*time.Hour), makePod("active2", "active", 3*time.Hour),
+		makePod("old1", "old", 2*time.Hour), makePod("old2", "old", 2*time.Hour),
+		makePod("young1", "young", time.Hour), makePod("young2", "young", time.Hour),
+	}
+	h := newTestFrameworkWithPods(t, pods)
+	store := h.SharedInformerFactory().Core().V1().Pods().Informer().GetStore()
+	cs := &CustomScheduler{
+		handle:               h,
+		groupLabelKey:        groupNameLabel,
+		minAvailableLabelKey: minAvailableLabel,
+		groups:               make(map[string]*groupState),
+		maxConcurrentGroups:  1,
+		admission:            newGroupAdmission(),
+	}
+	for i, want := range []framework.Code{framework.Success, framework.Unschedulable, framework.Unschedulable} {
+		if _, status := cs.PreFilter(context.Background(), framework.NewCycleState(), pods[2*i]); status.Code() != want {
+			t.Fatalf("pod %s: expected %v, got %v", pods[2*i].Name, want, status)
+		}
+	}
+
+	// the old group loses a member while queued, so it can't take the slot
+	// and must not keep the young group waiting
+	for _, p := range pods[:2] {
+		bound := p.DeepCopy()
+		bound.Spec.NodeName = "node1"
+		store.Update(bound)
+		cs.onPodUpdate(p, bound)
+	}
+	store.Delete(pods[3])
+	cs.onPodDelete(pods[3])
+	if _, status := cs.PreFilter(context.Background(), framework.NewCycleState(), pods[4]); !status.IsSuccess() {
+		t.Errorf("expected the young group to be admitted, got %v", status)
+	}
+}
@@ -0,0 +1,59 @@
+package plugins
+
+import (
+	"encoding/json"
+
+	"k8s.io/component-base/configz"
+	"k8s.io/klog/v2"
+)
+
+// configzName is the name the effective arguments are served under by the
+// /configz endpoint.
+const configzName = "customscheduler"
+
+// EffectiveConfig returns a copy of the arguments the plugin runs with: the
+// defaulted arguments it was created with, along with the part reloaded from
+// the ConfigMap. Pod annotations and namespace labels may still override the
+// mode of single pods.
+func (cs *CustomScheduler) EffectiveConfig() CustomSchedulerArgs {
+	var args CustomSchedulerArgs
+	if cs.args != nil {
+		cs.args.DeepCopyInto(&args)
+	}
+	c := cs.config()
+	args.Mode = c.scoreMode
+	args.Resources = append([]ResourceSpec(nil), c.resources...)
+	args.MinAvailableLabelKey = c.minAvailableLabelKey
+	args.MinAvailableAnnotationKey = c.minAvailableAnnotationKey
+	args.MaxAvailableLabelKey = c.maxAvailableLabelKey
+	return args
+}
+
+// setupConfigz registers the effective arguments with the /configz endpoint.
+// Only the first profile enabling it is served, the others are logged.
+func (cs *CustomScheduler) setupConfigz() {
+	cz, err := configz.New(configzName)
+	if err != nil {
+		klog.ErrorS(err, "Failed to register the args with configz")
+		return
+	}
+	cs.configz = cz
+}
+
+// publishEffectiveConfig logs the effective arguments and hands them to the
+// /configz endpoint, once the plugin is created and after every reload.
+func (cs *CustomScheduler) publishEffectiveConfig(reason string) {
+	args := cs.EffectiveConfig()
+	if cs.configz != nil {
+		cs.configz.Set(args)
+	}
+	if !klog.V(2).Enabled() {
+		return
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		klog.ErrorS(err, "Failed to encode the effective args")
+		return
+	}
+	klog.V(2).InfoS("Effective args", "reason", reason, "args", string(data))
+}
@@ -0,0 +1,84 @@
+package plugins
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/component-base/configz"
+)
+
+func TestCustomScheduler_EffectiveConfig(t *testing.T) {
+	args := &CustomSchedulerArgs{Mode: "mostallocated", ConfigMapRef: &ConfigMapRef{Namespace: "kube-system", Name: "custom-scheduler"}}
+	p, err := New(args, newTestFrameworkWithPods(t, nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cs := p.(*CustomScheduler)
+
+	got := cs.EffectiveConfig()
+	if got.Mode != mostMode {
+		t.Errorf("expected the mode alias to be resolved to %s, got %s", mostMode, got.Mode)
+	}
+	if got.PermitWaitingTimeSeconds != defaultPermitWaitingTimeSeconds {
+		t.Errorf("expected permitWaitingTimeSeconds to default to %d, got %d", defaultPermitWaitingTimeSeconds, got.PermitWaitingTimeSeconds)
+	}
+	if got.GroupLabelKey != groupNameLabel || got.ConfigMapRef.Key != defaultConfigMapKey {
+		t.Errorf("expected the keys to be defaulted, got %q and %q", got.GroupLabelKey, got.ConfigMapRef.Key)
+	}
+	// the returned args are a copy
+	got.ConfigMapRef.Name = "changed"
+	*got.EnableScoring = false
+	if again := cs.EffectiveConfig(); again.ConfigMapRef.Name != "custom-scheduler" || !*again.EnableScoring {
+		t.Errorf("expected changes to the returned args not to reach the plugin")
+	}
+
+	if err := cs.reloadArgs([]byte(`{"mode": "Weighted", "resources": [{"name": "cpu", "weight": 2}], "minAvailableLabelKey": "min"}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got = cs.EffectiveConfig()
+	if got.Mode != weightedMode || len(got.Resources) != 1 || got.Resources[0].Weight != 2 || got.MinAvailableLabelKey != "min" {
+		t.Errorf("expected the reloaded args, got mode %s, resources %v and minAvailableLabelKey %q", got.Mode, got.Resources, got.MinAvailableLabelKey)
+	}
+	if got.PermitWaitingTimeSeconds != defaultPermitWaitingTimeSeconds || got.ConfigMapRef.Name != "custom-scheduler" {
+		t.Errorf("expected the args needing a restart to be kept")
+	}
+}
+
+func TestCustomScheduler_Configz(t *testing.T) {
+	t.Cleanup(func() { configz.Delete(configzName) })
+	args := &CustomSchedulerArgs{
+		Mode:          mostMode,
+		EnableConfigz: true,
+		ConfigMapRef:  &ConfigMapRef{Namespace: "kube-system", Name: "custom-scheduler"},
+	}
+	p, err := New(args, newTestFrameworkWithPods(t, nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cs := p.(*CustomScheduler)
+	served := func() string {
+		t.Helper()
+		data, err := cs.configz.MarshalJSON()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return string(data)
+	}
+	if data := served(); !strings.Contains(data, `"mode":"Most"`) {
+		t.Errorf("expected configz to serve the Most mode, got %s", data)
+	}
+	cs.applyConfigMap(&v1.ConfigMap{Data: map[string]string{defaultConfigMapKey: "mode: Balanced\nenableConfigz: true\n"}})
+	if data := served(); !strings.Contains(data, `"mode":"Balanced"`) {
+		t.Errorf("expected configz to serve the reloaded mode, got %s", data)
+	}
+
+	// a second profile can't register the same name
+	p, err = New(args, newTestFrameworkWithPods(t, nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.(*CustomScheduler).configz != nil {
+		t.Errorf("expected only the first profile to be served")
+	}
+}
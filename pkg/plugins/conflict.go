@@ -0,0 +1,79 @@
+package plugins
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// resolveMinAvailable checks that the members of the group agree on
+// minAvailable. When they don't, the conflict policy either rejects the pod
+// or picks the largest or smallest value. Members without a readable
+// minAvailable of their own are ignored.
+func (cs *CustomScheduler) resolveMinAvailable(groups *GroupManager, pod *v1.Pod, group string, minAvailable int, pods []*v1.Pod) (int, *framework.Status) {
+	values := map[string]int{podKey(pod): minAvailable}
+	for _, p := range pods {
+		// opted-out pods don't take part in the gang check
+		if !isActivePod(p) || gangOptedOut(p) {
+			continue
+		}
+		if value, ok := groups.ownMinAvailable(p); ok {
+			values[podKey(p)] = value
+		}
+	}
+
+	lowest, highest := minAvailable, minAvailable
+	for _, value := range values {
+		if value < lowest {
+			lowest = value
+		}
+		if value > highest {
+			highest = value
+		}
+	}
+	if lowest == highest {
+		return minAvailable, nil
+	}
+
+	minAvailableConflicts.WithLabelValues(cs.conflictPolicy).Inc()
+	switch cs.conflictPolicy {
+	case conflictMax:
+		return highest, nil
+	case conflictMin:
+		return lowest, nil
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	conflicts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		conflicts = append(conflicts, fmt.Sprintf("%s=%d", key, values[key]))
+	}
+	return 0, framework.NewStatus(framework.Unschedulable, fmt.Sprintf("Pod cannot be scheduled because the group '%s' has conflicting minAvailable values: %s", group, strings.Join(conflicts, ", ")))
+}
+
+// ownMinAvailable returns the minAvailable a pod declares itself through the
+// label or the annotation.
+func (m *GroupManager) ownMinAvailable(p *v1.Pod) (int, bool) {
+	cs := m.cs
+	_, value, ok := cs.podMinAvailableLabel(p)
+	allowPercent := false
+	if !ok {
+		value, ok = p.Annotations[cs.config().minAvailableAnnotationKey]
+		allowPercent = true
+	}
+	if !ok {
+		return 0, false
+	}
+	group, _ := cs.podGroupName(p)
+	minAvailable, err := m.parseMinAvailable(value, p, group, allowPercent)
+	if err != nil {
+		return 0, false
+	}
+	return minAvailable, true
+}
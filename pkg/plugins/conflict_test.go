@@ -0,0 +1,114 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/component-base/metrics/testutil"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_PreFilter_ConflictPolicy(t *testing.T) {
+	RegisterMetrics()
+
+	// pod0 and pod1 disagree on minAvailable; the group has 3 live pods
+	// including the incoming one
+	var existing []*v1.Pod
+	for i, minAvailable := range []string{"2", "4"} {
+		existing = append(existing, &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("pod%d", i),
+				Namespace: "default",
+				Labels:    map[string]string{"podGroup": "g1", "minAvailable": minAvailable},
+			},
+		})
+	}
+	incoming := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "incoming",
+			Namespace: "default",
+			Labels:    map[string]string{"podGroup": "g1", "minAvailable": "3"},
+		},
+	}
+	existing = append(existing, incoming)
+
+	tests := []struct {
+		name        string
+		policy      string
+		want        framework.Code
+		wantMessage string
+	}{
+		{
+			name:        "conflicts are rejected",
+			policy:      conflictReject,
+			want:        framework.Unschedulable,
+			wantMessage: "Pod cannot be scheduled because the group 'g1' has conflicting minAvailable values: default/incoming=3, default/pod0=2, default/pod1=4",
+		},
+		{
+			name:        "largest value is used",
+			policy:      conflictMax,
+			want:        framework.Unschedulable,
+			wantMessage: "Pod cannot be scheduled because the group 'g1' has only 3 pods, but needs 4 (0 running, 3 pending, 3 unscheduled: incoming, pod0, pod1)",
+		},
+		{
+			name:   "smallest value is used",
+			policy: conflictMin,
+			want:   framework.Success,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:               newTestFrameworkWithPods(t, existing),
+				scoreMode:            leastMode,
+				groupLabelKey:        groupNameLabel,
+				minAvailableLabelKey: minAvailableLabel,
+				conflictPolicy:       tt.policy,
+			}
+			before, _ := testutil.GetCounterMetricValue(minAvailableConflicts.WithLabelValues(tt.policy))
+
+			_, status := cs.PreFilter(context.Background(), nil, incoming)
+			if status.Code() != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, status.Code())
+			}
+			if tt.wantMessage != "" && status.Message() != tt.wantMessage {
+				t.Errorf("expected message %q, got %q", tt.wantMessage, status.Message())
+			}
+			after, _ := testutil.GetCounterMetricValue(minAvailableConflicts.WithLabelValues(tt.policy))
+			if after-before != 1 {
+				t.Errorf("expected the conflict counter to increase by 1, got %v", after-before)
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_PreFilter_NoConflict(t *testing.T) {
+	existing := []*v1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "pod0",
+				Labels: map[string]string{"podGroup": "g1", "minAvailable": "2"},
+			},
+		},
+	}
+	cs := &CustomScheduler{
+		handle:               newTestFrameworkWithPods(t, existing),
+		scoreMode:            leastMode,
+		groupLabelKey:        groupNameLabel,
+		minAvailableLabelKey: minAvailableLabel,
+		conflictPolicy:       conflictReject,
+	}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "incoming",
+			Labels: map[string]string{"podGroup": "g1", "minAvailable": "2"},
+		},
+	}
+	_, status := cs.PreFilter(context.Background(), nil, pod)
+	if status.Code() != framework.Unschedulable || status.Message() != "Pod cannot be scheduled because the group 'g1' has only 1 pods, but needs 2 (0 running, 1 pending, 1 unscheduled: pod0)" {
+		t.Errorf("expected the group count to be checked, got %v", status)
+	}
+}
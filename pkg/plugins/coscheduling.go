@@ -0,0 +1,59 @@
+package plugins
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// coschedulingGroupLabel and coschedulingMinAvailableLabel are the labels
+	// of the sig-scheduling coscheduling plugin. They are recognized next to
+	// the plugin's own labels when CoschedulingLabels is set.
+	coschedulingGroupLabel        string = "pod-group.scheduling.sigs.k8s.io/name"
+	coschedulingMinAvailableLabel string = "pod-group.scheduling.sigs.k8s.io/min-available"
+)
+
+// podGroupName returns the group of the pod. The plugin's own group label
+// takes precedence over the coscheduling label, so a pod carrying both is
+// only ever known under a single group.
+func (cs *CustomScheduler) podGroupName(pod *v1.Pod) (string, bool) {
+	return groupName(pod, cs.groupLabelKey, cs.coschedulingLabels)
+}
+
+// groupName returns the group of the pod under the given group label, and
+// under the coscheduling label if it is recognized.
+func groupName(pod *v1.Pod, groupLabelKey string, coschedulingLabels bool) (string, bool) {
+	if group, ok := pod.Labels[groupLabelKey]; ok {
+		return group, true
+	}
+	if coschedulingLabels {
+		group, ok := pod.Labels[coschedulingGroupLabel]
+		return group, ok
+	}
+	return "", false
+}
+
+// podMinAvailableLabel returns the key and value of the minAvailable label of
+// the pod, preferring the plugin's own label over the coscheduling one.
+func (cs *CustomScheduler) podMinAvailableLabel(pod *v1.Pod) (key, value string, ok bool) {
+	labelKey := cs.config().minAvailableLabelKey
+	if value, ok := pod.Labels[labelKey]; ok {
+		return labelKey, value, true
+	}
+	if cs.coschedulingLabels {
+		if value, ok := pod.Labels[coschedulingMinAvailableLabel]; ok {
+			return coschedulingMinAvailableLabel, value, true
+		}
+	}
+	return "", "", false
+}
+
+// groupSelectors returns the label selectors matching the members of the
+// group, one per label family in use.
+func (cs *CustomScheduler) groupSelectors(group string) []labels.Selector {
+	selectors := []labels.Selector{labels.SelectorFromSet(map[string]string{cs.groupLabelKey: group})}
+	if cs.coschedulingLabels {
+		selectors = append(selectors, labels.SelectorFromSet(map[string]string{coschedulingGroupLabel: group}))
+	}
+	return selectors
+}
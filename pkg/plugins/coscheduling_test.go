@@ -0,0 +1,95 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_PreFilter_CoschedulingLabels(t *testing.T) {
+	existing := []*v1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "own", Labels: map[string]string{"podGroup": "g1"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "sigs", Labels: map[string]string{coschedulingGroupLabel: "g1"}}},
+		// the own label wins, so this pod belongs to g2 only
+		{ObjectMeta: metav1.ObjectMeta{Name: "both", Labels: map[string]string{"podGroup": "g2", coschedulingGroupLabel: "g1"}}},
+	}
+	tests := []struct {
+		name               string
+		coschedulingLabels bool
+		labels             map[string]string
+		want               framework.Code
+		wantMessage        string
+	}{
+		{
+			name:        "coscheduling labels are ignored by default",
+			labels:      map[string]string{"podGroup": "g1", "minAvailable": "2"},
+			want:        framework.Unschedulable,
+			wantMessage: "Pod cannot be scheduled because the group 'g1' has only 1 pods, but needs 2 (0 running, 1 pending, 1 unscheduled: own)",
+		},
+		{
+			name:               "own labels count both label families",
+			coschedulingLabels: true,
+			labels:             map[string]string{"podGroup": "g1", "minAvailable": "2"},
+			want:               framework.Success,
+		},
+		{
+			name:               "coscheduling labels count both label families",
+			coschedulingLabels: true,
+			labels:             map[string]string{coschedulingGroupLabel: "g1", coschedulingMinAvailableLabel: "2"},
+			want:               framework.Success,
+		},
+		{
+			name:               "pod labelled with both families belongs to the group of its own label",
+			coschedulingLabels: true,
+			labels:             map[string]string{coschedulingGroupLabel: "g1", coschedulingMinAvailableLabel: "3"},
+			want:               framework.Unschedulable,
+			wantMessage:        "Pod cannot be scheduled because the group 'g1' has only 2 pods, but needs 3 (0 running, 2 pending, 2 unscheduled: own, sigs)",
+		},
+		{
+			name:               "own labels take precedence over coscheduling labels",
+			coschedulingLabels: true,
+			labels: map[string]string{
+				"podGroup":                    "g2",
+				"minAvailable":                "1",
+				coschedulingGroupLabel:        "g1",
+				coschedulingMinAvailableLabel: "3",
+			},
+			want: framework.Success,
+		},
+	}
+	for _, tt := range tests {
+		for _, indexed := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/indexed=%v", tt.name, indexed), func(t *testing.T) {
+				fh := newTestFrameworkWithPods(t, nil)
+				cs := &CustomScheduler{
+					handle:               fh,
+					scoreMode:            leastMode,
+					groupLabelKey:        groupNameLabel,
+					minAvailableLabelKey: minAvailableLabel,
+					coschedulingLabels:   tt.coschedulingLabels,
+				}
+				informer := fh.SharedInformerFactory().Core().V1().Pods().Informer()
+				if indexed {
+					if err := cs.addGroupIndexer(informer); err != nil {
+						t.Fatalf("fail to add the group index: %v", err)
+					}
+				}
+				for _, p := range existing {
+					informer.GetStore().Add(p)
+				}
+				pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "incoming", Labels: tt.labels}}
+				_, status := cs.PreFilter(context.Background(), nil, pod)
+				if status.Code() != tt.want {
+					t.Fatalf("expected %v, got %v: %s", tt.want, status.Code(), status.Message())
+				}
+				if tt.wantMessage != "" && status.Message() != tt.wantMessage {
+					t.Errorf("expected message %q, got %q", tt.wantMessage, status.Message())
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// domainStateKey is the cycle state key of the domains a group spreads over.
+const domainStateKey = framework.StateKey("Domains" + Name)
+
+// domainState is how the members of the pod's group spread over the domains
+// of the topology key, computed once per scheduling cycle.
+type domainState struct {
+	group      string
+	minDomains int
+	// members counts the assigned members of the group, including assumed
+	// ones, in each domain of the cluster, which also holds the domains
+	// without members.
+	members map[string]int
+	// remaining counts the live members of the group other than the pod
+	// that aren't assigned yet.
+	remaining int
+}
+
+// Clone implements framework.StateData. The state isn't modified after
+// PreFilter, so it can be shared.
+func (s *domainState) Clone() framework.StateData {
+	return s
+}
+
+// topologyKey returns the node label whose values are the domains groups
+// spread over.
+func (cs *CustomScheduler) topologyKey() string {
+	if cs.domainTopologyKey == "" {
+		return v1.LabelTopologyZone
+	}
+	return cs.domainTopologyKey
+}
+
+// podMinDomains returns the group of the pod and the domains its members must
+// spread over, or 0 when the pod has no minDomains label.
+func (cs *CustomScheduler) podMinDomains(pod *v1.Pod) (string, int, *framework.Status) {
+	value, ok := pod.Labels[minDomainsLabel]
+	if !ok {
+		return "", 0, nil
+	}
+	group, ok := cs.podGroupName(pod)
+	if !ok || gangOptedOut(pod) {
+		return "", 0, nil
+	}
+	minDomains, err := strconv.Atoi(value)
+	if err != nil || minDomains < 1 {
+		return "", 0, framework.NewStatus(framework.UnschedulableAndUnresolvable, fmt.Sprintf("Invalid minDomains value: label %s %q is not a positive integer", minDomainsLabel, value))
+	}
+	return group, minDomains, nil
+}
+
+// newDomainState counts the members of the pod's group in each domain of the
+// snapshot. It returns nil for pods without the minDomains label.
+func (cs *CustomScheduler) newDomainState(state *framework.CycleState, pod *v1.Pod) (*domainState, *framework.Status) {
+	group, minDomains, status := cs.podMinDomains(pod)
+	if status != nil || minDomains == 0 {
+		return nil, status
+	}
+	nodeInfos, err := cs.nodeInfoLister().List()
+	if err != nil {
+		return nil, framework.AsStatus(fmt.Errorf("listing nodes: %w", err))
+	}
+	pods, err := cs.groupManager().ForCycle(state).Members(pod.Namespace, group)
+	if err != nil {
+		return nil, framework.AsStatus(fmt.Errorf("listing the members of the group: %w", err))
+	}
+	s := &domainState{group: group, minDomains: minDomains, members: make(map[string]int)}
+	assigned := 0
+	for _, nodeInfo := range nodeInfos {
+		if nodeInfo.Node() == nil {
+			continue
+		}
+		members := cs.countNodeMembers(pod, group, nodeInfo)
+		assigned += members
+		if domain, ok := nodeInfo.Node().Labels[cs.topologyKey()]; ok {
+			s.members[domain] += members
+		}
+	}
+	for _, p := range pods {
+		if isActivePod(p) && podKey(p) != podKey(pod) {
+			s.remaining++
+		}
+	}
+	if s.remaining -= assigned; s.remaining < 0 {
+		s.remaining = 0
+	}
+	return s, nil
+}
+
+// readDomainState returns the domains written by PreFilter, computing them
+// when PreFilter didn't.
+func (cs *CustomScheduler) readDomainState(state *framework.CycleState, pod *v1.Pod) (*domainState, *framework.Status) {
+	if state != nil {
+		c, err := state.Read(domainStateKey)
+		if err == nil {
+			if s, ok := c.(*domainState); ok {
+				return s, nil
+			}
+		} else if !errors.Is(err, framework.ErrNotFound) {
+			return nil, framework.AsStatus(err)
+		}
+	}
+	return cs.newDomainState(state, pod)
+}
+
+// checkMinDomains rejects the node when placing the pod there leaves too few
+// members to schedule for the group to reach minDomains domains, counting
+// one new domain per member left. Nodes without the topology label are
+// rejected, and so is every node when the cluster has too few domains.
+func (cs *CustomScheduler) checkMinDomains(state *framework.CycleState, pod *v1.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
+	s, status := cs.readDomainState(state, pod)
+	if s == nil {
+		return status
+	}
+	key := cs.topologyKey()
+	if len(s.members) < s.minDomains {
+		return framework.NewStatus(framework.UnschedulableAndUnresolvable, fmt.Sprintf("Cluster has only %d domains of %s, but the group '%s' needs %d", len(s.members), key, s.group, s.minDomains))
+	}
+	domain, ok := nodeInfo.Node().Labels[key]
+	if !ok {
+		return framework.NewStatus(framework.UnschedulableAndUnresolvable, fmt.Sprintf("Node has no %s label, which the group '%s' spreads over", key, s.group))
+	}
+	occupied := 0
+	for d, members := range s.members {
+		if members > 0 || d == domain {
+			occupied++
+		}
+	}
+	if occupied+s.remaining < s.minDomains {
+		return framework.NewStatus(framework.Unschedulable, fmt.Sprintf("Placing the pod on the node leaves the group '%s' in %d domains of %s with %d members left to schedule, but it needs %d", s.group, occupied, key, s.remaining, s.minDomains))
+	}
+	return nil
+}
+
+// domainPreferences scores each node on how under-represented its domain is
+// among the members of the pod's group: the fewer members in the domain of a
+// node, the more it scores. It returns nil for pods without the
+// minDomains label.
+func (cs *CustomScheduler) domainPreferences(pod *v1.Pod, nodeInfos []*framework.NodeInfo) map[string]int64 {
+	group, minDomains, _ := cs.podMinDomains(pod)
+	if minDomains == 0 {
+		return nil
+	}
+	key := cs.topologyKey()
+	members := make(map[string]int)
+	for _, nodeInfo := range nodeInfos {
+		if nodeInfo.Node() == nil {
+			continue
+		}
+		if domain, ok := nodeInfo.Node().Labels[key]; ok {
+			members[domain] += cs.countNodeMembers(pod, group, nodeInfo)
+		}
+	}
+	most := 0
+	for _, count := range members {
+		if count > most {
+			most = count
+		}
+	}
+	preferences := make(map[string]int64)
+	for _, nodeInfo := range nodeInfos {
+		if nodeInfo.Node() == nil {
+			continue
+		}
+		domain, ok := nodeInfo.Node().Labels[key]
+		switch {
+		case !ok:
+			preferences[nodeInfo.Node().Name] = framework.MinNodeScore
+		case most == 0:
+			preferences[nodeInfo.Node().Name] = framework.MaxNodeScore
+		default:
+			preferences[nodeInfo.Node().Name] = int64(most-members[domain]) * framework.MaxNodeScore / int64(most)
+		}
+	}
+	return preferences
+}
+
+// applyDomainPreferences averages the normalized scores with the domain
+// preferences of the nodes the pod fits on.
+func applyDomainPreferences(scores framework.NodeScoreList, unfit map[string]bool, preferences map[string]int64) {
+	if preferences == nil {
+		return
+	}
+	for i := range scores {
+		if !unfit[scores[i].Name] {
+			scores[i].Score = (scores[i].Score + preferences[scores[i].Name]) / 2
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// makeZonedCluster returns a node in each of the zones, and one without a
+// zone, with the members of group g1 bound to the nodes of the zones as
+// given by assigned. The first member is the one being scheduled, and the
+// members missing from assigned are pending.
+func makeZonedCluster(zones []string, members int, minDomains string, assigned map[int]string) ([]*v1.Pod, []*framework.NodeInfo) {
+	nodeInfos := make(map[string]*framework.NodeInfo)
+	var ordered []*framework.NodeInfo
+	for _, zone := range append(zones, "") {
+		name := "node-" + zone
+		if zone == "" {
+			name = "unlabeled"
+		}
+		ni := makeNodeInfo(name, 4000, 8<<30)
+		if zone != "" {
+			ni.Node().Labels = map[string]string{v1.LabelTopologyZone: zone}
+		}
+		nodeInfos[zone] = ni
+		ordered = append(ordered, ni)
+	}
+	var pods []*v1.Pod
+	for i := 0; i < members; i++ {
+		p := makeGangPod(fmt.Sprintf("pod%d", i), "g1", members)
+		p.Labels[minDomainsLabel] = minDomains
+		if zone, ok := assigned[i]; ok {
+			p.Spec.NodeName = nodeInfos[zone].Node().Name
+			nodeInfos[zone].AddPod(p)
+		}
+		pods = append(pods, p)
+	}
+	return pods, ordered
+}
+
+func TestCustomScheduler_Filter_MinDomains(t *testing.T) {
+	zones := []string{"a", "b", "c"}
+	tests := []struct {
+		name        string
+		minDomains  string
+		members     int
+		assigned    map[int]string
+		want        map[string]framework.Code
+		wantMessage string
+	}{
+		{
+			name:       "two domains reachable from any zone",
+			minDomains: "2",
+			members:    3,
+			assigned:   map[int]string{1: "a"},
+			want:       map[string]framework.Code{"node-a": framework.Success, "node-b": framework.Success, "node-c": framework.Success, "unlabeled": framework.UnschedulableAndUnresolvable},
+		},
+		{
+			name:        "three domains need another zone",
+			minDomains:  "3",
+			members:     3,
+			assigned:    map[int]string{1: "a"},
+			want:        map[string]framework.Code{"node-a": framework.Unschedulable, "node-b": framework.Success, "node-c": framework.Success, "unlabeled": framework.UnschedulableAndUnresolvable},
+			wantMessage: "Placing the pod on the node leaves the group 'g1' in 1 domains of topology.kubernetes.io/zone with 1 members left to schedule, but it needs 3",
+		},
+		{
+			name:       "last member must take the last zone",
+			minDomains: "3",
+			members:    3,
+			assigned:   map[int]string{1: "a", 2: "b"},
+			want:       map[string]framework.Code{"node-a": framework.Unschedulable, "node-b": framework.Unschedulable, "node-c": framework.Success, "unlabeled": framework.UnschedulableAndUnresolvable},
+		},
+		{
+			name:        "more domains than the cluster has",
+			minDomains:  "4",
+			members:     4,
+			want:        map[string]framework.Code{"node-a": framework.UnschedulableAndUnresolvable, "node-b": framework.UnschedulableAndUnresolvable, "node-c": framework.UnschedulableAndUnresolvable, "unlabeled": framework.UnschedulableAndUnresolvable},
+			wantMessage: "Cluster has only 3 domains of topology.kubernetes.io/zone, but the group 'g1' needs 4",
+		},
+		{
+			name:        "invalid minDomains",
+			minDomains:  "many",
+			members:     2,
+			want:        map[string]framework.Code{"node-a": framework.UnschedulableAndUnresolvable, "node-b": framework.UnschedulableAndUnresolvable, "node-c": framework.UnschedulableAndUnresolvable, "unlabeled": framework.UnschedulableAndUnresolvable},
+			wantMessage: `Invalid minDomains value: label minDomains "many" is not a positive integer`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pods, nodeInfos := makeZonedCluster(zones, tt.members, tt.minDomains, tt.assigned)
+			cs := &CustomScheduler{
+				handle:               newTestFrameworkWithNodes(t, pods, nodeInfos),
+				groupLabelKey:        groupNameLabel,
+				minAvailableLabelKey: minAvailableLabel,
+			}
+			state := framework.NewCycleState()
+			for _, nodeInfo := range nodeInfos {
+				status := cs.Filter(context.Background(), state, pods[0], nodeInfo)
+				if want := tt.want[nodeInfo.Node().Name]; status.Code() != want {
+					t.Errorf("expected node %s to be %v, got %v", nodeInfo.Node().Name, want, status)
+				}
+				if nodeInfo.Node().Name == "node-a" && tt.wantMessage != "" && !strings.HasPrefix(status.Message(), tt.wantMessage) {
+					t.Errorf("expected message %q, got %q", tt.wantMessage, status.Message())
+				}
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_Score_MinDomains(t *testing.T) {
+	// zone a holds two members, zone b one and zone c none
+	pods, nodeInfos := makeZonedCluster([]string{"a", "b", "c"}, 4, "3", map[int]string{1: "a", 2: "a", 3: "b"})
+	cs := &CustomScheduler{
+		handle:               newTestFrameworkWithNodes(t, pods, nodeInfos),
+		scoreMode:            mostMode,
+		groupLabelKey:        groupNameLabel,
+		minAvailableLabelKey: minAvailableLabel,
+	}
+	scores := make(map[string]int64)
+	for _, score := range scoreNodes(t, cs, pods[0], nodeInfos) {
+		scores[score.Name] = score.Score
+	}
+	if !(scores["node-c"] > scores["node-b"] && scores["node-b"] > scores["node-a"]) {
+		t.Errorf("expected the zones with fewer members to score more, got %v", scores)
+	}
+
+	// pods without the label aren't spread
+	for _, p := range pods {
+		delete(p.Labels, minDomainsLabel)
+	}
+	s, err := cs.newPreScoreState(pods[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.domainPreferences != nil {
+		t.Errorf("expected no domain preferences, got %v", s.domainPreferences)
+	}
+}
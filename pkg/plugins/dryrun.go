@@ -0,0 +1,99 @@
+package plugins
+
+import (
+	"context"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+const dryRunStateKey = framework.StateKey("DryRun" + Name)
+
+// dryRunState tracks the node the dry-run mode ranks first while Score runs
+// on the nodes of a cycle. Only the best node is kept, so the comparison
+// costs no more than one extra free-resource computation per node.
+type dryRunState struct {
+	mode string
+
+	mu    sync.Mutex
+	node  string
+	score int64
+}
+
+// Clone implements framework.StateData. Score updates the state in place and
+// PostBind reads it from the same cycle, so it is shared.
+func (d *dryRunState) Clone() framework.StateData {
+	return d
+}
+
+// offer ranks the node with its raw score in the dry-run mode. Ties go to
+// the node whose name sorts first, so that the result doesn't depend on the
+// order in which the nodes are scored.
+func (d *dryRunState) offer(node string, score int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	better := score > d.score
+	if invertsScores(d.mode) {
+		better = score < d.score
+	}
+	if d.node == "" || better || score == d.score && node < d.node {
+		d.node, d.score = node, score
+	}
+}
+
+// best returns the node the dry-run mode ranked first, or "" when the pod
+// fit no node.
+func (d *dryRunState) best() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.node
+}
+
+// scoreDryRun scores the node in the dry-run mode. The score isn't returned
+// to the framework, so it doesn't change where the pod is placed.
+func (cs *CustomScheduler) scoreDryRun(state *framework.CycleState, s *preScoreState, nodeInfo *framework.NodeInfo) {
+	d := readDryRunState(state)
+	if d == nil {
+		return
+	}
+	dryRun := *s
+	dryRun.mode = d.mode
+	if score, fits := cs.nodeFree(&dryRun, nodeInfo, cs.scoredResource(d.mode)); fits {
+		d.offer(nodeInfo.Node().Name, score)
+	}
+}
+
+// compareDryRun logs and counts whether the pod was bound to the node the
+// dry-run mode ranked first.
+func (cs *CustomScheduler) compareDryRun(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) {
+	d := readDryRunState(state)
+	if d == nil {
+		return
+	}
+	best := d.best()
+	if best == "" {
+		return
+	}
+	result := "agree"
+	if best != nodeName {
+		result = "disagree"
+	}
+	dryRunPlacements.WithLabelValues(d.mode, result).Inc()
+	klog.FromContext(ctx).V(4).Info("Compared the placement with the dry-run mode", "pod", klog.KObj(pod), "node", nodeName, "dryRunMode", d.mode, "dryRunNode", best)
+}
+
+// readDryRunState returns the state written by PreScore, or nil when there
+// is no dry-run mode or PreScore didn't run.
+func readDryRunState(state *framework.CycleState) *dryRunState {
+	if state == nil {
+		return nil
+	}
+	c, err := state.Read(dryRunStateKey)
+	if err != nil {
+		return nil
+	}
+	d, _ := c.(*dryRunState)
+	return d
+}
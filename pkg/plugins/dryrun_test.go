@@ -0,0 +1,103 @@
+package plugins
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/component-base/metrics/testutil"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_DryRunMode(t *testing.T) {
+	RegisterMetrics()
+	nodeInfos := []*framework.NodeInfo{
+		makeNodeInfo("small", 4000, 2<<30),
+		makeNodeInfo("medium", 4000, 4<<30),
+		makeNodeInfo("large", 4000, 8<<30),
+	}
+	var nodes []*v1.Node
+	for _, nodeInfo := range nodeInfos {
+		nodes = append(nodes, nodeInfo.Node())
+	}
+	pod := &v1.Pod{}
+	h := newTestFrameworkWithNodes(t, nil, nodeInfos)
+	schedule := func(cs *CustomScheduler) (*framework.CycleState, framework.NodeScoreList) {
+		t.Helper()
+		state := framework.NewCycleState()
+		if status := cs.PreScore(context.Background(), state, pod, nodes); !status.IsSuccess() {
+			t.Fatalf("unexpected error: %v", status)
+		}
+		var scores framework.NodeScoreList
+		for _, node := range nodes {
+			score, status := cs.Score(context.Background(), state, pod, node.Name)
+			if !status.IsSuccess() {
+				t.Fatalf("unexpected error: %v", status)
+			}
+			scores = append(scores, framework.NodeScore{Name: node.Name, Score: score})
+		}
+		if status := cs.NormalizeScore(context.Background(), state, pod, scores); !status.IsSuccess() {
+			t.Fatalf("unexpected error: %v", status)
+		}
+		return state, scores
+	}
+
+	_, want := schedule(&CustomScheduler{handle: h, scoreMode: leastMode})
+	state, got := schedule(&CustomScheduler{handle: h, scoreMode: leastMode, dryRunMode: mostMode})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the dry-run mode to leave the scores %v, got %v", want, got)
+	}
+
+	cs := &CustomScheduler{handle: h, scoreMode: leastMode, dryRunMode: mostMode}
+	counter := func(result string) float64 {
+		t.Helper()
+		value, err := testutil.GetCounterMetricValue(dryRunPlacements.WithLabelValues(mostMode, result))
+		if err != nil {
+			t.Fatalf("failed to read the counter: %v", err)
+		}
+		return value
+	}
+	agreed, disagreed := counter("agree"), counter("disagree")
+	// Least places the pod on the small node, where Most would have picked
+	// the large one
+	cs.PostBind(context.Background(), state, pod, "small")
+	cs.PostBind(context.Background(), state, pod, "large")
+	if got := counter("disagree") - disagreed; got != 1 {
+		t.Errorf("expected 1 disagreeing placement, got %v", got)
+	}
+	if got := counter("agree") - agreed; got != 1 {
+		t.Errorf("expected 1 agreeing placement, got %v", got)
+	}
+
+	// nothing is compared without a dry-run mode
+	state, _ = schedule(&CustomScheduler{handle: h, scoreMode: leastMode})
+	cs.PostBind(context.Background(), state, pod, "small")
+	if got := counter("disagree") - disagreed; got != 1 {
+		t.Errorf("expected no comparison without a dry-run mode, got %v more", got-1)
+	}
+}
+
+func TestDryRunState_Offer(t *testing.T) {
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{mode: leastMode, want: "a"},
+		{mode: mostMode, want: "c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			d := &dryRunState{mode: tt.mode}
+			d.offer("b", 2)
+			d.offer("c", 3)
+			d.offer("d", 1)
+			// ties go to the first node by name
+			d.offer("a", 1)
+			d.offer("e", 3)
+			if got := d.best(); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+var _ framework.PreBindPlugin = &CustomScheduler{}
+
+const (
+	explainStateKey = framework.StateKey("Explain" + Name)
+	// scoreExplanationAnnotation holds why the pod was placed on its node.
+	scoreExplanationAnnotation = "scheduler.nthu.io/score-explanation"
+	// maxExplanationSize caps the size of the annotation in bytes.
+	maxExplanationSize = 1024
+)
+
+// explainState keeps the scores of the nodes before and after NormalizeScore
+// for PreBind.
+type explainState struct {
+	rawScores map[string]int64
+	scores    map[string]int64
+}
+
+// Clone implements framework.StateData. The state isn't modified after
+// NormalizeScore, so it can be shared.
+func (s *explainState) Clone() framework.StateData {
+	return s
+}
+
+// scoreExplanation is written to the score explanation annotation. The scores
+// are missing when the node was the only feasible one, as nodes aren't scored
+// then. The resource amounts are those of the resource the mode scores on, or
+// memory for the modes scoring several resources.
+type scoreExplanation struct {
+	Node        string `json:"node"`
+	Mode        string `json:"mode"`
+	RawScore    *int64 `json:"rawScore,omitempty"`
+	Score       *int64 `json:"score,omitempty"`
+	Resource    string `json:"resource"`
+	Allocatable int64  `json:"allocatable"`
+	Requested   int64  `json:"requested"`
+	PodRequest  int64  `json:"podRequest"`
+	Free        int64  `json:"free"`
+}
+
+// recordScores keeps the raw and normalized scores for PreBind.
+func recordScores(state *framework.CycleState, rawScores map[string]int64, scores framework.NodeScoreList) {
+	s := &explainState{rawScores: rawScores, scores: make(map[string]int64, len(scores))}
+	for _, nodeScore := range scores {
+		s.scores[nodeScore.Name] = nodeScore.Score
+	}
+	state.Write(explainStateKey, s)
+}
+
+// PreBind annotates the pod with the explanation of its score on the node when
+// explaining scores is enabled. The explanation is only a debugging aid, so
+// failing to write it is logged and doesn't hold back the binding.
+func (cs *CustomScheduler) PreBind(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) *framework.Status {
+	if !cs.explainScores {
+		return nil
+	}
+	logger := klog.FromContext(ctx)
+	explanation, err := cs.explainScore(state, pod, nodeName)
+	if err != nil {
+		logger.Error(err, "Failed to explain the score of the pod", "pod", klog.KObj(pod), "node", nodeName)
+		return nil
+	}
+	data, err := json.Marshal(explanation)
+	if err != nil {
+		logger.Error(err, "Failed to encode the score explanation", "pod", klog.KObj(pod))
+		return nil
+	}
+	if len(data) > maxExplanationSize {
+		logger.V(2).Info("Score explanation is too large, not annotating the pod", "pod", klog.KObj(pod), "size", len(data))
+		return nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{scoreExplanationAnnotation: string(data)},
+		},
+	})
+	if err != nil {
+		logger.Error(err, "Failed to encode the score explanation", "pod", klog.KObj(pod))
+		return nil
+	}
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		_, err := cs.handle.ClientSet().CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+		return err
+	})
+	if err != nil {
+		logger.Error(err, "Failed to annotate the pod with its score explanation", "pod", klog.KObj(pod))
+	}
+	return nil
+}
+
+// explainScore explains the score of the pod on the node.
+func (cs *CustomScheduler) explainScore(state *framework.CycleState, pod *v1.Pod, nodeName string) (*scoreExplanation, error) {
+	s, err := cs.getPreScoreState(state, pod)
+	if err != nil {
+		return nil, err
+	}
+	nodeInfo, err := cs.nodeInfoLister().Get(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	resourceName := cs.scoredResource(s.mode)
+	explanation := &scoreExplanation{Node: nodeName, Mode: s.mode, Resource: string(resourceName)}
+	explanation.Allocatable, explanation.Requested, explanation.PodRequest, _ = nodeAmounts(s.requests, nodeInfo, resourceName)
+	explanation.Free, _ = cs.nodeFree(s, nodeInfo, resourceName)
+
+	c, err := state.Read(explainStateKey)
+	if err != nil {
+		// the node was the only feasible one
+		return explanation, nil
+	}
+	e, ok := c.(*explainState)
+	if !ok {
+		return nil, fmt.Errorf("%+v convert to CustomScheduler.explainState error", c)
+	}
+	if rawScore, ok := e.rawScores[nodeName]; ok {
+		explanation.RawScore = &rawScore
+	}
+	if score, ok := e.scores[nodeName]; ok {
+		explanation.Score = &score
+	}
+	return explanation, nil
+}
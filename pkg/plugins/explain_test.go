@@ -0,0 +1,168 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_PreBind_ExplainScores(t *testing.T) {
+	int64Ptr := func(i int64) *int64 { return &i }
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+		Spec: v1.PodSpec{Containers: []v1.Container{{
+			Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")}},
+		}}},
+	}
+	tests := []struct {
+		name      string
+		enabled   bool
+		nodeInfos []*framework.NodeInfo
+		// conflicts is the number of patches failing with a conflict
+		conflicts int
+		want      *scoreExplanation
+	}{
+		{
+			name:      "disabled",
+			nodeInfos: []*framework.NodeInfo{makeNodeInfo("node1", 4000, 4<<30), makeNodeInfo("node2", 4000, 2<<30)},
+		},
+		{
+			name:      "scored nodes",
+			enabled:   true,
+			nodeInfos: []*framework.NodeInfo{makeNodeInfo("node1", 4000, 4<<30), makeNodeInfo("node2", 4000, 2<<30)},
+			want: &scoreExplanation{
+				Node:        "node1",
+				Mode:        mostMode,
+				RawScore:    int64Ptr(3 << 30),
+				Score:       int64Ptr(framework.MaxNodeScore),
+				Resource:    "memory",
+				Allocatable: 4 << 30,
+				PodRequest:  1 << 30,
+				Free:        3 << 30,
+			},
+		},
+		{
+			name:      "retried on conflict",
+			enabled:   true,
+			nodeInfos: []*framework.NodeInfo{makeNodeInfo("node1", 4000, 4<<30), makeNodeInfo("node2", 4000, 2<<30)},
+			conflicts: 2,
+			want: &scoreExplanation{
+				Node:        "node1",
+				Mode:        mostMode,
+				RawScore:    int64Ptr(3 << 30),
+				Score:       int64Ptr(framework.MaxNodeScore),
+				Resource:    "memory",
+				Allocatable: 4 << 30,
+				PodRequest:  1 << 30,
+				Free:        3 << 30,
+			},
+		},
+		{
+			// the pod is bound without the explanation
+			name:      "conflicts outlast the retries",
+			enabled:   true,
+			nodeInfos: []*framework.NodeInfo{makeNodeInfo("node1", 4000, 4<<30), makeNodeInfo("node2", 4000, 2<<30)},
+			conflicts: 100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := newTestFrameworkWithNodes(t, nil, tt.nodeInfos)
+			client := fh.ClientSet().(*clientsetfake.Clientset)
+			if _, err := client.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			conflicts := tt.conflicts
+			client.PrependReactor("patch", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if conflicts == 0 {
+					return false, nil, nil
+				}
+				conflicts--
+				return true, nil, apierrors.NewConflict(schema.GroupResource{Resource: "pods"}, pod.Name, nil)
+			})
+			cs := &CustomScheduler{
+				handle:        fh,
+				scoreMode:     mostMode,
+				explainScores: tt.enabled,
+			}
+
+			state := framework.NewCycleState()
+			if status := cs.PreScore(context.Background(), state, pod, []*v1.Node{tt.nodeInfos[0].Node(), tt.nodeInfos[1].Node()}); !status.IsSuccess() {
+				t.Fatalf("unexpected error: %v", status)
+			}
+			var scores framework.NodeScoreList
+			for _, nodeInfo := range tt.nodeInfos {
+				score, status := cs.Score(context.Background(), state, pod, nodeInfo.Node().Name)
+				if !status.IsSuccess() {
+					t.Fatalf("unexpected error: %v", status)
+				}
+				scores = append(scores, framework.NodeScore{Name: nodeInfo.Node().Name, Score: score})
+			}
+			if status := cs.NormalizeScore(context.Background(), state, pod, scores); !status.IsSuccess() {
+				t.Fatalf("unexpected error: %v", status)
+			}
+			if status := cs.PreBind(context.Background(), state, pod, "node1"); !status.IsSuccess() {
+				t.Fatalf("unexpected error: %v", status)
+			}
+
+			got, err := client.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			value, ok := got.Annotations[scoreExplanationAnnotation]
+			if tt.want == nil {
+				if ok {
+					t.Errorf("expected no explanation, got %s", value)
+				}
+				return
+			}
+			if len(value) > maxExplanationSize {
+				t.Errorf("expected the explanation to be at most %d bytes, got %d", maxExplanationSize, len(value))
+			}
+			var explanation scoreExplanation
+			if err := json.Unmarshal([]byte(value), &explanation); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(&explanation, tt.want) {
+				t.Errorf("expected explanation %+v, got %s", tt.want, value)
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_PreBind_OnlyFeasibleNode(t *testing.T) {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}}
+	nodeInfos := []*framework.NodeInfo{makeNodeInfo("node1", 4000, 4<<30)}
+	fh := newTestFrameworkWithNodes(t, nil, nodeInfos)
+	if _, err := fh.ClientSet().CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cs := &CustomScheduler{handle: fh, scoreMode: leastMode, explainScores: true}
+
+	// nodes aren't scored when a single one is feasible
+	if status := cs.PreBind(context.Background(), framework.NewCycleState(), pod, "node1"); !status.IsSuccess() {
+		t.Fatalf("unexpected error: %v", status)
+	}
+	got, err := fh.ClientSet().CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var explanation scoreExplanation
+	if err := json.Unmarshal([]byte(got.Annotations[scoreExplanationAnnotation]), &explanation); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if explanation.Node != "node1" || explanation.Mode != leastMode || explanation.RawScore != nil || explanation.Score != nil {
+		t.Errorf("expected an explanation without scores, got %+v", explanation)
+	}
+}
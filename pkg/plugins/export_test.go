@@ -0,0 +1,13 @@
+package plugins
+
+// Exported for the tests of package plugins_test.
+var (
+	NewTestFrameworkWithNodes = newTestFrameworkWithNodes
+	MakeNodeInfo              = makeNodeInfo
+)
+
+// SkipPodCacheSync lets PreFilter count groups from an informer whose store
+// the test fills without running it.
+func SkipPodCacheSync(cs *CustomScheduler) {
+	cs.podsSynced = nil
+}
@@ -0,0 +1,121 @@
+package plugins
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// groupResourcesFit rejects the pod when the members of its group that are
+// not bound yet request more memory or CPU than is free in the whole cluster,
+// since the group can't possibly fit then.
+func (cs *CustomScheduler) groupResourcesFit(group string, pods []*v1.Pod) *framework.Status {
+	requiredMilliCPU, requiredMemory := unboundRequests(pods)
+	freeMilliCPU, freeMemory, err := cs.freeClusterResources()
+	if err != nil {
+		return framework.NewStatus(framework.Error, fmt.Sprintf("Failed to list nodes: %v", err))
+	}
+
+	if requiredMilliCPU > freeMilliCPU || requiredMemory > freeMemory {
+		return framework.NewStatus(framework.Unschedulable, fmt.Sprintf("Pod cannot be scheduled because the group '%s' requires %s CPU and %s memory, but only %s CPU and %s memory are free",
+			group,
+			resource.NewMilliQuantity(requiredMilliCPU, resource.DecimalSI), resource.NewQuantity(requiredMemory, resource.BinarySI),
+			resource.NewMilliQuantity(freeMilliCPU, resource.DecimalSI), resource.NewQuantity(freeMemory, resource.BinarySI)))
+	}
+	return nil
+}
+
+// unboundRequests sums the CPU and memory requested by the live pods that are
+// not bound yet.
+func unboundRequests(pods []*v1.Pod) (milliCPU, memory int64) {
+	for _, p := range pods {
+		if !isActivePod(p) || p.Spec.NodeName != "" {
+			continue
+		}
+		requests := PodEffectiveRequests(p)
+		milliCPU += requests.Cpu().MilliValue()
+		memory += requests.Memory().Value()
+	}
+	return milliCPU, memory
+}
+
+// freeClusterResources sums the CPU and memory free on the nodes.
+func (cs *CustomScheduler) freeClusterResources() (milliCPU, memory int64, err error) {
+	nodeInfos, err := cs.nodeInfoLister().List()
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, nodeInfo := range nodeInfos {
+		if nodeInfo.Node() == nil {
+			continue
+		}
+		if free := nodeInfo.Allocatable.MilliCPU - nodeInfo.Requested.MilliCPU; free > 0 {
+			milliCPU += free
+		}
+		if free := nodeInfo.Allocatable.Memory - nodeInfo.Requested.Memory; free > 0 {
+			memory += free
+		}
+	}
+	return milliCPU, memory, nil
+}
+
+const candidateNodesStateKey = framework.StateKey("CandidateNodes" + Name)
+
+// candidateNodesState caches the nodes the members of a group fit on.
+type candidateNodesState struct {
+	group string
+	nodes sets.String
+}
+
+// Clone implements framework.StateData. The state isn't modified once
+// written, so it can be shared.
+func (s *candidateNodesState) Clone() framework.StateData {
+	return s
+}
+
+// candidateNodes returns the nodes with enough free memory for the largest
+// request among the pod and the members of its group that are not bound yet,
+// so that the framework doesn't evaluate the others. It returns nil, meaning
+// all nodes, when the nodes can't be listed.
+func (cs *CustomScheduler) candidateNodes(state *framework.CycleState, pod *v1.Pod, group string, pods []*v1.Pod) sets.String {
+	if state != nil {
+		if c, err := state.Read(candidateNodesStateKey); err == nil {
+			if s, ok := c.(*candidateNodesState); ok && s.group == group {
+				return s.nodes
+			}
+		}
+	}
+	requests := PodEffectiveRequests(pod)
+	largest := requests.Memory().Value()
+	for _, p := range pods {
+		if !isActivePod(p) || p.Spec.NodeName != "" {
+			continue
+		}
+		requests := PodEffectiveRequests(p)
+		if request := requests.Memory().Value(); request > largest {
+			largest = request
+		}
+	}
+	nodeInfos, err := cs.nodeInfoLister().List()
+	if err != nil {
+		klog.ErrorS(err, "Failed to list nodes, not restricting the candidate nodes", "group", group)
+		return nil
+	}
+	nodes := sets.NewString()
+	for _, nodeInfo := range nodeInfos {
+		if nodeInfo.Node() == nil {
+			continue
+		}
+		if nodeInfo.Allocatable.Memory-nodeInfo.Requested.Memory >= largest {
+			nodes.Insert(nodeInfo.Node().Name)
+		}
+	}
+	if state != nil {
+		state.Write(candidateNodesStateKey, &candidateNodesState{group: group, nodes: nodes})
+	}
+	return nodes
+}
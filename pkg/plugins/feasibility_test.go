@@ -0,0 +1,200 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_PreFilter_GroupResources(t *testing.T) {
+	var members []*v1.Pod
+	for i := 0; i < 3; i++ {
+		members = append(members, &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   fmt.Sprintf("pod%d", i),
+				Labels: map[string]string{"podGroup": "g1", "minAvailable": "3"},
+			},
+			Spec: v1.PodSpec{
+				Containers: []v1.Container{{
+					Resources: v1.ResourceRequirements{
+						Requests: v1.ResourceList{
+							v1.ResourceCPU:    resource.MustParse("100m"),
+							v1.ResourceMemory: resource.MustParse("512Mi"),
+						},
+					},
+				}},
+			},
+		})
+	}
+	tests := []struct {
+		name        string
+		nodeInfos   []*framework.NodeInfo
+		enabled     bool
+		want        framework.Code
+		wantMessage string
+	}{
+		{
+			name:        "group doesn't fit the cluster",
+			nodeInfos:   []*framework.NodeInfo{makeNodeInfo("n1", 1000, 1024*1024*1024)},
+			enabled:     true,
+			want:        framework.Unschedulable,
+			wantMessage: "Pod cannot be scheduled because the group 'g1' requires 300m CPU and 1536Mi memory, but only 1 CPU and 1Gi memory are free",
+		},
+		{
+			name:      "group fits once a node is added",
+			nodeInfos: []*framework.NodeInfo{makeNodeInfo("n1", 1000, 1024*1024*1024), makeNodeInfo("n2", 1000, 1024*1024*1024)},
+			enabled:   true,
+			want:      framework.Success,
+		},
+		{
+			name:      "check is disabled",
+			nodeInfos: []*framework.NodeInfo{makeNodeInfo("n1", 1000, 1024*1024*1024)},
+			enabled:   false,
+			want:      framework.Success,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:               newTestFrameworkWithNodes(t, members, tt.nodeInfos),
+				scoreMode:            leastMode,
+				groupLabelKey:        groupNameLabel,
+				minAvailableLabelKey: minAvailableLabel,
+				checkGroupResources:  tt.enabled,
+			}
+			_, status := cs.PreFilter(context.Background(), nil, members[0])
+			if status.Code() != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, status.Code())
+			}
+			if tt.wantMessage != "" && status.Message() != tt.wantMessage {
+				t.Errorf("expected message %q, got %q", tt.wantMessage, status.Message())
+			}
+		})
+	}
+}
+
+// failingSnapshotHandle is a framework handle whose snapshot can't list the
+// nodes.
+type failingSnapshotHandle struct {
+	framework.Handle
+}
+
+func (h failingSnapshotHandle) SnapshotSharedLister() framework.SharedLister {
+	return failingSharedLister{}
+}
+
+type failingSharedLister struct {
+	framework.SharedLister
+}
+
+func (failingSharedLister) NodeInfos() framework.NodeInfoLister {
+	return failingNodeInfoLister{}
+}
+
+type failingNodeInfoLister struct {
+	framework.NodeInfoLister
+}
+
+func (failingNodeInfoLister) List() ([]*framework.NodeInfo, error) {
+	return nil, errors.New("snapshot unavailable")
+}
+
+func TestCustomScheduler_PreFilter_NodePrefiltering(t *testing.T) {
+	makeMember := func(name, memory, nodeName string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"podGroup": "g1", "minAvailable": "3"}},
+			Spec: v1.PodSpec{
+				NodeName: nodeName,
+				Containers: []v1.Container{{
+					Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceMemory: resource.MustParse(memory)}},
+				}},
+			},
+		}
+	}
+	// the bound member requests the most, but only the pending ones count
+	members := []*v1.Pod{makeMember("pod0", "1Gi", ""), makeMember("pod1", "3Gi", ""), makeMember("pod2", "8Gi", "node-large")}
+	busy := makeNodeInfo("node-busy", 4000, 4<<30)
+	busy.AddPod(makeMember("other", "2Gi", "node-busy"))
+	nodeInfos := []*framework.NodeInfo{
+		makeNodeInfo("node-small", 4000, 2<<30),
+		makeNodeInfo("node-exact", 4000, 3<<30),
+		makeNodeInfo("node-large", 4000, 16<<30),
+		busy,
+	}
+	tests := []struct {
+		name          string
+		disabled      bool
+		failSnapshot  bool
+		cached        *candidateNodesState
+		wantAllNodes  bool
+		wantNodeNames []string
+	}{
+		{
+			name:          "nodes fitting the largest pending member",
+			wantNodeNames: []string{"node-exact", "node-large"},
+		},
+		{
+			name:         "prefiltering disabled",
+			disabled:     true,
+			wantAllNodes: true,
+		},
+		{
+			name:         "snapshot unavailable",
+			failSnapshot: true,
+			wantAllNodes: true,
+		},
+		{
+			name:          "cached for the group",
+			cached:        &candidateNodesState{group: "g1", nodes: sets.NewString("node-small")},
+			wantNodeNames: []string{"node-small"},
+		},
+		{
+			name:          "cached for another group",
+			cached:        &candidateNodesState{group: "g2", nodes: sets.NewString("node-small")},
+			wantNodeNames: []string{"node-exact", "node-large"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var h framework.Handle = newTestFrameworkWithNodes(t, members, nodeInfos)
+			if tt.failSnapshot {
+				h = failingSnapshotHandle{h}
+			}
+			cs := &CustomScheduler{
+				handle:               h,
+				scoreMode:            leastMode,
+				groupLabelKey:        groupNameLabel,
+				minAvailableLabelKey: minAvailableLabel,
+				nodePrefiltering:     !tt.disabled,
+			}
+			state := framework.NewCycleState()
+			if tt.cached != nil {
+				state.Write(candidateNodesStateKey, tt.cached)
+			}
+			result, status := cs.PreFilter(context.Background(), state, members[0])
+			if !status.IsSuccess() {
+				t.Fatalf("unexpected status: %v", status)
+			}
+			if tt.wantAllNodes {
+				if !result.AllNodes() {
+					t.Errorf("expected all nodes, got %v", result.NodeNames.List())
+				}
+				return
+			}
+			if result.AllNodes() {
+				t.Fatalf("expected nodes %v, got all nodes", tt.wantNodeNames)
+			}
+			if got := result.NodeNames.List(); !reflect.DeepEqual(got, tt.wantNodeNames) {
+				t.Errorf("expected nodes %v, got %v", tt.wantNodeNames, got)
+			}
+		})
+	}
+}
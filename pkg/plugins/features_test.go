@@ -0,0 +1,244 @@
+package plugins
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/events"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/apis/config"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/defaultbinder"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/queuesort"
+	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+	"k8s.io/kubernetes/pkg/scheduler/profile"
+)
+
+func TestNew_SingleFeatureProfiles(t *testing.T) {
+	// the group of the pod needs two members but only has the pod itself
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      "incoming",
+		Namespace: "default",
+		Labels:    map[string]string{"podGroup": "g1", "minAvailable": "2"},
+	}}
+	nodeInfos := []*framework.NodeInfo{makeNodeInfo("node1", 4000, 4<<30)}
+	tests := []struct {
+		name          string
+		args          string
+		wantErr       bool
+		wantPreFilter framework.Code
+		wantPermit    framework.Code
+		wantPreScore  framework.Code
+	}{
+		{
+			name:          "both features",
+			args:          `{"mode": "Most"}`,
+			wantPreFilter: framework.Unschedulable,
+			wantPermit:    framework.Wait,
+			wantPreScore:  framework.Success,
+		},
+		{
+			name:          "gang scheduling only",
+			args:          `{"mode": "Most", "enableScoring": false}`,
+			wantPreFilter: framework.Unschedulable,
+			wantPermit:    framework.Wait,
+			wantPreScore:  framework.Skip,
+		},
+		{
+			name:          "scoring only",
+			args:          `{"mode": "Most", "enableGangFilter": false}`,
+			wantPreFilter: framework.Success,
+			wantPermit:    framework.Success,
+			wantPreScore:  framework.Success,
+		},
+		{
+			name:    "neither feature",
+			args:    `{"mode": "Most", "enableGangFilter": false, "enableScoring": false}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(&runtime.Unknown{Raw: []byte(tt.args)}, newTestFrameworkWithNodes(t, []*v1.Pod{pod}, nodeInfos))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			cs := p.(*CustomScheduler)
+			state := framework.NewCycleState()
+			if _, status := cs.PreFilter(context.Background(), state, pod); status.Code() != tt.wantPreFilter {
+				t.Errorf("expected PreFilter to return %v, got %v: %s", tt.wantPreFilter, status.Code(), status.Message())
+			}
+			if status, _ := cs.Permit(context.Background(), state, pod, "node1"); status.Code() != tt.wantPermit {
+				t.Errorf("expected Permit to return %v, got %v: %s", tt.wantPermit, status.Code(), status.Message())
+			}
+			if status := cs.PreScore(context.Background(), state, pod, []*v1.Node{nodeInfos[0].Node()}); status.Code() != tt.wantPreScore {
+				t.Errorf("expected PreScore to return %v, got %v: %s", tt.wantPreScore, status.Code(), status.Message())
+			}
+		})
+	}
+}
+
+func TestNew_MultipleProfiles(t *testing.T) {
+	nodeInfos := []*framework.NodeInfo{
+		makeNodeInfo("small", 4000, 1<<30),
+		makeNodeInfo("large", 4000, 4<<30),
+	}
+	// the profiles of a scheduler share the informers but not the plugins
+	h := newTestFrameworkWithNodes(t, nil, nodeInfos)
+	profiles := map[string]string{
+		"batch":    `{"mode": "Most"}`,
+		"services": `{"mode": "Least", "enableGangFilter": false}`,
+	}
+	plugins := make(map[string]*CustomScheduler)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, args := range profiles {
+		name, args := name, args
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p, err := New(&runtime.Unknown{Raw: []byte(args)}, h)
+			if err != nil {
+				t.Errorf("unexpected error for profile %s: %v", name, err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			plugins[name] = p.(*CustomScheduler)
+		}()
+	}
+	wg.Wait()
+	if t.Failed() {
+		t.FailNow()
+	}
+	batch, services := plugins["batch"], plugins["services"]
+	if batch.podIndexer == nil || services.podIndexer == nil || batch.groupIndexName != services.groupIndexName {
+		t.Fatalf("expected both profiles to use the same group index, got %q and %q", batch.groupIndexName, services.groupIndexName)
+	}
+
+	// the group of the pod needs two members but only has the pod itself
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      "incoming",
+		Namespace: "default",
+		Labels:    map[string]string{"podGroup": "g1", "minAvailable": "2"},
+	}}
+	h.SharedInformerFactory().Core().V1().Pods().Informer().GetStore().Add(pod)
+	if _, status := batch.PreFilter(context.Background(), framework.NewCycleState(), pod); status.Code() != framework.Unschedulable {
+		t.Errorf("expected the batch profile to reject the incomplete gang, got %v: %s", status.Code(), status.Message())
+	}
+	if _, status := services.PreFilter(context.Background(), framework.NewCycleState(), pod); !status.IsSuccess() {
+		t.Errorf("expected the services profile to ignore the gang, got %v: %s", status.Code(), status.Message())
+	}
+	if status, _ := batch.Permit(context.Background(), framework.NewCycleState(), pod, "large"); status.Code() != framework.Wait {
+		t.Errorf("expected the batch profile to hold the pod, got %v", status.Code())
+	}
+	if status, _ := services.Permit(context.Background(), framework.NewCycleState(), pod, "small"); !status.IsSuccess() {
+		t.Errorf("expected the services profile to allow the pod, got %v", status.Code())
+	}
+	batch.mu.Lock()
+	batchGroups := len(batch.groups)
+	batch.mu.Unlock()
+	services.mu.Lock()
+	servicesGroups := len(services.groups)
+	services.mu.Unlock()
+	if batchGroups != 1 || servicesGroups != 0 {
+		t.Errorf("expected only the batch profile to track the group, got %d and %d groups", batchGroups, servicesGroups)
+	}
+
+	for name, want := range map[string]string{"batch": "large", "services": "small"} {
+		scores := scoreNodes(t, plugins[name], &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web"}}, nodeInfos)
+		best := scores[0]
+		for _, score := range scores {
+			if score.Score > best.Score {
+				best = score
+			}
+		}
+		if best.Name != want {
+			t.Errorf("expected the %s profile to prefer node %s, got %v", name, want, scores)
+		}
+	}
+}
+
+func TestNew_ProfileMap(t *testing.T) {
+	newProfile := func(name, queueSortPlugin, args string) schedulerapi.KubeSchedulerProfile {
+		return schedulerapi.KubeSchedulerProfile{
+			SchedulerName: name,
+			Plugins: &schedulerapi.Plugins{
+				QueueSort: schedulerapi.PluginSet{Enabled: []schedulerapi.Plugin{{Name: queueSortPlugin}}},
+				PreFilter: schedulerapi.PluginSet{Enabled: []schedulerapi.Plugin{{Name: Name}}},
+				Score:     schedulerapi.PluginSet{Enabled: []schedulerapi.Plugin{{Name: Name, Weight: 1}}},
+				Permit:    schedulerapi.PluginSet{Enabled: []schedulerapi.Plugin{{Name: Name}}},
+				Bind:      schedulerapi.PluginSet{Enabled: []schedulerapi.Plugin{{Name: defaultbinder.Name}}},
+			},
+			PluginConfig: []schedulerapi.PluginConfig{{Name: Name, Args: &runtime.Unknown{Raw: []byte(args)}}},
+		}
+	}
+	tests := []struct {
+		name     string
+		profiles []schedulerapi.KubeSchedulerProfile
+		// wantErr is part of the error expected, if any
+		wantErr string
+	}{
+		{
+			name: "different args sorting with PrioritySort",
+			profiles: []schedulerapi.KubeSchedulerProfile{
+				newProfile("batch", queuesort.Name, `{"mode": "Most"}`),
+				newProfile("services", queuesort.Name, `{"mode": "Least", "enableGangFilter": false}`),
+			},
+		},
+		{
+			name: "same args sorting with the plugin",
+			profiles: []schedulerapi.KubeSchedulerProfile{
+				newProfile("batch", Name, `{"mode": "Most"}`),
+				newProfile("services", Name, `{"mode": "Most"}`),
+			},
+		},
+		{
+			name: "different args sorting with the plugin",
+			profiles: []schedulerapi.KubeSchedulerProfile{
+				newProfile("batch", Name, `{"mode": "Most"}`),
+				newProfile("services", Name, `{"mode": "Least", "enableGangFilter": false}`),
+			},
+			wantErr: "different queue sort plugin args",
+		},
+	}
+	registry := frameworkruntime.Registry{
+		Name:               New,
+		queuesort.Name:     queuesort.New,
+		defaultbinder.Name: defaultbinder.New,
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := clientsetfake.NewSimpleClientset()
+			recorderFactory := func(string) events.EventRecorder { return &events.FakeRecorder{} }
+			profiles, err := profile.NewMap(tt.profiles, registry, recorderFactory, wait.NeverStop,
+				frameworkruntime.WithClientSet(client),
+				frameworkruntime.WithInformerFactory(informers.NewSharedInformerFactory(client, 0)))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(profiles) != len(tt.profiles) {
+				t.Errorf("expected %d profiles, got %d", len(tt.profiles), len(profiles))
+			}
+		})
+	}
+}
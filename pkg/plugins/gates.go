@@ -0,0 +1,212 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+)
+
+// schedulingGate holds the members of a group out of the scheduling queue
+// until the group has minAvailable pods created.
+const schedulingGate = "scheduler.nthu.io/gang"
+
+// gateController removes the scheduling gate from the members of a group
+// once enough of them exist, so that the scheduler never sees an incomplete
+// gang.
+//
+// It runs inside every scheduler replica, including the ones that aren't the
+// leader, since plugins can't tell whether they lead. That is safe because
+// releasing a pod is idempotent: the patch only removes the gate if it is
+// still there, so replicas racing on the same pod leave it released once and
+// the losers find the gate gone and move on. The informer's view of a pod may
+// lag behind the patch, so released remembers the pods this replica already
+// released until they are deleted.
+type gateController struct {
+	queue workqueue.RateLimitingInterface
+
+	// mu guards released, the UIDs of the pods whose gate was removed but
+	// whose update the informer may not have delivered yet.
+	mu       sync.Mutex
+	released sets.Set[types.UID]
+}
+
+// setupGateController starts the controller releasing gated gang members,
+// which runs until the plugin is closed. The group of every member added,
+// gated or not, and of every gated pod updated is queued and checked again,
+// so members created over a while are released together when the last one
+// needed shows up.
+func (cs *CustomScheduler) setupGateController(informer cache.SharedIndexInformer) {
+	cs.gates = &gateController{
+		queue:    workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		released: sets.New[types.UID](),
+	}
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: cs.enqueueMember,
+		UpdateFunc: func(_, obj interface{}) {
+			cs.enqueueGatedPod(obj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if pod, ok := obj.(*v1.Pod); ok {
+				cs.gates.mu.Lock()
+				cs.gates.released.Delete(pod.UID)
+				cs.gates.mu.Unlock()
+			}
+		},
+	})
+	go wait.Until(cs.runGateWorker, time.Second, cs.stopCh)
+	go func() {
+		<-cs.stopCh
+		cs.gates.queue.ShutDown()
+	}()
+}
+
+// enqueueMember queues the group of the pod, since any new member may
+// complete it.
+func (cs *CustomScheduler) enqueueMember(obj interface{}) {
+	pod, ok := obj.(*v1.Pod)
+	if !ok {
+		return
+	}
+	if group, ok := cs.podGroupName(pod); ok {
+		cs.gates.queue.Add(pod.Namespace + "/" + group)
+	}
+}
+
+// enqueueGatedPod queues the group of the pod if the pod is gated.
+func (cs *CustomScheduler) enqueueGatedPod(obj interface{}) {
+	if pod, ok := obj.(*v1.Pod); ok && gateIndex(pod) >= 0 {
+		cs.enqueueMember(pod)
+	}
+}
+
+func (cs *CustomScheduler) runGateWorker() {
+	for cs.processNextGroup() {
+	}
+}
+
+// processNextGroup releases the next queued group, retrying it with backoff
+// when releasing fails.
+func (cs *CustomScheduler) processNextGroup() bool {
+	item, shutdown := cs.gates.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer cs.gates.queue.Done(item)
+	key := item.(string)
+	if err := cs.releaseGroup(context.Background(), key); err != nil {
+		klog.ErrorS(err, "Failed to release the group, retrying", "group", key)
+		cs.gates.queue.AddRateLimited(key)
+		return true
+	}
+	cs.gates.queue.Forget(key)
+	return true
+}
+
+// releaseGroup removes the scheduling gate from the members of the group once
+// the group has minAvailable pods created. The key is "<namespace>/<group>".
+func (cs *CustomScheduler) releaseGroup(ctx context.Context, key string) error {
+	namespace, group, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	pods, err := cs.groupManager().Members(namespace, group)
+	if err != nil {
+		return err
+	}
+	var gated []*v1.Pod
+	cs.gates.mu.Lock()
+	for _, p := range pods {
+		if gateIndex(p) >= 0 && !cs.gates.released.Has(p.UID) {
+			gated = append(gated, p)
+		}
+	}
+	cs.gates.mu.Unlock()
+	if len(gated) == 0 {
+		return nil
+	}
+	// pods outside of a gang, or with an invalid minAvailable PreFilter
+	// reports, have nothing to wait for
+	if _, minAvailable, isGang, err := cs.groupManager().requirement(gated[0]); isGang && err == nil {
+		if created := countActivePods(pods); created < minAvailable {
+			klog.V(4).InfoS("Group is still incomplete, keeping its members gated", "group", key, "created", created, "minAvailable", minAvailable)
+			return nil
+		}
+	}
+	for _, p := range gated {
+		if err := cs.removeSchedulingGate(ctx, p); err != nil {
+			return err
+		}
+	}
+	klog.V(2).InfoS("Released the members of the group", "group", key, "pods", len(gated))
+	return nil
+}
+
+// removeSchedulingGate removes the gate from the pod. The patch tests that
+// the gate is still where the informer saw it, so that it never removes
+// another gate; when the test fails, the pod is read again and patched once
+// more only if it still carries the gate.
+func (cs *CustomScheduler) removeSchedulingGate(ctx context.Context, pod *v1.Pod) error {
+	pods := cs.handle.ClientSet().CoreV1().Pods(pod.Namespace)
+	err := patchSchedulingGate(ctx, pods, pod)
+	if err != nil && !apierrors.IsNotFound(err) {
+		current, getErr := pods.Get(ctx, pod.Name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(getErr):
+			err = nil
+		case getErr != nil:
+			return err
+		case gateIndex(current) < 0:
+			// another replica released it first
+			err = nil
+		default:
+			err = patchSchedulingGate(ctx, pods, current)
+		}
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	cs.gates.mu.Lock()
+	cs.gates.released.Insert(pod.UID)
+	cs.gates.mu.Unlock()
+	return nil
+}
+
+func patchSchedulingGate(ctx context.Context, pods corev1client.PodInterface, pod *v1.Pod) error {
+	path := fmt.Sprintf("/spec/schedulingGates/%d", gateIndex(pod))
+	patch, err := json.Marshal([]map[string]interface{}{
+		{"op": "test", "path": path + "/name", "value": schedulingGate},
+		{"op": "remove", "path": path},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = pods.Patch(ctx, pod.Name, types.JSONPatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// gateIndex returns the index of the plugin's scheduling gate in the pod's
+// gates, or -1 if the pod doesn't carry it.
+func gateIndex(pod *v1.Pod) int {
+	for i, gate := range pod.Spec.SchedulingGates {
+		if gate.Name == schedulingGate {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,197 @@
+package plugins
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func makeGatedPod(name, group string, minAvailable int) *v1.Pod {
+	pod := makeGangPod(name, group, minAvailable)
+	pod.Namespace = "default"
+	pod.Spec.SchedulingGates = []v1.PodSchedulingGate{{Name: "example.com/other"}, {Name: schedulingGate}}
+	return pod
+}
+
+func TestCustomScheduler_ReleaseGroup(t *testing.T) {
+	h := newTestFrameworkWithPods(t, nil)
+	cs := &CustomScheduler{
+		handle:               h,
+		groupLabelKey:        groupNameLabel,
+		minAvailableLabelKey: minAvailableLabel,
+		gates: &gateController{
+			queue:    workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+			released: sets.New[types.UID](),
+		},
+	}
+	ctx := context.Background()
+	store := h.SharedInformerFactory().Core().V1().Pods().Informer().GetStore()
+	// create adds the pod to the API and to the informer, whose store keeps
+	// the gates after they are removed, as if its updates were late
+	create := func(pod *v1.Pod) {
+		t.Helper()
+		if _, err := h.ClientSet().CoreV1().Pods(pod.Namespace).Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		store.Add(pod)
+		cs.enqueueGatedPod(pod)
+		cs.processNextGroup()
+	}
+	patches := func() map[string]int {
+		patched := make(map[string]int)
+		for _, action := range h.ClientSet().(*clientsetfake.Clientset).Actions() {
+			if patch, ok := action.(k8stesting.PatchAction); ok {
+				patched[patch.GetName()]++
+			}
+		}
+		return patched
+	}
+	gates := func(name string) []v1.PodSchedulingGate {
+		t.Helper()
+		pod, err := h.ClientSet().CoreV1().Pods("default").Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return pod.Spec.SchedulingGates
+	}
+
+	// the first members are created while the group is still incomplete
+	create(makeGatedPod("pod1", "g1", 3))
+	create(makeGatedPod("pod2", "g1", 3))
+	if patched := patches(); len(patched) != 0 {
+		t.Fatalf("expected the incomplete group to stay gated, got patches %v", patched)
+	}
+
+	// the last member needed releases the whole group
+	create(makeGatedPod("pod3", "g1", 3))
+	for _, name := range []string{"pod1", "pod2", "pod3"} {
+		if got := gates(name); len(got) != 1 || got[0].Name != "example.com/other" {
+			t.Errorf("expected only the other gate to be left on %s, got %v", name, got)
+		}
+	}
+
+	// syncing the group again, or a member created later, doesn't patch
+	// the released members again
+	if err := cs.releaseGroup(ctx, "default/g1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	create(makeGatedPod("pod4", "g1", 3))
+	want := map[string]int{"pod1": 1, "pod2": 1, "pod3": 1, "pod4": 1}
+	if patched := patches(); len(patched) != len(want) {
+		t.Errorf("expected patches %v, got %v", want, patched)
+	} else {
+		for name, count := range want {
+			if patched[name] != count {
+				t.Errorf("expected patches %v, got %v", want, patched)
+			}
+		}
+	}
+
+	// a pod another replica already released is left alone
+	released := makeGatedPod("pod5", "g2", 1)
+	if _, err := h.ClientSet().CoreV1().Pods("default").Create(ctx, released, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	store.Add(makeGatedPod("pod5", "g2", 1))
+	released.Spec.SchedulingGates = nil
+	if _, err := h.ClientSet().CoreV1().Pods("default").Update(ctx, released, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cs.releaseGroup(ctx, "default/g2"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if got := gates("pod5"); len(got) != 0 {
+		t.Errorf("expected pod5 to stay ungated, got %v", got)
+	}
+}
+
+func TestCustomScheduler_ReleaseGroupWithoutGang(t *testing.T) {
+	h := newTestFrameworkWithPods(t, nil)
+	cs := &CustomScheduler{
+		handle:               h,
+		groupLabelKey:        groupNameLabel,
+		minAvailableLabelKey: minAvailableLabel,
+		gangFilterDisabled:   true,
+		gates: &gateController{
+			queue:    workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+			released: sets.New[types.UID](),
+		},
+	}
+	pod := makeGatedPod("pod1", "g1", 3)
+	if _, err := h.ClientSet().CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h.SharedInformerFactory().Core().V1().Pods().Informer().GetStore().Add(pod)
+	if err := cs.releaseGroup(context.Background(), "default/g1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := h.ClientSet().CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.Spec.SchedulingGates) != 1 {
+		t.Errorf("expected the gang gate to be removed when the gang filter is off, got %v", got.Spec.SchedulingGates)
+	}
+}
+
+func TestCustomScheduler_GateController(t *testing.T) {
+	h := newTestFrameworkWithPods(t, nil)
+	cs := &CustomScheduler{
+		handle:               h,
+		groupLabelKey:        groupNameLabel,
+		minAvailableLabelKey: minAvailableLabel,
+		stopCh:               make(chan struct{}),
+	}
+	informerFactory := h.SharedInformerFactory()
+	cs.setupGateController(informerFactory.Core().V1().Pods().Informer())
+	informerFactory.Start(cs.stopCh)
+	ctx := context.Background()
+	pods := h.ClientSet().CoreV1().Pods("default")
+	gated := func(name string) bool {
+		t.Helper()
+		pod, err := pods.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return gateIndex(pod) >= 0
+	}
+
+	for _, pod := range []*v1.Pod{makeGatedPod("pod1", "g1", 3), makeGatedPod("pod2", "g1", 3)} {
+		if _, err := pods.Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	if !gated("pod1") || !gated("pod2") {
+		t.Fatalf("expected the incomplete group to stay gated")
+	}
+
+	// a member created without the gate completes the group too
+	ungated := makeGangPod("pod3", "g1", 3)
+	ungated.Namespace = "default"
+	if _, err := pods.Create(ctx, ungated, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return !gated("pod1") && !gated("pod2"), nil
+	}); err != nil {
+		t.Errorf("expected the complete group to be released")
+	}
+
+	// closing the plugin stops the worker and its queue
+	cs.Close()
+	if err := wait.PollImmediate(10*time.Millisecond, time.Second, func() (bool, error) {
+		return cs.gates.queue.ShuttingDown(), nil
+	}); err != nil {
+		t.Errorf("expected the queue to shut down once the plugin is closed")
+	}
+}
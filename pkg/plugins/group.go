@@ -0,0 +1,315 @@
+package plugins
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// groupState is the in-memory bookkeeping for a single pod group.
+type groupState struct {
+	// deadline is the time at which the waiting members of the group are
+	// rejected, so that all members of the group time out together.
+	deadline time.Time
+	// blockedUntil is set by PostFilter when a member of the group couldn't
+	// fit on any node. Until then PreFilter rejects the other members.
+	blockedUntil  time.Time
+	blockedReason string
+	// firstSeen is when PreFilter first saw a member of the group since the
+	// group last became ready. It drives the gang timeout.
+	firstSeen time.Time
+	// createdAt caches the creation time of the oldest member of the group
+	// for sorting the scheduling queue.
+	createdAt time.Time
+	// assumed are the members reserved on a node by Reserve, keyed by UID.
+	assumed map[types.UID]assumedMember
+	// failedAttempts counts the attempts that failed in a row since the
+	// breaker last tripped. breakerTrips counts the trips in a row, and
+	// until breakerOpenUntil PreFilter rejects the members of the group.
+	failedAttempts   int
+	breakerTrips     int
+	breakerOpenUntil time.Time
+	// bestEffortReleased is set once the members of a best-effort group are
+	// scheduled on their own.
+	bestEffortReleased bool
+	// contender is set by PreFilter when priority admission is enabled, so
+	// that groups of lower priority can wait for the group.
+	contender *groupContender
+	// placements are the nodes PostBind bound the members to, keyed by
+	// member name, and memberNodes the nodes in the group's info series.
+	placements  map[string]string
+	memberNodes string
+}
+
+// isEmpty reports whether there is nothing left to track for the group.
+func (gs *groupState) isEmpty(now time.Time) bool {
+	return gs.deadline.IsZero() && !gs.blockedUntil.After(now) && gs.firstSeen.IsZero() && gs.createdAt.IsZero() && !gs.hasAssumed(now) &&
+		gs.failedAttempts == 0 && gs.breakerTrips == 0 && !gs.bestEffortReleased && gs.contender == nil &&
+		len(gs.placements) == 0
+}
+
+// updateGroup calls fn with the state of the group while holding the lock.
+// The state is created on demand and dropped again once it is empty.
+func (cs *CustomScheduler) updateGroup(key string, fn func(gs *groupState)) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.groups == nil {
+		cs.groups = make(map[string]*groupState)
+	}
+	gs := cs.groups[key]
+	if gs == nil {
+		gs = &groupState{}
+	}
+	fn(gs)
+	if gs.isEmpty(cs.now()) {
+		delete(cs.groups, key)
+	} else {
+		cs.groups[key] = gs
+	}
+}
+
+// inGroup reports whether the pod belongs to the given group.
+func (cs *CustomScheduler) inGroup(pod *v1.Pod, namespace, group string) bool {
+	if podGroup, _ := cs.podGroupName(pod); podGroup != group {
+		return false
+	}
+	return cs.clusterWideGroups || pod.Namespace == namespace
+}
+
+// groupKey identifies a group in the plugin's internal state.
+func (cs *CustomScheduler) groupKey(namespace, group string) string {
+	if cs.clusterWideGroups {
+		return group
+	}
+	return namespace + "/" + group
+}
+
+// rejectWaitingPods rejects the members of the group waiting at Permit,
+// except for the pod itself.
+func (cs *CustomScheduler) rejectWaitingPods(pod *v1.Pod, group, msg string) {
+	cs.handle.IterateOverWaitingPods(func(wp framework.WaitingPod) {
+		if wp.GetPod().UID != pod.UID && cs.inGroup(wp.GetPod(), pod.Namespace, group) {
+			wp.Reject(cs.Name(), msg)
+		}
+	})
+}
+
+// registerEventHandlers unblocks groups when the cluster changes in a way that
+// may let them fit: a member of the group goes away, a node is added, or a
+// node gains allocatable resources or becomes schedulable. Such node changes
+// also reset the circuit breakers. A node being removed is dropped from the
+// free-resource cache, along with the members reserved on it.
+func (cs *CustomScheduler) registerEventHandlers(informerFactory informers.SharedInformerFactory) {
+	informerFactory.Core().V1().Pods().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: cs.onPodUpdate,
+		DeleteFunc: cs.onPodDelete,
+	})
+	informerFactory.Core().V1().Nodes().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    cs.onNodeAdd,
+		UpdateFunc: cs.onNodeUpdate,
+		DeleteFunc: cs.onNodeDelete,
+	})
+}
+
+// EventsToRegister returns the events that may make a pod rejected by the
+// plugin schedulable: a pod being added may complete its group, a pod that
+// finishes or is deleted frees resources, unblocks its group or makes room
+// below maxAvailable, and a new node adds to the capacity the group is
+// checked against, as does a node whose allocatable resources change or
+// whose taints are lifted. Other events, such as volume changes, no longer
+// requeue the rejected pods. The scheduler has no queueing hints, so an
+// event requeues every pod the plugin rejected, not only the members of the
+// affected group.
+func (cs *CustomScheduler) EventsToRegister() []framework.ClusterEvent {
+	return []framework.ClusterEvent{
+		{Resource: framework.Pod, ActionType: framework.Add | framework.Update | framework.Delete},
+		{Resource: framework.Node, ActionType: framework.Add | framework.UpdateNodeAllocatable | framework.UpdateNodeTaint},
+	}
+}
+
+// onPodUpdate frees the admission slot of a group whose member was bound or
+// finished.
+func (cs *CustomScheduler) onPodUpdate(oldObj, newObj interface{}) {
+	oldPod, ok := oldObj.(*v1.Pod)
+	if !ok {
+		return
+	}
+	newPod, ok := newObj.(*v1.Pod)
+	if !ok {
+		return
+	}
+	if oldPod.Spec.NodeName == newPod.Spec.NodeName && isActivePod(oldPod) == isActivePod(newPod) {
+		return
+	}
+	if group, ok := cs.podGroupName(newPod); ok {
+		cs.pruneAdmission(newPod.Namespace, group)
+	}
+}
+
+func (cs *CustomScheduler) onPodDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	pod, ok := obj.(*v1.Pod)
+	if !ok {
+		return
+	}
+	group, ok := cs.podGroupName(pod)
+	if !ok {
+		return
+	}
+	// the deleted pod is already gone from the informer
+	pods, err := cs.groupManager().Members(pod.Namespace, group)
+	if err != nil {
+		klog.ErrorS(err, "Failed to list pods of group", "group", group)
+	}
+	allDeleted := err == nil && countActivePods(pods) == 0
+	if allDeleted {
+		cs.deleteGroupMetrics(pod.Namespace, group)
+	}
+	cs.updateGroup(cs.groupKey(pod.Namespace, group), func(gs *groupState) {
+		if !gs.blockedUntil.IsZero() {
+			klog.V(2).InfoS("Pod of the group was deleted, unblocking the group", "pod", klog.KObj(pod), "group", group)
+		}
+		gs.blockedUntil = time.Time{}
+		gs.blockedReason = ""
+		delete(gs.assumed, pod.UID)
+		cs.forgetPlacement(pod, group, gs)
+		if allDeleted {
+			gs.firstSeen = time.Time{}
+			gs.createdAt = time.Time{}
+			gs.bestEffortReleased = false
+			gs.contender = nil
+			gs.resetBreaker()
+		}
+	})
+	cs.pruneAdmission(pod.Namespace, group)
+}
+
+func (cs *CustomScheduler) onNodeAdd(obj interface{}) {
+	cs.unblockGroups()
+}
+
+func (cs *CustomScheduler) onNodeUpdate(oldObj, newObj interface{}) {
+	oldNode, ok := oldObj.(*v1.Node)
+	if !ok {
+		return
+	}
+	newNode, ok := newObj.(*v1.Node)
+	if !ok {
+		return
+	}
+	if nodeGainedCapacity(oldNode, newNode) {
+		klog.V(4).InfoS("Node gained capacity, unblocking the groups", "node", klog.KObj(newNode))
+		cs.unblockGroups()
+	}
+}
+
+// nodeGainedCapacity reports whether the node can take more pods than it
+// could before the update: it became schedulable, one of its allocatable
+// resources grew, or one of its taints was lifted.
+func nodeGainedCapacity(oldNode, newNode *v1.Node) bool {
+	if oldNode.Spec.Unschedulable && !newNode.Spec.Unschedulable {
+		return true
+	}
+	for name, quantity := range newNode.Status.Allocatable {
+		if old, ok := oldNode.Status.Allocatable[name]; !ok || quantity.Cmp(old) > 0 {
+			return true
+		}
+	}
+	for i := range oldNode.Spec.Taints {
+		if !hasTaint(newNode.Spec.Taints, &oldNode.Spec.Taints[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTaint reports whether the taints hold one matching the taint.
+func hasTaint(taints []v1.Taint, taint *v1.Taint) bool {
+	for i := range taints {
+		if taints[i].MatchTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
+
+// unblockGroups clears the blocks and resets the circuit breakers of all
+// groups, so that their members are evaluated again on the grown cluster.
+func (cs *CustomScheduler) unblockGroups() {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	// new capacity may fit the groups the breaker holds back
+	groupBreakerTrips.Reset()
+	now := cs.now()
+	for key, gs := range cs.groups {
+		gs.blockedUntil = time.Time{}
+		gs.blockedReason = ""
+		gs.resetBreaker()
+		if gs.isEmpty(now) {
+			delete(cs.groups, key)
+		}
+	}
+}
+
+func (cs *CustomScheduler) onNodeDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	node, ok := obj.(*v1.Node)
+	if !ok {
+		return
+	}
+	if cs.limitCache != nil {
+		cs.limitCache.forgetNode(node.Name)
+	}
+
+	// members reserved on the node no longer count toward their group
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	now := cs.now()
+	for key, gs := range cs.groups {
+		for uid, member := range gs.assumed {
+			if member.nodeName == node.Name {
+				klog.V(4).InfoS("Node of a reserved member was deleted", "node", klog.KObj(node), "pod", member.key)
+				delete(gs.assumed, uid)
+			}
+		}
+		if gs.isEmpty(now) {
+			delete(cs.groups, key)
+		}
+	}
+}
+
+// gangTimedOut records when a member of the group was first seen and reports
+// whether the group has been waiting for longer than the gang timeout.
+func (cs *CustomScheduler) gangTimedOut(key string) bool {
+	if cs.gangTimeout <= 0 {
+		return false
+	}
+	timedOut := false
+	cs.updateGroup(key, func(gs *groupState) {
+		now := cs.now()
+		if gs.firstSeen.IsZero() {
+			gs.firstSeen = now
+		}
+		timedOut = now.Sub(gs.firstSeen) > cs.gangTimeout
+	})
+	return timedOut
+}
+
+// now returns the current time of the plugin's clock.
+func (cs *CustomScheduler) now() time.Time {
+	if cs.clock == nil {
+		return time.Now()
+	}
+	return cs.clock.Now()
+}
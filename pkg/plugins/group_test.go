@@ -0,0 +1,208 @@
+package plugins
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/defaultbinder"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/queuesort"
+	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+	st "k8s.io/kubernetes/pkg/scheduler/testing"
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+func TestCustomScheduler_GangTimeout(t *testing.T) {
+	member := makeGangPod("pod0", "g1", 3)
+	tests := []struct {
+		name       string
+		bestEffort bool
+		// existing are the pods in the informer
+		existing []*v1.Pod
+		// beforeTimeout runs halfway through the timeout
+		beforeTimeout func(cs *CustomScheduler)
+		want          framework.Code
+	}{
+		{
+			name:          "group is rejected after the timeout",
+			existing:      []*v1.Pod{member},
+			beforeTimeout: func(*CustomScheduler) {},
+			want:          framework.UnschedulableAndUnresolvable,
+		},
+		{
+			name:          "group is scheduled best-effort after the timeout",
+			bestEffort:    true,
+			existing:      []*v1.Pod{member},
+			beforeTimeout: func(*CustomScheduler) {},
+			want:          framework.Success,
+		},
+		{
+			name:     "timer resets when the group becomes ready",
+			existing: []*v1.Pod{member},
+			beforeTimeout: func(cs *CustomScheduler) {
+				if status, _ := cs.Permit(context.Background(), nil, makeGangPod("pod0", "g1", 1), "node1"); !status.IsSuccess() {
+					t.Fatalf("expected the group to be ready, got %v", status)
+				}
+			},
+			want: framework.Unschedulable,
+		},
+		{
+			name:     "timer resets when all members are deleted",
+			existing: nil,
+			beforeTimeout: func(cs *CustomScheduler) {
+				cs.onPodDelete(member)
+			},
+			want: framework.Unschedulable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClock := testingclock.NewFakeClock(time.Now())
+			cs := &CustomScheduler{
+				handle:                newTestFrameworkWithPods(t, tt.existing),
+				scoreMode:             leastMode,
+				groupLabelKey:         groupNameLabel,
+				minAvailableLabelKey:  minAvailableLabel,
+				gangTimeout:           time.Minute,
+				gangTimeoutBestEffort: tt.bestEffort,
+				clock:                 fakeClock,
+			}
+
+			pod := makeGangPod("incoming", "g1", 3)
+			if _, status := cs.PreFilter(context.Background(), nil, pod); status.Code() != framework.Unschedulable {
+				t.Fatalf("expected %v before the timeout, got %v", framework.Unschedulable, status.Code())
+			}
+			fakeClock.Step(30 * time.Second)
+			tt.beforeTimeout(cs)
+			fakeClock.Step(45 * time.Second)
+
+			_, status := cs.PreFilter(context.Background(), nil, pod)
+			if status.Code() != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, status.Code())
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_EventsToRegister(t *testing.T) {
+	eventMap := make(map[framework.ClusterEvent]sets.String)
+	factory := func(_ runtime.Object, h framework.Handle) (framework.Plugin, error) {
+		return &CustomScheduler{handle: h}, nil
+	}
+	_, err := st.NewFramework(
+		[]st.RegisterPluginFunc{
+			st.RegisterBindPlugin(defaultbinder.Name, defaultbinder.New),
+			st.RegisterQueueSortPlugin(queuesort.Name, queuesort.New),
+			st.RegisterPreFilterPlugin(Name, factory),
+		},
+		"default-scheduler",
+		wait.NeverStop,
+		frameworkruntime.WithClusterEventMap(eventMap),
+	)
+	if err != nil {
+		t.Fatalf("fail to create framework: %s", err)
+	}
+
+	tests := []struct {
+		event framework.ClusterEvent
+		want  bool
+	}{
+		{event: framework.ClusterEvent{Resource: framework.Pod, ActionType: framework.Add | framework.Update | framework.Delete}, want: true},
+		{event: framework.ClusterEvent{Resource: framework.Node, ActionType: framework.Add | framework.UpdateNodeAllocatable | framework.UpdateNodeTaint}, want: true},
+		{event: framework.ClusterEvent{Resource: framework.PersistentVolume, ActionType: framework.Add}, want: false},
+		{event: framework.ClusterEvent{Resource: framework.WildCard, ActionType: framework.All}, want: false},
+	}
+	for _, tt := range tests {
+		if got := eventMap[tt.event].Has(Name); got != tt.want {
+			t.Errorf("%s %v: expected registered=%v, got %v", tt.event.Resource, tt.event.ActionType, tt.want, got)
+		}
+	}
+}
+
+func TestCustomScheduler_NodeEvents(t *testing.T) {
+	members := []*v1.Pod{makeGangPod("pod0", "g1", 2), makeGangPod("pod1", "g1", 2)}
+	cs := &CustomScheduler{
+		handle:               newTestFrameworkWithPods(t, members),
+		scoreMode:            leastMode,
+		groupBackoff:         time.Minute,
+		groupLabelKey:        groupNameLabel,
+		minAvailableLabelKey: minAvailableLabel,
+		groups:               make(map[string]*groupState),
+	}
+	client := clientsetfake.NewSimpleClientset()
+	factory := informers.NewSharedInformerFactory(client, 0)
+	cs.registerEventHandlers(factory)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+
+	nodeStatuses := framework.NodeToStatusMap{"node1": framework.NewStatus(framework.Unschedulable, "too big")}
+	if _, status := cs.PostFilter(ctx, nil, members[0], nodeStatuses); status.Code() != framework.Unschedulable {
+		t.Fatalf("expected %v from PostFilter, got %v", framework.Unschedulable, status)
+	}
+	if _, status := cs.PreFilter(ctx, nil, members[1]); status.Code() != framework.Unschedulable {
+		t.Fatalf("expected the group to be blocked, got %v", status)
+	}
+
+	// the informer delivers the new node to the plugin
+	if _, err := client.CoreV1().Nodes().Create(ctx, makeNodeInfo("node2", 4000, 4<<30).Node(), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, wait.ForeverTestTimeout, true, func(ctx context.Context) (bool, error) {
+		_, status := cs.PreFilter(ctx, nil, members[1])
+		return status.IsSuccess(), nil
+	})
+	if err != nil {
+		t.Fatalf("expected the node addition to unblock the group: %v", err)
+	}
+
+	// deleting a node drops the members reserved on it
+	key := cs.groupKey("", "g1")
+	cs.assume(key, members[0], "node2")
+	if err := client.CoreV1().Nodes().Delete(ctx, "node2", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, wait.ForeverTestTimeout, true, func(ctx context.Context) (bool, error) {
+		return len(cs.assumedMembers(key)) == 0, nil
+	})
+	if err != nil {
+		t.Errorf("expected the member reserved on the deleted node to be forgotten: %v", err)
+	}
+}
+
+func TestNodeGainedCapacity(t *testing.T) {
+	taint := v1.Taint{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectNoSchedule}
+	makeNode := func(unschedulable bool, taints ...v1.Taint) *v1.Node {
+		node := makeNodeInfo("node1", 4000, 4<<30).Node().DeepCopy()
+		node.Spec.Unschedulable = unschedulable
+		node.Spec.Taints = taints
+		return node
+	}
+	tests := []struct {
+		name     string
+		old, new *v1.Node
+		want     bool
+	}{
+		{name: "unchanged", old: makeNode(false, taint), new: makeNode(false, taint), want: false},
+		{name: "cordoned", old: makeNode(false), new: makeNode(true), want: false},
+		{name: "uncordoned", old: makeNode(true), new: makeNode(false), want: true},
+		{name: "taint added", old: makeNode(false), new: makeNode(false, taint), want: false},
+		{name: "taint lifted", old: makeNode(false, taint), new: makeNode(false), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nodeGainedCapacity(tt.old, tt.new); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package plugins
+
+import (
+	"fmt"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/informers"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// GroupManager answers what the extension points need to know about the
+// members of a group: which pods they are, how many are assigned and what
+// minAvailable the group needs. It reads the pods from the group index of the
+// pod informer, or by label selector without one, and follows the plugin's
+// configuration as it is reloaded.
+//
+// A GroupManager bound to a scheduling cycle with ForCycle lists each group
+// at most once for the cycle, so that PreFilter sees one consistent list of
+// members. Extension points that must see members arriving in the meantime,
+// such as Permit, use the unbound manager.
+type GroupManager struct {
+	cs *CustomScheduler
+	// members caches the members listed in the cycle the manager is bound
+	// to. It is nil for the unbound manager.
+	members *cycleMembers
+}
+
+// groupMembersStateKey is where a bound GroupManager caches the members it
+// listed in the cycle.
+const groupMembersStateKey = framework.StateKey("GroupMembers" + Name)
+
+// cycleMembers are the members of the groups listed in a scheduling cycle,
+// keyed by group key.
+type cycleMembers struct {
+	mu   sync.Mutex
+	pods map[string][]*v1.Pod
+}
+
+// Clone implements framework.StateData. The members don't change when the
+// framework simulates the cycle on other nodes, so the cache is shared.
+func (c *cycleMembers) Clone() framework.StateData {
+	return c
+}
+
+// newGroupManager adds the group index to the pod informer of the factory,
+// unless the pods are listed from another lister, and returns the manager of
+// the plugin's groups.
+func newGroupManager(cs *CustomScheduler, informerFactory informers.SharedInformerFactory) *GroupManager {
+	if cs.podListerOverride == nil {
+		cs.setupGroupIndexer(informerFactory.Core().V1().Pods().Informer())
+	}
+	return &GroupManager{cs: cs}
+}
+
+// groupManager returns the manager of the plugin's groups. Plugins that
+// weren't built by New get one over their fields as they are.
+func (cs *CustomScheduler) groupManager() *GroupManager {
+	if cs.groupMgr != nil {
+		return cs.groupMgr
+	}
+	return &GroupManager{cs: cs}
+}
+
+// ForCycle returns the manager bound to the scheduling cycle of the state.
+// Without a state it returns the unbound manager.
+func (m *GroupManager) ForCycle(state *framework.CycleState) *GroupManager {
+	if state == nil {
+		return m
+	}
+	var members *cycleMembers
+	if c, err := state.Read(groupMembersStateKey); err == nil {
+		members, _ = c.(*cycleMembers)
+	}
+	if members == nil {
+		members = &cycleMembers{pods: make(map[string][]*v1.Pod)}
+		state.Write(groupMembersStateKey, members)
+	}
+	return &GroupManager{cs: m.cs, members: members}
+}
+
+// Members returns the pods labelled with the given group. Unless the plugin
+// is configured for cluster-wide groups, only pods in the given namespace are
+// returned. Pods that opted out with gangIgnored are left out, but pods in
+// any phase are returned.
+func (m *GroupManager) Members(namespace, group string) ([]*v1.Pod, error) {
+	if m.members == nil {
+		return m.listMembers(namespace, group)
+	}
+	key := m.cs.groupKey(namespace, group)
+	m.members.mu.Lock()
+	defer m.members.mu.Unlock()
+	if pods, ok := m.members.pods[key]; ok {
+		return pods, nil
+	}
+	pods, err := m.listMembers(namespace, group)
+	if err != nil {
+		return nil, err
+	}
+	m.members.pods[key] = pods
+	return pods, nil
+}
+
+func (m *GroupManager) listMembers(namespace, group string) ([]*v1.Pod, error) {
+	cs := m.cs
+	if cs.podIndexer != nil {
+		pods, err := cs.indexedGroupPods(namespace, group)
+		return withoutIgnoredPods(pods), err
+	}
+	lister := cs.podLister()
+	var pods []*v1.Pod
+	seen := sets.New[string]()
+	for _, selector := range cs.groupSelectors(group) {
+		var matched []*v1.Pod
+		var err error
+		if cs.clusterWideGroups {
+			matched, err = lister.List(selector)
+		} else {
+			matched, err = lister.Pods(namespace).List(selector)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range matched {
+			// a pod labelled with both families belongs to the group of
+			// the label that takes precedence
+			if podGroup, _ := cs.podGroupName(p); podGroup == group && !seen.Has(podKey(p)) {
+				seen.Insert(podKey(p))
+				pods = append(pods, p)
+			}
+		}
+	}
+	return withoutIgnoredPods(pods), nil
+}
+
+// AssignedCount returns the number of live members of the group that are
+// bound to a node or reserved in an earlier cycle.
+func (m *GroupManager) AssignedCount(namespace, group string) (int, error) {
+	pods, err := m.Members(namespace, group)
+	if err != nil {
+		return 0, err
+	}
+	return m.assignedMembers(namespace, group, pods).Len(), nil
+}
+
+// assignedMembers returns the UIDs of the members among pods that are bound
+// to a node, and of the members reserved in an earlier cycle.
+func (m *GroupManager) assignedMembers(namespace, group string, pods []*v1.Pod) sets.Set[types.UID] {
+	assigned := sets.New[types.UID]()
+	for _, p := range pods {
+		if p.Spec.NodeName != "" && isActivePod(p) {
+			assigned.Insert(p.UID)
+		}
+	}
+	for uid := range m.cs.assumedMembers(m.cs.groupKey(namespace, group)) {
+		assigned.Insert(uid)
+	}
+	return assigned
+}
+
+// MinAvailable returns the minAvailable of the pod's group, or 0 for a pod
+// outside of a gang.
+func (m *GroupManager) MinAvailable(pod *v1.Pod) (int, error) {
+	_, minAvailable, _, err := m.requirement(pod)
+	return minAvailable, err
+}
+
+// requirement returns the group name and minAvailable of the pod. isGang is
+// false when the pod doesn't carry both the group and minAvailable labels.
+// A PodGroup resource takes precedence over the pod, and when the
+// minAvailable label is absent, the minAvailable annotation and then the pod
+// owner are used instead. No pod is a gang member with the gang filter
+// disabled.
+func (m *GroupManager) requirement(pod *v1.Pod) (group string, minAvailable int, isGang bool, err error) {
+	cs := m.cs
+	if cs.gangFilterDisabled {
+		return "", 0, false, nil
+	}
+	group, hasGroup := cs.podGroupName(pod)
+	if !hasGroup || gangOptedOut(pod) {
+		return "", 0, false, nil
+	}
+	if minMember, ok := cs.podGroupMinMember(pod.Namespace, group); ok {
+		return group, minMember, true, nil
+	}
+	if key, value, ok := cs.podMinAvailableLabel(pod); ok {
+		minAvailable, err = m.parseMinAvailable(value, pod, group, false)
+		if err != nil {
+			err = fmt.Errorf("label %s %w", key, err)
+		}
+		return group, minAvailable, true, err
+	}
+	annotationKey := cs.config().minAvailableAnnotationKey
+	if value, ok := pod.Annotations[annotationKey]; ok {
+		minAvailable, err = m.parseMinAvailable(value, pod, group, true)
+		if err != nil {
+			err = fmt.Errorf("annotation %s %w", annotationKey, err)
+		}
+		return group, minAvailable, true, err
+	}
+	if cs.minAvailableFromOwner {
+		if replicas, _, ok := cs.ownerReplicas(pod); ok {
+			return group, replicas, true, nil
+		}
+	}
+	return "", 0, false, nil
+}
+
+// parseMinAvailable parses the minAvailable value of the pod's group. With
+// allowPercent, which the annotation sets since "%" can't appear in a label
+// value, it also accepts a percentage such as "60%". A percentage is taken of
+// the replicas of the Job or StatefulSet owning the pod, or else of the live
+// members of the group, such as the pods of a Deployment scaled by an HPA,
+// and rounded up, so that the threshold follows the workload as it is scaled.
+func (m *GroupManager) parseMinAvailable(value string, pod *v1.Pod, group string, allowPercent bool) (int, error) {
+	limit := m.cs.maxMinAvailable
+	if limit == 0 {
+		limit = defaultMaxMinAvailable
+	}
+	minAvailable, isPercent, err := parseMinAvailableValue(value, limit)
+	if err != nil || !isPercent {
+		return minAvailable, err
+	}
+	if !allowPercent {
+		return 0, &invalidMinAvailableError{value: value, limit: limit, percent: true}
+	}
+	total, _, ok := m.cs.ownerReplicas(pod)
+	if !ok {
+		pods, err := m.Members(pod.Namespace, group)
+		if err != nil {
+			return 0, err
+		}
+		// the pod counts even when the informer doesn't show it yet
+		total = countActivePods(pods)
+		if total == 0 {
+			total = 1
+		}
+	}
+	return (minAvailable*total + 99) / 100, nil
+}
@@ -0,0 +1,144 @@
+package plugins
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func newTestGroupManager(t *testing.T, pods []*v1.Pod) (*GroupManager, func(*v1.Pod)) {
+	t.Helper()
+	h := newTestFrameworkWithPods(t, nil)
+	cs := &CustomScheduler{
+		handle:               h,
+		groupLabelKey:        groupNameLabel,
+		minAvailableLabelKey: minAvailableLabel,
+		groups:               make(map[string]*groupState),
+	}
+	cs.groupMgr = newGroupManager(cs, h.SharedInformerFactory())
+	store := h.SharedInformerFactory().Core().V1().Pods().Informer().GetStore()
+	for _, p := range pods {
+		store.Add(p)
+	}
+	return cs.groupManager(), func(p *v1.Pod) { store.Add(p) }
+}
+
+func memberNames(pods []*v1.Pod) sets.Set[string] {
+	names := sets.New[string]()
+	for _, p := range pods {
+		names.Insert(p.Name)
+	}
+	return names
+}
+
+func TestGroupManager_Members(t *testing.T) {
+	ignored := makeGangPod("ignored", "g1", 2)
+	ignored.Annotations = map[string]string{gangAnnotation: gangIgnored}
+	finished := makeGangPod("finished", "g1", 2)
+	finished.Status.Phase = v1.PodSucceeded
+	other := makeGangPod("other", "g1", 2)
+	other.Namespace = "team-b"
+	m, _ := newTestGroupManager(t, []*v1.Pod{
+		makeGangPod("pod1", "g1", 2),
+		makeGangPod("pod2", "g1", 2),
+		makeGangPod("pod3", "g2", 2),
+		ignored, finished, other,
+	})
+
+	pods, err := m.Members("", "g1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// ignored pods and other namespaces are left out, finished pods are not
+	if want := sets.New("pod1", "pod2", "finished"); !memberNames(pods).Equal(want) {
+		t.Errorf("expected members %v, got %v", sets.List(want), sets.List(memberNames(pods)))
+	}
+	if pods, err := m.Members("", "missing"); err != nil || len(pods) != 0 {
+		t.Errorf("expected no members of an unknown group, got %v, %v", pods, err)
+	}
+}
+
+func TestGroupManager_ForCycle(t *testing.T) {
+	m, add := newTestGroupManager(t, []*v1.Pod{makeGangPod("pod1", "g1", 2)})
+	state := framework.NewCycleState()
+	if pods, _ := m.ForCycle(state).Members("", "g1"); len(pods) != 1 {
+		t.Fatalf("expected 1 member, got %d", len(pods))
+	}
+	add(makeGangPod("pod2", "g1", 2))
+
+	// the cycle keeps the members it listed first, even through a new bound
+	// manager or a clone of the state
+	if pods, _ := m.ForCycle(state).Members("", "g1"); len(pods) != 1 {
+		t.Errorf("expected the cycle to keep 1 member, got %d", len(pods))
+	}
+	if pods, _ := m.ForCycle(state.Clone()).Members("", "g1"); len(pods) != 1 {
+		t.Errorf("expected the cloned cycle to keep 1 member, got %d", len(pods))
+	}
+	// other cycles and the unbound manager see the new member
+	if pods, _ := m.ForCycle(framework.NewCycleState()).Members("", "g1"); len(pods) != 2 {
+		t.Errorf("expected a new cycle to see 2 members, got %d", len(pods))
+	}
+	if pods, _ := m.Members("", "g1"); len(pods) != 2 {
+		t.Errorf("expected the unbound manager to see 2 members, got %d", len(pods))
+	}
+	if m.ForCycle(nil) != m {
+		t.Error("expected the manager to stay unbound without a state")
+	}
+}
+
+func TestGroupManager_AssignedCount(t *testing.T) {
+	bound := makeGangPod("bound", "g1", 3)
+	bound.Spec.NodeName = "node1"
+	finished := makeGangPod("finished", "g1", 3)
+	finished.Spec.NodeName = "node1"
+	finished.Status.Phase = v1.PodFailed
+	m, _ := newTestGroupManager(t, []*v1.Pod{bound, finished, makeGangPod("pending", "g1", 3)})
+
+	if got, err := m.AssignedCount("", "g1"); err != nil || got != 1 {
+		t.Errorf("expected 1 assigned member, got %d, %v", got, err)
+	}
+	// a member reserved in an earlier cycle counts before it shows bound
+	m.cs.assume(m.cs.groupKey("", "g1"), makeGangPod("pending", "g1", 3), "node2")
+	if got, err := m.AssignedCount("", "g1"); err != nil || got != 2 {
+		t.Errorf("expected 2 assigned members, got %d, %v", got, err)
+	}
+}
+
+func TestGroupManager_MinAvailable(t *testing.T) {
+	withMinAvailable := func(name, value string) *v1.Pod {
+		p := makeGangPod(name, "g1", 0)
+		p.Labels[minAvailableLabel] = value
+		return p
+	}
+	m, _ := newTestGroupManager(t, []*v1.Pod{
+		withMinAvailable("pod1", "3"),
+		withMinAvailable("pod2", "3"),
+		withMinAvailable("pod3", "3"),
+	})
+	tests := []struct {
+		name    string
+		pod     *v1.Pod
+		want    int
+		wantErr bool
+	}{
+		{name: "count", pod: withMinAvailable("pod", "3"), want: 3},
+		// label values can't hold percentages
+		{name: "percentage", pod: withMinAvailable("pod", "50%"), wantErr: true},
+		{name: "invalid", pod: withMinAvailable("pod", "many"), wantErr: true},
+		{name: "outside of a gang", pod: &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod"}}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.MinAvailable(tt.pod)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected minAvailable %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
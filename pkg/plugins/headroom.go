@@ -0,0 +1,36 @@
+package plugins
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog/v2"
+)
+
+// nodeHeadroomAnnotation overrides NodeHeadroomBytes for the node it is set
+// on, as a quantity such as 2Gi.
+const nodeHeadroomAnnotation = "scheduler.nthu.io/memory-headroom"
+
+// nodeHeadroom returns the memory kept free on the node on top of what its
+// allocatable memory already excludes: the quantity of the node's headroom
+// annotation, or NodeHeadroomBytes. An invalid annotation is logged and
+// ignored.
+func (cs *CustomScheduler) nodeHeadroom(node *v1.Node) int64 {
+	if value, ok := node.Annotations[nodeHeadroomAnnotation]; ok {
+		quantity, err := resource.ParseQuantity(value)
+		if err == nil && quantity.Sign() >= 0 {
+			return quantity.Value()
+		}
+		klog.V(2).InfoS("Node has an invalid memory headroom, using the default", "node", klog.KObj(node), "annotation", value, "headroom", cs.nodeHeadroomBytes)
+	}
+	return cs.nodeHeadroomBytes
+}
+
+// withoutHeadroom subtracts the headroom of the node from an amount of its
+// memory, clamping at zero so that a headroom larger than the node leaves it
+// with no memory rather than a negative amount.
+func (cs *CustomScheduler) withoutHeadroom(memory int64, node *v1.Node) int64 {
+	if headroom := cs.nodeHeadroom(node); headroom < memory {
+		return memory - headroom
+	}
+	return 0
+}
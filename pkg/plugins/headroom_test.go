@@ -0,0 +1,118 @@
+package plugins
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_Score_NodeHeadroom(t *testing.T) {
+	makeNode := func(name string, memory int64, headroom string) *framework.NodeInfo {
+		ni := makeNodeInfo(name, 4000, memory)
+		if headroom != "" {
+			ni.Node().Annotations = map[string]string{nodeHeadroomAnnotation: headroom}
+		}
+		return ni
+	}
+	nodeInfos := []*framework.NodeInfo{
+		makeNode("default", 8<<30, ""),
+		makeNode("override", 8<<30, "1Gi"),
+		makeNode("invalid", 8<<30, "lots"),
+		// the headroom is larger than the node
+		makeNode("clamped", 1<<30, ""),
+	}
+	want := map[string]int64{"default": 6 << 30, "override": 7 << 30, "invalid": 6 << 30, "clamped": 0}
+	cs := &CustomScheduler{
+		handle:            newTestFrameworkWithNodes(t, nil, nodeInfos),
+		scoreMode:         mostMode,
+		nodeHeadroomBytes: 2 << 30,
+	}
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod"}}
+	for _, nodeInfo := range nodeInfos {
+		name := nodeInfo.Node().Name
+		score, status := cs.Score(context.Background(), framework.NewCycleState(), pod, name)
+		if !status.IsSuccess() {
+			t.Fatalf("unexpected error: %v", status)
+		}
+		if score != want[name] {
+			t.Errorf("expected node %s to have %d bytes free, got %d", name, want[name], score)
+		}
+	}
+}
+
+func TestCustomScheduler_Filter_NodeHeadroom(t *testing.T) {
+	tests := []struct {
+		name        string
+		headroom    int64
+		annotation  string
+		memory      int64
+		optedOut    bool
+		want        framework.Code
+		wantMessage string
+	}{
+		{
+			name:   "no headroom",
+			memory: 4 << 30,
+			want:   framework.Success,
+		},
+		{
+			name:        "pod eats into the headroom",
+			headroom:    2 << 30,
+			memory:      4 << 30,
+			want:        framework.Unschedulable,
+			wantMessage: "Node would have -1Gi of memory left after the pod, below the safety margin of 0 (allocatable 4Gi, headroom 2Gi, requested 0, pod 3Gi)",
+		},
+		{
+			name:        "pod opted out of the margin",
+			headroom:    2 << 30,
+			memory:      4 << 30,
+			optedOut:    true,
+			want:        framework.Unschedulable,
+			wantMessage: "Node would have -1Gi of memory left after the pod, below the safety margin of 0",
+		},
+		{
+			name:       "annotation overrides the headroom",
+			headroom:   2 << 30,
+			annotation: "1Gi",
+			memory:     4 << 30,
+			want:       framework.Success,
+		},
+		{
+			name:        "headroom larger than the node",
+			headroom:    8 << 30,
+			memory:      4 << 30,
+			want:        framework.Unschedulable,
+			wantMessage: "Node would have -3Gi of memory left after the pod",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{nodeHeadroomBytes: tt.headroom}
+			nodeInfo := makeNodeInfo("node1", 4000, tt.memory)
+			if tt.annotation != "" {
+				nodeInfo.Node().Annotations = map[string]string{nodeHeadroomAnnotation: tt.annotation}
+			}
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "pod"},
+				Spec: v1.PodSpec{Containers: []v1.Container{{Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+					v1.ResourceMemory: resource.MustParse("3Gi"),
+				}}}}},
+			}
+			if tt.optedOut {
+				pod.Annotations = map[string]string{memoryMarginAnnotation: disabledValue}
+			}
+			status := cs.Filter(context.Background(), nil, pod, nodeInfo)
+			if status.Code() != tt.want {
+				t.Fatalf("expected %v, got %v: %s", tt.want, status.Code(), status.Message())
+			}
+			if !strings.Contains(status.Message(), tt.wantMessage) {
+				t.Errorf("expected message containing %q, got %q", tt.wantMessage, status.Message())
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package plugins
+
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// componentImageLocality scores the bytes of the pod's images already on the
+// node.
+const componentImageLocality string = "ImageLocality"
+
+// imageLocality returns the bytes of the pod's container images present on
+// each node of the snapshot, and the most present on any of them. It is only
+// computed when the ImageLocality component weighs something.
+func (cs *CustomScheduler) imageLocality(pod *v1.Pod) (map[string]int64, int64, error) {
+	if cs.componentWeight(componentImageLocality) == 0 {
+		return nil, 0, nil
+	}
+	images := podImages(pod)
+	nodeInfos, err := cs.nodeInfoLister().List()
+	if err != nil {
+		return nil, 0, err
+	}
+	nodeBytes := make(map[string]int64)
+	var maxBytes int64
+	for _, nodeInfo := range nodeInfos {
+		node := nodeInfo.Node()
+		if node == nil {
+			continue
+		}
+		bytes := presentImageBytes(node, images)
+		if bytes == 0 {
+			continue
+		}
+		nodeBytes[node.Name] = bytes
+		if bytes > maxBytes {
+			maxBytes = bytes
+		}
+	}
+	return nodeBytes, maxBytes, nil
+}
+
+// podImages returns the normalized images of the pod's containers and init
+// containers.
+func podImages(pod *v1.Pod) map[string]bool {
+	images := make(map[string]bool)
+	for _, containers := range [][]v1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for _, c := range containers {
+			if c.Image != "" {
+				images[normalizedImageName(c.Image)] = true
+			}
+		}
+	}
+	return images
+}
+
+// presentImageBytes sums the sizes of the node's images named by any of the
+// images. An image the node lists under both a tag and a digest is counted
+// once.
+func presentImageBytes(node *v1.Node, images map[string]bool) int64 {
+	var bytes int64
+	for _, image := range node.Status.Images {
+		for _, name := range image.Names {
+			if images[normalizedImageName(name)] {
+				bytes += image.SizeBytes
+				break
+			}
+		}
+	}
+	return bytes
+}
+
+// normalizedImageName returns the name of an image the way pods and nodes
+// both spell it: without the default registry and library namespace, with
+// the latest tag when it has neither a tag nor a digest, and without the tag
+// when it has a digest, which pins the image whatever the tag.
+func normalizedImageName(name string) string {
+	if i := strings.Index(name, "@"); i >= 0 {
+		repository, digest := name[:i], name[i:]
+		if j := strings.LastIndex(repository, ":"); j > strings.LastIndex(repository, "/") {
+			repository = repository[:j]
+		}
+		return trimDefaultRegistry(repository) + digest
+	}
+	if strings.LastIndex(name, ":") <= strings.LastIndex(name, "/") {
+		name += ":latest"
+	}
+	return trimDefaultRegistry(name)
+}
+
+// trimDefaultRegistry strips the Docker Hub registry and library namespace
+// the container runtimes add to short image names.
+func trimDefaultRegistry(name string) string {
+	name = strings.TrimPrefix(name, "docker.io/")
+	return strings.TrimPrefix(name, "library/")
+}
+
+// imageLocalitySubScorer scores the bytes of the pod's images on the node,
+// against the most present on any node of the cluster.
+type imageLocalitySubScorer struct{}
+
+func (imageLocalitySubScorer) subScore(s *preScoreState, nodeInfo *framework.NodeInfo) int64 {
+	if s.maxImageBytes == 0 {
+		return framework.MinNodeScore
+	}
+	return s.nodeImageBytes[nodeInfo.Node().Name] * framework.MaxNodeScore / s.maxImageBytes
+}
@@ -0,0 +1,111 @@
+package plugins
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestNormalizedImageName(t *testing.T) {
+	tests := []struct {
+		name  string
+		image string
+		want  string
+	}{
+		{name: "short name", image: "busybox", want: "busybox:latest"},
+		{name: "tag", image: "busybox:1.36", want: "busybox:1.36"},
+		{name: "default registry", image: "docker.io/library/busybox:1.36", want: "busybox:1.36"},
+		{name: "registry with a port", image: "registry.example.com:5000/train", want: "registry.example.com:5000/train:latest"},
+		{name: "digest", image: "docker.io/library/busybox@sha256:abc", want: "busybox@sha256:abc"},
+		{name: "tag and digest", image: "registry.example.com/train:v1@sha256:abc", want: "registry.example.com/train@sha256:abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizedImageName(tt.image); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_Score_ImageLocality(t *testing.T) {
+	train := v1.ContainerImage{Names: []string{"registry.example.com/train@sha256:abc", "registry.example.com/train:v1"}, SizeBytes: 3000}
+	busybox := v1.ContainerImage{Names: []string{"docker.io/library/busybox:latest"}, SizeBytes: 1000}
+	other := v1.ContainerImage{Names: []string{"docker.io/library/nginx:1.25"}, SizeBytes: 5000}
+	makeNode := func(name string, images ...v1.ContainerImage) *framework.NodeInfo {
+		ni := makeNodeInfo(name, 4000, 8<<30)
+		ni.Node().Status.Images = images
+		return ni
+	}
+	nodeInfos := []*framework.NodeInfo{
+		makeNode("none", other),
+		makeNode("train", train, other),
+		makeNode("busybox", busybox),
+		makeNode("all", train, busybox),
+	}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod"},
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{{Name: "init", Image: "busybox"}},
+			Containers:     []v1.Container{{Name: "train", Image: "registry.example.com/train@sha256:abc"}},
+		},
+	}
+	tests := []struct {
+		name       string
+		components []ScoreComponent
+		want       map[string]int64
+	}{
+		{
+			name:       "image locality",
+			components: []ScoreComponent{{Name: componentImageLocality, Weight: 1}},
+			want:       map[string]int64{"none": 0, "train": 75, "busybox": 25, "all": 100},
+		},
+		{
+			name:       "image locality disabled",
+			components: []ScoreComponent{{Name: mostMode, Weight: 1}, {Name: componentImageLocality, Weight: 0}},
+			want:       map[string]int64{"none": 100, "train": 100, "busybox": 100, "all": 100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:          newTestFrameworkWithNodes(t, nil, nodeInfos),
+				scoreMode:       compositeMode,
+				scoreComponents: tt.components,
+			}
+			for _, score := range scoreNodes(t, cs, pod, nodeInfos) {
+				if score.Score != tt.want[score.Name] {
+					t.Errorf("expected node %s to score %d, got %d", score.Name, tt.want[score.Name], score.Score)
+				}
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_PreScore_ImageLocalityOutsideCompositeMode(t *testing.T) {
+	nodeInfos := []*framework.NodeInfo{makeNodeInfo("node1", 4000, 8<<30)}
+	nodeInfos[0].Node().Status.Images = []v1.ContainerImage{{Names: []string{"docker.io/library/busybox:latest"}, SizeBytes: 1000}}
+	cs := &CustomScheduler{
+		handle:          newTestFrameworkWithNodes(t, nil, nodeInfos),
+		scoreMode:       leastMode,
+		scoreComponents: []ScoreComponent{{Name: componentImageLocality, Weight: 1}},
+	}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod"},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "busybox", Image: "busybox"}}},
+	}
+	state := framework.NewCycleState()
+	if status := cs.PreScore(context.Background(), state, pod, []*v1.Node{nodeInfos[0].Node()}); !status.IsSuccess() {
+		t.Fatalf("unexpected error: %v", status)
+	}
+	s, err := readPreScoreState(state)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.nodeImageBytes != nil || s.maxImageBytes != 0 {
+		t.Errorf("expected no image locality outside of the Composite mode, got %v", s.nodeImageBytes)
+	}
+}
@@ -0,0 +1,74 @@
+package plugins
+
+import (
+	"fmt"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// groupIndexMu serializes adding the group index, since the profiles of a
+// scheduler share the pod informer and may create their plugins concurrently.
+var groupIndexMu sync.Mutex
+
+// addGroupIndexer indexes the pods of the informer by group so that the
+// members of a group can be fetched without walking every pod. Each pod is
+// indexed under "<namespace>/<group>" and under "<group>", which serves
+// namespaced and cluster-wide groups alike since neither a namespace nor a
+// label value contains a slash. The index only depends on the group labels,
+// so plugins of other profiles recognizing the same labels reuse it.
+func (cs *CustomScheduler) addGroupIndexer(informer cache.SharedIndexInformer) error {
+	groupLabelKey, coschedulingLabels := cs.groupLabelKey, cs.coschedulingLabels
+	indexName := fmt.Sprintf("%s/%s", Name, groupLabelKey)
+	if coschedulingLabels {
+		indexName += "," + coschedulingGroupLabel
+	}
+	groupIndexMu.Lock()
+	defer groupIndexMu.Unlock()
+	if _, ok := informer.GetIndexer().GetIndexers()[indexName]; !ok {
+		err := informer.AddIndexers(cache.Indexers{
+			indexName: func(obj interface{}) ([]string, error) {
+				pod, ok := obj.(*v1.Pod)
+				if !ok {
+					return nil, nil
+				}
+				group, ok := groupName(pod, groupLabelKey, coschedulingLabels)
+				if !ok {
+					return nil, nil
+				}
+				return []string{pod.Namespace + "/" + group, group}, nil
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cs.podIndexer = informer.GetIndexer()
+	cs.groupIndexName = indexName
+	return nil
+}
+
+// indexedGroupPods returns the pods of the group from the group index.
+func (cs *CustomScheduler) indexedGroupPods(namespace, group string) ([]*v1.Pod, error) {
+	objs, err := cs.podIndexer.ByIndex(cs.groupIndexName, cs.groupKey(namespace, group))
+	if err != nil {
+		return nil, err
+	}
+	pods := make([]*v1.Pod, 0, len(objs))
+	for _, obj := range objs {
+		if pod, ok := obj.(*v1.Pod); ok {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+// setupGroupIndexer adds the group index, falling back to listing pods by
+// label selector when that isn't possible.
+func (cs *CustomScheduler) setupGroupIndexer(informer cache.SharedIndexInformer) {
+	if err := cs.addGroupIndexer(informer); err != nil {
+		klog.ErrorS(err, "Failed to add the group index, listing pods by selector instead")
+	}
+}
@@ -0,0 +1,127 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_GroupIndexer(t *testing.T) {
+	pods := []*v1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "a0", Namespace: "team-a", Labels: map[string]string{"podGroup": "exp1"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "a1", Namespace: "team-a", Labels: map[string]string{"podGroup": "exp2"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "b0", Namespace: "team-b", Labels: map[string]string{"podGroup": "exp1"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "c0", Namespace: "team-a"}},
+	}
+	tests := []struct {
+		name        string
+		clusterWide bool
+		want        []string
+	}{
+		{
+			name: "namespaced group",
+			want: []string{"team-a/a0"},
+		},
+		{
+			name:        "cluster-wide group",
+			clusterWide: true,
+			want:        []string{"team-a/a0", "team-b/b0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := newTestFrameworkWithPods(t, nil)
+			cs := &CustomScheduler{
+				handle:            fh,
+				groupLabelKey:     groupNameLabel,
+				clusterWideGroups: tt.clusterWide,
+			}
+			informer := fh.SharedInformerFactory().Core().V1().Pods().Informer()
+			if err := cs.addGroupIndexer(informer); err != nil {
+				t.Fatalf("fail to add the group index: %v", err)
+			}
+			for _, p := range pods {
+				informer.GetStore().Add(p)
+			}
+
+			got, err := cs.groupManager().Members("team-a", "exp1")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var keys []string
+			for _, p := range got {
+				keys = append(keys, podKey(p))
+			}
+			sort.Strings(keys)
+			if !reflect.DeepEqual(keys, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, keys)
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_GroupIndexer_Fallback(t *testing.T) {
+	existing := []*v1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "pod0", Labels: map[string]string{"podGroup": "g1"}}}}
+	fh := newTestFrameworkWithPods(t, existing)
+	cs := &CustomScheduler{handle: fh, groupLabelKey: groupNameLabel}
+	// indexes can't be added to a store that already holds pods
+	cs.setupGroupIndexer(fh.SharedInformerFactory().Core().V1().Pods().Informer())
+	if cs.podIndexer != nil {
+		t.Fatalf("expected the group index not to be added")
+	}
+	got, err := cs.groupManager().Members("", "g1")
+	if err != nil || len(got) != 1 {
+		t.Errorf("expected the selector to find 1 pod, got %d, %v", len(got), err)
+	}
+}
+
+func BenchmarkCustomScheduler_PreFilter(b *testing.B) {
+	for _, indexed := range []bool{false, true} {
+		b.Run(fmt.Sprintf("indexed=%v", indexed), func(b *testing.B) {
+			fh := newTestFrameworkWithPods(b, nil)
+			cs := &CustomScheduler{
+				handle:               fh,
+				scoreMode:            leastMode,
+				groupLabelKey:        groupNameLabel,
+				minAvailableLabelKey: minAvailableLabel,
+			}
+			informer := fh.SharedInformerFactory().Core().V1().Pods().Informer()
+			if indexed {
+				if err := cs.addGroupIndexer(informer); err != nil {
+					b.Fatalf("fail to add the group index: %v", err)
+				}
+			}
+			// 10k pods in 1k groups of 10
+			for i := 0; i < 10000; i++ {
+				informer.GetStore().Add(&v1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      fmt.Sprintf("pod%d", i),
+						Namespace: "default",
+						Labels:    map[string]string{"podGroup": fmt.Sprintf("g%d", i%1000)},
+					},
+				})
+			}
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "incoming",
+					Namespace: "default",
+					Labels:    map[string]string{"podGroup": "g42", "minAvailable": "10"},
+				},
+			}
+			state := framework.NewCycleState()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, status := cs.PreFilter(context.Background(), state, pod); !status.IsSuccess() {
+					b.Fatalf("unexpected status: %v", status)
+				}
+			}
+		})
+	}
+}
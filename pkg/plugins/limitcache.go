@@ -0,0 +1,136 @@
+package plugins
+
+import (
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// limitCache keeps the sum of the memory limits of each node's pods across
+// scheduling cycles, for the Limits capacity policy. Summing the limits walks
+// the containers of every pod on the node, while what is left of the requests
+// comes straight from the NodeInfo, so only the sums are worth keeping. The
+// members of a gang are scheduled back to back on snapshots that barely
+// change between them, so most sums hold for every member. An entry is reused
+// as long as the node's NodeInfo has the generation and the requested
+// resources it was computed from.
+type limitCache struct {
+	mu    sync.Mutex
+	nodes map[string]nodeLimit
+}
+
+// nodeLimit is the sum of the memory limits of a node's pods.
+type nodeLimit struct {
+	generation int64
+	// requested are the requests of the node's pods the sum was computed
+	// with.
+	requested   *framework.Resource
+	memoryLimit int64
+}
+
+func newLimitCache() *limitCache {
+	return &limitCache{nodes: make(map[string]nodeLimit)}
+}
+
+// nodeResources is what is left on a node before the pod is placed on it.
+type nodeResources struct {
+	// free is the allocatable amount of each resource minus what the node's
+	// pods request, or hold while they are resized in place, with the pod
+	// slots left as AllowedPodNumber.
+	free *framework.Resource
+	// memoryLimit sums the memory limits of the node's pods. It is only
+	// computed with the Limits capacity policy.
+	memoryLimit int64
+}
+
+// nodeResources returns what is left on the node.
+func (cs *CustomScheduler) nodeResources(nodeInfo *framework.NodeInfo) *nodeResources {
+	allocatable, requested := nodeInfo.Allocatable, nodeInfo.Requested
+	free := &framework.Resource{
+		MilliCPU:         allocatable.MilliCPU - requested.MilliCPU,
+		Memory:           allocatable.Memory - requested.Memory,
+		EphemeralStorage: allocatable.EphemeralStorage - requested.EphemeralStorage,
+		AllowedPodNumber: allocatable.AllowedPodNumber - len(nodeInfo.Pods),
+	}
+	for name, amount := range allocatable.ScalarResources {
+		free.SetScalar(name, amount-requested.ScalarResources[name])
+	}
+	if adjustment := resizeAdjustment(nodeInfo); adjustment != nil {
+		free.MilliCPU -= adjustment.MilliCPU
+		free.Memory -= adjustment.Memory
+		free.EphemeralStorage -= adjustment.EphemeralStorage
+		for name, amount := range adjustment.ScalarResources {
+			if _, ok := free.ScalarResources[name]; ok {
+				free.SetScalar(name, free.ScalarResources[name]-amount)
+			}
+		}
+	}
+	r := &nodeResources{free: free}
+	if cs.capacityPolicy == capacityLimits {
+		r.memoryLimit = cs.cachedNodeMemoryLimit(nodeInfo)
+	}
+	return r
+}
+
+// cachedNodeMemoryLimit returns the sum of the memory limits of the node's
+// pods, from the cache when the node didn't change since it was summed.
+func (cs *CustomScheduler) cachedNodeMemoryLimit(nodeInfo *framework.NodeInfo) int64 {
+	c := cs.limitCache
+	if c == nil {
+		return cs.nodeMemoryLimit(nodeInfo)
+	}
+	name := nodeInfo.Node().Name
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if l, ok := c.nodes[name]; ok && l.generation == nodeInfo.Generation && sameResource(l.requested, nodeInfo.Requested) {
+		limitCacheLookups.WithLabelValues("hit").Inc()
+		return l.memoryLimit
+	}
+	limitCacheLookups.WithLabelValues("miss").Inc()
+	l := nodeLimit{
+		generation:  nodeInfo.Generation,
+		requested:   nodeInfo.Requested.Clone(),
+		memoryLimit: cs.nodeMemoryLimit(nodeInfo),
+	}
+	c.nodes[name] = l
+	return l.memoryLimit
+}
+
+// nodeResourcesOf returns what is left on each of the nodes, keyed by node
+// name. Nodes missing from the snapshot are left out.
+func (cs *CustomScheduler) nodeResourcesOf(nodes []*v1.Node) map[string]*nodeResources {
+	resources := make(map[string]*nodeResources, len(nodes))
+	for _, node := range nodes {
+		nodeInfo, err := cs.nodeInfoLister().Get(node.Name)
+		if err != nil || nodeInfo.Node() == nil {
+			continue
+		}
+		resources[node.Name] = cs.nodeResources(nodeInfo)
+	}
+	return resources
+}
+
+// forgetNode drops the cached sum of a node that was removed.
+func (c *limitCache) forgetNode(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.nodes, name)
+}
+
+// sameResource reports whether both resources hold the same amounts.
+func sameResource(a, b *framework.Resource) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.MilliCPU != b.MilliCPU || a.Memory != b.Memory || a.EphemeralStorage != b.EphemeralStorage ||
+		a.AllowedPodNumber != b.AllowedPodNumber || len(a.ScalarResources) != len(b.ScalarResources) {
+		return false
+	}
+	for name, amount := range a.ScalarResources {
+		if other, ok := b.ScalarResources[name]; !ok || other != amount {
+			return false
+		}
+	}
+	return true
+}
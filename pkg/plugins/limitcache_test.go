@@ -0,0 +1,160 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/component-base/metrics/testutil"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func makeLimitedPod(name string, memory int64) *v1.Pod {
+	quantity := *resource.NewQuantity(memory, resource.BinarySI)
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1.PodSpec{Containers: []v1.Container{{Resources: v1.ResourceRequirements{
+			Requests: v1.ResourceList{v1.ResourceMemory: quantity},
+			Limits:   v1.ResourceList{v1.ResourceMemory: quantity},
+		}}}},
+	}
+}
+
+func TestCustomScheduler_NodeResources(t *testing.T) {
+	RegisterMetrics()
+	nodeInfo := makeNodeInfo("node1", 4000, 8<<30)
+	nodeInfo.AddPod(makeLimitedPod("pod1", 1<<30))
+	cs := &CustomScheduler{capacityPolicy: capacityLimits, limitCache: newLimitCache()}
+	lookups := func(result string) float64 {
+		value, err := testutil.GetCounterMetricValue(limitCacheLookups.WithLabelValues(result))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return value
+	}
+	hits, misses := lookups("hit"), lookups("miss")
+	check := func(wantHits, wantMisses float64, wantFree, wantLimit int64) {
+		t.Helper()
+		r := cs.nodeResources(nodeInfo)
+		if r.free.Memory != wantFree || r.memoryLimit != wantLimit {
+			t.Errorf("expected %d bytes free and %d limited, got %d and %d", wantFree, wantLimit, r.free.Memory, r.memoryLimit)
+		}
+		if got := lookups("hit") - hits; got != wantHits {
+			t.Errorf("expected %v hits, got %v", wantHits, got)
+		}
+		if got := lookups("miss") - misses; got != wantMisses {
+			t.Errorf("expected %v misses, got %v", wantMisses, got)
+		}
+	}
+
+	check(0, 1, 7<<30, 1<<30)
+	// the next member of the gang sees the same node
+	check(1, 1, 7<<30, 1<<30)
+	// a pod placed on the node changes its generation
+	nodeInfo.AddPod(makeLimitedPod("pod2", 2<<30))
+	check(1, 2, 5<<30, 3<<30)
+	// the requests changing under the same generation invalidate the entry
+	nodeInfo.Requested.Memory = 4 << 30
+	check(1, 3, 4<<30, 3<<30)
+	// the memory limits aren't looked up with the Requests capacity policy
+	cs.capacityPolicy = capacityRequests
+	check(1, 3, 4<<30, 0)
+	cs.capacityPolicy = capacityLimits
+	check(2, 3, 4<<30, 3<<30)
+
+	cs.limitCache.forgetNode("node1")
+	check(2, 4, 4<<30, 3<<30)
+}
+
+func TestCustomScheduler_Score_LimitCache(t *testing.T) {
+	nodeInfos := []*framework.NodeInfo{makeNodeInfo("node1", 4000, 8<<30), makeNodeInfo("node2", 4000, 8<<30)}
+	nodeInfos[0].AddPod(makeLimitedPod("pod1", 1<<30))
+	nodeInfos[1].AddPod(makeLimitedPod("pod2", 3<<30))
+	var nodes []*v1.Node
+	for _, nodeInfo := range nodeInfos {
+		nodes = append(nodes, nodeInfo.Node())
+	}
+	pod := makeLimitedPod("incoming", 1<<30)
+	for _, policy := range []string{capacityRequests, capacityLimits} {
+		t.Run(policy, func(t *testing.T) {
+			h := newTestFrameworkWithNodes(t, nil, nodeInfos)
+			cached := &CustomScheduler{handle: h, scoreMode: leastMode, capacityPolicy: policy, limitCache: newLimitCache()}
+			uncached := &CustomScheduler{handle: h, scoreMode: leastMode, capacityPolicy: policy}
+			// scores stay the same with the cache, across members too
+			for member := 0; member < 2; member++ {
+				cachedState, uncachedState := framework.NewCycleState(), framework.NewCycleState()
+				if status := cached.PreScore(context.Background(), cachedState, pod, nodes); !status.IsSuccess() {
+					t.Fatalf("unexpected error: %v", status)
+				}
+				if status := uncached.PreScore(context.Background(), uncachedState, pod, nodes); !status.IsSuccess() {
+					t.Fatalf("unexpected error: %v", status)
+				}
+				for _, node := range nodes {
+					want, _ := uncached.Score(context.Background(), uncachedState, pod, node.Name)
+					got, status := cached.Score(context.Background(), cachedState, pod, node.Name)
+					if !status.IsSuccess() {
+						t.Fatalf("unexpected error: %v", status)
+					}
+					if got != want {
+						t.Errorf("expected node %s to score %d with the cache, got %d", node.Name, want, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCustomScheduler_ScoreGang(b *testing.B) {
+	const members = 32
+	newNodeInfos := func() []*framework.NodeInfo {
+		var nodeInfos []*framework.NodeInfo
+		for i := 0; i < 1000; i++ {
+			nodeInfo := makeNodeInfo(fmt.Sprintf("node%d", i), 4000, 64<<30)
+			for j := 0; j < 20; j++ {
+				nodeInfo.AddPod(makeLimitedPod(fmt.Sprintf("pod%d-%d", i, j), 1<<30))
+			}
+			nodeInfos = append(nodeInfos, nodeInfo)
+		}
+		return nodeInfos
+	}
+	for _, cached := range []bool{false, true} {
+		b.Run(fmt.Sprintf("cache=%v", cached), func(b *testing.B) {
+			nodeInfos := newNodeInfos()
+			var nodes []*v1.Node
+			for _, nodeInfo := range nodeInfos {
+				nodes = append(nodes, nodeInfo.Node())
+			}
+			cs := &CustomScheduler{
+				handle:         newTestFrameworkWithNodes(b, nil, nodeInfos),
+				scoreMode:      leastMode,
+				capacityPolicy: capacityLimits,
+			}
+			if cached {
+				cs.limitCache = newLimitCache()
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// the members are placed one after the other, each changing
+				// one node
+				for member := 0; member < members; member++ {
+					pod := makeLimitedPod(fmt.Sprintf("member%d-%d", i, member), 1<<30)
+					state := framework.NewCycleState()
+					if status := cs.PreScore(context.Background(), state, pod, nodes); !status.IsSuccess() {
+						b.Fatalf("unexpected error: %v", status)
+					}
+					for _, node := range nodes {
+						if _, status := cs.Score(context.Background(), state, pod, node.Name); !status.IsSuccess() {
+							b.Fatalf("unexpected error: %v", status)
+						}
+					}
+					b.StopTimer()
+					nodeInfos[member%len(nodeInfos)].Requested.Memory++
+					b.StartTimer()
+				}
+			}
+		})
+	}
+}
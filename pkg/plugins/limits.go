@@ -0,0 +1,27 @@
+package plugins
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// podMemoryLimit returns the memory the pod may use with the Limits capacity
+// policy. A pod limiting and requesting no memory is taken to use
+// defaultMemoryRequest.
+func (cs *CustomScheduler) podMemoryLimit(pod *v1.Pod) int64 {
+	limits := podEffectiveLimits(pod)
+	limit := limits.Memory()
+	if limit.IsZero() {
+		return cs.defaultMemoryRequest.Value()
+	}
+	return limit.Value()
+}
+
+// nodeMemoryLimit sums the memory limits of the pods on the node.
+func (cs *CustomScheduler) nodeMemoryLimit(nodeInfo *framework.NodeInfo) int64 {
+	var sum int64
+	for _, podInfo := range nodeInfo.Pods {
+		sum += cs.podMemoryLimit(podInfo.Pod)
+	}
+	return sum
+}
@@ -0,0 +1,87 @@
+package plugins
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_Score_CapacityPolicy(t *testing.T) {
+	makePod := func(name, request, limit string) *v1.Pod {
+		resources := v1.ResourceRequirements{Requests: v1.ResourceList{}, Limits: v1.ResourceList{}}
+		if request != "" {
+			resources.Requests[v1.ResourceMemory] = resource.MustParse(request)
+		}
+		if limit != "" {
+			resources.Limits[v1.ResourceMemory] = resource.MustParse(limit)
+		}
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Resources: resources}}},
+		}
+	}
+	makeNode := func(name string, pods ...*v1.Pod) *framework.NodeInfo {
+		nodeInfo := makeNodeInfo(name, 4000, 4<<30)
+		for _, p := range pods {
+			nodeInfo.AddPod(p)
+		}
+		return nodeInfo
+	}
+	nodeInfos := []*framework.NodeInfo{
+		// overcommitted: little requested, but a lot allowed
+		makeNode("burstable", makePod("burstable-0", "1Gi", "3Gi")),
+		makeNode("guaranteed", makePod("guaranteed-0", "2Gi", "2Gi")),
+		// counts with its request of 2560Mi under both policies
+		makeNode("unlimited", makePod("unlimited-0", "2560Mi", "")),
+		// counts with the default of 200Mi under the Limits policy
+		makeNode("besteffort", makePod("besteffort-0", "", ""), makePod("besteffort-1", "3Gi", "3Gi")),
+	}
+	pod := makePod("incoming", "256Mi", "512Mi")
+	tests := []struct {
+		name   string
+		policy string
+		want   map[string]int64
+	}{
+		{
+			name:   "requests",
+			policy: capacityRequests,
+			// 2816Mi, 1792Mi, 1280Mi and 768Mi left
+			want: map[string]int64{"burstable": 100, "guaranteed": 50, "unlimited": 25, "besteffort": 0},
+		},
+		{
+			name:   "limits",
+			policy: capacityLimits,
+			// 512Mi, 1536Mi, 1024Mi and 312Mi left
+			want: map[string]int64{"burstable": 16, "guaranteed": 100, "unlimited": 58, "besteffort": 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:               newTestFrameworkWithNodes(t, nil, nodeInfos),
+				scoreMode:            mostMode,
+				defaultMemoryRequest: resource.MustParse("200Mi"),
+				capacityPolicy:       tt.policy,
+			}
+			for _, score := range scoreNodes(t, cs, pod, nodeInfos) {
+				if score.Score != tt.want[score.Name] {
+					t.Errorf("expected node %s to score %d, got %d", score.Name, tt.want[score.Name], score.Score)
+				}
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_PodMemoryLimit(t *testing.T) {
+	// a container limiting memory without requesting any
+	pod := &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{{Resources: v1.ResourceRequirements{
+		Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")},
+	}}}}}
+	cs := &CustomScheduler{defaultMemoryRequest: resource.MustParse("200Mi")}
+	if got := cs.podMemoryLimit(pod); got != 1<<30 {
+		t.Errorf("expected a limit of 1Gi, got %d", got)
+	}
+}
@@ -0,0 +1,94 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// captureKlog sends the klog output at the given verbosity to a buffer until
+// the test ends.
+func captureKlog(t *testing.T, verbosity string) *bytes.Buffer {
+	t.Helper()
+	fs := flag.NewFlagSet("klog", flag.ContinueOnError)
+	klog.InitFlags(fs)
+	for name, value := range map[string]string{"logtostderr": "false", "alsologtostderr": "false", "stderrthreshold": "FATAL", "v": verbosity} {
+		if err := fs.Set(name, value); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	var buf bytes.Buffer
+	klog.SetOutput(&buf)
+	t.Cleanup(func() {
+		klog.Flush()
+		klog.SetOutput(os.Stderr)
+		fs.Set("logtostderr", "true")
+		fs.Set("v", "0")
+	})
+	return &buf
+}
+
+func TestCustomScheduler_Logging(t *testing.T) {
+	// a complete group of two: the other member is already bound
+	member := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "member", Namespace: "default", Labels: map[string]string{"podGroup": "g1", "minAvailable": "2"}},
+		Spec:       v1.PodSpec{NodeName: "node1"},
+	}
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "incoming", Namespace: "default", Labels: map[string]string{"podGroup": "g1", "minAvailable": "2"}}}
+	nodeInfos := []*framework.NodeInfo{
+		makeNodeInfo("node1", 4000, 4<<30),
+		makeNodeInfo("node2", 4000, 2<<30),
+	}
+	tests := []struct {
+		verbosity string
+		// wantLogs are part of the expected output, which is empty if unset
+		wantLogs []string
+	}{
+		{verbosity: "0"},
+		{verbosity: "5", wantLogs: []string{`"Checking the group of the pod" pod="default/incoming" group="g1" minAvailable=2`, `"Scored the node" pod="default/incoming" node="node2"`}},
+	}
+	for _, tt := range tests {
+		t.Run("v="+tt.verbosity, func(t *testing.T) {
+			p, err := New(nil, newTestFrameworkWithNodes(t, []*v1.Pod{member, pod}, nodeInfos))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			cs := p.(*CustomScheduler)
+			// the pods are added to the informer's store without running it
+			cs.podsSynced = nil
+			buf := captureKlog(t, tt.verbosity)
+
+			ctx := context.Background()
+			state := framework.NewCycleState()
+			if _, status := cs.PreFilter(ctx, state, pod); !status.IsSuccess() {
+				t.Fatalf("unexpected PreFilter status: %v", status)
+			}
+			scoreNodes(t, cs, pod, nodeInfos)
+			if status, _ := cs.Permit(ctx, state, pod, "node1"); !status.IsSuccess() {
+				t.Fatalf("unexpected Permit status: %v", status)
+			}
+			if status := cs.Reserve(ctx, state, pod, "node1"); !status.IsSuccess() {
+				t.Fatalf("unexpected Reserve status: %v", status)
+			}
+			klog.Flush()
+
+			got := buf.String()
+			if len(tt.wantLogs) == 0 && got != "" {
+				t.Errorf("expected no logs, got:\n%s", got)
+			}
+			for _, want := range tt.wantLogs {
+				if !strings.Contains(got, want) {
+					t.Errorf("expected the logs to contain %s, got:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
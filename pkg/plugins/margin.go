@@ -0,0 +1,73 @@
+package plugins
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// memoryMarginAnnotation opts a pod out of the memory safety margin when set
+// to disabledValue.
+const (
+	memoryMarginAnnotation = "scheduler.nthu.io/memory-safety-margin"
+	disabledValue          = "disabled"
+)
+
+// parseMemorySafetyMargin parses a memory safety margin, either a quantity
+// such as "512Mi" or a percentage of the allocatable memory such as "5%".
+func parseMemorySafetyMargin(value string) (bytes, percent int64, err error) {
+	if value == "" {
+		return 0, 0, nil
+	}
+	if number, isPercent := strings.CutSuffix(value, "%"); isPercent {
+		percent, err = strconv.ParseInt(number, 10, 64)
+		if err != nil || percent < 0 || percent >= 100 {
+			return 0, 0, fmt.Errorf("%q is not a percentage between 0%% and 99%%", value)
+		}
+		return 0, percent, nil
+	}
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil || quantity.Sign() < 0 {
+		return 0, 0, fmt.Errorf("%q is not a non-negative quantity or a percentage", value)
+	}
+	return quantity.Value(), 0, nil
+}
+
+// checkMemoryMargin rejects the node when the memory left on it once the
+// pod's requests are placed would fall below the safety margin. The node's
+// headroom is taken off its allocatable memory first. A pod opted out of the
+// margin still can't eat into the headroom, which belongs to the node.
+func (cs *CustomScheduler) checkMemoryMargin(pod *v1.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
+	headroom := cs.nodeHeadroom(nodeInfo.Node())
+	optedOut := pod.Annotations[memoryMarginAnnotation] == disabledValue
+	if (optedOut || cs.memorySafetyMargin == 0 && cs.memorySafetyMarginPercent == 0) && headroom == 0 {
+		return nil
+	}
+	allocatable, requested, podRequest, _ := nodeAmounts(cs.podRequests(pod), nodeInfo, v1.ResourceMemory)
+	var margin int64
+	switch {
+	case optedOut:
+	case cs.memorySafetyMarginPercent > 0:
+		margin = allocatable / 100 * cs.memorySafetyMarginPercent
+	default:
+		margin = cs.memorySafetyMargin
+	}
+	if left := cs.withoutHeadroom(allocatable, nodeInfo.Node()) - requested - podRequest; left < margin {
+		details := fmt.Sprintf("allocatable %s", formatBytes(allocatable))
+		if headroom > 0 {
+			details += fmt.Sprintf(", headroom %s", formatBytes(headroom))
+		}
+		return framework.NewStatus(framework.Unschedulable, fmt.Sprintf("Node would have %s of memory left after the pod, below the safety margin of %s (%s, requested %s, pod %s)",
+			formatBytes(left), formatBytes(margin), details, formatBytes(requested), formatBytes(podRequest)))
+	}
+	return nil
+}
+
+// formatBytes formats an amount of memory for a status message.
+func formatBytes(bytes int64) string {
+	return resource.NewQuantity(bytes, resource.BinarySI).String()
+}
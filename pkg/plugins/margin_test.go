@@ -0,0 +1,79 @@
+package plugins
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_Filter_MemorySafetyMargin(t *testing.T) {
+	tests := []struct {
+		name        string
+		margin      string
+		annotations map[string]string
+		want        framework.Code
+		// wantMessage is part of the expected message, if any
+		wantMessage string
+	}{
+		{
+			name: "no margin",
+			want: framework.Success,
+		},
+		{
+			name:   "exactly the margin left",
+			margin: "3Gi",
+			want:   framework.Success,
+		},
+		{
+			name:        "one byte short of the margin",
+			margin:      "3221225473",
+			want:        framework.Unschedulable,
+			wantMessage: "Node would have 3Gi of memory left after the pod, below the safety margin of 3221225473 (allocatable 4Gi, requested 0, pod 1Gi)",
+		},
+		{
+			name:   "percentage left",
+			margin: "75%",
+			want:   framework.Success,
+		},
+		{
+			name:        "percentage short",
+			margin:      "76%",
+			want:        framework.Unschedulable,
+			wantMessage: "below the safety margin of",
+		},
+		{
+			name:        "pod opted out",
+			margin:      "76%",
+			annotations: map[string]string{memoryMarginAnnotation: disabledValue},
+			want:        framework.Success,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{}
+			var err error
+			cs.memorySafetyMargin, cs.memorySafetyMarginPercent, err = parseMemorySafetyMargin(tt.margin)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "pod", Annotations: tt.annotations},
+				Spec: v1.PodSpec{Containers: []v1.Container{{Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+					v1.ResourceMemory: resource.MustParse("1Gi"),
+				}}}}},
+			}
+			status := cs.Filter(context.Background(), nil, pod, makeNodeInfo("node1", 4000, 4<<30))
+			if status.Code() != tt.want {
+				t.Fatalf("expected %v, got %v: %s", tt.want, status.Code(), status.Message())
+			}
+			if !strings.Contains(status.Message(), tt.wantMessage) {
+				t.Errorf("expected message containing %q, got %q", tt.wantMessage, status.Message())
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package plugins
+
+import (
+	"fmt"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// checkMaxAvailable rejects the pod when its group already has as many
+// members assigned to nodes as the maxAvailable label allows. Pods without
+// the label are never limited.
+func (cs *CustomScheduler) checkMaxAvailable(pod *v1.Pod, group string, pods []*v1.Pod) *framework.Status {
+	labelKey := cs.config().maxAvailableLabelKey
+	value, ok := pod.Labels[labelKey]
+	if !ok {
+		return nil
+	}
+	maxAvailable, err := strconv.Atoi(value)
+	if err != nil || maxAvailable < 1 {
+		return framework.NewStatus(framework.Unschedulable, fmt.Sprintf("Invalid maxAvailable value: label %s %q is not a positive integer", labelKey, value))
+	}
+	if assigned := cs.countAssignedMembers(pod, group, pods); assigned >= maxAvailable {
+		return framework.NewStatus(framework.Unschedulable, fmt.Sprintf("Pod cannot be scheduled because the group '%s' already has %d pods assigned, and allows at most %d", group, assigned, maxAvailable))
+	}
+	return nil
+}
+
+// countAssignedMembers returns the number of other live members of the group
+// that are bound to a node or reserved on one by this scheduler.
+func (cs *CustomScheduler) countAssignedMembers(pod *v1.Pod, group string, pods []*v1.Pod) int {
+	s := cs.groupManager().assignedMembers(pod.Namespace, group, pods)
+	s.Delete(pod.UID)
+	return s.Len()
+}
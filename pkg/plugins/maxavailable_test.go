@@ -0,0 +1,102 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_PreFilter_MaxAvailable(t *testing.T) {
+	// makeMembers returns the given number of assigned and unassigned members
+	makeMembers := func(assigned, unassigned int) []*v1.Pod {
+		var pods []*v1.Pod
+		for i := 0; i < assigned+unassigned; i++ {
+			pod := makeGangPod(fmt.Sprintf("pod%d", i), "g1", 1)
+			if i < assigned {
+				pod.Spec.NodeName = "node1"
+			}
+			pods = append(pods, pod)
+		}
+		return pods
+	}
+	finished := makeMembers(2, 0)
+	for _, pod := range finished {
+		pod.Status.Phase = v1.PodSucceeded
+	}
+	tests := []struct {
+		name         string
+		existing     []*v1.Pod
+		maxAvailable string
+		want         framework.Code
+		wantMessage  string
+	}{
+		{
+			name:     "no limit without the label",
+			existing: makeMembers(5, 0),
+			want:     framework.Success,
+		},
+		{
+			name:         "below the limit",
+			existing:     makeMembers(1, 0),
+			maxAvailable: "2",
+			want:         framework.Success,
+		},
+		{
+			name:         "at the limit",
+			existing:     makeMembers(2, 0),
+			maxAvailable: "2",
+			want:         framework.Unschedulable,
+			wantMessage:  "Pod cannot be scheduled because the group 'g1' already has 2 pods assigned, and allows at most 2",
+		},
+		{
+			name:         "above the limit",
+			existing:     makeMembers(3, 0),
+			maxAvailable: "2",
+			want:         framework.Unschedulable,
+		},
+		{
+			name:         "unassigned members do not count",
+			existing:     makeMembers(1, 3),
+			maxAvailable: "2",
+			want:         framework.Success,
+		},
+		{
+			name:         "finished members do not count",
+			existing:     append(finished, makeMembers(1, 0)...),
+			maxAvailable: "2",
+			want:         framework.Success,
+		},
+		{
+			name:         "invalid label",
+			existing:     makeMembers(0, 0),
+			maxAvailable: "0",
+			want:         framework.Unschedulable,
+			wantMessage:  `Invalid maxAvailable value: label maxAvailable "0" is not a positive integer`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:               newTestFrameworkWithPods(t, tt.existing),
+				scoreMode:            leastMode,
+				groupLabelKey:        groupNameLabel,
+				minAvailableLabelKey: minAvailableLabel,
+				maxAvailableLabelKey: maxAvailableLabel,
+			}
+			pod := makeGangPod("incoming", "g1", 1)
+			if tt.maxAvailable != "" {
+				pod.Labels[maxAvailableLabel] = tt.maxAvailable
+			}
+			_, status := cs.PreFilter(context.Background(), nil, pod)
+			if status.Code() != tt.want {
+				t.Fatalf("expected %v, got %v: %s", tt.want, status.Code(), status.Message())
+			}
+			if tt.wantMessage != "" && status.Message() != tt.wantMessage {
+				t.Errorf("expected message %q, got %q", tt.wantMessage, status.Message())
+			}
+		})
+	}
+}
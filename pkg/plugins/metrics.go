@@ -0,0 +1,235 @@
+package plugins
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const metricsSubsystem = "custom_scheduler"
+
+var (
+	minAvailableConflicts = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "min_available_conflicts_total",
+			Help:           "Number of times members of a group disagreed on minAvailable, by conflict policy.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"policy"},
+	)
+
+	mixedSchedulerGroups = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "mixed_scheduler_name_groups_total",
+			Help:           "Number of gang checks that found members of one group assigned to different schedulers.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	groupMembers = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "group_members",
+			Help:           "Number of live members of a group counted at its last PreFilter.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"namespace", "group"},
+	)
+
+	groupMinAvailable = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "group_min_available",
+			Help:           "minAvailable of a group at its last PreFilter.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"namespace", "group"},
+	)
+
+	groupPreFilterRejections = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "group_prefilter_rejections_total",
+			Help:           "Number of members of a group rejected in PreFilter.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"namespace", "group"},
+	)
+
+	preFilterDuration = metrics.NewHistogram(
+		&metrics.HistogramOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "prefilter_duration_seconds",
+			Help:           "Latency of PreFilter, including the listing of the group members.",
+			Buckets:        durationBuckets,
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	scoreDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "score_duration_seconds",
+			Help:           "Latency of scoring a single node, by score mode.",
+			Buckets:        durationBuckets,
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"mode"},
+	)
+
+	normalizeScoreDuration = metrics.NewHistogram(
+		&metrics.HistogramOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "normalize_score_duration_seconds",
+			Help:           "Latency of NormalizeScore over all the scored nodes.",
+			Buckets:        durationBuckets,
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	preFilterRejections = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "prefilter_rejections_total",
+			Help:           "Number of pods rejected in PreFilter, by reason.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"reason"},
+	)
+
+	groupBreakerTrips = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "group_breaker_trips",
+			Help:           "Number of times in a row the circuit breaker of a group tripped, 0 once it was reset.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"namespace", "group"},
+	)
+
+	panics = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "panics_total",
+			Help:           "Number of panics recovered in the plugin, by extension point.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"extension_point"},
+	)
+
+	limitCacheLookups = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "limit_cache_lookups_total",
+			Help:           "Number of lookups of the memory limits of a node's pods in the limit cache, by result.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"result"},
+	)
+
+	namespaceActiveGroups = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "namespace_active_groups",
+			Help:           "Number of groups of a namespace admitted to schedule at once.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"namespace"},
+	)
+
+	namespaceQueuedGroups = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "namespace_queued_groups",
+			Help:           "Number of complete groups of a namespace waiting for another group to finish scheduling.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"namespace"},
+	)
+
+	dryRunPlacements = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "dry_run_placements_total",
+			Help:           "Number of bound pods by dry-run mode and whether the mode ranked their node first.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"mode", "result"},
+	)
+
+	groupMemberNodes = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "group_member_nodes_info",
+			Help:           "Nodes the scheduled members of a group were bound to, as a comma-separated list. Set to 1.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"namespace", "group", "nodes"},
+	)
+
+	metricsList = []metrics.Registerable{
+		minAvailableConflicts,
+		mixedSchedulerGroups,
+		groupMembers,
+		groupMinAvailable,
+		groupPreFilterRejections,
+		preFilterDuration,
+		scoreDuration,
+		normalizeScoreDuration,
+		preFilterRejections,
+		groupBreakerTrips,
+		panics,
+		limitCacheLookups,
+		namespaceActiveGroups,
+		namespaceQueuedGroups,
+		dryRunPlacements,
+		groupMemberNodes,
+	}
+)
+
+// durationBuckets range from 10µs to about 160ms, since the plugin works on
+// the informer caches and never waits for the API server in these phases.
+var durationBuckets = metrics.ExponentialBuckets(0.00001, 2, 15)
+
+// Reasons of the PreFilter rejections. Rejections for other reasons, such as
+// a blocked group or a maxAvailable limit, are counted as other.
+const (
+	rejectionInvalidMinAvailable = "invalid_min_available"
+	rejectionGroupIncomplete     = "group_incomplete"
+	rejectionListerError         = "lister_error"
+	rejectionCacheNotSynced      = "cache_not_synced"
+	rejectionQuotaExceeded       = "quota_exceeded"
+	rejectionOther               = "other"
+)
+
+var registerMetrics sync.Once
+
+// RegisterMetrics registers the plugin metrics with the scheduler's registry.
+func RegisterMetrics() {
+	registerMetrics.Do(func() {
+		for _, metric := range metricsList {
+			legacyregistry.MustRegister(metric)
+		}
+	})
+}
+
+// groupMetricLabels returns the namespace and group label values of the
+// group's series. Cluster-wide groups are reported without a namespace.
+func (cs *CustomScheduler) groupMetricLabels(namespace, group string) []string {
+	if cs.clusterWideGroups {
+		namespace = ""
+	}
+	return []string{namespace, group}
+}
+
+// deleteGroupMetrics drops the series of a group that has no members left.
+func (cs *CustomScheduler) deleteGroupMetrics(namespace, group string) {
+	labels := cs.groupMetricLabels(namespace, group)
+	groupMembers.DeleteLabelValues(labels...)
+	groupMinAvailable.DeleteLabelValues(labels...)
+	groupPreFilterRejections.DeleteLabelValues(labels...)
+	groupBreakerTrips.DeleteLabelValues(labels...)
+}
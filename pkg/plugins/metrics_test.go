@@ -0,0 +1,136 @@
+package plugins
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/component-base/metrics/testutil"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_GroupMetrics(t *testing.T) {
+	RegisterMetrics()
+	groupMembers.Reset()
+	groupMinAvailable.Reset()
+	groupPreFilterRejections.Reset()
+
+	existing := []*v1.Pod{makeGangPod("pod0", "g1", 3), makeGangPod("pod1", "g1", 3), makeGangPod("pod2", "g2", 1)}
+	for _, pod := range existing {
+		pod.Namespace = "default"
+	}
+	fh := newTestFrameworkWithPods(t, existing)
+	cs := &CustomScheduler{
+		handle:               fh,
+		scoreMode:            leastMode,
+		groupLabelKey:        groupNameLabel,
+		minAvailableLabelKey: minAvailableLabel,
+	}
+	for _, pod := range []*v1.Pod{existing[0], existing[0], existing[2]} {
+		cs.PreFilter(context.Background(), nil, pod)
+	}
+
+	names := []string{
+		"custom_scheduler_group_members",
+		"custom_scheduler_group_min_available",
+		"custom_scheduler_group_prefilter_rejections_total",
+	}
+	want := `
+# HELP custom_scheduler_group_members [ALPHA] Number of live members of a group counted at its last PreFilter.
+# TYPE custom_scheduler_group_members gauge
+custom_scheduler_group_members{group="g1",namespace="default"} 2
+custom_scheduler_group_members{group="g2",namespace="default"} 1
+# HELP custom_scheduler_group_min_available [ALPHA] minAvailable of a group at its last PreFilter.
+# TYPE custom_scheduler_group_min_available gauge
+custom_scheduler_group_min_available{group="g1",namespace="default"} 3
+custom_scheduler_group_min_available{group="g2",namespace="default"} 1
+# HELP custom_scheduler_group_prefilter_rejections_total [ALPHA] Number of members of a group rejected in PreFilter.
+# TYPE custom_scheduler_group_prefilter_rejections_total counter
+custom_scheduler_group_prefilter_rejections_total{group="g1",namespace="default"} 2
+`
+	if err := testutil.GatherAndCompare(legacyregistry.DefaultGatherer, strings.NewReader(want), names...); err != nil {
+		t.Fatal(err)
+	}
+
+	// the series of g2 go away with its last member
+	fh.SharedInformerFactory().Core().V1().Pods().Informer().GetStore().Delete(existing[2])
+	cs.onPodDelete(existing[2])
+	want = `
+# HELP custom_scheduler_group_members [ALPHA] Number of live members of a group counted at its last PreFilter.
+# TYPE custom_scheduler_group_members gauge
+custom_scheduler_group_members{group="g1",namespace="default"} 2
+# HELP custom_scheduler_group_min_available [ALPHA] minAvailable of a group at its last PreFilter.
+# TYPE custom_scheduler_group_min_available gauge
+custom_scheduler_group_min_available{group="g1",namespace="default"} 3
+# HELP custom_scheduler_group_prefilter_rejections_total [ALPHA] Number of members of a group rejected in PreFilter.
+# TYPE custom_scheduler_group_prefilter_rejections_total counter
+custom_scheduler_group_prefilter_rejections_total{group="g1",namespace="default"} 2
+`
+	if err := testutil.GatherAndCompare(legacyregistry.DefaultGatherer, strings.NewReader(want), names...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCustomScheduler_LatencyMetrics(t *testing.T) {
+	RegisterMetrics()
+	preFilterRejections.Reset()
+	preFilterCount, _ := testutil.GetHistogramMetricCount(preFilterDuration.ObserverMetric)
+	scoreCount, _ := testutil.GetHistogramMetricCount(scoreDuration.WithLabelValues(leastMode))
+	normalizeCount, _ := testutil.GetHistogramMetricCount(normalizeScoreDuration.ObserverMetric)
+
+	existing := []*v1.Pod{makeGangPod("pod0", "g1", 3), makeGangPod("pod1", "g1", 3), makeGangPod("pod2", "g2", 1)}
+	invalid := makeGangPod("pod3", "g3", 1)
+	invalid.Labels["minAvailable"] = "many"
+	nodeInfos := []*framework.NodeInfo{makeNodeInfo("node1", 4000, 4<<30), makeNodeInfo("node2", 4000, 2<<30)}
+	fh := newTestFrameworkWithNodes(t, existing, nodeInfos)
+	cs := &CustomScheduler{
+		handle:               fh,
+		scoreMode:            leastMode,
+		groupLabelKey:        groupNameLabel,
+		minAvailableLabelKey: minAvailableLabel,
+	}
+	for _, pod := range []*v1.Pod{existing[0], existing[2], invalid} {
+		cs.PreFilter(context.Background(), nil, pod)
+	}
+	// listing the members from a missing index fails
+	cs.podIndexer = fh.SharedInformerFactory().Core().V1().Pods().Informer().GetIndexer()
+	cs.groupIndexName = "missing"
+	cs.PreFilter(context.Background(), nil, existing[0])
+	scoreNodes(t, cs, existing[2], nodeInfos)
+
+	want := `
+# HELP custom_scheduler_prefilter_rejections_total [ALPHA] Number of pods rejected in PreFilter, by reason.
+# TYPE custom_scheduler_prefilter_rejections_total counter
+custom_scheduler_prefilter_rejections_total{reason="group_incomplete"} 1
+custom_scheduler_prefilter_rejections_total{reason="invalid_min_available"} 1
+custom_scheduler_prefilter_rejections_total{reason="lister_error"} 1
+`
+	if err := testutil.GatherAndCompare(legacyregistry.DefaultGatherer, strings.NewReader(want), "custom_scheduler_prefilter_rejections_total"); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name   string
+		metric metrics.ObserverMetric
+		before uint64
+		want   uint64
+	}{
+		{name: "PreFilter", metric: preFilterDuration.ObserverMetric, before: preFilterCount, want: 4},
+		{name: "Score", metric: scoreDuration.WithLabelValues(leastMode), before: scoreCount, want: 2},
+		{name: "NormalizeScore", metric: normalizeScoreDuration.ObserverMetric, before: normalizeCount, want: 1},
+	} {
+		count, err := testutil.GetHistogramMetricCount(tt.metric)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if count-tt.before != tt.want {
+			t.Errorf("expected %d %s observations, got %d", tt.want, tt.name, count-tt.before)
+		}
+		// the phases run on caches, so they take well below a second
+		if sum, _ := testutil.GetHistogramMetricValue(tt.metric); sum <= 0 || sum >= float64(count) {
+			t.Errorf("expected %s to take between 0 and 1s on average, got %vs over %d observations", tt.name, sum, count)
+		}
+	}
+}
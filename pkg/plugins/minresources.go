@@ -0,0 +1,100 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// minResourcesAnnotation holds the resources the members of a group must
+// request in total before any of them is scheduled, as a JSON object of
+// quantities such as {"cpu": "64", "memory": "512Gi"}.
+const minResourcesAnnotation = "scheduler.nthu.io/min-resources"
+
+// checkMinResources rejects the pod while the live members of its group
+// request less of a resource than the group's minResources. The PodGroup's
+// spec.minResources takes precedence over the annotation, which may be set
+// on any member. Groups without minResources are never held back.
+func (cs *CustomScheduler) checkMinResources(pod *v1.Pod, group string, pods []*v1.Pod) *framework.Status {
+	minResources, source, err := cs.groupMinResources(pod, group, pods)
+	if err != nil {
+		return framework.NewStatus(framework.Unschedulable, fmt.Sprintf("Invalid minResources value: %s %v", source, err))
+	}
+	if len(minResources) == 0 {
+		return nil
+	}
+	requested := v1.ResourceList{}
+	for _, p := range pods {
+		if !isActivePod(p) {
+			continue
+		}
+		for name, quantity := range PodEffectiveRequests(p) {
+			total := requested[name]
+			total.Add(quantity)
+			requested[name] = total
+		}
+	}
+	names := make([]string, 0, len(minResources))
+	for name := range minResources {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		required := minResources[v1.ResourceName(name)]
+		total := requested[v1.ResourceName(name)]
+		if total.Cmp(required) < 0 {
+			return framework.NewStatus(framework.Unschedulable, fmt.Sprintf("Pod cannot be scheduled because the group '%s' requests %s %s, but needs %s", group, total.String(), name, required.String()))
+		}
+	}
+	return nil
+}
+
+// groupMinResources returns the minResources of the group and where they
+// were read from, or nil when the group has none.
+func (cs *CustomScheduler) groupMinResources(pod *v1.Pod, group string, pods []*v1.Pod) (v1.ResourceList, string, error) {
+	if quantities, ok := cs.podGroupMinResources(pod.Namespace, group); ok {
+		source := fmt.Sprintf("PodGroup %s/%s", pod.Namespace, group)
+		minResources, err := parseMinResources(quantities)
+		return minResources, source, err
+	}
+	value, ok := pod.Annotations[minResourcesAnnotation]
+	if !ok {
+		// members are checked by name, so that all of them read the same
+		// annotation
+		members := make([]*v1.Pod, 0, len(pods))
+		for _, p := range pods {
+			if _, ok := p.Annotations[minResourcesAnnotation]; ok && isActivePod(p) {
+				members = append(members, p)
+			}
+		}
+		if len(members) == 0 {
+			return nil, "", nil
+		}
+		sort.Slice(members, func(i, j int) bool { return podKey(members[i]) < podKey(members[j]) })
+		value = members[0].Annotations[minResourcesAnnotation]
+	}
+	source := fmt.Sprintf("annotation %s", minResourcesAnnotation)
+	var quantities map[string]string
+	if err := json.Unmarshal([]byte(value), &quantities); err != nil {
+		return nil, source, err
+	}
+	minResources, err := parseMinResources(quantities)
+	return minResources, source, err
+}
+
+// parseMinResources parses the quantities of minResources.
+func parseMinResources(quantities map[string]string) (v1.ResourceList, error) {
+	minResources := make(v1.ResourceList, len(quantities))
+	for name, value := range quantities {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s %q: %w", name, value, err)
+		}
+		minResources[v1.ResourceName(name)] = quantity
+	}
+	return minResources, nil
+}
@@ -0,0 +1,112 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_PreFilter_MinResources(t *testing.T) {
+	makeMembers := func(count int, annotation string, annotated int) []*v1.Pod {
+		var pods []*v1.Pod
+		for i := 0; i < count; i++ {
+			p := makeGangPod(fmt.Sprintf("pod%d", i), "g1", 2)
+			if i == annotated {
+				p.Annotations = map[string]string{minResourcesAnnotation: annotation}
+			}
+			p.Spec.Containers = []v1.Container{{
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse("1"),
+						v1.ResourceMemory: resource.MustParse("1Gi"),
+					},
+				},
+			}}
+			pods = append(pods, p)
+		}
+		return pods
+	}
+	tests := []struct {
+		name        string
+		pods        []*v1.Pod
+		want        framework.Code
+		wantMessage string
+	}{
+		{
+			name:        "group below the resource threshold",
+			pods:        makeMembers(2, `{"cpu": "4", "memory": "4Gi"}`, 0),
+			want:        framework.Unschedulable,
+			wantMessage: "Pod cannot be scheduled because the group 'g1' requests 2 cpu, but needs 4",
+		},
+		{
+			name: "group reaching the resource threshold",
+			pods: makeMembers(4, `{"cpu": "4", "memory": "4Gi"}`, 0),
+			want: framework.Success,
+		},
+		{
+			name:        "annotation on another member",
+			pods:        makeMembers(3, `{"memory": "4Gi"}`, 2),
+			want:        framework.Unschedulable,
+			wantMessage: "Pod cannot be scheduled because the group 'g1' requests 3Gi memory, but needs 4Gi",
+		},
+		{
+			name:        "invalid quantity",
+			pods:        makeMembers(4, `{"cpu": "four"}`, 0),
+			want:        framework.Unschedulable,
+			wantMessage: `Invalid minResources value: annotation scheduler.nthu.io/min-resources cpu "four": quantities must match the regular expression`,
+		},
+		{
+			name: "group without minResources",
+			pods: makeMembers(2, "", -1),
+			want: framework.Success,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:               newTestFrameworkWithPods(t, tt.pods),
+				groupLabelKey:        groupNameLabel,
+				minAvailableLabelKey: minAvailableLabel,
+				groups:               make(map[string]*groupState),
+			}
+			_, status := cs.PreFilter(context.Background(), framework.NewCycleState(), tt.pods[0])
+			if status.Code() != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, status)
+			}
+			if tt.wantMessage != "" && !strings.HasPrefix(status.Message(), tt.wantMessage) {
+				t.Errorf("expected message %q, got %q", tt.wantMessage, status.Message())
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_PreFilter_PodGroupMinResources(t *testing.T) {
+	var pods []*v1.Pod
+	for i := 0; i < 2; i++ {
+		p := makeGangPod(fmt.Sprintf("pod%d", i), "g1", 2)
+		p.Namespace = "default"
+		// the PodGroup takes precedence over the annotation
+		p.Annotations = map[string]string{minResourcesAnnotation: `{"cpu": "1"}`}
+		p.Spec.Containers = []v1.Container{{
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+			},
+		}}
+		pods = append(pods, p)
+	}
+	pg := makePodGroup("default", "g1", 2)
+	if err := unstructured.SetNestedField(pg.Object, map[string]interface{}{"cpu": "4"}, "spec", "minResources"); err != nil {
+		t.Fatalf("fail to set minResources: %v", err)
+	}
+	cs := newPodGroupScheduler(t, newTestFrameworkWithPods(t, pods), []*unstructured.Unstructured{pg})
+	_, status := cs.PreFilter(context.Background(), nil, pods[0])
+	if want := "Pod cannot be scheduled because the group 'g1' requests 2 cpu, but needs 4"; status.Message() != want {
+		t.Errorf("expected message %q, got %v", want, status)
+	}
+}
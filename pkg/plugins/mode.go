@@ -0,0 +1,115 @@
+package plugins
+
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/apis/core/v1/helper/qos"
+)
+
+// scoreModeAnnotation overrides the score mode of the profile for the pod.
+const scoreModeAnnotation = "scheduler.nthu.io/score-mode"
+
+// namespaceScoreModeLabel overrides the score mode of the profile for the
+// pods of a namespace that don't pick one with scoreModeAnnotation.
+const namespaceScoreModeLabel = "scheduler.nthu.io/score-mode"
+
+// scoreModes are the canonical names of the modes.
+var scoreModes = []string{leastMode, mostMode, leastCPUMode, mostCPUMode, balancedMode, leastPodsMode, mostPodsMode, weightedMode, randomMode, shapedMode, compositeMode}
+
+// scoreModeAliases map the upstream names of the modes to theirs.
+var scoreModeAliases = map[string]string{
+	"LeastAllocated": leastMode,
+	"MostAllocated":  mostMode,
+}
+
+// canonicalScoreMode returns the canonical name of a mode or of its alias,
+// ignoring case. Unknown modes are returned as they are.
+func canonicalScoreMode(mode string) string {
+	for _, known := range scoreModes {
+		if strings.EqualFold(mode, known) {
+			return known
+		}
+	}
+	for alias, known := range scoreModeAliases {
+		if strings.EqualFold(mode, alias) {
+			return known
+		}
+	}
+	return mode
+}
+
+// isScoreMode reports whether the mode is one Score knows.
+func isScoreMode(mode string) bool {
+	_, ok := freeModes[mode]
+	return ok || mode == balancedMode || mode == weightedMode || mode == shapedMode || mode == compositeMode
+}
+
+// podScoreMode returns the score mode of the pod: the one in its score mode
+// annotation, else the one in the score mode label of its namespace, else the
+// one ModeByQoS maps its QoS class to, else the mode of the profile. Unknown
+// modes are passed over. The annotation and the label are read like the mode
+// of the profile, ignoring case and accepting aliases. Pods can only pick the
+// Random mode when the profile allows it.
+func (cs *CustomScheduler) podScoreMode(pod *v1.Pod) string {
+	if mode, ok := pod.Annotations[scoreModeAnnotation]; ok {
+		if mode = canonicalScoreMode(mode); cs.isPodScoreMode(mode) {
+			return mode
+		}
+	}
+	if mode, ok := cs.namespaceScoreMode(pod.Namespace); ok {
+		return mode
+	}
+	if len(cs.modeByQoS) > 0 {
+		if mode, ok := cs.modeByQoS[string(qos.GetPodQOS(pod))]; ok {
+			return mode
+		}
+	}
+	return cs.config().scoreMode
+}
+
+// isPodScoreMode reports whether a pod or namespace may pick the mode. The
+// Shaped mode needs a shape and the Composite mode a component weighing
+// something.
+func (cs *CustomScheduler) isPodScoreMode(mode string) bool {
+	switch mode {
+	case shapedMode:
+		return len(cs.shape) > 0
+	case compositeMode:
+		return totalWeight(cs.scoreComponents) > 0
+	}
+	return isScoreMode(mode) || mode == randomMode && cs.allowRandomMode
+}
+
+// namespaceScoreMode returns the score mode in the label of the namespace, if
+// it names a known one. Namespaces are read from the informer's cache, so the
+// lookup is cheap enough to make for every pod.
+func (cs *CustomScheduler) namespaceScoreMode(namespace string) (string, bool) {
+	if cs.namespaceLister == nil {
+		return "", false
+	}
+	ns, err := cs.namespaceLister.Get(namespace)
+	if err != nil {
+		return "", false
+	}
+	value, ok := ns.Labels[namespaceScoreModeLabel]
+	if !ok {
+		return "", false
+	}
+	if mode := canonicalScoreMode(value); cs.isPodScoreMode(mode) {
+		return mode, true
+	}
+	klog.V(2).InfoS("Namespace has an unknown score mode", "namespace", namespace, "label", value)
+	return "", false
+}
+
+// recordInvalidScoreMode emits a Warning event on a pod whose score mode
+// annotation names an unknown mode, saying which mode is used instead.
+func (cs *CustomScheduler) recordInvalidScoreMode(pod *v1.Pod, mode, used string) {
+	if cs.eventRecorder == nil {
+		return
+	}
+	cs.eventRecorder.Eventf(pod, nil, v1.EventTypeWarning, "InvalidScoreMode", "Scoring",
+		"Unknown score mode %q in annotation %s, using %s", mode, scoreModeAnnotation, used)
+}
@@ -0,0 +1,233 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_Score_PodScoreMode(t *testing.T) {
+	nodeInfos := []*framework.NodeInfo{
+		makeNodeInfo("small", 4000, 1<<30),
+		makeNodeInfo("large", 4000, 4<<30),
+	}
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantBest    string
+		wantEvents  []string
+	}{
+		{
+			name:     "no annotation uses the profile mode",
+			wantBest: "small",
+		},
+		{
+			name:        "annotation overrides the profile mode",
+			annotations: map[string]string{scoreModeAnnotation: "Most"},
+			wantBest:    "large",
+		},
+		{
+			name:        "unknown mode falls back to the profile mode",
+			annotations: map[string]string{scoreModeAnnotation: "Spread"},
+			wantBest:    "small",
+			wantEvents:  []string{`Warning InvalidScoreMode Unknown score mode "Spread" in annotation scheduler.nthu.io/score-mode, using Least`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := events.NewFakeRecorder(10)
+			cs := &CustomScheduler{
+				handle:        newTestFrameworkWithNodes(t, nil, nodeInfos),
+				scoreMode:     leastMode,
+				eventRecorder: recorder,
+			}
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Annotations: tt.annotations}}
+			scores := scoreNodes(t, cs, pod, nodeInfos)
+			best := scores[0]
+			for _, score := range scores {
+				if score.Score > best.Score {
+					best = score
+				}
+			}
+			if best.Name != tt.wantBest {
+				t.Errorf("expected node %s to score best, got %v", tt.wantBest, scores)
+			}
+
+			close(recorder.Events)
+			var got []string
+			for event := range recorder.Events {
+				got = append(got, event)
+			}
+			if !reflect.DeepEqual(got, tt.wantEvents) {
+				t.Errorf("expected events %q, got %q", tt.wantEvents, got)
+			}
+		})
+	}
+}
+
+func TestNew_ModeSpelling(t *testing.T) {
+	tests := []struct {
+		mode    string
+		want    string
+		wantErr bool
+	}{
+		{mode: "Least", want: leastMode},
+		{mode: "least", want: leastMode},
+		{mode: "LEAST", want: leastMode},
+		{mode: "most", want: mostMode},
+		{mode: "leastcpu", want: leastCPUMode},
+		{mode: "MOSTPODS", want: mostPodsMode},
+		{mode: "balanced", want: balancedMode},
+		{mode: "LeastAllocated", want: leastMode},
+		{mode: "leastallocated", want: leastMode},
+		{mode: "MostAllocated", want: mostMode},
+		{mode: "MOSTALLOCATED", want: mostMode},
+		{mode: "Least ", wantErr: true},
+		{mode: "BalancedAllocation", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			p, err := New(&runtime.Unknown{Raw: []byte(fmt.Sprintf(`{"mode": %q}`, tt.mode))}, newTestFrameworkWithPods(t, nil))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error for mode %q", tt.mode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if mode := p.(*CustomScheduler).scoreMode; mode != tt.want {
+				t.Errorf("expected mode %s, got %s", tt.want, mode)
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_PodScoreMode_Spelling(t *testing.T) {
+	cs := &CustomScheduler{scoreMode: leastMode}
+	for annotation, want := range map[string]string{"most": mostMode, "MostAllocated": mostMode, "MOSTCPU": mostCPUMode, "random": leastMode} {
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{scoreModeAnnotation: annotation}}}
+		if mode := cs.podScoreMode(pod); mode != want {
+			t.Errorf("expected annotation %q to pick mode %s, got %s", annotation, want, mode)
+		}
+	}
+}
+
+func TestCustomScheduler_PodScoreMode_Namespace(t *testing.T) {
+	fh := newTestFrameworkWithPods(t, nil)
+	namespaces := fh.SharedInformerFactory().Core().V1().Namespaces()
+	for name, mode := range map[string]string{"batch": "most", "prod": "Least", "typo": "Spread", "lottery": "Random"} {
+		namespaces.Informer().GetStore().Add(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"scheduler.nthu.io/score-mode": mode}}})
+	}
+	namespaces.Informer().GetStore().Add(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "plain"}})
+	cs := &CustomScheduler{scoreMode: balancedMode, namespaceLister: namespaces.Lister()}
+	tests := []struct {
+		name       string
+		namespace  string
+		annotation string
+		want       string
+	}{
+		{name: "namespace label overrides the profile mode", namespace: "batch", want: mostMode},
+		{name: "pod annotation overrides the namespace label", namespace: "batch", annotation: "LeastCPU", want: leastCPUMode},
+		{name: "unknown pod annotation falls back to the namespace label", namespace: "prod", annotation: "Spread", want: leastMode},
+		{name: "unknown namespace label falls back to the profile mode", namespace: "typo", want: balancedMode},
+		{name: "namespace can't pick Random unless allowed", namespace: "lottery", want: balancedMode},
+		{name: "namespace without the label", namespace: "plain", want: balancedMode},
+		{name: "namespace not in the cache", namespace: "missing", want: balancedMode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: tt.namespace}}
+			if tt.annotation != "" {
+				pod.Annotations = map[string]string{scoreModeAnnotation: tt.annotation}
+			}
+			if mode := cs.podScoreMode(pod); mode != tt.want {
+				t.Errorf("expected mode %s, got %s", tt.want, mode)
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_PodScoreMode_QoS(t *testing.T) {
+	makePod := func(name string, requests, limits v1.ResourceList) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: v1.PodSpec{Containers: []v1.Container{{
+				Resources: v1.ResourceRequirements{Requests: requests, Limits: limits},
+			}}},
+		}
+	}
+	resources := v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi")}
+	guaranteed := makePod("guaranteed", resources, resources)
+	burstable := makePod("burstable", resources, nil)
+	bestEffort := makePod("best-effort", nil, nil)
+	annotated := makePod("annotated", nil, nil)
+	annotated.Annotations = map[string]string{scoreModeAnnotation: "LeastCPU"}
+
+	p, err := New(&runtime.Unknown{Raw: []byte(`{"mode": "Balanced", "modeByQoS": {"Guaranteed": "least", "BestEffort": "MostAllocated"}}`)}, newTestFrameworkWithPods(t, nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cs := p.(*CustomScheduler)
+	tests := []struct {
+		pod  *v1.Pod
+		want string
+	}{
+		{pod: guaranteed, want: leastMode},
+		{pod: burstable, want: balancedMode},
+		{pod: bestEffort, want: mostMode},
+		{pod: annotated, want: leastCPUMode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pod.Name, func(t *testing.T) {
+			state := framework.NewCycleState()
+			if status := cs.PreScore(context.Background(), state, tt.pod, nil); !status.IsSuccess() {
+				t.Fatalf("unexpected error: %v", status)
+			}
+			s, err := readPreScoreState(state)
+			if err != nil || s == nil {
+				t.Fatalf("expected PreScore to write the score state, got %v", err)
+			}
+			if s.mode != tt.want {
+				t.Errorf("expected mode %s, got %s", tt.want, s.mode)
+			}
+		})
+	}
+}
+
+func TestNew_ModeByQoS(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    string
+		wantErr string
+	}{
+		{name: "every class", args: `{"modeByQoS": {"Guaranteed": "Least", "Burstable": "Balanced", "BestEffort": "Most"}}`},
+		{name: "unknown class", args: `{"modeByQoS": {"Critical": "Least"}}`, wantErr: "invalid modeByQoS, unknown QoS class Critical"},
+		{name: "unknown mode", args: `{"modeByQoS": {"Guaranteed": "Spread"}}`, wantErr: "invalid modeByQoS for Guaranteed, got Spread"},
+		{name: "random mode not allowed", args: `{"modeByQoS": {"BestEffort": "Random"}}`, wantErr: "invalid modeByQoS for BestEffort, Random needs allowRandomMode"},
+		{name: "shaped mode without a shape", args: `{"modeByQoS": {"Burstable": "Shaped"}}`, wantErr: "invalid modeByQoS for Burstable, Shaped needs a shape"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(&runtime.Unknown{Raw: []byte(tt.args)}, newTestFrameworkWithPods(t, nil))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
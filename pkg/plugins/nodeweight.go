@@ -0,0 +1,54 @@
+package plugins
+
+import (
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// scoreWeightLabel scales the score of a node by its value in percent, for
+// example to prefer on-demand over spot nodes. Nodes without a valid weight
+// count at 100, and a weight of 0 leaves a node the minimum score without
+// filtering it out.
+const (
+	scoreWeightLabel   = "scheduler.nthu.io/score-weight"
+	defaultScoreWeight = 100
+)
+
+// nodeScoreWeights returns the weights of the nodes labeled with one other
+// than the default.
+func nodeScoreWeights(nodes []*v1.Node) map[string]int64 {
+	var weights map[string]int64
+	for _, node := range nodes {
+		value, ok := node.Labels[scoreWeightLabel]
+		if !ok {
+			continue
+		}
+		weight, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || weight < 0 {
+			klog.V(2).InfoS("Node has an invalid score weight label, using the default", "node", klog.KObj(node), "label", scoreWeightLabel, "value", value, "weight", defaultScoreWeight)
+			continue
+		}
+		if weight == defaultScoreWeight {
+			continue
+		}
+		if weights == nil {
+			weights = make(map[string]int64)
+		}
+		weights[node.Name] = weight
+	}
+	return weights
+}
+
+// applyNodeWeights scales the normalized scores by the weights of the nodes.
+// It is applied after normalizing so that a lower weight makes a node less
+// preferred in the modes inverting their scores too.
+func applyNodeWeights(scores framework.NodeScoreList, weights map[string]int64) {
+	for i := range scores {
+		if weight, ok := weights[scores[i].Name]; ok {
+			scores[i].Score = clampScore(scores[i].Score * weight / defaultScoreWeight)
+		}
+	}
+}
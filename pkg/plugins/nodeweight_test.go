@@ -0,0 +1,54 @@
+package plugins
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_NodeScoreWeight(t *testing.T) {
+	makeNode := func(name string, memory int64, weight string) *framework.NodeInfo {
+		nodeInfo := makeNodeInfo(name, 1000, memory)
+		if weight != "" {
+			nodeInfo.Node().Labels = map[string]string{scoreWeightLabel: weight}
+		}
+		return nodeInfo
+	}
+	nodeInfos := []*framework.NodeInfo{
+		makeNode("spot", 4<<30, "50"),
+		makeNode("plain", 3<<30, ""),
+		makeNode("excluded", 2<<30, "0"),
+		makeNode("invalid", 1<<30, "heavy"),
+	}
+	tests := []struct {
+		name string
+		mode string
+		want map[string]int64
+	}{
+		{
+			name: "most mode",
+			mode: mostMode,
+			want: map[string]int64{"spot": 50, "plain": 66, "excluded": 0, "invalid": 0},
+		},
+		{
+			name: "least mode",
+			mode: leastMode,
+			want: map[string]int64{"spot": 0, "plain": 34, "excluded": 0, "invalid": 100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:    newTestFrameworkWithNodes(t, nil, nodeInfos),
+				scoreMode: tt.mode,
+			}
+			scores := scoreNodes(t, cs, &v1.Pod{}, nodeInfos)
+			for _, score := range scores {
+				if score.Score != tt.want[score.Name] {
+					t.Errorf("expected node %s to score %d, got %d", score.Name, tt.want[score.Name], score.Score)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package plugins
+
+import (
+	"math"
+	"sort"
+
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// Strategies NormalizeScore maps the raw scores to the node score range with.
+const (
+	normalizeMinMax     string = "MinMax"
+	normalizeZScore     string = "ZScore"
+	normalizePercentile string = "Percentile"
+)
+
+// zScoreClamp is how many standard deviations from the mean the ZScore
+// strategy tells apart. Nodes further out get the minimum or maximum score.
+const zScoreClamp = 2.0
+
+// scoreNormalizer maps a raw score to the node score range.
+type scoreNormalizer func(score int64) int64
+
+// newScoreNormalizer returns the normalizer of the strategy for the raw
+// scores of the nodes the pod fits on. Every strategy keeps the order of the
+// scores, which invert reverses, and gives the maximum score to all nodes
+// when they score the same, including when there is a single one.
+func newScoreNormalizer(strategy string, scores []int64, invert bool) scoreNormalizer {
+	var normalize scoreNormalizer
+	switch strategy {
+	case normalizeZScore:
+		normalize = zScoreNormalizer(scores)
+	case normalizePercentile:
+		normalize = percentileNormalizer(scores)
+	default:
+		normalize = minMaxNormalizer(scores)
+	}
+	if !invert || !spread(scores) {
+		return normalize
+	}
+	return func(score int64) int64 {
+		return framework.MaxNodeScore - normalize(score) + framework.MinNodeScore
+	}
+}
+
+// spread reports whether the scores differ.
+func spread(scores []int64) bool {
+	for _, score := range scores {
+		if score != scores[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// minMaxNormalizer scales the scores linearly from the lowest to the highest.
+// A single outlier compresses the other scores towards one end.
+func minMaxNormalizer(scores []int64) scoreNormalizer {
+	minScore := int64(math.MaxInt64)
+	maxScore := int64(math.MinInt64)
+	for _, score := range scores {
+		if score < minScore {
+			minScore = score
+		}
+		if score > maxScore {
+			maxScore = score
+		}
+	}
+	// scale in float64, as the range of raw scores can overflow int64
+	scoreRange := float64(maxScore) - float64(minScore)
+	return func(score int64) int64 {
+		if scoreRange <= 0 {
+			return framework.MaxNodeScore
+		}
+		return clampScore(int64((float64(score)-float64(minScore))*nodeScoreRange/scoreRange) + framework.MinNodeScore)
+	}
+}
+
+// zScoreNormalizer scales the scores on how many standard deviations they
+// are from the mean, clamped at zScoreClamp, so that outliers don't squeeze
+// the other nodes together.
+func zScoreNormalizer(scores []int64) scoreNormalizer {
+	var mean float64
+	for _, score := range scores {
+		mean += float64(score)
+	}
+	mean /= float64(len(scores))
+	var variance float64
+	for _, score := range scores {
+		variance += (float64(score) - mean) * (float64(score) - mean)
+	}
+	stddev := math.Sqrt(variance / float64(len(scores)))
+	return func(score int64) int64 {
+		if stddev == 0 {
+			return framework.MaxNodeScore
+		}
+		z := math.Max(-zScoreClamp, math.Min(zScoreClamp, (float64(score)-mean)/stddev))
+		return clampScore(int64(math.Round((z+zScoreClamp)/(2*zScoreClamp)*nodeScoreRange)) + framework.MinNodeScore)
+	}
+}
+
+// percentileNormalizer scores each node on its rank: the share of the other
+// nodes with a different score that score lower. How far apart the raw
+// scores are doesn't matter, and nodes scoring the same share a rank.
+func percentileNormalizer(scores []int64) scoreNormalizer {
+	sorted := append([]int64(nil), scores...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return func(score int64) int64 {
+		lower := sort.Search(len(sorted), func(i int) bool { return sorted[i] >= score })
+		higher := len(sorted) - sort.Search(len(sorted), func(i int) bool { return sorted[i] > score })
+		if lower+higher == 0 {
+			return framework.MaxNodeScore
+		}
+		return clampScore(int64(math.Round(float64(lower)/float64(lower+higher)*nodeScoreRange)) + framework.MinNodeScore)
+	}
+}
+
+// nodeScoreRange is the width of the node score range.
+const nodeScoreRange = float64(framework.MaxNodeScore - framework.MinNodeScore)
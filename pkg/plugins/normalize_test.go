@@ -0,0 +1,97 @@
+package plugins
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScoreNormalizers(t *testing.T) {
+	tests := []struct {
+		name   string
+		scores []int64
+		want   map[string][]int64
+	}{
+		{
+			name:   "evenly spread",
+			scores: []int64{10, 20, 30, 40, 50},
+			want: map[string][]int64{
+				normalizeMinMax:     {0, 25, 50, 75, 100},
+				normalizeZScore:     {15, 32, 50, 68, 85},
+				normalizePercentile: {0, 25, 50, 75, 100},
+			},
+		},
+		{
+			// one nearly empty node squeezes the others to 0 under MinMax
+			name:   "outlier",
+			scores: []int64{10, 11, 12, 13, 2000},
+			want: map[string][]int64{
+				normalizeMinMax:     {0, 0, 0, 0, 100},
+				normalizeZScore:     {37, 37, 38, 38, 100},
+				normalizePercentile: {0, 25, 50, 75, 100},
+			},
+		},
+		{
+			name:   "ties",
+			scores: []int64{5, 5, 10, 20},
+			want: map[string][]int64{
+				normalizeMinMax:     {0, 0, 33, 100},
+				normalizeZScore:     {30, 30, 50, 91},
+				normalizePercentile: {0, 0, 67, 100},
+			},
+		},
+		{
+			name:   "single node",
+			scores: []int64{42},
+			want: map[string][]int64{
+				normalizeMinMax:     {100},
+				normalizeZScore:     {100},
+				normalizePercentile: {100},
+			},
+		},
+		{
+			name:   "all equal",
+			scores: []int64{7, 7, 7},
+			want: map[string][]int64{
+				normalizeMinMax:     {100, 100, 100},
+				normalizeZScore:     {100, 100, 100},
+				normalizePercentile: {100, 100, 100},
+			},
+		},
+		{
+			name:   "range beyond int64",
+			scores: []int64{-1 << 62, 0, 1 << 62},
+			want: map[string][]int64{
+				normalizeMinMax:     {0, 50, 100},
+				normalizeZScore:     {19, 50, 81},
+				normalizePercentile: {0, 50, 100},
+			},
+		},
+	}
+	for _, tt := range tests {
+		for _, strategy := range []string{normalizeMinMax, normalizeZScore, normalizePercentile} {
+			t.Run(tt.name+"/"+strategy, func(t *testing.T) {
+				normalize := newScoreNormalizer(strategy, tt.scores, false)
+				got := make([]int64, len(tt.scores))
+				for i, score := range tt.scores {
+					got[i] = normalize(score)
+				}
+				if !reflect.DeepEqual(got, tt.want[strategy]) {
+					t.Errorf("expected %v, got %v", tt.want[strategy], got)
+				}
+
+				// inverting reverses the order, but nodes scoring the same
+				// still get the maximum score
+				invert := newScoreNormalizer(strategy, tt.scores, true)
+				for i, score := range tt.scores {
+					want := got[i]
+					if spread(tt.scores) {
+						want = 100 - got[i]
+					}
+					if inverted := invert(score); inverted != want {
+						t.Errorf("expected %d to invert to %d, got %d", score, want, inverted)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package plugins
+
+import (
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/utils/clock"
+)
+
+// Option overrides what NewWithOptions otherwise takes from the handle, so
+// that tests can use fakes in its place.
+type Option func(cs *CustomScheduler)
+
+// WithPodLister makes the plugin list the members of groups from the lister
+// instead of the pod informer. The informer is then neither indexed nor
+// waited for.
+func WithPodLister(lister corelisters.PodLister) Option {
+	return func(cs *CustomScheduler) {
+		cs.podListerOverride = lister
+	}
+}
+
+// WithNodeInfoLister makes the plugin read the nodes from the lister instead
+// of the snapshot of the scheduling cycle.
+func WithNodeInfoLister(lister framework.NodeInfoLister) Option {
+	return func(cs *CustomScheduler) {
+		cs.nodeInfoListerOverride = lister
+	}
+}
+
+// WithClock sets the clock the plugin times groups with.
+func WithClock(c clock.PassiveClock) Option {
+	return func(cs *CustomScheduler) {
+		cs.clock = c
+	}
+}
+
+// WithEventRecorder sets the recorder the plugin emits events with.
+func WithEventRecorder(recorder events.EventRecorder) Option {
+	return func(cs *CustomScheduler) {
+		cs.eventRecorder = recorder
+	}
+}
+
+// podLister returns the lister of the pods the groups are listed from.
+func (cs *CustomScheduler) podLister() corelisters.PodLister {
+	if cs.podListerOverride != nil {
+		return cs.podListerOverride
+	}
+	return cs.handle.SharedInformerFactory().Core().V1().Pods().Lister()
+}
+
+// nodeInfoLister returns the lister of the nodes of the scheduling cycle.
+func (cs *CustomScheduler) nodeInfoLister() framework.NodeInfoLister {
+	if cs.nodeInfoListerOverride != nil {
+		return cs.nodeInfoListerOverride
+	}
+	return cs.handle.SnapshotSharedLister().NodeInfos()
+}
@@ -0,0 +1,89 @@
+package plugins
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	testingclock "k8s.io/utils/clock/testing"
+
+	plugintesting "my-scheduler-plugins/pkg/plugins/testing"
+)
+
+func TestNewWithOptions_Score(t *testing.T) {
+	nodeInfos := []*framework.NodeInfo{
+		makeNodeInfo("small", 4000, 2<<30),
+		makeNodeInfo("medium", 4000, 4<<30),
+		makeNodeInfo("large", 4000, 8<<30),
+	}
+	h := plugintesting.NewHandle(nodeInfos, nil)
+	p, err := NewWithOptions(&CustomSchedulerArgs{Mode: mostMode}, h,
+		WithPodLister(h.PodLister()),
+		WithNodeInfoLister(h.NodeInfoLister()),
+	)
+	if err != nil {
+		t.Fatalf("fail to create plugin: %v", err)
+	}
+	cs := p.(*CustomScheduler)
+	pod := &v1.Pod{}
+	want := map[string]int64{"small": 0, "medium": 33, "large": 100}
+	for _, score := range scoreNodes(t, cs, pod, nodeInfos) {
+		if score.Score != want[score.Name] {
+			t.Errorf("expected node %s to score %d, got %d", score.Name, want[score.Name], score.Score)
+		}
+	}
+}
+
+func TestNewWithOptions_PreFilter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fakeClock := testingclock.NewFakeClock(now)
+	h := plugintesting.NewHandle(nil, []*v1.Pod{makeGangPod("pod1", "g1", 3)})
+	p, err := NewWithOptions(&CustomSchedulerArgs{GangTimeoutSeconds: 60}, h,
+		WithPodLister(h.PodLister()),
+		WithClock(fakeClock),
+		WithEventRecorder(h.EventRecorder()),
+	)
+	if err != nil {
+		t.Fatalf("fail to create plugin: %v", err)
+	}
+	cs := p.(*CustomScheduler)
+	pod := makeGangPod("pod2", "g1", 3)
+	h.AddPod(pod)
+
+	// the lister is used without waiting for the informer
+	if _, status := cs.PreFilter(context.Background(), framework.NewCycleState(), pod); status.Code() != framework.Unschedulable || !strings.Contains(status.Message(), "has only 2 pods") {
+		t.Errorf("expected the incomplete group to be rejected, got %v", status)
+	}
+	select {
+	case event := <-h.Events():
+		if !strings.Contains(event, "GroupNotReady") {
+			t.Errorf("expected a GroupNotReady event, got %q", event)
+		}
+	default:
+		t.Error("expected an event on the injected recorder")
+	}
+
+	// the group times out on the injected clock
+	fakeClock.Step(2 * time.Minute)
+	if _, status := cs.PreFilter(context.Background(), framework.NewCycleState(), pod); status.Code() != framework.UnschedulableAndUnresolvable {
+		t.Errorf("expected the group to time out, got %v", status)
+	}
+}
+
+func TestNew_FakeHandle(t *testing.T) {
+	h := plugintesting.NewHandle(nil, nil)
+	p, err := New(nil, h)
+	if err != nil {
+		t.Fatalf("fail to create plugin: %v", err)
+	}
+	cs := p.(*CustomScheduler)
+	if cs.podListerOverride != nil || cs.nodeInfoListerOverride != nil {
+		t.Error("expected New to use the listers of the handle")
+	}
+	if cs.podIndexer == nil || cs.podsSynced == nil {
+		t.Error("expected New to index and wait for the pod informer")
+	}
+}
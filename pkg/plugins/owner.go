@@ -0,0 +1,53 @@
+package plugins
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// ownerReplicas returns the number of pods the workload controlling the pod
+// wants to run: spec.parallelism of a Job or spec.replicas of a StatefulSet,
+// along with a description of the owner. ok is false when the pod has no
+// recognized owner.
+func (cs *CustomScheduler) ownerReplicas(pod *v1.Pod) (replicas int, owner string, ok bool) {
+	if cs.jobLister == nil || cs.statefulSetLister == nil {
+		return 0, "", false
+	}
+	ref := metav1.GetControllerOf(pod)
+	if ref == nil {
+		return 0, "", false
+	}
+	owner = fmt.Sprintf("%s %s/%s", ref.Kind, pod.Namespace, ref.Name)
+	switch ref.Kind {
+	case "Job":
+		job, err := cs.jobLister.Jobs(pod.Namespace).Get(ref.Name)
+		if err != nil {
+			klog.ErrorS(err, "Failed to get the Job owning the pod", "job", klog.KRef(pod.Namespace, ref.Name), "pod", klog.KObj(pod))
+			return 0, "", false
+		}
+		if job.UID != ref.UID {
+			return 0, "", false
+		}
+		if job.Spec.Parallelism == nil {
+			return 1, owner, true
+		}
+		return int(*job.Spec.Parallelism), owner, true
+	case "StatefulSet":
+		sts, err := cs.statefulSetLister.StatefulSets(pod.Namespace).Get(ref.Name)
+		if err != nil {
+			klog.ErrorS(err, "Failed to get the StatefulSet owning the pod", "statefulSet", klog.KRef(pod.Namespace, ref.Name), "pod", klog.KObj(pod))
+			return 0, "", false
+		}
+		if sts.UID != ref.UID {
+			return 0, "", false
+		}
+		if sts.Spec.Replicas == nil {
+			return 1, owner, true
+		}
+		return int(*sts.Spec.Replicas), owner, true
+	}
+	return 0, "", false
+}
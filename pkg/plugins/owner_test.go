@@ -0,0 +1,323 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/utils/pointer"
+)
+
+func TestCustomScheduler_PreFilter_OwnerMinAvailable(t *testing.T) {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "train", Namespace: "default", UID: "job-uid"},
+		Spec:       batchv1.JobSpec{Parallelism: pointer.Int32(3)},
+	}
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "workers", Namespace: "default", UID: "sts-uid"},
+		Spec:       appsv1.StatefulSetSpec{Replicas: pointer.Int32(2)},
+	}
+	controlledBy := func(kind, name string, uid string) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{Kind: kind, Name: name, UID: types.UID(uid), Controller: pointer.Bool(true)}}
+	}
+
+	var existing []*v1.Pod
+	for i := 0; i < 2; i++ {
+		existing = append(existing, &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("pod%d", i),
+				Namespace: "default",
+				Labels:    map[string]string{"podGroup": "g1"},
+			},
+		})
+	}
+	tests := []struct {
+		name   string
+		labels map[string]string
+		owners []metav1.OwnerReference
+		want   framework.Code
+	}{
+		{
+			name:   "Job parallelism is not met",
+			labels: map[string]string{"podGroup": "g1"},
+			owners: controlledBy("Job", "train", "job-uid"),
+			want:   framework.Unschedulable,
+		},
+		{
+			name:   "StatefulSet replicas are met",
+			labels: map[string]string{"podGroup": "g1"},
+			owners: controlledBy("StatefulSet", "workers", "sts-uid"),
+			want:   framework.Success,
+		},
+		{
+			name:   "label takes precedence over the owner",
+			labels: map[string]string{"podGroup": "g1", "minAvailable": "2"},
+			owners: controlledBy("Job", "train", "job-uid"),
+			want:   framework.Success,
+		},
+		{
+			name:   "unrecognized owner has no gang requirement",
+			labels: map[string]string{"podGroup": "g1"},
+			owners: controlledBy("ReplicaSet", "web", "rs-uid"),
+			want:   framework.Success,
+		},
+		{
+			name:   "missing owner has no gang requirement",
+			labels: map[string]string{"podGroup": "g1"},
+			owners: controlledBy("Job", "gone", "gone-uid"),
+			want:   framework.Success,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := newTestFrameworkWithPods(t, existing)
+			fh.SharedInformerFactory().Batch().V1().Jobs().Informer().GetStore().Add(job)
+			fh.SharedInformerFactory().Apps().V1().StatefulSets().Informer().GetStore().Add(sts)
+			cs := &CustomScheduler{
+				handle:                fh,
+				scoreMode:             leastMode,
+				groupLabelKey:         groupNameLabel,
+				minAvailableLabelKey:  minAvailableLabel,
+				minAvailableFromOwner: true,
+				jobLister:             fh.SharedInformerFactory().Batch().V1().Jobs().Lister(),
+				statefulSetLister:     fh.SharedInformerFactory().Apps().V1().StatefulSets().Lister(),
+			}
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "incoming",
+					Namespace:       "default",
+					Labels:          tt.labels,
+					OwnerReferences: tt.owners,
+				},
+			}
+			_, status := cs.PreFilter(context.Background(), nil, pod)
+			if status.Code() != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, status.Code())
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_PreFilter_Unresolvable(t *testing.T) {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "train", Namespace: "default", UID: "job-uid"},
+		Spec:       batchv1.JobSpec{Parallelism: pointer.Int32(4)},
+	}
+	owners := []metav1.OwnerReference{{Kind: "Job", Name: "train", UID: "job-uid", Controller: pointer.Bool(true)}}
+	existing := []*v1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "pod0", Namespace: "default", Labels: map[string]string{"podGroup": "g1"}, OwnerReferences: owners}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "default", Labels: map[string]string{"podGroup": "g1"}, OwnerReferences: owners}},
+	}
+	tests := []struct {
+		name         string
+		minAvailable string
+		owners       []metav1.OwnerReference
+		want         framework.Code
+		wantMessage  string
+	}{
+		{
+			name:         "owner will never create enough pods",
+			minAvailable: "10",
+			owners:       owners,
+			want:         framework.UnschedulableAndUnresolvable,
+			wantMessage:  "Pod cannot be scheduled because the group 'g1' needs 10 pods, but Job default/train only wants 4",
+		},
+		{
+			name:         "owner has not created all pods yet",
+			minAvailable: "4",
+			owners:       owners,
+			want:         framework.Unschedulable,
+			wantMessage:  "Pod cannot be scheduled because the group 'g1' has only 2 pods, but needs 4 (0 running, 2 pending, 2 unscheduled: pod0, pod1); Job default/train has 2 pods left to create",
+		},
+		{
+			name:         "pods without an owner may still be created",
+			minAvailable: "10",
+			want:         framework.Unschedulable,
+			wantMessage:  "Pod cannot be scheduled because the group 'g1' has only 2 pods, but needs 10 (0 running, 2 pending, 2 unscheduled: pod0, pod1)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := newTestFrameworkWithPods(t, existing)
+			fh.SharedInformerFactory().Batch().V1().Jobs().Informer().GetStore().Add(job)
+			cs := &CustomScheduler{
+				handle:               fh,
+				scoreMode:            leastMode,
+				groupLabelKey:        groupNameLabel,
+				minAvailableLabelKey: minAvailableLabel,
+				jobLister:            fh.SharedInformerFactory().Batch().V1().Jobs().Lister(),
+				statefulSetLister:    fh.SharedInformerFactory().Apps().V1().StatefulSets().Lister(),
+			}
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "incoming",
+					Namespace:       "default",
+					Labels:          map[string]string{"podGroup": "g1", "minAvailable": tt.minAvailable},
+					OwnerReferences: tt.owners,
+				},
+			}
+			_, status := cs.PreFilter(context.Background(), nil, pod)
+			if status.Code() != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, status.Code())
+			}
+			if status.Message() != tt.wantMessage {
+				t.Errorf("expected message %q, got %q", tt.wantMessage, status.Message())
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_MinAvailablePercentage(t *testing.T) {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "train", Namespace: "default", UID: "job-uid"},
+		Spec:       batchv1.JobSpec{Parallelism: pointer.Int32(3)},
+	}
+	owners := []metav1.OwnerReference{{Kind: "Job", Name: "train", UID: "job-uid", Controller: pointer.Bool(true)}}
+	tests := []struct {
+		name         string
+		value        string
+		label        bool
+		owners       []metav1.OwnerReference
+		members      int
+		minAvailable int
+		wantErr      bool
+	}{
+		{
+			name:         "integer is used as is",
+			value:        "5",
+			owners:       owners,
+			minAvailable: 5,
+		},
+		{
+			name:         "percentage rounds up",
+			value:        "60%",
+			owners:       owners,
+			minAvailable: 2,
+		},
+		{
+			name:         "percentage just above a third rounds up",
+			value:        "34%",
+			owners:       owners,
+			minAvailable: 2,
+		},
+		{
+			name:         "exact percentage is not rounded",
+			value:        "33%",
+			owners:       owners,
+			minAvailable: 1,
+		},
+		{
+			name:         "smallest percentage needs one pod",
+			value:        "1%",
+			owners:       owners,
+			minAvailable: 1,
+		},
+		{
+			name:         "100% needs every replica",
+			value:        "100%",
+			owners:       owners,
+			minAvailable: 3,
+		},
+		{
+			name:    "0% is rejected",
+			value:   "0%",
+			owners:  owners,
+			wantErr: true,
+		},
+		{
+			name:    "more than 100% is rejected",
+			value:   "101%",
+			owners:  owners,
+			wantErr: true,
+		},
+		{
+			name:    "malformed percentage is rejected",
+			value:   "sixty%",
+			owners:  owners,
+			wantErr: true,
+		},
+		{
+			name:         "percentage of the members rounds up",
+			value:        "60%",
+			members:      4,
+			minAvailable: 3,
+		},
+		{
+			name:         "exact percentage of the members is not rounded",
+			value:        "50%",
+			members:      4,
+			minAvailable: 2,
+		},
+		{
+			name:         "100% needs every member",
+			value:        "100%",
+			members:      4,
+			minAvailable: 4,
+		},
+		{
+			name:         "percentage of the pods of a ReplicaSet",
+			value:        "60%",
+			owners:       []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-7d4b9", UID: "rs-uid", Controller: pointer.Bool(true)}},
+			members:      5,
+			minAvailable: 3,
+		},
+		{
+			name:         "percentage of a pod the informer doesn't show yet",
+			value:        "60%",
+			minAvailable: 1,
+		},
+		{
+			name:    "percentage in the label is rejected",
+			value:   "60%",
+			label:   true,
+			owners:  owners,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the members of the group include the pod itself
+			var members []*v1.Pod
+			for i := 0; i < tt.members; i++ {
+				member := makeGangPod(fmt.Sprintf("pod%d", i), "g1", 1)
+				member.Namespace = "default"
+				members = append(members, member)
+			}
+			fh := newTestFrameworkWithPods(t, members)
+			fh.SharedInformerFactory().Batch().V1().Jobs().Informer().GetStore().Add(job)
+			cs := &CustomScheduler{
+				handle:                    fh,
+				groupLabelKey:             groupNameLabel,
+				minAvailableLabelKey:      minAvailableLabel,
+				minAvailableAnnotationKey: minAvailableAnnotation,
+				jobLister:                 fh.SharedInformerFactory().Batch().V1().Jobs().Lister(),
+				statefulSetLister:         fh.SharedInformerFactory().Apps().V1().StatefulSets().Lister(),
+			}
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "pod0",
+					Namespace:       "default",
+					Labels:          map[string]string{"podGroup": "g1"},
+					Annotations:     map[string]string{minAvailableAnnotation: tt.value},
+					OwnerReferences: tt.owners,
+				},
+			}
+			if tt.label {
+				pod.Labels["minAvailable"] = tt.value
+			}
+			_, minAvailable, _, err := cs.groupManager().requirement(pod)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && minAvailable != tt.minAvailable {
+				t.Errorf("expected minAvailable %d, got %d", tt.minAvailable, minAvailable)
+			}
+		})
+	}
+}
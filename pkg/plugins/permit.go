@@ -0,0 +1,140 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// Permit holds a gang member until minAvailable members of its group count as
+// ready according to the gang count policy, and then allows the whole group
+// at once, or once the groups that completed before it are released when
+// MaxConcurrentReleasingGroups is set.
+func (cs *CustomScheduler) Permit(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) (*framework.Status, time.Duration) {
+	logger := klog.FromContext(ctx)
+	logger.V(5).Info("Permit", "pod", klog.KObj(pod), "node", nodeName)
+
+	group, minAvailable, isGang, err := cs.groupManager().requirement(pod)
+	if !isGang {
+		return framework.NewStatus(framework.Success, ""), 0
+	}
+	if err != nil {
+		return invalidMinAvailableStatus(err), 0
+	}
+
+	key := cs.groupKey(pod.Namespace, group)
+	if cs.gangTimeoutBestEffort && cs.gangTimedOut(key) {
+		logger.V(2).Info("Group timed out, allowing the pod on its own", "pod", klog.KObj(pod), "group", group)
+		return framework.NewStatus(framework.Success, ""), 0
+	}
+
+	pods, err := cs.groupManager().Members(pod.Namespace, group)
+	if err != nil {
+		return framework.NewStatus(framework.Error, fmt.Sprintf("Failed to list pods: %v", err)), 0
+	}
+	pods = sameSchedulerPods(pod, pods)
+	// PreFilter only admits the pod when enough live members exist. Under
+	// the Created policy that is all Permit checks again, so a pod passes
+	// right away unless members went away in the meantime. Under the
+	// Assigned policy members only count once they are bound, reserved or
+	// waiting here, so a group whose siblings can't be placed never starts.
+	// The pod itself is counted as well, which lets the last member of a
+	// group of exactly minAvailable release the others.
+	var ready int
+	switch cs.gangCountPolicy {
+	case gangCountAssigned:
+		ready = cs.countAssignedPods(pod, group, pods)
+	default:
+		ready = countActivePods(pods)
+	}
+	if ready < minAvailable && cs.bestEffortReleased(pod, group) {
+		logger.V(4).Info("Best-effort group stalled, allowing the pod on its own", "pod", klog.KObj(pod), "group", group)
+		return framework.NewStatus(framework.Success, ""), 0
+	}
+	if ready < minAvailable {
+		waitTime := cs.groupWaitTime(key)
+		logger.V(4).Info("Pod waits for its group", "pod", klog.KObj(pod), "group", group, "ready", ready, "minAvailable", minAvailable)
+		return framework.NewStatus(framework.Wait, ""), waitTime
+	}
+
+	if cs.releases != nil {
+		if !cs.permitRelease(pod, group) {
+			logger.V(4).Info("Group is ready, waiting for the groups before it to be released", "pod", klog.KObj(pod), "group", group)
+			return framework.NewStatus(framework.Wait, ""), cs.groupWaitTime(key)
+		}
+		return framework.NewStatus(framework.Success, ""), 0
+	}
+	logger.V(3).Info("Group is ready, allowing its waiting pods", "group", group)
+	cs.updateGroup(key, func(gs *groupState) {
+		gs.deadline = time.Time{}
+		gs.firstSeen = time.Time{}
+	})
+	cs.handle.IterateOverWaitingPods(func(wp framework.WaitingPod) {
+		if cs.inGroup(wp.GetPod(), pod.Namespace, group) {
+			wp.Allow(cs.Name())
+		}
+	})
+	return framework.NewStatus(framework.Success, ""), 0
+}
+
+// groupWaitTime returns how long a member of the group may still wait. The
+// first waiting member starts the deadline and later members share it, so
+// that the whole group is rejected at once when it doesn't complete in time.
+func (cs *CustomScheduler) groupWaitTime(key string) time.Duration {
+	var waitTime time.Duration
+	cs.updateGroup(key, func(gs *groupState) {
+		now := cs.now()
+		if !gs.deadline.After(now) {
+			gs.deadline = now.Add(cs.permitWaitingTime)
+		}
+		waitTime = gs.deadline.Sub(now)
+	})
+	return waitTime
+}
+
+// Reserve records the pod as an assumed member of its group, so that members
+// scheduled right after it count it before the informer shows it bound.
+func (cs *CustomScheduler) Reserve(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) *framework.Status {
+	if group, _, isGang, _ := cs.groupManager().requirement(pod); isGang {
+		cs.assume(cs.groupKey(pod.Namespace, group), pod, nodeName)
+	}
+	return framework.NewStatus(framework.Success, "")
+}
+
+// Unreserve rejects the members of the pod's group that are waiting at
+// Permit. Once one member failed the group can't be complete, so there is no
+// point in holding resources for the others until they time out. The group
+// also gives up its place in the release queue.
+func (cs *CustomScheduler) Unreserve(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) {
+	group, _, isGang, _ := cs.groupManager().requirement(pod)
+	if !isGang {
+		return
+	}
+	klog.FromContext(ctx).V(3).Info("Rejecting the waiting pods of the group", "pod", klog.KObj(pod), "group", group)
+
+	cs.updateGroup(cs.groupKey(pod.Namespace, group), func(gs *groupState) {
+		gs.deadline = time.Time{}
+		delete(gs.assumed, pod.UID)
+	})
+	cs.abortRelease(pod, group)
+	cs.rejectWaitingPods(pod, group, fmt.Sprintf("pod %s/%s of group '%s' failed to be scheduled", pod.Namespace, pod.Name, group))
+}
+
+// countAssignedPods returns the number of members of the group that are
+// bound, reserved by this scheduler or waiting at Permit, including the pod
+// itself. Reserved members count before the informer shows them bound, so
+// that members scheduled back to back see each other.
+func (cs *CustomScheduler) countAssignedPods(pod *v1.Pod, group string, pods []*v1.Pod) int {
+	assigned := cs.groupManager().assignedMembers(pod.Namespace, group, pods)
+	assigned.Insert(pod.UID)
+	cs.handle.IterateOverWaitingPods(func(wp framework.WaitingPod) {
+		if cs.inGroup(wp.GetPod(), pod.Namespace, group) {
+			assigned.Insert(wp.GetPod().UID)
+		}
+	})
+	return assigned.Len()
+}
@@ -0,0 +1,297 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/apis/config"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/defaultbinder"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/queuesort"
+	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+	st "k8s.io/kubernetes/pkg/scheduler/testing"
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+func TestCustomScheduler_Permit(t *testing.T) {
+	tests := []struct {
+		name         string
+		minAvailable int
+		waitingTime  time.Duration
+		// number of pods of the group that arrive at Permit
+		arrivals int
+		want     framework.Code
+	}{
+		{
+			name:         "group completes before the timeout",
+			minAvailable: 3,
+			waitingTime:  10 * time.Second,
+			arrivals:     3,
+			want:         framework.Success,
+		},
+		{
+			name:         "group does not complete before the timeout",
+			minAvailable: 3,
+			waitingTime:  100 * time.Millisecond,
+			arrivals:     2,
+			want:         framework.Unschedulable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fwk := newPermitTestFramework(t, newGangScheduler(tt.waitingTime))
+
+			var waiting []*v1.Pod
+			for i := 0; i < tt.arrivals; i++ {
+				pod := makeGangPod(fmt.Sprintf("pod%d", i), "g1", tt.minAvailable)
+				status := fwk.RunPermitPlugins(context.Background(), framework.NewCycleState(), pod, "node1")
+				if i+1 == tt.minAvailable {
+					if !status.IsSuccess() {
+						t.Fatalf("pod %s: expected success at Permit, got %v", pod.Name, status)
+					}
+					continue
+				}
+				if !status.IsWait() {
+					t.Fatalf("pod %s: expected Wait at Permit, got %v", pod.Name, status)
+				}
+				waiting = append(waiting, pod)
+			}
+
+			// every member that had to wait must end up with the same result
+			for _, pod := range waiting {
+				status := fwk.WaitOnPermit(context.Background(), pod)
+				if status.Code() != tt.want {
+					t.Errorf("pod %s: expected %v, got %v", pod.Name, tt.want, status.Code())
+				}
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_Permit_GangCountPolicy(t *testing.T) {
+	unbound := []*v1.Pod{makeGangPod("pod0", "g1", 3), makeGangPod("pod1", "g1", 3), makeGangPod("pod2", "g1", 3)}
+	bound := []*v1.Pod{makeGangPod("pod0", "g1", 3), makeGangPod("pod1", "g1", 3), makeGangPod("pod2", "g1", 3)}
+	bound[1].Spec.NodeName = "node1"
+	bound[2].Spec.NodeName = "node2"
+	tests := []struct {
+		name   string
+		policy string
+		pods   []*v1.Pod
+		want   framework.Code
+	}{
+		{
+			name:   "created members are enough",
+			policy: gangCountCreated,
+			pods:   unbound,
+			want:   framework.Success,
+		},
+		{
+			name:   "created members are not assigned",
+			policy: gangCountAssigned,
+			pods:   unbound,
+			want:   framework.Wait,
+		},
+		{
+			name:   "assigned members and the pod itself are enough",
+			policy: gangCountAssigned,
+			pods:   bound,
+			want:   framework.Success,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := newGangScheduler(time.Second)
+			cs.gangCountPolicy = tt.policy
+			fwk := newPermitTestFramework(t, cs, tt.pods...)
+			status := fwk.RunPermitPlugins(context.Background(), framework.NewCycleState(), tt.pods[0], "node1")
+			if status.Code() != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, status.Code())
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_Permit_NoGang(t *testing.T) {
+	fwk := newPermitTestFramework(t, newGangScheduler(time.Second))
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "single"}}
+	status := fwk.RunPermitPlugins(context.Background(), framework.NewCycleState(), pod, "node1")
+	if !status.IsSuccess() {
+		t.Errorf("expected success, got %v", status)
+	}
+}
+
+func TestCustomScheduler_Unreserve(t *testing.T) {
+	fwk := newPermitTestFramework(t, newGangScheduler(10*time.Second))
+
+	var waiting []*v1.Pod
+	for i := 0; i < 2; i++ {
+		pod := makeGangPod(fmt.Sprintf("pod%d", i), "g1", 3)
+		if status := fwk.RunPermitPlugins(context.Background(), framework.NewCycleState(), pod, "node1"); !status.IsWait() {
+			t.Fatalf("pod %s: expected Wait at Permit, got %v", pod.Name, status)
+		}
+		waiting = append(waiting, pod)
+	}
+	other := makeGangPod("other", "g2", 2)
+	if status := fwk.RunPermitPlugins(context.Background(), framework.NewCycleState(), other, "node1"); !status.IsWait() {
+		t.Fatalf("pod %s: expected Wait at Permit, got %v", other.Name, status)
+	}
+
+	failed := makeGangPod("broken", "g1", 3)
+	fwk.RunReservePluginsUnreserve(context.Background(), framework.NewCycleState(), failed, "node1")
+
+	for _, pod := range waiting {
+		status := fwk.WaitOnPermit(context.Background(), pod)
+		if status.Code() != framework.Unschedulable {
+			t.Fatalf("pod %s: expected %v, got %v", pod.Name, framework.Unschedulable, status.Code())
+		}
+		if !strings.Contains(status.Message(), failed.Name) {
+			t.Errorf("pod %s: expected the rejection to name the failed pod, got %q", pod.Name, status.Message())
+		}
+	}
+	if wp := fwk.GetWaitingPod(other.UID); wp == nil {
+		t.Errorf("pod %s of another group should still be waiting", other.Name)
+	}
+}
+
+func TestCustomScheduler_Reserve(t *testing.T) {
+	tests := []struct {
+		name string
+		// afterBind runs once the first two members were allowed
+		afterBind func(cs *CustomScheduler, fakeClock *testingclock.FakeClock, fwk framework.Framework)
+		want      framework.Code
+	}{
+		{
+			name:      "reserved members count before the informer shows them bound",
+			afterBind: func(*CustomScheduler, *testingclock.FakeClock, framework.Framework) {},
+			want:      framework.Success,
+		},
+		{
+			name: "unreserved members no longer count",
+			afterBind: func(_ *CustomScheduler, _ *testingclock.FakeClock, fwk framework.Framework) {
+				for _, name := range []string{"pod0", "pod1"} {
+					fwk.RunReservePluginsUnreserve(context.Background(), framework.NewCycleState(), makeGangPod(name, "g1", 2), "node1")
+				}
+			},
+			want: framework.Wait,
+		},
+		{
+			name: "reserved members expire",
+			afterBind: func(_ *CustomScheduler, fakeClock *testingclock.FakeClock, _ framework.Framework) {
+				fakeClock.Step(assumedMemberTTL)
+			},
+			want: framework.Wait,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClock := testingclock.NewFakeClock(time.Now())
+			cs := newGangScheduler(10 * time.Second)
+			cs.clock = fakeClock
+			members := []*v1.Pod{makeGangPod("pod0", "g1", 2), makeGangPod("pod1", "g1", 2), makeGangPod("pod2", "g1", 2)}
+			// the informer never catches up with the bindings
+			fwk := newPermitTestFramework(t, cs, members...)
+
+			schedule := func(pod *v1.Pod) *framework.Status {
+				state := framework.NewCycleState()
+				if status := fwk.RunReservePluginsReserve(context.Background(), state, pod, "node1"); !status.IsSuccess() {
+					t.Fatalf("pod %s: expected success at Reserve, got %v", pod.Name, status)
+				}
+				return fwk.RunPermitPlugins(context.Background(), state, pod, "node1")
+			}
+			if status := schedule(members[0]); !status.IsWait() {
+				t.Fatalf("pod %s: expected Wait at Permit, got %v", members[0].Name, status)
+			}
+			if status := schedule(members[1]); !status.IsSuccess() {
+				t.Fatalf("pod %s: expected success at Permit, got %v", members[1].Name, status)
+			}
+			if status := fwk.WaitOnPermit(context.Background(), members[0]); !status.IsSuccess() {
+				t.Fatalf("pod %s: expected to be allowed, got %v", members[0].Name, status)
+			}
+
+			tt.afterBind(cs, fakeClock, fwk)
+			if status := schedule(members[2]); status.Code() != tt.want {
+				t.Errorf("pod %s: expected %v at Permit, got %v", members[2].Name, tt.want, status.Code())
+			}
+		})
+	}
+}
+
+// newPermitTestFramework returns a framework that runs cs as its Permit and
+// Reserve plugin and whose pod informer contains the given pods.
+func newPermitTestFramework(t *testing.T, cs *CustomScheduler, pods ...*v1.Pod) framework.Framework {
+	t.Helper()
+	return newPluginTestFramework(t, cs, []string{"Permit", "Reserve"}, pods...)
+}
+
+// newPluginTestFramework returns a framework running cs at the given
+// extension points, with the pods in its informer.
+func newPluginTestFramework(t *testing.T, cs *CustomScheduler, extensions []string, pods ...*v1.Pod) framework.Framework {
+	t.Helper()
+	client := clientsetfake.NewSimpleClientset()
+	informerFactory := informers.NewSharedInformerFactory(client, 0)
+	factory := func(_ runtime.Object, h framework.Handle) (framework.Plugin, error) {
+		cs.handle = h
+		return cs, nil
+	}
+	registeredPlugins := []st.RegisterPluginFunc{
+		st.RegisterBindPlugin(defaultbinder.Name, defaultbinder.New),
+		st.RegisterQueueSortPlugin(queuesort.Name, queuesort.New),
+		st.RegisterPluginAsExtensions(Name, factory, extensions...),
+	}
+	for _, extension := range extensions {
+		// the testing helpers don't know the PostFilter extension point
+		if extension == "PostFilter" {
+			registeredPlugins = append(registeredPlugins, func(_ *frameworkruntime.Registry, profile *schedulerapi.KubeSchedulerProfile) {
+				profile.Plugins.PostFilter.Enabled = append(profile.Plugins.PostFilter.Enabled, schedulerapi.Plugin{Name: Name})
+			})
+		}
+	}
+	fwk, err := st.NewFramework(
+		registeredPlugins,
+		"default-scheduler",
+		wait.NeverStop,
+		frameworkruntime.WithClientSet(client),
+		frameworkruntime.WithInformerFactory(informerFactory),
+	)
+	if err != nil {
+		t.Fatalf("fail to create framework: %s", err)
+	}
+	for _, p := range pods {
+		informerFactory.Core().V1().Pods().Informer().GetStore().Add(p)
+	}
+	return fwk
+}
+
+// newGangScheduler returns a CustomScheduler using the default label keys, the
+// Assigned gang count policy and the given Permit waiting time.
+func newGangScheduler(waitingTime time.Duration) *CustomScheduler {
+	return &CustomScheduler{
+		permitWaitingTime:    waitingTime,
+		gangCountPolicy:      gangCountAssigned,
+		groupLabelKey:        groupNameLabel,
+		minAvailableLabelKey: minAvailableLabel,
+	}
+}
+
+func makeGangPod(name, group string, minAvailable int) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			UID:  types.UID(name),
+			Labels: map[string]string{
+				"podGroup":     group,
+				"minAvailable": fmt.Sprint(minAvailable),
+			},
+		},
+	}
+}
@@ -0,0 +1,131 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+// memberNodesAnnotation lists the node of each scheduled member of a group
+// on the leader of the group, its oldest member.
+const memberNodesAnnotation = "scheduler.nthu.io/member-nodes"
+
+// memberName names a member of a group in placements and messages: by name
+// within a namespace, and by namespace and name for cluster-wide groups.
+func (cs *CustomScheduler) memberName(p *v1.Pod) string {
+	if cs.clusterWideGroups {
+		return podKey(p)
+	}
+	return p.Name
+}
+
+// recordPlacement records in the group store that PostBind bound the member
+// to the node, and exposes the nodes of the group's members as configured.
+func (cs *CustomScheduler) recordPlacement(ctx context.Context, pod *v1.Pod, group, nodeName string) {
+	var placements map[string]string
+	cs.updateGroup(cs.groupKey(pod.Namespace, group), func(gs *groupState) {
+		if gs.placements == nil {
+			gs.placements = make(map[string]string)
+		}
+		gs.placements[cs.memberName(pod)] = nodeName
+		cs.setMemberNodesMetric(pod.Namespace, group, gs)
+		placements = make(map[string]string, len(gs.placements))
+		for name, node := range gs.placements {
+			placements[name] = node
+		}
+	})
+	if cs.annotateMemberNodes {
+		cs.annotateLeader(ctx, pod, group, placements)
+	}
+}
+
+// forgetPlacement drops the placement of a deleted member. Called with the
+// lock held.
+func (cs *CustomScheduler) forgetPlacement(pod *v1.Pod, group string, gs *groupState) {
+	if _, ok := gs.placements[cs.memberName(pod)]; !ok {
+		return
+	}
+	delete(gs.placements, cs.memberName(pod))
+	cs.setMemberNodesMetric(pod.Namespace, group, gs)
+}
+
+// setMemberNodesMetric replaces the info series of the group with one
+// listing the nodes of its members, so that a group has at most one series.
+// Called with the lock held.
+func (cs *CustomScheduler) setMemberNodesMetric(namespace, group string, gs *groupState) {
+	if !cs.memberNodesMetric {
+		return
+	}
+	labels := cs.groupMetricLabels(namespace, group)
+	if gs.memberNodes != "" {
+		groupMemberNodes.DeleteLabelValues(append(labels, gs.memberNodes)...)
+	}
+	gs.memberNodes = placementNodes(gs.placements)
+	if gs.memberNodes != "" {
+		groupMemberNodes.WithLabelValues(append(labels, gs.memberNodes)...).Set(1)
+	}
+}
+
+// placementNodes returns the distinct nodes of the placements, sorted and
+// joined with commas.
+func placementNodes(placements map[string]string) string {
+	seen := make(map[string]bool)
+	var nodes []string
+	for _, node := range placements {
+		if !seen[node] {
+			seen[node] = true
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Strings(nodes)
+	return strings.Join(nodes, ",")
+}
+
+// annotateLeader patches the member nodes annotation onto the oldest live
+// member of the group. Members the informer shows bound by an earlier
+// scheduler instance are listed too. The annotation only helps debugging, so
+// failures are logged.
+func (cs *CustomScheduler) annotateLeader(ctx context.Context, pod *v1.Pod, group string, placements map[string]string) {
+	logger := klog.FromContext(ctx)
+	pods, err := cs.groupManager().Members(pod.Namespace, group)
+	if err != nil {
+		logger.Error(err, "Failed to list pods of group", "group", group)
+		return
+	}
+	leader := pod
+	for _, p := range pods {
+		if !isActivePod(p) {
+			continue
+		}
+		if _, ok := placements[cs.memberName(p)]; !ok && p.Spec.NodeName != "" {
+			placements[cs.memberName(p)] = p.Spec.NodeName
+		}
+		if p.CreationTimestamp.Before(&leader.CreationTimestamp) ||
+			p.CreationTimestamp.Equal(&leader.CreationTimestamp) && podKey(p) < podKey(leader) {
+			leader = p
+		}
+	}
+	data, err := json.Marshal(placements)
+	if err != nil {
+		logger.Error(err, "Failed to encode the member nodes", "group", group)
+		return
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{memberNodesAnnotation: string(data)},
+		},
+	})
+	if err != nil {
+		logger.Error(err, "Failed to encode the member nodes", "group", group)
+		return
+	}
+	if _, err := cs.handle.ClientSet().CoreV1().Pods(leader.Namespace).Patch(ctx, leader.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		logger.Error(err, "Failed to annotate the leader of the group", "pod", klog.KObj(leader), "group", group)
+	}
+}
@@ -0,0 +1,85 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/component-base/metrics/testutil"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_PostBind_MemberNodes(t *testing.T) {
+	RegisterMetrics()
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var pods []*v1.Pod
+	for i := 0; i < 3; i++ {
+		p := makeGangPod(fmt.Sprintf("pod%d", i), "g1", 3)
+		p.Namespace = "default"
+		// the last pod is the oldest, so it leads the group
+		p.CreationTimestamp = metav1.NewTime(created.Add(-time.Duration(i) * time.Minute))
+		pods = append(pods, p)
+	}
+	h := newTestFrameworkWithPods(t, pods)
+	for _, p := range pods {
+		if _, err := h.ClientSet().CoreV1().Pods(p.Namespace).Create(context.Background(), p, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	cs := &CustomScheduler{
+		handle:               h,
+		groupLabelKey:        groupNameLabel,
+		minAvailableLabelKey: minAvailableLabel,
+		groups:               make(map[string]*groupState),
+		annotateMemberNodes:  true,
+		memberNodesMetric:    true,
+	}
+	for i, node := range []string{"node1", "node2", "node1"} {
+		cs.PostBind(context.Background(), framework.NewCycleState(), pods[i], node)
+	}
+
+	leader, err := h.ClientSet().CoreV1().Pods("default").Get(context.Background(), "pod2", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"pod0":"node1","pod1":"node2","pod2":"node1"}`
+	if got := leader.Annotations[memberNodesAnnotation]; got != want {
+		t.Errorf("expected the leader to list %s, got %q", want, got)
+	}
+	for _, name := range []string{"pod0", "pod1"} {
+		p, err := h.ClientSet().CoreV1().Pods("default").Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, ok := p.Annotations[memberNodesAnnotation]; ok {
+			t.Errorf("expected only the leader to be annotated, got %s annotated", name)
+		}
+	}
+	if value, _ := testutil.GetGaugeMetricValue(groupMemberNodes.WithLabelValues("default", "g1", "node1,node2")); value != 1 {
+		t.Errorf("expected the group to be on node1 and node2, got %v", value)
+	}
+
+	// deleting a member forgets its node
+	store := h.SharedInformerFactory().Core().V1().Pods().Informer().GetStore()
+	store.Delete(pods[1])
+	cs.onPodDelete(pods[1])
+	if got, want := cs.groups[cs.groupKey("default", "g1")].placements, map[string]string{"pod0": "node1", "pod2": "node1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected placements %v, got %v", want, got)
+	}
+	if value, _ := testutil.GetGaugeMetricValue(groupMemberNodes.WithLabelValues("default", "g1", "node1")); value != 1 {
+		t.Errorf("expected the group to be on node1 only, got %v", value)
+	}
+
+	// the group is forgotten once all of its members are deleted
+	for _, p := range []*v1.Pod{pods[0], pods[2]} {
+		store.Delete(p)
+		cs.onPodDelete(p)
+	}
+	if gs, ok := cs.groups[cs.groupKey("default", "g1")]; ok {
+		t.Errorf("expected the group to be forgotten, got %+v", gs)
+	}
+}
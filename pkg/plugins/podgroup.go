@@ -0,0 +1,147 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// podGroupGVR is the PodGroup custom resource of the sig-scheduling
+// coscheduling plugin. Its spec.minMember replaces the minAvailable label and
+// its status reports how many members are scheduled and running.
+var podGroupGVR = schema.GroupVersionResource{
+	Group:    "scheduling.x-k8s.io",
+	Version:  "v1alpha1",
+	Resource: "podgroups",
+}
+
+// setupPodGroupCRD creates the client and informer used to read and update
+// PodGroup resources. The informer runs until the plugin is closed.
+func (cs *CustomScheduler) setupPodGroupCRD(h framework.Handle) error {
+	client, err := dynamic.NewForConfig(h.KubeConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create PodGroup client: %w", err)
+	}
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(client, 0)
+	informer := factory.ForResource(podGroupGVR)
+	cs.podGroupClient = client
+	cs.podGroupLister = informer.Lister()
+	factory.Start(cs.stopCh)
+	return nil
+}
+
+// podGroupMinMember returns spec.minMember of the PodGroup with the given
+// name. ok is false when PodGroup support is disabled or the PodGroup doesn't
+// exist, in which case the pod labels are used instead.
+func (cs *CustomScheduler) podGroupMinMember(namespace, name string) (minMember int, ok bool) {
+	u, ok := cs.getPodGroup(namespace, name)
+	if !ok {
+		return 0, false
+	}
+	value, found, err := unstructured.NestedInt64(u.Object, "spec", "minMember")
+	if err != nil || !found {
+		klog.V(2).InfoS("PodGroup has no valid spec.minMember", "podGroup", klog.KRef(namespace, name))
+		return 0, false
+	}
+	return int(value), true
+}
+
+// podGroupMinResources returns spec.minResources of the PodGroup with the
+// given name, with each quantity as a string. ok is false when PodGroup
+// support is disabled, or the PodGroup doesn't exist or has no minResources.
+func (cs *CustomScheduler) podGroupMinResources(namespace, name string) (minResources map[string]string, ok bool) {
+	u, ok := cs.getPodGroup(namespace, name)
+	if !ok {
+		return nil, false
+	}
+	value, found, err := unstructured.NestedMap(u.Object, "spec", "minResources")
+	if err != nil || !found {
+		return nil, false
+	}
+	minResources = make(map[string]string, len(value))
+	for name, quantity := range value {
+		minResources[name] = fmt.Sprint(quantity)
+	}
+	return minResources, true
+}
+
+// getPodGroup returns the PodGroup with the given name from the informer's
+// cache. ok is false when PodGroup support is disabled or the PodGroup
+// doesn't exist.
+func (cs *CustomScheduler) getPodGroup(namespace, name string) (*unstructured.Unstructured, bool) {
+	if cs.podGroupLister == nil {
+		return nil, false
+	}
+	obj, err := cs.podGroupLister.ByNamespace(namespace).Get(name)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.ErrorS(err, "Failed to get PodGroup", "podGroup", klog.KRef(namespace, name))
+		}
+		return nil, false
+	}
+	u, isUnstructured := obj.(*unstructured.Unstructured)
+	return u, isUnstructured
+}
+
+// PostBind compares the placement with the dry-run mode, records the node of
+// the member, counts it as bound in the release queue, resets the circuit
+// breaker of the pod's group and updates the status of its PodGroup with the
+// number of members that are scheduled and running.
+func (cs *CustomScheduler) PostBind(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) {
+	cs.compareDryRun(ctx, state, pod, nodeName)
+	group, ok := cs.podGroupName(pod)
+	if !ok {
+		return
+	}
+	cs.recordPlacement(ctx, pod, group, nodeName)
+	cs.finishRelease(pod, group)
+	cs.resetBreaker(pod.Namespace, group)
+	if _, ok := cs.podGroupMinMember(pod.Namespace, group); !ok {
+		return
+	}
+
+	pods, err := cs.groupManager().Members(pod.Namespace, group)
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "Failed to list pods of PodGroup", "podGroup", klog.KRef(pod.Namespace, group))
+		return
+	}
+	// the informer may not have seen the binding of this pod yet
+	scheduled, running := 1, 0
+	for _, p := range pods {
+		if !isActivePod(p) {
+			continue
+		}
+		if p.UID != pod.UID && p.Spec.NodeName != "" {
+			scheduled++
+		}
+		if p.Status.Phase == v1.PodRunning {
+			running++
+		}
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"scheduled": scheduled,
+			"running":   running,
+		},
+	})
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "Failed to build the status patch of PodGroup", "podGroup", klog.KRef(pod.Namespace, group))
+		return
+	}
+	_, err = cs.podGroupClient.Resource(podGroupGVR).Namespace(pod.Namespace).Patch(ctx, group, types.MergePatchType, patch, metav1.PatchOptions{}, "status")
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "Failed to update the status of PodGroup", "podGroup", klog.KRef(pod.Namespace, group))
+	}
+}
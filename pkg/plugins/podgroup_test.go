@@ -0,0 +1,136 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_PreFilter_PodGroupCRD(t *testing.T) {
+	var existing []*v1.Pod
+	for i := 0; i < 2; i++ {
+		existing = append(existing, &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("pod%d", i),
+				Namespace: "default",
+				Labels:    map[string]string{"podGroup": "g1"},
+			},
+		})
+	}
+	tests := []struct {
+		name      string
+		podGroups []*unstructured.Unstructured
+		labels    map[string]string
+		want      framework.Code
+	}{
+		{
+			name:      "minMember of the PodGroup overrides the label",
+			podGroups: []*unstructured.Unstructured{makePodGroup("default", "g1", 3)},
+			labels:    map[string]string{"podGroup": "g1", "minAvailable": "1"},
+			want:      framework.Unschedulable,
+		},
+		{
+			name:      "PodGroup makes the pod a gang member without minAvailable",
+			podGroups: []*unstructured.Unstructured{makePodGroup("default", "g1", 3)},
+			labels:    map[string]string{"podGroup": "g1"},
+			want:      framework.Unschedulable,
+		},
+		{
+			name:      "PodGroup is satisfied",
+			podGroups: []*unstructured.Unstructured{makePodGroup("default", "g1", 2)},
+			labels:    map[string]string{"podGroup": "g1", "minAvailable": "5"},
+			want:      framework.Success,
+		},
+		{
+			name:      "labels are used when the PodGroup doesn't exist",
+			podGroups: []*unstructured.Unstructured{makePodGroup("other", "g1", 1)},
+			labels:    map[string]string{"podGroup": "g1", "minAvailable": "3"},
+			want:      framework.Unschedulable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := newPodGroupScheduler(t, newTestFrameworkWithPods(t, existing), tt.podGroups)
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "incoming", Namespace: "default", Labels: tt.labels},
+			}
+			_, status := cs.PreFilter(context.Background(), nil, pod)
+			if status.Code() != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, status.Code())
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_PostBind(t *testing.T) {
+	existing := []*v1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "bound", Namespace: "default", UID: "bound", Labels: map[string]string{"podGroup": "g1"}},
+			Spec:       v1.PodSpec{NodeName: "node1"},
+			Status:     v1.PodStatus{Phase: v1.PodRunning},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "default", UID: "pending", Labels: map[string]string{"podGroup": "g1"}},
+		},
+	}
+	cs := newPodGroupScheduler(t, newTestFrameworkWithPods(t, existing), []*unstructured.Unstructured{makePodGroup("default", "g1", 3)})
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "incoming", Namespace: "default", UID: "incoming", Labels: map[string]string{"podGroup": "g1"}},
+	}
+	cs.PostBind(context.Background(), nil, pod, "node1")
+
+	got, err := cs.podGroupClient.Resource(podGroupGVR).Namespace("default").Get(context.Background(), "g1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("fail to get PodGroup: %v", err)
+	}
+	for field, want := range map[string]int64{"scheduled": 2, "running": 1} {
+		value, _, _ := unstructured.NestedInt64(got.Object, "status", field)
+		if value != want {
+			t.Errorf("expected status.%s %d, got %d", field, want, value)
+		}
+	}
+}
+
+// newPodGroupScheduler returns a CustomScheduler with PodGroup support backed
+// by a fake client holding the given PodGroups.
+func newPodGroupScheduler(t *testing.T, fh framework.Handle, podGroups []*unstructured.Unstructured) *CustomScheduler {
+	t.Helper()
+	var objects []runtime.Object
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, pg := range podGroups {
+		objects = append(objects, pg)
+		if err := indexer.Add(pg); err != nil {
+			t.Fatalf("fail to add PodGroup: %v", err)
+		}
+	}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{podGroupGVR: "PodGroupList"}, objects...)
+	return &CustomScheduler{
+		handle:               fh,
+		scoreMode:            leastMode,
+		groupLabelKey:        groupNameLabel,
+		minAvailableLabelKey: minAvailableLabel,
+		podGroupClient:       client,
+		podGroupLister:       cache.NewGenericLister(indexer, podGroupGVR.GroupResource()),
+	}
+}
+
+func makePodGroup(namespace, name string, minMember int64) *unstructured.Unstructured {
+	pg := &unstructured.Unstructured{}
+	pg.SetAPIVersion(podGroupGVR.GroupVersion().String())
+	pg.SetKind("PodGroup")
+	pg.SetNamespace(namespace)
+	pg.SetName(name)
+	_ = unstructured.SetNestedField(pg.Object, minMember, "spec", "minMember")
+	return pg
+}
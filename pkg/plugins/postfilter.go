@@ -0,0 +1,97 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// PostFilter is called when a pod couldn't fit on any node. If the pod belongs
+// to a gang, the whole group can't be scheduled either. With group preemption
+// enabled, lower-priority groups are evicted to make room for it if that
+// helps. Otherwise the group is blocked for a backoff window and its members
+// waiting at Permit are rejected. Pods this plugin rejected itself, in
+// PreFilter or on every node in Filter, are left alone: they are waiting for
+// their group rather than failing to fit.
+func (cs *CustomScheduler) PostFilter(ctx context.Context, state *framework.CycleState, pod *v1.Pod, filteredNodeStatusMap framework.NodeToStatusMap) (*framework.PostFilterResult, *framework.Status) {
+	logger := klog.FromContext(ctx)
+	logger.V(5).Info("PostFilter", "pod", klog.KObj(pod))
+
+	group, _, isGang, _ := cs.groupManager().requirement(pod)
+	if !isGang {
+		return nil, framework.NewStatus(framework.Unschedulable, "pod doesn't belong to a group")
+	}
+	if cs.rejectedByPlugin(filteredNodeStatusMap) {
+		logger.V(5).Info("Pod was rejected by the plugin, not blocking its group", "pod", klog.KObj(pod), "group", group)
+		return nil, framework.NewStatus(framework.Unschedulable, fmt.Sprintf("pod was rejected by %s", Name))
+	}
+
+	if cs.groupPreemption {
+		nominated, status := cs.preemptGroups(ctx, pod, group, filteredNodeStatusMap)
+		if !status.IsSuccess() {
+			return nil, status
+		}
+		if nominated != "" {
+			logger.V(2).Info("Nominated a node after preempting for the group", "pod", klog.KObj(pod), "node", nominated, "group", group)
+			return framework.NewPostFilterResultWithNominatedNode(nominated), framework.NewStatus(framework.Success)
+		}
+	}
+
+	reason := fmt.Sprintf("pod %s/%s of the group couldn't fit on any of %d nodes", pod.Namespace, pod.Name, len(filteredNodeStatusMap))
+	// the breaker only counts the attempts the other Filter plugins failed on
+	// every node
+	if !rejectedOnAnyNode(filteredNodeStatusMap) {
+		if backoff := cs.recordGroupFailure(pod.Namespace, group); backoff > 0 {
+			logger.V(2).Info("Group failed too many attempts in a row, holding it back", "group", group, "attempts", cs.maxGroupAttempts, "backoff", backoff)
+		}
+	}
+	cs.updateGroup(cs.groupKey(pod.Namespace, group), func(gs *groupState) {
+		gs.blockedUntil = cs.now().Add(cs.groupBackoff)
+		gs.blockedReason = reason
+		// a blocked group no longer holds back groups of lower priority
+		gs.contender = nil
+	})
+	logger.V(2).Info("Blocked the group", "group", group, "backoff", cs.groupBackoff, "reason", reason)
+	cs.rejectWaitingPods(pod, group, fmt.Sprintf("group '%s' is blocked: %s", group, reason))
+
+	return nil, framework.NewStatus(framework.Unschedulable, fmt.Sprintf("group '%s' is blocked: %s", group, reason))
+}
+
+// rejectedByPlugin reports whether the pod didn't fail Filter on its own
+// merits. In 1.27 the framework runs PostFilter with no node statuses when
+// PreFilter rejected the pod, and the statuses of nodes this plugin's Filter
+// rejected name it as the failed plugin.
+func (cs *CustomScheduler) rejectedByPlugin(filteredNodeStatusMap framework.NodeToStatusMap) bool {
+	for _, status := range filteredNodeStatusMap {
+		if status.FailedPlugin() != Name {
+			return false
+		}
+	}
+	return true
+}
+
+// rejectedOnAnyNode reports whether this plugin's Filter rejected the pod on
+// any of the nodes.
+func rejectedOnAnyNode(filteredNodeStatusMap framework.NodeToStatusMap) bool {
+	for _, status := range filteredNodeStatusMap {
+		if status.FailedPlugin() == Name {
+			return true
+		}
+	}
+	return false
+}
+
+// blockedReason returns why the group is blocked, or "" if it isn't.
+func (cs *CustomScheduler) blockedReason(key string) string {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	gs := cs.groups[key]
+	if gs == nil || !gs.blockedUntil.After(cs.now()) {
+		return ""
+	}
+	return gs.blockedReason
+}
@@ -0,0 +1,128 @@
+package plugins
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_PostFilter(t *testing.T) {
+	members := []*v1.Pod{makeGangPod("pod0", "g1", 2), makeGangPod("pod1", "g1", 2)}
+	tests := []struct {
+		name    string
+		backoff time.Duration
+		// unblock is called after the group has been blocked
+		unblock func(cs *CustomScheduler)
+		// wantBlocked is whether the sibling is still rejected after unblock
+		wantBlocked bool
+	}{
+		{
+			name:        "group stays blocked during the backoff",
+			backoff:     time.Minute,
+			unblock:     func(cs *CustomScheduler) {},
+			wantBlocked: true,
+		},
+		{
+			name:        "block expires after the backoff",
+			backoff:     50 * time.Millisecond,
+			unblock:     func(cs *CustomScheduler) { time.Sleep(100 * time.Millisecond) },
+			wantBlocked: false,
+		},
+		{
+			name:        "deleting a member unblocks the group",
+			backoff:     time.Minute,
+			unblock:     func(cs *CustomScheduler) { cs.onPodDelete(members[0]) },
+			wantBlocked: false,
+		},
+		{
+			name:    "adding a node unblocks the group",
+			backoff: time.Minute,
+			unblock: func(cs *CustomScheduler) {
+				cs.onNodeAdd(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "new-node"}})
+			},
+			wantBlocked: false,
+		},
+		{
+			name:    "growing the allocatable memory of a node unblocks the group",
+			backoff: time.Minute,
+			unblock: func(cs *CustomScheduler) {
+				cs.onNodeUpdate(makeNodeInfo("node1", 4000, 4<<30).Node(), makeNodeInfo("node1", 4000, 8<<30).Node())
+			},
+			wantBlocked: false,
+		},
+		{
+			name:    "updating a node without adding capacity keeps the group blocked",
+			backoff: time.Minute,
+			unblock: func(cs *CustomScheduler) {
+				cs.onNodeUpdate(makeNodeInfo("node1", 4000, 8<<30).Node(), makeNodeInfo("node1", 4000, 4<<30).Node())
+			},
+			wantBlocked: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:               newTestFrameworkWithPods(t, members),
+				scoreMode:            leastMode,
+				groupBackoff:         tt.backoff,
+				groupLabelKey:        groupNameLabel,
+				minAvailableLabelKey: minAvailableLabel,
+			}
+
+			if _, status := cs.PreFilter(context.Background(), nil, members[1]); !status.IsSuccess() {
+				t.Fatalf("expected success before the group is blocked, got %v", status)
+			}
+
+			nodeStatuses := framework.NodeToStatusMap{"node1": framework.NewStatus(framework.Unschedulable, "too big")}
+			_, status := cs.PostFilter(context.Background(), nil, members[0], nodeStatuses)
+			if status.Code() != framework.Unschedulable {
+				t.Fatalf("expected %v from PostFilter, got %v", framework.Unschedulable, status.Code())
+			}
+
+			_, status = cs.PreFilter(context.Background(), nil, members[1])
+			if status.Code() != framework.Unschedulable {
+				t.Fatalf("expected %v for a sibling of the failed pod, got %v", framework.Unschedulable, status.Code())
+			}
+			if !strings.Contains(status.Message(), members[0].Name) {
+				t.Errorf("expected the rejection to reference %s, got %q", members[0].Name, status.Message())
+			}
+
+			tt.unblock(cs)
+			_, status = cs.PreFilter(context.Background(), nil, members[1])
+			if blocked := !status.IsSuccess(); blocked != tt.wantBlocked {
+				t.Errorf("expected blocked=%v, got status %v", tt.wantBlocked, status)
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_PostFilter_PreFilterRejection(t *testing.T) {
+	// the group lacks a member, so PreFilter rejects its pods while pod0
+	// already waits at Permit
+	members := []*v1.Pod{makeGangPod("pod0", "g1", 3), makeGangPod("pod1", "g1", 3)}
+	cs := newGangScheduler(10 * time.Second)
+	cs.groupBackoff = time.Minute
+	fwk := newPluginTestFramework(t, cs, []string{"PreFilter", "PostFilter", "Permit"}, members...)
+	runPermit(t, fwk, members[0], framework.Wait)
+	waiting := waitOnPermit(fwk, members[0])
+
+	// as in the scheduling cycle of 1.27, PostFilter runs with the node
+	// statuses of the diagnosis, which PreFilter leaves empty
+	state := framework.NewCycleState()
+	if _, status := fwk.RunPreFilterPlugins(context.Background(), state, members[1]); status.Code() != framework.Unschedulable {
+		t.Fatalf("expected %v from PreFilter, got %v", framework.Unschedulable, status)
+	}
+	if _, status := fwk.RunPostFilterPlugins(context.Background(), state, members[1], framework.NodeToStatusMap{}); status.IsSuccess() {
+		t.Fatalf("expected PostFilter not to help, got %v", status)
+	}
+
+	if reason := cs.blockedReason(cs.groupKey("", "g1")); reason != "" {
+		t.Errorf("expected the group not to be blocked, got %q", reason)
+	}
+	expectHeld(t, members[0], waiting)
+}
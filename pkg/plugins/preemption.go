@@ -0,0 +1,278 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1helpers "k8s.io/component-helpers/scheduling/corev1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// victimGroup is a set of assigned pods that are preempted together: all
+// members of a lower-priority group, or a single pod outside of any group.
+type victimGroup struct {
+	key string
+	// priority is the highest priority among the members.
+	priority int32
+	pods     []*v1.Pod
+	// onNodes is set when a member runs on one of the nodes preempting may
+	// free.
+	onNodes bool
+}
+
+// nodeCapacity is the CPU and memory left on a node.
+type nodeCapacity struct {
+	name     string
+	milliCPU int64
+	memory   int64
+}
+
+// preemptGroups tries to make room for the pending members of the pod's group
+// by evicting whole groups of lower priority. It returns the node nominated
+// for the pod, or "" when preempting can't make the group fit.
+func (cs *CustomScheduler) preemptGroups(ctx context.Context, pod *v1.Pod, group string, filteredNodeStatusMap framework.NodeToStatusMap) (string, *framework.Status) {
+	pending, err := cs.pendingMembers(pod, group)
+	if err != nil {
+		return "", framework.NewStatus(framework.Error, fmt.Sprintf("Failed to list pods: %v", err))
+	}
+	nodeInfos, err := cs.nodeInfoLister().List()
+	if err != nil {
+		return "", framework.NewStatus(framework.Error, fmt.Sprintf("Failed to list nodes: %v", err))
+	}
+
+	free := make(map[string]*nodeCapacity)
+	for _, nodeInfo := range nodeInfos {
+		node := nodeInfo.Node()
+		if node == nil {
+			continue
+		}
+		// preempting can't help on a node the pod can never run on
+		if status := filteredNodeStatusMap[node.Name]; status.Code() == framework.UnschedulableAndUnresolvable {
+			continue
+		}
+		free[node.Name] = &nodeCapacity{
+			name:     node.Name,
+			milliCPU: nodeInfo.Allocatable.MilliCPU - nodeInfo.Requested.MilliCPU,
+			memory:   nodeInfo.Allocatable.Memory - nodeInfo.Requested.Memory,
+		}
+	}
+	if _, fits := placeGroup(pod, pending, free); fits {
+		// the group is held back by something other than CPU or memory
+		return "", nil
+	}
+
+	candidates := cs.victimGroups(pod, group, nodeInfos, free)
+	var victims []*victimGroup
+	nominated := ""
+	for _, candidate := range candidates {
+		release(free, candidate, 1)
+		victims = append(victims, candidate)
+		if node, fits := placeGroup(pod, pending, free); fits {
+			nominated = node
+			break
+		}
+	}
+	if nominated == "" {
+		return "", nil
+	}
+	// spare the most important victims that turn out not to be needed
+	for i := len(victims) - 1; i >= 0; i-- {
+		release(free, victims[i], -1)
+		if node, fits := placeGroup(pod, pending, free); fits {
+			nominated = node
+			victims = append(victims[:i], victims[i+1:]...)
+			continue
+		}
+		release(free, victims[i], 1)
+	}
+
+	for _, victim := range victims {
+		for _, p := range victim.pods {
+			klog.FromContext(ctx).V(2).Info("Preempting a pod of another group", "pod", klog.KObj(p), "victimGroup", victim.key, "group", group)
+			err := cs.handle.ClientSet().CoreV1().Pods(p.Namespace).Delete(ctx, p.Name, metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return "", framework.NewStatus(framework.Error, fmt.Sprintf("Failed to preempt pod %s/%s: %v", p.Namespace, p.Name, err))
+			}
+			if cs.eventRecorder != nil {
+				cs.eventRecorder.Eventf(p, pod, v1.EventTypeNormal, "Preempted", "Preempting",
+					"Preempted with '%s' to make room for group '%s'", victim.key, group)
+			}
+		}
+	}
+	return nominated, nil
+}
+
+// pendingMembers returns the live members of the pod's group that still need
+// a node, including the pod itself.
+func (cs *CustomScheduler) pendingMembers(pod *v1.Pod, group string) ([]*v1.Pod, error) {
+	pods, err := cs.groupManager().Members(pod.Namespace, group)
+	if err != nil {
+		return nil, err
+	}
+	pending := []*v1.Pod{pod}
+	for _, p := range sameSchedulerPods(pod, pods) {
+		if podKey(p) != podKey(pod) && p.Spec.NodeName == "" && isActivePod(p) {
+			pending = append(pending, p)
+		}
+	}
+	return pending, nil
+}
+
+// victimGroups returns the groups running on the given nodes that may be
+// preempted for the pod's group, least important first. A group qualifies
+// when all of its members have a lower priority than the pod and evicting
+// them doesn't violate a PodDisruptionBudget. A group holds all of its
+// assigned members, including those on other nodes, so that it is never
+// left running below minAvailable.
+func (cs *CustomScheduler) victimGroups(pod *v1.Pod, group string, nodeInfos []*framework.NodeInfo, nodes map[string]*nodeCapacity) []*victimGroup {
+	preemptorKey := cs.groupKey(pod.Namespace, group)
+	priority := corev1helpers.PodPriority(pod)
+
+	groups := make(map[string]*victimGroup)
+	for _, nodeInfo := range nodeInfos {
+		if nodeInfo.Node() == nil {
+			continue
+		}
+		onNodes := nodes[nodeInfo.Node().Name] != nil
+		for _, podInfo := range nodeInfo.Pods {
+			p := podInfo.Pod
+			if !isActivePod(p) {
+				continue
+			}
+			key := "pod " + podKey(p)
+			if group, ok := cs.podGroupName(p); ok {
+				key = cs.groupKey(p.Namespace, group)
+			}
+			if key == preemptorKey {
+				continue
+			}
+			vg := groups[key]
+			if vg == nil {
+				vg = &victimGroup{key: key, priority: corev1helpers.PodPriority(p)}
+				groups[key] = vg
+			}
+			if prio := corev1helpers.PodPriority(p); prio > vg.priority {
+				vg.priority = prio
+			}
+			vg.pods = append(vg.pods, p)
+			vg.onNodes = vg.onNodes || onNodes
+		}
+	}
+
+	var candidates []*victimGroup
+	for _, vg := range groups {
+		if !vg.onNodes || vg.priority >= priority || cs.violatesPDB(vg) {
+			continue
+		}
+		candidates = append(candidates, vg)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].priority != candidates[j].priority {
+			return candidates[i].priority < candidates[j].priority
+		}
+		if len(candidates[i].pods) != len(candidates[j].pods) {
+			return len(candidates[i].pods) < len(candidates[j].pods)
+		}
+		return candidates[i].key < candidates[j].key
+	})
+	return candidates
+}
+
+// violatesPDB reports whether evicting all members of the group exceeds the
+// disruptions allowed by any PodDisruptionBudget covering them.
+func (cs *CustomScheduler) violatesPDB(vg *victimGroup) bool {
+	if cs.pdbLister == nil {
+		return false
+	}
+	pdbs, err := cs.pdbLister.List(labels.Everything())
+	if err != nil {
+		klog.ErrorS(err, "Failed to list PodDisruptionBudgets, not preempting the group", "group", vg.key)
+		return true
+	}
+	for _, pdb := range pdbs {
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || selector.Empty() {
+			continue
+		}
+		if int32(countCovered(pdb, selector, vg.pods)) > pdb.Status.DisruptionsAllowed {
+			return true
+		}
+	}
+	return false
+}
+
+// countCovered returns the number of pods covered by the budget.
+func countCovered(pdb *policy.PodDisruptionBudget, selector labels.Selector, pods []*v1.Pod) int {
+	count := 0
+	for _, p := range pods {
+		if p.Namespace == pdb.Namespace && selector.Matches(labels.Set(p.Labels)) {
+			count++
+		}
+	}
+	return count
+}
+
+// release gives the requests of the group's pods back to their nodes when
+// sign is 1, and takes them again when sign is -1.
+func release(nodes map[string]*nodeCapacity, vg *victimGroup, sign int64) {
+	for _, p := range vg.pods {
+		node := nodes[p.Spec.NodeName]
+		if node == nil {
+			continue
+		}
+		requests := PodEffectiveRequests(p)
+		node.milliCPU += sign * requests.Cpu().MilliValue()
+		node.memory += sign * requests.Memory().Value()
+	}
+}
+
+// placeGroup reports whether the pending pods fit onto the free capacity of
+// the nodes, placing the largest requests first, and returns the node the
+// pod itself is placed on.
+func placeGroup(pod *v1.Pod, pending []*v1.Pod, nodes map[string]*nodeCapacity) (string, bool) {
+	left := make([]*nodeCapacity, 0, len(nodes))
+	for _, node := range nodes {
+		copied := *node
+		left = append(left, &copied)
+	}
+	sort.Slice(left, func(i, j int) bool { return left[i].name < left[j].name })
+
+	type request struct {
+		pod      *v1.Pod
+		milliCPU int64
+		memory   int64
+	}
+	requests := make([]request, 0, len(pending))
+	for _, p := range pending {
+		r := PodEffectiveRequests(p)
+		requests = append(requests, request{pod: p, milliCPU: r.Cpu().MilliValue(), memory: r.Memory().Value()})
+	}
+	sort.SliceStable(requests, func(i, j int) bool { return requests[i].memory > requests[j].memory })
+
+	podNode := ""
+	for _, r := range requests {
+		placedOn := ""
+		for _, node := range left {
+			if node.milliCPU >= r.milliCPU && node.memory >= r.memory {
+				node.milliCPU -= r.milliCPU
+				node.memory -= r.memory
+				placedOn = node.name
+				break
+			}
+		}
+		if placedOn == "" {
+			return "", false
+		}
+		if r.pod == pod {
+			podNode = placedOn
+		}
+	}
+	return podNode, true
+}
@@ -0,0 +1,163 @@
+package plugins
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_PostFilter_GroupPreemption(t *testing.T) {
+	makePod := func(name, group string, priority int32, nodeName string) *v1.Pod {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{}},
+			Spec: v1.PodSpec{
+				NodeName: nodeName,
+				Priority: &priority,
+				Containers: []v1.Container{{
+					Resources: v1.ResourceRequirements{
+						Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")},
+					},
+				}},
+			},
+		}
+		if group != "" {
+			pod.Labels["podGroup"] = group
+			pod.Labels["minAvailable"] = "2"
+		}
+		return pod
+	}
+	// both nodes are full: node1 runs a member of "low" and a pod outside of
+	// any group, node2 runs the other member of "low" and one of "high"
+	running := []*v1.Pod{
+		makePod("low0", "low", 10, "node1"),
+		makePod("single", "", 10, "node1"),
+		makePod("low1", "low", 10, "node2"),
+		makePod("high0", "high", 1000, "node2"),
+	}
+	preemptor := makePod("big0", "big", 100, "")
+	pending := []*v1.Pod{preemptor, makePod("big1", "big", 100, "")}
+
+	tests := []struct {
+		name      string
+		enabled   bool
+		preemptor *v1.Pod
+		pdbs      []*policy.PodDisruptionBudget
+		// unresolvable are the nodes preempting can't help
+		unresolvable []string
+		want         framework.Code
+		// wantNode is the node nominated for the preemptor
+		wantNode string
+		// wantDeleted are the names of the preempted pods
+		wantDeleted []string
+	}{
+		{
+			name:      "preemption is disabled",
+			preemptor: preemptor,
+			want:      framework.Unschedulable,
+		},
+		{
+			name:        "whole lower-priority group is preempted",
+			enabled:     true,
+			preemptor:   preemptor,
+			want:        framework.Success,
+			wantNode:    "node1",
+			wantDeleted: []string{"low0", "low1"},
+		},
+		{
+			name:         "members on nodes preempting can't help are preempted with their group",
+			enabled:      true,
+			preemptor:    preemptor,
+			unresolvable: []string{"node2"},
+			want:         framework.Success,
+			wantNode:     "node1",
+			wantDeleted:  []string{"low0", "low1", "single"},
+		},
+		{
+			name:      "group protected by a PodDisruptionBudget is spared",
+			enabled:   true,
+			preemptor: preemptor,
+			pdbs: []*policy.PodDisruptionBudget{{
+				ObjectMeta: metav1.ObjectMeta{Name: "low", Namespace: "default"},
+				Spec:       policy.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"podGroup": "low"}}},
+				Status:     policy.PodDisruptionBudgetStatus{DisruptionsAllowed: 1},
+			}},
+			want: framework.Unschedulable,
+		},
+		{
+			name:      "groups of higher priority are not preempted",
+			enabled:   true,
+			preemptor: makePod("big0", "big", 5, ""),
+			want:      framework.Unschedulable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeInfos := []*framework.NodeInfo{makeNodeInfo("node1", 4000, 1<<30), makeNodeInfo("node2", 4000, 1<<30)}
+			for _, p := range running {
+				if p.Spec.NodeName == "node1" {
+					nodeInfos[0].AddPod(p)
+				} else {
+					nodeInfos[1].AddPod(p)
+				}
+			}
+			fh := newTestFrameworkWithNodes(t, append([]*v1.Pod{tt.preemptor, pending[1]}, running...), nodeInfos)
+			pdbInformer := fh.SharedInformerFactory().Policy().V1().PodDisruptionBudgets()
+			for _, pdb := range tt.pdbs {
+				pdbInformer.Informer().GetStore().Add(pdb)
+			}
+			cs := &CustomScheduler{
+				handle:               fh,
+				scoreMode:            leastMode,
+				groupBackoff:         time.Minute,
+				groupLabelKey:        groupNameLabel,
+				minAvailableLabelKey: minAvailableLabel,
+				groupPreemption:      tt.enabled,
+				pdbLister:            pdbInformer.Lister(),
+			}
+
+			nodeStatuses := framework.NodeToStatusMap{
+				"node1": framework.NewStatus(framework.Unschedulable, "Insufficient cpu"),
+				"node2": framework.NewStatus(framework.Unschedulable, "Insufficient cpu"),
+			}
+			for _, name := range tt.unresolvable {
+				nodeStatuses[name] = framework.NewStatus(framework.UnschedulableAndUnresolvable, "node(s) didn't match Pod's node affinity")
+			}
+			result, status := cs.PostFilter(context.Background(), nil, tt.preemptor, nodeStatuses)
+			if status.Code() != tt.want {
+				t.Fatalf("expected %v, got %v: %s", tt.want, status.Code(), status.Message())
+			}
+			var node string
+			if result != nil && result.NominatingInfo != nil {
+				node = result.NominatedNodeName
+			}
+			if node != tt.wantNode {
+				t.Errorf("expected nominated node %q, got %q", tt.wantNode, node)
+			}
+
+			var deleted []string
+			for _, action := range fh.ClientSet().(*clientsetfake.Clientset).Actions() {
+				if deleteAction, ok := action.(k8stesting.DeleteAction); ok && action.GetResource().Resource == "pods" {
+					deleted = append(deleted, deleteAction.GetName())
+				}
+			}
+			sort.Strings(deleted)
+			if len(deleted) != len(tt.wantDeleted) {
+				t.Fatalf("expected %v to be preempted, got %v", tt.wantDeleted, deleted)
+			}
+			for i := range deleted {
+				if deleted[i] != tt.wantDeleted[i] {
+					t.Errorf("expected %v to be preempted, got %v", tt.wantDeleted, deleted)
+				}
+			}
+		})
+	}
+}
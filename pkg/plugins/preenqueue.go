@@ -0,0 +1,77 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+var _ framework.PreEnqueuePlugin = &CustomScheduler{}
+
+// PreEnqueue keeps the members of a group with fewer than minAvailable pods
+// created out of the active queue, so that they don't cost a scheduling
+// attempt each until the group is complete. They wait among the
+// unschedulable pods until the member completing the group activates them in
+// PreFilter, or an event registered in EventsToRegister moves them back, when
+// PreEnqueue runs again.
+//
+// PreEnqueue runs on every queue insertion, so it only counts the pods in
+// the group index and leaves everything else to PreFilter: pods are admitted
+// when the index is missing or not synced yet, when minAvailable is invalid,
+// when conflicting values may resolve to a lower one, when the group timed
+// out or when a best-effort group stalled.
+func (cs *CustomScheduler) PreEnqueue(ctx context.Context, pod *v1.Pod) *framework.Status {
+	if cs.podIndexer == nil || cs.conflictPolicy == conflictMin || !cs.podCacheSynced() {
+		return nil
+	}
+	group, minAvailable, isGang, err := cs.groupManager().requirement(pod)
+	if !isGang || err != nil {
+		return nil
+	}
+	if cs.gangTimedOut(cs.groupKey(pod.Namespace, group)) {
+		return nil
+	}
+	pods, err := cs.indexedGroupPods(pod.Namespace, group)
+	if err != nil {
+		return nil
+	}
+	created := countActivePods(sameSchedulerPods(pod, withoutIgnoredPods(pods)))
+	if created >= minAvailable || cs.bestEffortReleased(pod, group) {
+		return nil
+	}
+	klog.FromContext(ctx).V(4).Info("Keeping the pod out of the active queue until its group is complete", "pod", klog.KObj(pod), "group", group, "created", created, "minAvailable", minAvailable)
+	return framework.NewStatus(framework.Unschedulable, fmt.Sprintf("group '%s' has only %d pods, but needs %d", group, created, minAvailable))
+}
+
+// activateSiblings asks the scheduler to move the unscheduled members of the
+// pod's group back to the active queue once the group is complete. PreEnqueue
+// kept them among the unschedulable pods, and the scheduler only moves those
+// on events of assigned pods, so without it the members created before the
+// last one would wait for the periodic flush of the unschedulable pods. The
+// pods are activated at the end of the pod's scheduling cycle.
+func (cs *CustomScheduler) activateSiblings(state *framework.CycleState, pod *v1.Pod, pods []*v1.Pod) {
+	if state == nil || cs.podIndexer == nil || cs.conflictPolicy == conflictMin {
+		return
+	}
+	c, err := state.Read(framework.PodsToActivateKey)
+	if err != nil {
+		return
+	}
+	podsToActivate, ok := c.(*framework.PodsToActivate)
+	if !ok {
+		return
+	}
+	podsToActivate.Lock()
+	defer podsToActivate.Unlock()
+	for _, p := range pods {
+		// pods waiting at Permit are already past the queue, and pods with
+		// scheduling gates stay out of it anyway
+		if p.UID == pod.UID || p.Spec.NodeName != "" || !isActivePod(p) || len(p.Spec.SchedulingGates) > 0 || cs.handle.GetWaitingPod(p.UID) != nil {
+			continue
+		}
+		podsToActivate.Map[podKey(p)] = p
+	}
+}
@@ -0,0 +1,199 @@
+package plugins
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/kubernetes/pkg/scheduler"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/apis/config"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/defaultbinder"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/queuesort"
+	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+)
+
+func TestCustomScheduler_PreEnqueue(t *testing.T) {
+	tests := []struct {
+		name           string
+		pods           []*v1.Pod
+		pod            *v1.Pod
+		conflictPolicy string
+		noIndex        bool
+		want           framework.Code
+	}{
+		{
+			name: "pod outside of a group",
+			pod:  &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod"}},
+			want: framework.Success,
+		},
+		{
+			name: "complete group",
+			pods: []*v1.Pod{makeGangPod("pod1", "g1", 2)},
+			pod:  makeGangPod("pod2", "g1", 2),
+			want: framework.Success,
+		},
+		{
+			name: "incomplete group",
+			pods: []*v1.Pod{makeGangPod("pod1", "g1", 3)},
+			pod:  makeGangPod("pod2", "g1", 3),
+			want: framework.Unschedulable,
+		},
+		{
+			name: "finished members don't count",
+			pods: []*v1.Pod{func() *v1.Pod {
+				p := makeGangPod("pod1", "g1", 2)
+				p.Status.Phase = v1.PodSucceeded
+				return p
+			}()},
+			pod:  makeGangPod("pod2", "g1", 2),
+			want: framework.Unschedulable,
+		},
+		{
+			name: "invalid minAvailable is left to PreFilter",
+			pod:  makeGangPod("pod1", "g1", 0),
+			want: framework.Success,
+		},
+		{
+			name:           "conflicting values may resolve lower",
+			pods:           []*v1.Pod{makeGangPod("pod1", "g1", 1)},
+			pod:            makeGangPod("pod2", "g1", 3),
+			conflictPolicy: conflictMin,
+			want:           framework.Success,
+		},
+		{
+			name:    "without the group index",
+			pod:     makeGangPod("pod1", "g1", 3),
+			noIndex: true,
+			want:    framework.Success,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := newTestFrameworkWithPods(t, nil)
+			cs := &CustomScheduler{
+				handle:               fh,
+				groupLabelKey:        groupNameLabel,
+				minAvailableLabelKey: minAvailableLabel,
+				conflictPolicy:       tt.conflictPolicy,
+			}
+			informer := fh.SharedInformerFactory().Core().V1().Pods().Informer()
+			if !tt.noIndex {
+				if err := cs.addGroupIndexer(informer); err != nil {
+					t.Fatalf("fail to add the group index: %v", err)
+				}
+			}
+			for _, p := range append(tt.pods, tt.pod) {
+				informer.GetStore().Add(p)
+			}
+			if got := cs.PreEnqueue(context.Background(), tt.pod); got.Code() != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_PreEnqueue_MemberAdded(t *testing.T) {
+	client := clientsetfake.NewSimpleClientset()
+	var mu sync.Mutex
+	bound := sets.New[string]()
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "binding" {
+			return false, nil, nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		bound.Insert(action.(k8stesting.CreateAction).GetObject().(*v1.Binding).Name)
+		return true, nil, nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sched, informerFactory := newTestScheduler(ctx, t, client, &schedulerapi.Plugins{
+		PreEnqueue: schedulerapi.PluginSet{Enabled: []schedulerapi.Plugin{{Name: Name}}},
+		PreFilter:  schedulerapi.PluginSet{Enabled: []schedulerapi.Plugin{{Name: Name}}},
+		Permit:     schedulerapi.PluginSet{Enabled: []schedulerapi.Plugin{{Name: Name}}},
+	}, New)
+	informerFactory.Start(ctx.Done())
+	informerFactory.WaitForCacheSync(ctx.Done())
+	go sched.Run(ctx)
+
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Status: v1.NodeStatus{Allocatable: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse("4"),
+			v1.ResourceMemory: resource.MustParse("4Gi"),
+			v1.ResourcePods:   resource.MustParse("10"),
+		}},
+	}
+	if _, err := client.CoreV1().Nodes().Create(ctx, node, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	create := func(name string) {
+		t.Helper()
+		pod := makeGangPod(name, "g1", 3)
+		pod.Namespace = "default"
+		pod.Spec.SchedulerName = v1.DefaultSchedulerName
+		if _, err := client.CoreV1().Pods(pod.Namespace).Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// the first members are kept out of the active queue
+	create("pod1")
+	create("pod2")
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		_, summary := sched.SchedulingQueue.PendingPods()
+		return strings.Contains(summary, "unschedulablePods:2"), nil
+	}); err != nil {
+		_, summary := sched.SchedulingQueue.PendingPods()
+		t.Fatalf("expected the incomplete group to wait among the unschedulable pods, got %s", summary)
+	}
+
+	// the last member brings them back, and the whole group binds
+	create("pod3")
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return bound.Len() == 3, nil
+	}); err != nil {
+		mu.Lock()
+		defer mu.Unlock()
+		t.Errorf("expected the whole group to be bound, got %v", sets.List(bound))
+	}
+}
+
+// newTestScheduler creates a scheduler whose default profile runs the given
+// extension points of the plugin created by factory, with `{"mode": "Least"}`
+// as its args. The informers and the scheduler aren't started.
+func newTestScheduler(ctx context.Context, t *testing.T, client *clientsetfake.Clientset, plugins *schedulerapi.Plugins, factory frameworkruntime.PluginFactory) (*scheduler.Scheduler, informers.SharedInformerFactory) {
+	t.Helper()
+	plugins.QueueSort = schedulerapi.PluginSet{Enabled: []schedulerapi.Plugin{{Name: queuesort.Name}}}
+	plugins.Bind = schedulerapi.PluginSet{Enabled: []schedulerapi.Plugin{{Name: defaultbinder.Name}}}
+	profile := schedulerapi.KubeSchedulerProfile{
+		SchedulerName: v1.DefaultSchedulerName,
+		Plugins:       plugins,
+		PluginConfig:  []schedulerapi.PluginConfig{{Name: Name, Args: &runtime.Unknown{Raw: []byte(`{"mode": "Least"}`)}}},
+	}
+	informerFactory := informers.NewSharedInformerFactory(client, 0)
+	sched, err := scheduler.New(client, informerFactory, nil,
+		func(string) events.EventRecorder { return &events.FakeRecorder{} },
+		ctx.Done(),
+		scheduler.WithProfiles(profile),
+		scheduler.WithFrameworkOutOfTreeRegistry(frameworkruntime.Registry{Name: factory}))
+	if err != nil {
+		t.Fatalf("fail to create the scheduler: %v", err)
+	}
+	return sched, informerFactory
+}
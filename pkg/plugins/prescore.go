@@ -0,0 +1,192 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+var _ framework.PreScorePlugin = &CustomScheduler{}
+
+const preScoreStateKey = framework.StateKey("PreScore" + Name)
+
+// scoringAnnotation opts a pod out of the plugin's scoring when set to
+// disabledValue.
+const scoringAnnotation = "scheduler.nthu.io/scoring"
+
+// scoreSkippedStateKey records that PreScore skipped Score and NormalizeScore
+// for the pod.
+const scoreSkippedStateKey = framework.StateKey("ScoreSkipped" + Name)
+
+// scoreSkippedState is written by PreScore when the pod isn't scored.
+type scoreSkippedState struct{}
+
+// Clone implements framework.StateData.
+func (s scoreSkippedState) Clone() framework.StateData {
+	return s
+}
+
+// scoreSkipped reports whether PreScore skipped scoring the pod.
+func scoreSkipped(state *framework.CycleState) bool {
+	if state == nil {
+		return false
+	}
+	_, err := state.Read(scoreSkippedStateKey)
+	return err == nil
+}
+
+// preScoreState is what Score needs about the pod being scheduled, computed
+// once per scheduling cycle.
+type preScoreState struct {
+	// mode is the score mode of the pod.
+	mode string
+	// requests are the effective requests of the pod.
+	requests v1.ResourceList
+	// memberNodes counts the members of the pod's group on each node of the
+	// snapshot, including assumed ones. It is nil for pods outside of a group.
+	memberNodes map[string]int
+	// nodeWeights are the score weights of the nodes labeled with one other
+	// than 100. They are only known when PreScore ran.
+	nodeWeights map[string]int64
+	// nodePenalties are subtracted from the scores of nodes under pressure.
+	nodePenalties map[string]int64
+	// nodeBonuses are added to the scores of the nodes in a tier with a
+	// bonus.
+	nodeBonuses map[string]int64
+	// nodeImageBytes are the bytes of the pod's images present on each node,
+	// and maxImageBytes the most on any node. They are only set in the
+	// Composite mode, when the ImageLocality component weighs something.
+	nodeImageBytes map[string]int64
+	maxImageBytes  int64
+	// nodeResources are what is left on the scored nodes before the pod is
+	// placed. They are only known when PreScore ran.
+	nodeResources map[string]*nodeResources
+	// memoryLimit is the memory limit of the pod, and nodeMemoryLimits sums
+	// the memory limits of the pods on each node. They are only set with the
+	// Limits capacity policy.
+	memoryLimit      int64
+	nodeMemoryLimits map[string]int64
+	// nodeUsage is the memory the nodes with fresh metrics actually use. It
+	// is nil unless actual usage is used.
+	nodeUsage map[string]int64
+	// domainPreferences score how under-represented the domain of each node
+	// is among the members of the pod's group. They are only set for pods
+	// with the minDomains label.
+	domainPreferences map[string]int64
+}
+
+// Clone implements framework.StateData. The state isn't modified after
+// PreScore, so it can be shared.
+func (s *preScoreState) Clone() framework.StateData {
+	return s
+}
+
+// PreScore computes the per-cycle data of Score. With scoring disabled, or
+// for pods annotated with scheduler.nthu.io/scoring: disabled, it skips Score
+// and NormalizeScore, so the framework leaves the plugin out of the pod's
+// scores altogether.
+func (cs *CustomScheduler) PreScore(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodes []*v1.Node) *framework.Status {
+	if cs.scoringDisabled || pod.Annotations[scoringAnnotation] == disabledValue {
+		klog.FromContext(ctx).V(5).Info("Skipping the scores of the pod", "pod", klog.KObj(pod))
+		state.Write(scoreSkippedStateKey, scoreSkippedState{})
+		return framework.NewStatus(framework.Skip)
+	}
+	s, err := cs.newPreScoreState(pod)
+	if err != nil {
+		return framework.NewStatus(framework.Error, fmt.Sprintf("Failed to list nodes: %v", err))
+	}
+	logger := klog.FromContext(ctx)
+	logger.V(4).Info("Computed the score state", "pod", klog.KObj(pod), "mode", s.mode)
+	if mode, ok := pod.Annotations[scoreModeAnnotation]; ok && canonicalScoreMode(mode) != s.mode {
+		logger.V(2).Info("Pod has an unknown score mode", "pod", klog.KObj(pod), "annotation", mode, "mode", s.mode)
+		cs.recordInvalidScoreMode(pod, mode, s.mode)
+	}
+	s.nodeWeights = nodeScoreWeights(nodes)
+	s.nodePenalties = cs.pressurePenalties(nodes)
+	s.nodeBonuses = cs.tierBonuses(nodes)
+	s.nodeResources = cs.nodeResourcesOf(nodes)
+	// only the Composite mode weighs the ImageLocality component
+	if s.mode == compositeMode {
+		if s.nodeImageBytes, s.maxImageBytes, err = cs.imageLocality(pod); err != nil {
+			return framework.NewStatus(framework.Error, fmt.Sprintf("Failed to list nodes: %v", err))
+		}
+	}
+	state.Write(preScoreStateKey, s)
+	if cs.dryRunMode != "" {
+		state.Write(dryRunStateKey, &dryRunState{mode: cs.dryRunMode})
+	}
+	state.Write(ScoreInputsStateKey, cs.newScoreInputs(s, nodes))
+	return nil
+}
+
+// newPreScoreState computes the per-cycle data of Score for the pod.
+func (cs *CustomScheduler) newPreScoreState(pod *v1.Pod) (*preScoreState, error) {
+	s := &preScoreState{mode: cs.podScoreMode(pod), requests: cs.podRequests(pod), nodeUsage: cs.usageSnapshot()}
+	group, inGroup := cs.podGroupName(pod)
+	limits := cs.capacityPolicy == capacityLimits
+	if !inGroup && !limits {
+		return s, nil
+	}
+	nodeInfos, err := cs.nodeInfoLister().List()
+	if err != nil {
+		return nil, err
+	}
+	if inGroup {
+		s.memberNodes = make(map[string]int)
+	}
+	if limits {
+		s.memoryLimit = cs.podMemoryLimit(pod)
+		s.nodeMemoryLimits = make(map[string]int64)
+	}
+	for _, nodeInfo := range nodeInfos {
+		if nodeInfo.Node() == nil {
+			continue
+		}
+		if inGroup {
+			if members := cs.countNodeMembers(pod, group, nodeInfo); members > 0 {
+				s.memberNodes[nodeInfo.Node().Name] = members
+			}
+		}
+		if limits {
+			s.nodeMemoryLimits[nodeInfo.Node().Name] = cs.nodeResources(nodeInfo).memoryLimit
+		}
+	}
+	if inGroup {
+		s.domainPreferences = cs.domainPreferences(pod, nodeInfos)
+	}
+	return s, nil
+}
+
+// getPreScoreState returns the state written by PreScore, computing it when
+// PreScore didn't run.
+func (cs *CustomScheduler) getPreScoreState(state *framework.CycleState, pod *v1.Pod) (*preScoreState, error) {
+	s, err := readPreScoreState(state)
+	if err != nil || s != nil {
+		return s, err
+	}
+	return cs.newPreScoreState(pod)
+}
+
+// readPreScoreState returns the state written by PreScore, or nil if PreScore
+// didn't run.
+func readPreScoreState(state *framework.CycleState) (*preScoreState, error) {
+	if state == nil {
+		return nil, nil
+	}
+	c, err := state.Read(preScoreStateKey)
+	if errors.Is(err, framework.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s, ok := c.(*preScoreState)
+	if !ok {
+		return nil, fmt.Errorf("%+v convert to CustomScheduler.preScoreState error", c)
+	}
+	return s, nil
+}
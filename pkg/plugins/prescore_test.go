@@ -0,0 +1,118 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_PreScore(t *testing.T) {
+	makePod := func(name, nodeName string, phase v1.PodPhase) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"podGroup": "g1"}},
+			Spec:       v1.PodSpec{NodeName: nodeName},
+			Status:     v1.PodStatus{Phase: phase},
+		}
+	}
+	existing := []*v1.Pod{
+		makePod("pod0", "node1", v1.PodRunning),
+		makePod("pod1", "node1", v1.PodRunning),
+		makePod("pod2", "node2", v1.PodRunning),
+		makePod("pending", "", v1.PodPending),
+		makePod("done", "node2", v1.PodSucceeded),
+	}
+	pod := makePod("incoming", "", v1.PodPending)
+	pod.Spec.Containers = []v1.Container{{
+		Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceMemory: resource.MustParse("100Mi")}},
+	}}
+	nodeInfos := []*framework.NodeInfo{makeNodeInfo("node1", 1000, 1<<30), makeNodeInfo("node2", 1000, 2<<30)}
+	for _, p := range existing {
+		switch p.Spec.NodeName {
+		case "node1":
+			nodeInfos[0].AddPod(p)
+		case "node2":
+			nodeInfos[1].AddPod(p)
+		}
+	}
+	cs := &CustomScheduler{
+		handle:        newTestFrameworkWithNodes(t, existing, nodeInfos),
+		scoreMode:     leastMode,
+		groupLabelKey: groupNameLabel,
+	}
+
+	state := framework.NewCycleState()
+	if status := cs.PreScore(context.Background(), state, pod, nil); !status.IsSuccess() {
+		t.Fatalf("unexpected error: %v", status)
+	}
+	s, err := cs.getPreScoreState(state, pod)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := s.requests.Memory().Value(); got != 100<<20 {
+		t.Errorf("expected a memory request of %d, got %d", 100<<20, got)
+	}
+	if want := map[string]int{"node1": 2, "node2": 1}; !reflect.DeepEqual(s.memberNodes, want) {
+		t.Errorf("expected member nodes %v, got %v", want, s.memberNodes)
+	}
+
+	// Score gives the same result when PreScore didn't run
+	for _, nodeInfo := range nodeInfos {
+		withState, status := cs.Score(context.Background(), state, pod, nodeInfo.Node().Name)
+		if !status.IsSuccess() {
+			t.Fatalf("unexpected error: %v", status)
+		}
+		withoutState, status := cs.Score(context.Background(), framework.NewCycleState(), pod, nodeInfo.Node().Name)
+		if !status.IsSuccess() {
+			t.Fatalf("unexpected error: %v", status)
+		}
+		if withState != withoutState {
+			t.Errorf("expected node %s to score %d without PreScore, got %d", nodeInfo.Node().Name, withState, withoutState)
+		}
+	}
+}
+
+func BenchmarkCustomScheduler_Score(b *testing.B) {
+	var nodeInfos []*framework.NodeInfo
+	var pods []*v1.Pod
+	for i := 0; i < 1000; i++ {
+		name := fmt.Sprintf("node%d", i)
+		p := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("pod%d", i), Labels: map[string]string{"podGroup": "g1"}},
+			Spec:       v1.PodSpec{NodeName: name},
+		}
+		nodeInfo := makeNodeInfo(name, 4000, int64(i+1)<<30)
+		nodeInfo.AddPod(p)
+		nodeInfos = append(nodeInfos, nodeInfo)
+		pods = append(pods, p)
+	}
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "incoming", Labels: map[string]string{"podGroup": "g1"}}}
+	cs := &CustomScheduler{
+		handle:        newTestFrameworkWithNodes(b, pods, nodeInfos),
+		scoreMode:     leastMode,
+		groupLabelKey: groupNameLabel,
+	}
+
+	for _, preScore := range []bool{false, true} {
+		b.Run(fmt.Sprintf("preScore=%v", preScore), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				state := framework.NewCycleState()
+				if preScore {
+					if status := cs.PreScore(context.Background(), state, pod, nil); !status.IsSuccess() {
+						b.Fatalf("unexpected error: %v", status)
+					}
+				}
+				for _, nodeInfo := range nodeInfos {
+					if _, status := cs.Score(context.Background(), state, pod, nodeInfo.Node().Name); !status.IsSuccess() {
+						b.Fatalf("unexpected error: %v", status)
+					}
+				}
+			}
+		})
+	}
+}
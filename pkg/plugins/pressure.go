@@ -0,0 +1,42 @@
+package plugins
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// pressurePenalties returns the penalties of the nodes reporting
+// MemoryPressure or DiskPressure. The conditions come from the node objects
+// of the snapshot.
+func (cs *CustomScheduler) pressurePenalties(nodes []*v1.Node) map[string]int64 {
+	var penalties map[string]int64
+	for _, node := range nodes {
+		var penalty int64
+		for _, condition := range node.Status.Conditions {
+			if condition.Status != v1.ConditionTrue {
+				continue
+			}
+			switch condition.Type {
+			case v1.NodeMemoryPressure:
+				penalty += cs.memoryPressurePenalty
+			case v1.NodeDiskPressure:
+				penalty += cs.diskPressurePenalty
+			}
+		}
+		if penalty == 0 {
+			continue
+		}
+		if penalties == nil {
+			penalties = make(map[string]int64)
+		}
+		penalties[node.Name] = penalty
+	}
+	return penalties
+}
+
+// applyNodePenalties subtracts the penalties from the normalized scores.
+func applyNodePenalties(scores framework.NodeScoreList, penalties map[string]int64) {
+	for i := range scores {
+		scores[i].Score = clampScore(scores[i].Score - penalties[scores[i].Name])
+	}
+}
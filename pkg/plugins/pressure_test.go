@@ -0,0 +1,68 @@
+package plugins
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_Score_NodePressure(t *testing.T) {
+	makeNode := func(name string, conditions ...v1.NodeConditionType) *framework.NodeInfo {
+		nodeInfo := makeNodeInfo(name, 1000, 4<<30)
+		for _, condition := range conditions {
+			nodeInfo.Node().Status.Conditions = append(nodeInfo.Node().Status.Conditions, v1.NodeCondition{Type: condition, Status: v1.ConditionTrue})
+		}
+		return nodeInfo
+	}
+	tests := []struct {
+		name      string
+		nodeInfos []*framework.NodeInfo
+		want      map[string]int64
+	}{
+		{
+			name:      "healthy nodes",
+			nodeInfos: []*framework.NodeInfo{makeNode("node1"), makeNode("node2")},
+			want:      map[string]int64{"node1": 100, "node2": 100},
+		},
+		{
+			name:      "memory pressure",
+			nodeInfos: []*framework.NodeInfo{makeNode("node1", v1.NodeMemoryPressure), makeNode("node2")},
+			want:      map[string]int64{"node1": 70, "node2": 100},
+		},
+		{
+			name:      "disk pressure",
+			nodeInfos: []*framework.NodeInfo{makeNode("node1", v1.NodeDiskPressure), makeNode("node2")},
+			want:      map[string]int64{"node1": 90, "node2": 100},
+		},
+		{
+			name:      "both pressures",
+			nodeInfos: []*framework.NodeInfo{makeNode("node1", v1.NodeMemoryPressure, v1.NodeDiskPressure), makeNode("node2")},
+			want:      map[string]int64{"node1": 60, "node2": 100},
+		},
+		{
+			name: "condition that isn't true",
+			nodeInfos: func() []*framework.NodeInfo {
+				node1 := makeNodeInfo("node1", 1000, 4<<30)
+				node1.Node().Status.Conditions = []v1.NodeCondition{{Type: v1.NodeMemoryPressure, Status: v1.ConditionFalse}}
+				return []*framework.NodeInfo{node1, makeNode("node2")}
+			}(),
+			want: map[string]int64{"node1": 100, "node2": 100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:                newTestFrameworkWithNodes(t, nil, tt.nodeInfos),
+				scoreMode:             mostMode,
+				memoryPressurePenalty: 30,
+				diskPressurePenalty:   10,
+			}
+			for _, score := range scoreNodes(t, cs, &v1.Pod{}, tt.nodeInfos) {
+				if score.Score != tt.want[score.Name] {
+					t.Errorf("expected node %s to score %d, got %d", score.Name, tt.want[score.Name], score.Score)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package plugins
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	corev1helpers "k8s.io/component-helpers/scheduling/corev1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// groupContender is a complete group that passed the checks of PreFilter and
+// may compete with other groups for the free resources of the cluster.
+type groupContender struct {
+	namespace    string
+	group        string
+	priority     int32
+	minAvailable int
+}
+
+// registerContender records the pod's group as a contender with the priority
+// of the pod, raising the priority recorded by its other members if needed.
+// PreFilter only registers groups that reached minAvailable and aren't held
+// back, so that a group that can't schedule doesn't hold back the others.
+func (cs *CustomScheduler) registerContender(pod *v1.Pod, group string, minAvailable int) {
+	if !cs.priorityAdmission {
+		return
+	}
+	priority := corev1helpers.PodPriority(pod)
+	cs.updateGroup(cs.groupKey(pod.Namespace, group), func(gs *groupState) {
+		if gs.contender == nil || gs.contender.priority < priority {
+			gs.contender = &groupContender{namespace: pod.Namespace, group: group, priority: priority, minAvailable: minAvailable}
+		}
+	})
+}
+
+// higherPriorityContenders returns the contenders of higher priority than the
+// pod, highest first.
+func (cs *CustomScheduler) higherPriorityContenders(pod *v1.Pod, key string) []groupContender {
+	priority := corev1helpers.PodPriority(pod)
+	cs.mu.Lock()
+	var contenders []groupContender
+	for k, gs := range cs.groups {
+		if k != key && gs.contender != nil && gs.contender.priority > priority {
+			contenders = append(contenders, *gs.contender)
+		}
+	}
+	cs.mu.Unlock()
+	sort.Slice(contenders, func(i, j int) bool {
+		if contenders[i].priority != contenders[j].priority {
+			return contenders[i].priority > contenders[j].priority
+		}
+		return contenders[i].group < contenders[j].group
+	})
+	return contenders
+}
+
+// admitByPriority holds the pod's group back while a group of higher priority
+// waits to be scheduled and the pending members of both groups request more
+// CPU or memory than is free in the whole cluster, so that the groups don't
+// race for the same resources and the higher-priority one is scheduled first.
+// Groups that fit together are never held back. Contenders with no member
+// left to schedule, or left with fewer live members than minAvailable, are
+// forgotten.
+func (cs *CustomScheduler) admitByPriority(pod *v1.Pod, group string, pods []*v1.Pod) *framework.Status {
+	if !cs.priorityAdmission {
+		return nil
+	}
+	key := cs.groupKey(pod.Namespace, group)
+	contenders := cs.higherPriorityContenders(pod, key)
+	if len(contenders) == 0 {
+		return nil
+	}
+	freeMilliCPU, freeMemory, err := cs.freeClusterResources()
+	if err != nil {
+		return framework.NewStatus(framework.Error, fmt.Sprintf("Failed to list nodes: %v", err))
+	}
+	requiredMilliCPU, requiredMemory := unboundRequests(pods)
+	for _, c := range contenders {
+		contenderKey := cs.groupKey(c.namespace, c.group)
+		members, err := cs.groupManager().Members(c.namespace, c.group)
+		if err != nil {
+			klog.ErrorS(err, "Failed to list pods of group", "group", c.group)
+			continue
+		}
+		if !cs.hasUnscheduledMembers(contenderKey, members) || countActivePods(members) < c.minAvailable {
+			klog.V(4).InfoS("Group no longer contends for resources", "namespace", c.namespace, "group", c.group)
+			cs.updateGroup(contenderKey, func(gs *groupState) { gs.contender = nil })
+			continue
+		}
+		milliCPU, memory := unboundRequests(members)
+		if requiredMilliCPU+milliCPU > freeMilliCPU || requiredMemory+memory > freeMemory {
+			klog.V(4).InfoS("Group waits for a group of higher priority", "pod", klog.KObj(pod), "group", group, "blockingGroup", c.group, "priority", c.priority)
+			return framework.NewStatus(framework.Unschedulable, fmt.Sprintf("Pod cannot be scheduled because the group '%s' waits for the higher-priority group '%s' contending for the same resources", group, c.group))
+		}
+	}
+	return nil
+}
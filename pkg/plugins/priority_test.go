@@ -0,0 +1,145 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_PriorityAdmission(t *testing.T) {
+	makeMember := func(name, group string, minAvailable int, priority int32) *v1.Pod {
+		p := makeGangPod(name, group, minAvailable)
+		p.Spec.Priority = &priority
+		p.Spec.Containers = []v1.Container{{
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceMemory: resource.MustParse("3Gi")},
+			},
+		}}
+		return p
+	}
+	tests := []struct {
+		name         string
+		memory       int64
+		highPriority int32
+		highMembers  int
+		want         framework.Code
+		wantMessage  string
+	}{
+		{
+			name:         "groups contend for the node",
+			memory:       8 << 30,
+			highPriority: 100,
+			highMembers:  2,
+			want:         framework.Unschedulable,
+			wantMessage:  "Pod cannot be scheduled because the group 'low' waits for the higher-priority group 'high' contending for the same resources",
+		},
+		{
+			name:         "groups fit together",
+			memory:       16 << 30,
+			highPriority: 100,
+			highMembers:  2,
+			want:         framework.Success,
+		},
+		{
+			name:         "groups of the same priority",
+			memory:       8 << 30,
+			highPriority: 0,
+			highMembers:  2,
+			want:         framework.Success,
+		},
+		{
+			name:         "incomplete group of higher priority",
+			memory:       8 << 30,
+			highPriority: 100,
+			highMembers:  1,
+			want:         framework.Success,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var high []*v1.Pod
+			for i := 0; i < tt.highMembers; i++ {
+				high = append(high, makeMember(fmt.Sprintf("high-pod%d", i), "high", 2, tt.highPriority))
+			}
+			low := []*v1.Pod{makeMember("low-pod0", "low", 2, 0), makeMember("low-pod1", "low", 2, 0)}
+			pods := append(append([]*v1.Pod{}, high...), low...)
+			cs := &CustomScheduler{
+				handle:               newTestFrameworkWithNodes(t, pods, []*framework.NodeInfo{makeNodeInfo("n1", 4000, tt.memory)}),
+				groupLabelKey:        groupNameLabel,
+				minAvailableLabelKey: minAvailableLabel,
+				groups:               make(map[string]*groupState),
+				priorityAdmission:    true,
+			}
+			// only a complete group contends for the resources
+			wantHigh := framework.Success
+			if tt.highMembers < 2 {
+				wantHigh = framework.Unschedulable
+			}
+			if _, status := cs.PreFilter(context.Background(), framework.NewCycleState(), high[0]); status.Code() != wantHigh {
+				t.Fatalf("expected %v for the high-priority group, got %v", wantHigh, status)
+			}
+			_, status := cs.PreFilter(context.Background(), framework.NewCycleState(), low[0])
+			if status.Code() != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, status)
+			}
+			if tt.wantMessage != "" && status.Message() != tt.wantMessage {
+				t.Errorf("expected message %q, got %q", tt.wantMessage, status.Message())
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_PriorityAdmission_HigherPriorityNotBlocked(t *testing.T) {
+	var pods []*v1.Pod
+	for i, group := range []string{"low", "high"} {
+		priority := int32(i * 100)
+		for j := 0; j < 2; j++ {
+			p := makeGangPod(fmt.Sprintf("%s-pod%d", group, j), group, 2)
+			p.Spec.Priority = &priority
+			p.Spec.Containers = []v1.Container{{
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceMemory: resource.MustParse("3Gi")},
+				},
+			}}
+			pods = append(pods, p)
+		}
+	}
+	h := newTestFrameworkWithNodes(t, pods, []*framework.NodeInfo{makeNodeInfo("n1", 4000, 8<<30)})
+	cs := &CustomScheduler{
+		handle:               h,
+		groupLabelKey:        groupNameLabel,
+		minAvailableLabelKey: minAvailableLabel,
+		groups:               make(map[string]*groupState),
+		priorityAdmission:    true,
+	}
+	low, high := pods[0], pods[2]
+
+	// the lower-priority group asking first doesn't hold back the other one
+	if _, status := cs.PreFilter(context.Background(), framework.NewCycleState(), low); !status.IsSuccess() {
+		t.Fatalf("expected the low-priority group to pass on its own, got %v", status)
+	}
+	if _, status := cs.PreFilter(context.Background(), framework.NewCycleState(), high); !status.IsSuccess() {
+		t.Fatalf("expected the high-priority group to pass, got %v", status)
+	}
+	if _, status := cs.PreFilter(context.Background(), framework.NewCycleState(), low); status.Code() != framework.Unschedulable {
+		t.Fatalf("expected the low-priority group to wait, got %v", status)
+	}
+
+	// the contender is forgotten once all of its members are bound
+	store := h.SharedInformerFactory().Core().V1().Pods().Informer().GetStore()
+	for _, p := range pods[2:] {
+		bound := p.DeepCopy()
+		bound.Spec.NodeName = "n1"
+		store.Update(bound)
+	}
+	if _, status := cs.PreFilter(context.Background(), framework.NewCycleState(), low); !status.IsSuccess() {
+		t.Fatalf("expected the low-priority group to pass once the other is scheduled, got %v", status)
+	}
+	if gs := cs.groups[cs.groupKey("", "high")]; gs != nil && gs.contender != nil {
+		t.Error("expected the scheduled group to no longer contend")
+	}
+}
@@ -0,0 +1,79 @@
+package plugins
+
+import (
+	"time"
+
+	corev1helpers "k8s.io/component-helpers/scheduling/corev1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// Less sorts pods by priority, then by the creation time of their group, then
+// by group, so that all members of one gang are scheduled before the next
+// gang instead of interleaving with it. Pods outside of a group use the time
+// they were first queued. The scheduler only accepts one queue sort plugin
+// with the same arguments in all of its profiles, so profiles running the
+// plugin with different arguments sort the queue with PrioritySort instead.
+func (cs *CustomScheduler) Less(pInfo1, pInfo2 *framework.QueuedPodInfo) bool {
+	prio1 := corev1helpers.PodPriority(pInfo1.Pod)
+	prio2 := corev1helpers.PodPriority(pInfo2.Pod)
+	if prio1 != prio2 {
+		return prio1 > prio2
+	}
+
+	time1, key1 := cs.queueSortKey(pInfo1)
+	time2, key2 := cs.queueSortKey(pInfo2)
+	if !time1.Equal(time2) {
+		return time1.Before(time2)
+	}
+	if key1 != key2 {
+		return key1 < key2
+	}
+	return pInfo1.Timestamp.Before(pInfo2.Timestamp)
+}
+
+// queueSortKey returns the time and the group key a pod is sorted by.
+func (cs *CustomScheduler) queueSortKey(pInfo *framework.QueuedPodInfo) (time.Time, string) {
+	pod := pInfo.Pod
+	group, ok := cs.podGroupName(pod)
+	if !ok {
+		if pInfo.InitialAttemptTimestamp.IsZero() {
+			return pInfo.Timestamp, ""
+		}
+		return pInfo.InitialAttemptTimestamp, ""
+	}
+	key := cs.groupKey(pod.Namespace, group)
+	return cs.groupCreationTime(key, pod.Namespace, group, pod.CreationTimestamp.Time), key
+}
+
+// groupCreationTime returns the creation time of the oldest member of the
+// group. It is computed once per group and cached, as Less is called far too
+// often to list the group's pods every time.
+func (cs *CustomScheduler) groupCreationTime(key, namespace, group string, fallback time.Time) time.Time {
+	cs.mu.Lock()
+	if gs := cs.groups[key]; gs != nil && !gs.createdAt.IsZero() {
+		createdAt := gs.createdAt
+		cs.mu.Unlock()
+		return createdAt
+	}
+	cs.mu.Unlock()
+
+	createdAt := fallback
+	pods, err := cs.groupManager().Members(namespace, group)
+	if err != nil {
+		klog.ErrorS(err, "Failed to list pods of group", "group", group)
+		return createdAt
+	}
+	for _, p := range pods {
+		if isActivePod(p) && p.CreationTimestamp.Time.Before(createdAt) {
+			createdAt = p.CreationTimestamp.Time
+		}
+	}
+	cs.updateGroup(key, func(gs *groupState) {
+		if gs.createdAt.IsZero() || createdAt.Before(gs.createdAt) {
+			gs.createdAt = createdAt
+		}
+		createdAt = gs.createdAt
+	})
+	return createdAt
+}
@@ -0,0 +1,87 @@
+package plugins
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/utils/pointer"
+)
+
+func TestCustomScheduler_Less(t *testing.T) {
+	base := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	makePod := func(name, group string, created time.Duration, priority int32) *v1.Pod {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				CreationTimestamp: metav1.NewTime(base.Add(created)),
+			},
+			Spec: v1.PodSpec{Priority: pointer.Int32(priority)},
+		}
+		if group != "" {
+			pod.Labels = map[string]string{"podGroup": group}
+		}
+		return pod
+	}
+	// a0 is the oldest pod, so group a goes first even though b0 was queued
+	// before any of its members
+	a0 := makePod("a0", "a", 0, 0)
+	b0 := makePod("b0", "b", time.Second, 0)
+	a1 := makePod("a1", "a", 3*time.Second, 0)
+	b1 := makePod("b1", "b", 2*time.Second, 0)
+	single := makePod("single", "", 4*time.Second, 0)
+	urgent := makePod("urgent", "b", 5*time.Second, 100)
+
+	tests := []struct {
+		name string
+		// queued are the pods in the order they entered the queue
+		queued []*v1.Pod
+		want   []string
+	}{
+		{
+			name:   "members of a group are not interleaved",
+			queued: []*v1.Pod{b0, a1, b1, a0},
+			want:   []string{"a1", "a0", "b0", "b1"},
+		},
+		{
+			name:   "priority comes first",
+			queued: []*v1.Pod{a0, b0, urgent},
+			want:   []string{"urgent", "a0", "b0"},
+		},
+		{
+			name:   "pods outside of a group use the queue time",
+			queued: []*v1.Pod{b0, single, a0},
+			want:   []string{"a0", "b0", "single"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:        newTestFrameworkWithPods(t, []*v1.Pod{a0, a1, b0, b1, urgent}),
+				groupLabelKey: groupNameLabel,
+			}
+			var pInfos []*framework.QueuedPodInfo
+			for i, pod := range tt.queued {
+				pInfos = append(pInfos, &framework.QueuedPodInfo{
+					PodInfo:   mustNewPodInfo(t, pod),
+					Timestamp: base.Add(time.Duration(i) * time.Minute),
+				})
+			}
+			sort.SliceStable(pInfos, func(i, j int) bool {
+				return cs.Less(pInfos[i], pInfos[j])
+			})
+			var got []string
+			for _, pInfo := range pInfos {
+				got = append(got, pInfo.Pod.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected order %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
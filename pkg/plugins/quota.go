@@ -0,0 +1,101 @@
+package plugins
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// checkResourceQuota compares what the group requests in the pod's namespace
+// with the ResourceQuotas of the namespace. The members still missing from
+// the group are taken to request as much as the pod. A group requesting more
+// than a quota's hard limit can never be scheduled whole and is rejected as
+// unresolvable, and a group whose missing members don't fit in what the quota
+// has left waits for the quota to be freed. Scoped quotas, and quotas not
+// limiting the requests of the group, are ignored.
+func (cs *CustomScheduler) checkResourceQuota(pod *v1.Pod, group string, pods []*v1.Pod, minAvailable int) *framework.Status {
+	if cs.quotaLister == nil {
+		return nil
+	}
+	quotas, err := cs.quotaLister.ResourceQuotas(pod.Namespace).List(labels.Everything())
+	if err != nil {
+		klog.ErrorS(err, "Failed to list the resource quotas, not checking them", "pod", klog.KObj(pod), "group", group)
+		return nil
+	}
+	sort.Slice(quotas, func(i, j int) bool { return quotas[i].Name < quotas[j].Name })
+
+	requested := v1.ResourceList{}
+	members := 0
+	for _, p := range pods {
+		if p.Namespace != pod.Namespace || !isActivePod(p) {
+			continue
+		}
+		members++
+		addResources(requested, PodEffectiveRequests(p))
+	}
+	missing := minAvailable - members
+	if missing < 0 {
+		missing = 0
+	}
+	missingRequests := v1.ResourceList{}
+	podRequests := PodEffectiveRequests(pod)
+	for i := 0; i < missing; i++ {
+		addResources(missingRequests, podRequests)
+	}
+	requested[v1.ResourcePods] = *resource.NewQuantity(int64(members), resource.DecimalSI)
+	missingRequests[v1.ResourcePods] = *resource.NewQuantity(int64(missing), resource.DecimalSI)
+	total := requested.DeepCopy()
+	addResources(total, missingRequests)
+
+	for _, quota := range quotas {
+		if len(quota.Spec.Scopes) > 0 || quota.Spec.ScopeSelector != nil {
+			continue
+		}
+		names := make([]string, 0, len(quota.Spec.Hard))
+		for name := range quota.Spec.Hard {
+			names = append(names, string(name))
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			resourceName, ok := quotaRequestResource(v1.ResourceName(name))
+			if !ok {
+				continue
+			}
+			hard := quota.Spec.Hard[v1.ResourceName(name)]
+			need := total[resourceName]
+			if need.Cmp(hard) > 0 {
+				return framework.NewStatus(framework.UnschedulableAndUnresolvable, fmt.Sprintf("Pod cannot be scheduled because the group '%s' requests %s %s, more than the %s allowed by ResourceQuota %s/%s", group, need.String(), resourceName, hard.String(), quota.Namespace, quota.Name))
+			}
+			missingNeed := missingRequests[resourceName]
+			if missingNeed.IsZero() {
+				continue
+			}
+			left := hard.DeepCopy()
+			left.Sub(quota.Status.Used[v1.ResourceName(name)])
+			if missingNeed.Cmp(left) > 0 {
+				return framework.NewStatus(framework.Unschedulable, fmt.Sprintf("Pod cannot be scheduled because the %d missing members of the group '%s' need %s %s, but ResourceQuota %s/%s has %s left", missing, group, missingNeed.String(), resourceName, quota.Namespace, quota.Name, left.String()))
+			}
+		}
+	}
+	return nil
+}
+
+// quotaRequestResource returns the resource whose requests a quota limits
+// with the named hard limit: requests.cpu and cpu both limit the requested
+// CPU. Limits, object counts and resources pods don't request are ignored.
+func quotaRequestResource(name v1.ResourceName) (v1.ResourceName, bool) {
+	switch name {
+	case v1.ResourcePods, v1.ResourceCPU, v1.ResourceMemory, v1.ResourceEphemeralStorage:
+		return name, true
+	}
+	if resourceName, ok := strings.CutPrefix(string(name), "requests."); ok && resourceName != "storage" {
+		return v1.ResourceName(resourceName), true
+	}
+	return "", false
+}
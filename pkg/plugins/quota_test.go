@@ -0,0 +1,144 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/component-base/metrics/testutil"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_PreFilter_ResourceQuota(t *testing.T) {
+	RegisterMetrics()
+	makeMembers := func(count, minAvailable int) []*v1.Pod {
+		var pods []*v1.Pod
+		for i := 0; i < count; i++ {
+			p := makeGangPod(fmt.Sprintf("pod%d", i), "g1", minAvailable)
+			p.Namespace = "default"
+			p.Spec.Containers = []v1.Container{{
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse("1"),
+						v1.ResourceMemory: resource.MustParse("1Gi"),
+					},
+				},
+			}}
+			pods = append(pods, p)
+		}
+		return pods
+	}
+	makeQuota := func(name string, hard, used v1.ResourceList) *v1.ResourceQuota {
+		return &v1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec:       v1.ResourceQuotaSpec{Hard: hard},
+			Status:     v1.ResourceQuotaStatus{Hard: hard, Used: used},
+		}
+	}
+	scoped := makeQuota("best-effort", v1.ResourceList{v1.ResourcePods: resource.MustParse("1")}, nil)
+	scoped.Spec.Scopes = []v1.ResourceQuotaScope{v1.ResourceQuotaScopeBestEffort}
+	tests := []struct {
+		name        string
+		pods        []*v1.Pod
+		quotas      []*v1.ResourceQuota
+		want        framework.Code
+		wantMessage string
+	}{
+		{
+			name:        "quota below the group's requests",
+			pods:        makeMembers(2, 2),
+			quotas:      []*v1.ResourceQuota{makeQuota("compute", v1.ResourceList{"requests.cpu": resource.MustParse("1")}, nil)},
+			want:        framework.UnschedulableAndUnresolvable,
+			wantMessage: "Pod cannot be scheduled because the group 'g1' requests 2 cpu, more than the 1 allowed by ResourceQuota default/compute",
+		},
+		{
+			name:   "quota at the group's requests",
+			pods:   makeMembers(2, 2),
+			quotas: []*v1.ResourceQuota{makeQuota("compute", v1.ResourceList{"requests.cpu": resource.MustParse("2"), v1.ResourceMemory: resource.MustParse("2Gi")}, v1.ResourceList{"requests.cpu": resource.MustParse("2"), v1.ResourceMemory: resource.MustParse("2Gi")})},
+			want:   framework.Success,
+		},
+		{
+			name:   "quota above the group's requests",
+			pods:   makeMembers(2, 2),
+			quotas: []*v1.ResourceQuota{makeQuota("compute", v1.ResourceList{v1.ResourceCPU: resource.MustParse("8")}, nil)},
+			want:   framework.Success,
+		},
+		{
+			name:        "quota below the requests of the missing members",
+			pods:        makeMembers(2, 4),
+			quotas:      []*v1.ResourceQuota{makeQuota("compute", v1.ResourceList{"requests.memory": resource.MustParse("3Gi")}, nil)},
+			want:        framework.UnschedulableAndUnresolvable,
+			wantMessage: "Pod cannot be scheduled because the group 'g1' requests 4Gi memory, more than the 3Gi allowed by ResourceQuota default/compute",
+		},
+		{
+			name:        "missing members don't fit in what is left",
+			pods:        makeMembers(2, 3),
+			quotas:      []*v1.ResourceQuota{makeQuota("compute", v1.ResourceList{"requests.cpu": resource.MustParse("4")}, v1.ResourceList{"requests.cpu": resource.MustParse("3500m")})},
+			want:        framework.Unschedulable,
+			wantMessage: "Pod cannot be scheduled because the 1 missing members of the group 'g1' need 1 cpu, but ResourceQuota default/compute has 500m left",
+		},
+		{
+			name:        "missing members fit in what is left",
+			pods:        makeMembers(2, 3),
+			quotas:      []*v1.ResourceQuota{makeQuota("compute", v1.ResourceList{"requests.cpu": resource.MustParse("4")}, v1.ResourceList{"requests.cpu": resource.MustParse("3")})},
+			want:        framework.Unschedulable,
+			wantMessage: "Pod cannot be scheduled because the group 'g1' has only 2 pods, but needs 3",
+		},
+		{
+			name:        "pod count quota",
+			pods:        makeMembers(2, 2),
+			quotas:      []*v1.ResourceQuota{makeQuota("count", v1.ResourceList{v1.ResourcePods: resource.MustParse("1")}, nil)},
+			want:        framework.UnschedulableAndUnresolvable,
+			wantMessage: "Pod cannot be scheduled because the group 'g1' requests 2 pods, more than the 1 allowed by ResourceQuota default/count",
+		},
+		{
+			name: "quotas not covering the requests",
+			pods: makeMembers(2, 2),
+			quotas: []*v1.ResourceQuota{
+				makeQuota("limits", v1.ResourceList{"limits.cpu": resource.MustParse("1"), "count/configmaps": resource.MustParse("1")}, nil),
+				scoped,
+			},
+			want: framework.Success,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := newTestFrameworkWithPods(t, tt.pods)
+			quotaInformer := fh.SharedInformerFactory().Core().V1().ResourceQuotas()
+			for _, quota := range tt.quotas {
+				quotaInformer.Informer().GetStore().Add(quota)
+			}
+			cs := &CustomScheduler{
+				handle:               fh,
+				groupLabelKey:        groupNameLabel,
+				minAvailableLabelKey: minAvailableLabel,
+				groups:               make(map[string]*groupState),
+				quotaLister:          quotaInformer.Lister(),
+			}
+			rejections := func() float64 {
+				value, err := testutil.GetCounterMetricValue(preFilterRejections.WithLabelValues(rejectionQuotaExceeded))
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return value
+			}
+			before := rejections()
+			_, status := cs.PreFilter(context.Background(), framework.NewCycleState(), tt.pods[0])
+			if status.Code() != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, status)
+			}
+			// the rejections naming a quota are counted as such
+			wantCounted := strings.Contains(tt.wantMessage, "ResourceQuota")
+			if counted := rejections() > before; counted != wantCounted {
+				t.Errorf("expected the quota rejection to be counted: %v, got %v", wantCounted, counted)
+			}
+			if tt.wantMessage != "" && !strings.HasPrefix(status.Message(), tt.wantMessage) {
+				t.Errorf("expected message %q, got %q", tt.wantMessage, status.Message())
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package plugins
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// randomScore returns a score spread uniformly over the valid range. It is a
+// hash of the seed, the pod and the node rather than a draw from a shared
+// source, so that the scores don't depend on the order Score runs on the
+// nodes in and are reproducible with a fixed seed.
+func (cs *CustomScheduler) randomScore(pod *v1.Pod, nodeName string) int64 {
+	h := fnv.New64a()
+	var seed [8]byte
+	binary.LittleEndian.PutUint64(seed[:], uint64(cs.randomSeed))
+	h.Write(seed[:])
+	h.Write([]byte(string(pod.UID) + "/" + podKey(pod) + "/" + nodeName))
+	return int64(h.Sum64()%uint64(framework.MaxNodeScore-framework.MinNodeScore+1)) + framework.MinNodeScore
+}
+
+// randomSubScorer scores the nodes in the Random mode for the pod.
+type randomSubScorer struct {
+	cs  *CustomScheduler
+	pod *v1.Pod
+}
+
+func (r randomSubScorer) subScore(_ *preScoreState, nodeInfo *framework.NodeInfo) int64 {
+	return r.cs.randomScore(r.pod, nodeInfo.Node().Name)
+}
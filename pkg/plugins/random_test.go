@@ -0,0 +1,62 @@
+package plugins
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_Score_Random(t *testing.T) {
+	var nodeInfos []*framework.NodeInfo
+	for i := 0; i < 20; i++ {
+		nodeInfos = append(nodeInfos, makeNodeInfo(fmt.Sprintf("node%d", i), 4000, 4<<30))
+	}
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default", UID: "uid"}}
+	scoreWithSeed := func(seed int64) map[string]int64 {
+		cs := &CustomScheduler{
+			handle:          newTestFrameworkWithNodes(t, nil, nodeInfos),
+			scoreMode:       randomMode,
+			allowRandomMode: true,
+			randomSeed:      seed,
+		}
+		got := make(map[string]int64)
+		for _, score := range scoreNodes(t, cs, pod, nodeInfos) {
+			if score.Score < framework.MinNodeScore || score.Score > framework.MaxNodeScore {
+				t.Errorf("expected node %s to score within the valid range, got %d", score.Name, score.Score)
+			}
+			got[score.Name] = score.Score
+		}
+		return got
+	}
+
+	first, second := scoreWithSeed(42), scoreWithSeed(42)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("expected the same scores with the same seed, got %v and %v", first, second)
+	}
+	if other := scoreWithSeed(43); reflect.DeepEqual(first, other) {
+		t.Errorf("expected different scores with another seed, got %v", other)
+	}
+	distinct := make(map[int64]bool)
+	for _, score := range first {
+		distinct[score] = true
+	}
+	if len(distinct) < 2 {
+		t.Errorf("expected the nodes to score differently, got %v", first)
+	}
+}
+
+func TestCustomScheduler_PodScoreMode_Random(t *testing.T) {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{scoreModeAnnotation: randomMode}}}
+	cs := &CustomScheduler{scoreMode: leastMode}
+	if mode := cs.podScoreMode(pod); mode != leastMode {
+		t.Errorf("expected the Random mode to be ignored unless allowed, got %s", mode)
+	}
+	cs.allowRandomMode = true
+	if mode := cs.podScoreMode(pod); mode != randomMode {
+		t.Errorf("expected the Random mode once allowed, got %s", mode)
+	}
+}
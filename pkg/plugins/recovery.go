@@ -0,0 +1,26 @@
+package plugins
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// recoverPanic turns a panic in an extension point into an error status
+// carrying the stack, so that a bug in the plugin fails a single scheduling
+// cycle instead of the scheduler. It must be deferred directly, right after
+// the span of the extension point, so that the span records the error:
+//
+//	defer recoverPanic("Score", &status)
+func recoverPanic(phase string, status **framework.Status) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	panics.WithLabelValues(phase).Inc()
+	err := fmt.Errorf("panic in %s: %v\n%s", phase, r, debug.Stack())
+	klog.ErrorS(err, "Recovered from a panic in the plugin", "extensionPoint", phase)
+	*status = framework.AsStatus(err)
+}
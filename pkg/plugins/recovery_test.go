@@ -0,0 +1,115 @@
+package plugins
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics/testutil"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// panickingIndexer panics on every lookup by index.
+type panickingIndexer struct {
+	cache.Indexer
+}
+
+func (panickingIndexer) ByIndex(indexName, indexedValue string) ([]interface{}, error) {
+	panic("index corrupted")
+}
+
+// panickingNodeInfoLister panics on every node lookup.
+type panickingNodeInfoLister struct {
+	framework.NodeInfoLister
+}
+
+func (panickingNodeInfoLister) Get(nodeName string) (*framework.NodeInfo, error) {
+	panic("snapshot corrupted")
+}
+
+type panickingSnapshotHandle struct {
+	framework.Handle
+}
+
+func (h panickingSnapshotHandle) SnapshotSharedLister() framework.SharedLister {
+	return panickingSharedLister{SharedLister: h.Handle.SnapshotSharedLister()}
+}
+
+type panickingSharedLister struct {
+	framework.SharedLister
+}
+
+func (panickingSharedLister) NodeInfos() framework.NodeInfoLister {
+	return panickingNodeInfoLister{}
+}
+
+func TestCustomScheduler_RecoverPanic(t *testing.T) {
+	RegisterMetrics()
+	panicCount := func(phase string) float64 {
+		t.Helper()
+		value, err := testutil.GetCounterMetricValue(panics.WithLabelValues(phase))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return value
+	}
+	fh := newTestFrameworkWithNodes(t, nil, []*framework.NodeInfo{makeNodeInfo("node1", 4000, 4<<30)})
+	cs := &CustomScheduler{
+		handle:               panickingSnapshotHandle{Handle: fh},
+		scoreMode:            leastMode,
+		groupLabelKey:        groupNameLabel,
+		minAvailableLabelKey: minAvailableLabel,
+		podIndexer:           panickingIndexer{},
+	}
+	ctx := context.Background()
+	tests := []struct {
+		phase   string
+		run     func() *framework.Status
+		wantMsg string
+	}{
+		{
+			phase: "PreFilter",
+			run: func() *framework.Status {
+				_, status := cs.PreFilter(ctx, nil, makeGangPod("pod0", "g1", 2))
+				return status
+			},
+			wantMsg: "panic in PreFilter: index corrupted",
+		},
+		{
+			phase: "Score",
+			run: func() *framework.Status {
+				_, status := cs.Score(ctx, framework.NewCycleState(), &v1.Pod{}, "node1")
+				return status
+			},
+			wantMsg: "panic in Score: snapshot corrupted",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.phase, func(t *testing.T) {
+			before := panicCount(tt.phase)
+			status := tt.run()
+			if status.Code() != framework.Error {
+				t.Fatalf("expected %v, got %v", framework.Error, status)
+			}
+			if !strings.HasPrefix(status.Message(), tt.wantMsg) || !strings.Contains(status.Message(), "recovery.go") {
+				t.Errorf("expected the message to start with %q and carry the stack, got %q", tt.wantMsg, status.Message())
+			}
+			if got := panicCount(tt.phase) - before; got != 1 {
+				t.Errorf("expected the panic to be counted once, got %v", got)
+			}
+		})
+	}
+}
+
+func TestRecoverPanic_NoPanic(t *testing.T) {
+	want := framework.NewStatus(framework.Unschedulable, "rejected")
+	got := func() (status *framework.Status) {
+		defer recoverPanic("NormalizeScore", &status)
+		return want
+	}()
+	if got != want {
+		t.Errorf("expected the status to be left alone, got %v", got)
+	}
+}
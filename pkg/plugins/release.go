@@ -0,0 +1,151 @@
+package plugins
+
+import (
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// releaseQueue orders the groups that reached minAvailable at Permit, so that
+// only so many of them bind at once and the members of complete groups don't
+// race each other for the binding cycles. A group is released when it
+// reaches the front of the queue, and keeps releasing until all of its released
+// members are bound or one of them failed. A group releasing for longer than
+// the Permit waiting time is dropped, so that it doesn't hold its slot
+// forever.
+type releaseQueue struct {
+	mu sync.Mutex
+	// queue holds the complete groups waiting to be released, oldest first.
+	queue []queuedGroup
+	// releasing holds the released groups by key.
+	releasing map[string]*releasingGroup
+}
+
+// queuedGroup is a complete group waiting to be released.
+type queuedGroup struct {
+	key       string
+	namespace string
+	group     string
+}
+
+// releasingGroup is a group whose members are allowed through Permit.
+type releasingGroup struct {
+	queuedGroup
+	since time.Time
+	// pods holds the keys of the allowed members not bound yet.
+	pods sets.Set[string]
+}
+
+func newReleaseQueue() *releaseQueue {
+	return &releaseQueue{releasing: make(map[string]*releasingGroup)}
+}
+
+// permitRelease queues the complete group of the pod and releases the groups
+// that are next in line. It reports whether the pod may go on to bind, that
+// is whether its group is releasing.
+func (cs *CustomScheduler) permitRelease(pod *v1.Pod, group string) bool {
+	r := cs.releases
+	key := cs.groupKey(pod.Namespace, group)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.releasing[key]; !ok && !r.queued(key) {
+		r.queue = append(r.queue, queuedGroup{key: key, namespace: pod.Namespace, group: group})
+	}
+	cs.dispatchReleases()
+	g, ok := r.releasing[key]
+	if !ok {
+		return false
+	}
+	g.pods.Insert(podKey(pod))
+	return true
+}
+
+// dispatchReleases releases the queued groups while fewer than
+// MaxConcurrentReleasingGroups are releasing, allowing their waiting members.
+// The caller must hold r.mu.
+func (cs *CustomScheduler) dispatchReleases() {
+	r := cs.releases
+	now := cs.now()
+	for key, g := range r.releasing {
+		if now.Sub(g.since) > cs.permitWaitingTime {
+			klog.V(2).InfoS("Group took too long to bind, releasing the next one", "namespace", g.namespace, "group", g.group)
+			delete(r.releasing, key)
+		}
+	}
+	for len(r.releasing) < cs.maxReleasingGroups && len(r.queue) > 0 {
+		g := &releasingGroup{queuedGroup: r.queue[0], since: now, pods: sets.New[string]()}
+		r.queue = r.queue[1:]
+		r.releasing[g.key] = g
+		cs.updateGroup(g.key, func(gs *groupState) {
+			gs.deadline = time.Time{}
+			gs.firstSeen = time.Time{}
+		})
+		klog.V(3).InfoS("Releasing the waiting pods of the group", "namespace", g.namespace, "group", g.group, "queued", len(r.queue))
+		cs.handle.IterateOverWaitingPods(func(wp framework.WaitingPod) {
+			if cs.inGroup(wp.GetPod(), g.namespace, g.group) {
+				g.pods.Insert(podKey(wp.GetPod()))
+				wp.Allow(cs.Name())
+			}
+		})
+	}
+}
+
+// queued reports whether the group is waiting to be released. The caller
+// must hold r.mu.
+func (r *releaseQueue) queued(key string) bool {
+	for _, g := range r.queue {
+		if g.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// finishRelease records the pod as bound. Once all of the released members of
+// its group are bound, the next group is released.
+func (cs *CustomScheduler) finishRelease(pod *v1.Pod, group string) {
+	r := cs.releases
+	if r == nil {
+		return
+	}
+	key := cs.groupKey(pod.Namespace, group)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g, ok := r.releasing[key]
+	if !ok {
+		return
+	}
+	g.pods.Delete(podKey(pod))
+	if g.pods.Len() == 0 {
+		delete(r.releasing, key)
+		cs.dispatchReleases()
+	}
+}
+
+// abortRelease drops the group of a pod that failed, whether it was releasing
+// or waiting to be released, and releases the next group, so that a group
+// timing out doesn't hold back the ones after it.
+func (cs *CustomScheduler) abortRelease(pod *v1.Pod, group string) {
+	r := cs.releases
+	if r == nil {
+		return
+	}
+	key := cs.groupKey(pod.Namespace, group)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.releasing, key)
+	for i, g := range r.queue {
+		if g.key == key {
+			r.queue = append(r.queue[:i:i], r.queue[i+1:]...)
+			break
+		}
+	}
+	cs.dispatchReleases()
+}
@@ -0,0 +1,143 @@
+package plugins
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// newReleasingScheduler returns a gang scheduler releasing one complete group
+// at a time.
+func newReleasingScheduler(waitingTime time.Duration) *CustomScheduler {
+	cs := newGangScheduler(waitingTime)
+	cs.maxReleasingGroups = 1
+	cs.releases = newReleaseQueue()
+	return cs
+}
+
+// waitOnPermit waits on Permit for the pod in the background.
+func waitOnPermit(fwk framework.Framework, pod *v1.Pod) <-chan *framework.Status {
+	ch := make(chan *framework.Status, 1)
+	go func() { ch <- fwk.WaitOnPermit(context.Background(), pod) }()
+	return ch
+}
+
+func expectPermitted(t *testing.T, pod *v1.Pod, ch <-chan *framework.Status) {
+	t.Helper()
+	select {
+	case status := <-ch:
+		if !status.IsSuccess() {
+			t.Errorf("pod %s: expected to be allowed, got %v", pod.Name, status)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("pod %s: expected to be allowed, still waiting", pod.Name)
+	}
+}
+
+func expectHeld(t *testing.T, pod *v1.Pod, ch <-chan *framework.Status) {
+	t.Helper()
+	select {
+	case status := <-ch:
+		t.Errorf("pod %s: expected to keep waiting, got %v", pod.Name, status)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func runPermit(t *testing.T, fwk framework.Framework, pod *v1.Pod, want framework.Code) {
+	t.Helper()
+	if status := fwk.RunPermitPlugins(context.Background(), framework.NewCycleState(), pod, "node1"); status.Code() != want {
+		t.Fatalf("pod %s: expected %v at Permit, got %v", pod.Name, want, status)
+	}
+}
+
+func TestCustomScheduler_Permit_ReleaseOrder(t *testing.T) {
+	cs := newReleasingScheduler(10 * time.Second)
+	fwk := newPermitTestFramework(t, cs)
+	a0, a1 := makeGangPod("a0", "g1", 2), makeGangPod("a1", "g1", 2)
+	b0, b1 := makeGangPod("b0", "g2", 2), makeGangPod("b1", "g2", 2)
+
+	// both groups wait for their members at the same time, and g1 completes
+	// first
+	runPermit(t, fwk, a0, framework.Wait)
+	runPermit(t, fwk, b0, framework.Wait)
+	runPermit(t, fwk, a1, framework.Success)
+	runPermit(t, fwk, b1, framework.Wait)
+	a0Status := waitOnPermit(fwk, a0)
+	b0Status, b1Status := waitOnPermit(fwk, b0), waitOnPermit(fwk, b1)
+	expectPermitted(t, a0, a0Status)
+	expectHeld(t, b0, b0Status)
+
+	// g2 is released once all of g1 is bound
+	cs.PostBind(context.Background(), nil, a0, "node1")
+	expectHeld(t, b1, b1Status)
+	cs.PostBind(context.Background(), nil, a1, "node1")
+	expectPermitted(t, b0, b0Status)
+	expectPermitted(t, b1, b1Status)
+
+	// a later member of a released group isn't held
+	for _, pod := range []*v1.Pod{b0, b1} {
+		if status := fwk.RunReservePluginsReserve(context.Background(), framework.NewCycleState(), pod, "node1"); !status.IsSuccess() {
+			t.Fatalf("pod %s: unexpected status at Reserve: %v", pod.Name, status)
+		}
+	}
+	runPermit(t, fwk, makeGangPod("b2", "g2", 2), framework.Success)
+}
+
+func TestCustomScheduler_Permit_ReleaseFailure(t *testing.T) {
+	cs := newReleasingScheduler(10 * time.Second)
+	fwk := newPermitTestFramework(t, cs)
+	a0, a1 := makeGangPod("a0", "g1", 2), makeGangPod("a1", "g1", 2)
+	b0, b1 := makeGangPod("b0", "g2", 2), makeGangPod("b1", "g2", 2)
+
+	runPermit(t, fwk, a0, framework.Wait)
+	runPermit(t, fwk, a1, framework.Success)
+	runPermit(t, fwk, b0, framework.Wait)
+	runPermit(t, fwk, b1, framework.Wait)
+	expectPermitted(t, a0, waitOnPermit(fwk, a0))
+
+	// a released member failing to bind frees the slot of its group
+	b0Status := waitOnPermit(fwk, b0)
+	expectHeld(t, b0, b0Status)
+	fwk.RunReservePluginsUnreserve(context.Background(), framework.NewCycleState(), a1, "node1")
+	expectPermitted(t, b0, b0Status)
+}
+
+func TestCustomScheduler_Permit_ReleaseTimeout(t *testing.T) {
+	cs := newReleasingScheduler(time.Second)
+	fwk := newPermitTestFramework(t, cs)
+	a0, a1 := makeGangPod("a0", "g1", 2), makeGangPod("a1", "g1", 2)
+	b0, b1 := makeGangPod("b0", "g2", 2), makeGangPod("b1", "g2", 2)
+	c0, c1 := makeGangPod("c0", "g3", 2), makeGangPod("c1", "g3", 2)
+
+	runPermit(t, fwk, a0, framework.Wait)
+	runPermit(t, fwk, a1, framework.Success)
+	expectPermitted(t, a0, waitOnPermit(fwk, a0))
+
+	// g2 and g3 complete while g1 is binding, g3 half a timeout after g2
+	runPermit(t, fwk, b0, framework.Wait)
+	runPermit(t, fwk, b1, framework.Wait)
+	b0Status := waitOnPermit(fwk, b0)
+	time.Sleep(500 * time.Millisecond)
+	runPermit(t, fwk, c0, framework.Wait)
+	runPermit(t, fwk, c1, framework.Wait)
+	c0Status := waitOnPermit(fwk, c0)
+
+	// g2 times out in the queue, and the scheduler unreserves its members
+	select {
+	case status := <-b0Status:
+		if status.Code() != framework.Unschedulable {
+			t.Fatalf("expected g2 to time out, got %v", status)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected g2 to time out")
+	}
+	fwk.RunReservePluginsUnreserve(context.Background(), framework.NewCycleState(), b0, "node1")
+
+	// g3 goes next, before its own deadline
+	cs.PostBind(context.Background(), nil, a0, "node1")
+	cs.PostBind(context.Background(), nil, a1, "node1")
+	expectPermitted(t, c0, c0Status)
+}
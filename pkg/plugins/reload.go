@@ -0,0 +1,137 @@
+package plugins
+
+import (
+	"fmt"
+	"reflect"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// defaultConfigMapKey is the data key of the ConfigMap holding the arguments
+// when ConfigMapRef doesn't name one.
+const defaultConfigMapKey = "args"
+
+// ConfigMapRef names the ConfigMap the arguments are reloaded from.
+type ConfigMapRef struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Key is the data key holding the arguments, as JSON or YAML. It
+	// defaults to "args".
+	Key string `json:"key"`
+}
+
+// reloadableConfig is the part of the arguments that can change while the
+// scheduler runs. The other arguments need a restart.
+type reloadableConfig struct {
+	scoreMode                 string
+	resources                 []ResourceSpec
+	minAvailableLabelKey      string
+	minAvailableAnnotationKey string
+	maxAvailableLabelKey      string
+}
+
+// config returns the reloadable configuration. It is read as a whole so that
+// a caller never sees half of an update.
+func (cs *CustomScheduler) config() reloadableConfig {
+	cs.configMu.RLock()
+	defer cs.configMu.RUnlock()
+	return reloadableConfig{
+		scoreMode:                 cs.scoreMode,
+		resources:                 cs.resources,
+		minAvailableLabelKey:      cs.minAvailableLabelKey,
+		minAvailableAnnotationKey: cs.minAvailableAnnotationKey,
+		maxAvailableLabelKey:      cs.maxAvailableLabelKey,
+	}
+}
+
+// setConfig replaces the reloadable configuration.
+func (cs *CustomScheduler) setConfig(c reloadableConfig) {
+	cs.configMu.Lock()
+	defer cs.configMu.Unlock()
+	cs.scoreMode = c.scoreMode
+	cs.resources = c.resources
+	cs.minAvailableLabelKey = c.minAvailableLabelKey
+	cs.minAvailableAnnotationKey = c.minAvailableAnnotationKey
+	cs.maxAvailableLabelKey = c.maxAvailableLabelKey
+}
+
+// setupConfigReload watches the ConfigMap the arguments are reloaded from,
+// until the plugin is closed. Its current content is applied once the
+// informer lists it.
+func (cs *CustomScheduler) setupConfigReload(h framework.Handle, args *CustomSchedulerArgs) {
+	ref := args.ConfigMapRef
+	factory := informers.NewSharedInformerFactoryWithOptions(h.ClientSet(), 0,
+		informers.WithNamespace(ref.Namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", ref.Name).String()
+		}))
+	factory.Core().V1().ConfigMaps().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if cm, ok := obj.(*v1.ConfigMap); ok {
+				cs.applyConfigMap(cm)
+			}
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			if cm, ok := obj.(*v1.ConfigMap); ok {
+				cs.applyConfigMap(cm)
+			}
+		},
+	})
+	factory.Start(cs.stopCh)
+}
+
+// applyConfigMap reloads the arguments from the ConfigMap. Updates that are
+// invalid or change arguments needing a restart are logged and ignored, and
+// the running configuration is kept.
+func (cs *CustomScheduler) applyConfigMap(cm *v1.ConfigMap) {
+	key := cs.args.ConfigMapRef.Key
+	data, ok := cm.Data[key]
+	if !ok {
+		klog.InfoS("ConfigMap has no args, keeping the current args", "configMap", klog.KObj(cm), "key", key)
+		return
+	}
+	if err := cs.reloadArgs([]byte(data)); err != nil {
+		klog.ErrorS(err, "Rejected the args of the ConfigMap", "configMap", klog.KObj(cm))
+		return
+	}
+	klog.InfoS("Reloaded the args of the ConfigMap", "configMap", klog.KObj(cm), "mode", cs.config().scoreMode)
+	cs.publishEffectiveConfig("reloaded")
+}
+
+// reloadArgs validates the raw arguments and swaps in their reloadable part.
+func (cs *CustomScheduler) reloadArgs(raw []byte) error {
+	args, err := decodeArgs(&runtime.Unknown{Raw: raw})
+	if err != nil {
+		return err
+	}
+	if err := ValidateCustomSchedulerArgs(args); err != nil {
+		return err
+	}
+	// everything but the reloadable part has to match the running args
+	fixed := args.DeepCopy()
+	fixed.TypeMeta = cs.args.TypeMeta
+	fixed.Mode = cs.args.Mode
+	fixed.Resources = cs.args.Resources
+	fixed.MinAvailableLabelKey = cs.args.MinAvailableLabelKey
+	fixed.MinAvailableAnnotationKey = cs.args.MinAvailableAnnotationKey
+	fixed.MaxAvailableLabelKey = cs.args.MaxAvailableLabelKey
+	fixed.ConfigMapRef = cs.args.ConfigMapRef
+	if !reflect.DeepEqual(fixed, cs.args) {
+		return fmt.Errorf("only mode, resources and the minAvailable and maxAvailable keys can be reloaded, the other args need a restart")
+	}
+	cs.setConfig(reloadableConfig{
+		scoreMode:                 args.Mode,
+		resources:                 args.Resources,
+		minAvailableLabelKey:      args.MinAvailableLabelKey,
+		minAvailableAnnotationKey: args.MinAvailableAnnotationKey,
+		maxAvailableLabelKey:      args.MaxAvailableLabelKey,
+	})
+	return nil
+}
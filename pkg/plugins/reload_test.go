@@ -0,0 +1,155 @@
+package plugins
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_ReloadConfigMap(t *testing.T) {
+	nodeInfos := []*framework.NodeInfo{
+		makeNodeInfo("small", 4000, 1<<30),
+		makeNodeInfo("large", 4000, 4<<30),
+	}
+	h := newTestFrameworkWithNodes(t, nil, nodeInfos)
+	p, err := New(&CustomSchedulerArgs{Mode: leastMode, ConfigMapRef: &ConfigMapRef{Namespace: "kube-system", Name: "custom-scheduler"}}, h)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cs := p.(*CustomScheduler)
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod"}}
+	bestNode := func() string {
+		scores := scoreNodes(t, cs, pod, nodeInfos)
+		best := scores[0]
+		for _, score := range scores {
+			if score.Score > best.Score {
+				best = score
+			}
+		}
+		return best.Name
+	}
+	if best := bestNode(); best != "small" {
+		t.Fatalf("expected node small to score best before the reload, got %s", best)
+	}
+
+	ctx := context.Background()
+	configMaps := h.ClientSet().CoreV1().ConfigMaps("kube-system")
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "custom-scheduler"},
+		Data:       map[string]string{"args": "mode: most\n"},
+	}
+	if _, err := configMaps.Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitForMode(t, cs, mostMode)
+	if best := bestNode(); best != "large" {
+		t.Errorf("expected node large to score best after the reload, got %s", best)
+	}
+
+	// invalid updates and updates of args needing a restart are ignored
+	for _, data := range []string{"mode: Fastest\n", "mode: Least\ngroupLabelKey: team\n"} {
+		cm.Data["args"] = data
+		if _, err := configMaps.Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	cm.Data["args"] = "mode: Balanced\n"
+	if _, err := configMaps.Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitForMode(t, cs, balancedMode)
+
+	// the ConfigMap isn't watched anymore once the plugin is closed
+	cs.Close()
+	time.Sleep(100 * time.Millisecond)
+	cm.Data["args"] = "mode: Most\n"
+	if _, err := configMaps.Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if mode := cs.config().scoreMode; mode != balancedMode {
+		t.Errorf("expected the mode to stay %s after Close, got %s", balancedMode, mode)
+	}
+}
+
+// waitForMode waits for the informer to reload the mode.
+func waitForMode(t *testing.T, cs *CustomScheduler, mode string) {
+	t.Helper()
+	err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return cs.config().scoreMode == mode, nil
+	})
+	if err != nil {
+		t.Fatalf("expected the mode to be reloaded to %s, got %s", mode, cs.config().scoreMode)
+	}
+}
+
+func TestCustomScheduler_ReloadArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    reloadableConfig
+		wantErr bool
+	}{
+		{
+			name: "mode, resources and keys",
+			raw:  `{"mode": "Weighted", "resources": [{"name": "cpu", "weight": 1}], "minAvailableLabelKey": "min", "minAvailableAnnotationKey": "example.com/min", "maxAvailableLabelKey": "max"}`,
+			want: reloadableConfig{
+				scoreMode:                 weightedMode,
+				resources:                 []ResourceSpec{{Name: "cpu", Weight: 1}},
+				minAvailableLabelKey:      "min",
+				minAvailableAnnotationKey: "example.com/min",
+				maxAvailableLabelKey:      "max",
+			},
+		},
+		{
+			name:    "invalid args",
+			raw:     `{"mode": "Most", "resources": [{"name": "cpu", "weight": 0}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "misspelled field",
+			raw:     `{"moed": "Most"}`,
+			wantErr: true,
+		},
+		{
+			name:    "arg needing a restart",
+			raw:     `{"mode": "Most", "permitWaitingTimeSeconds": 5}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := &CustomSchedulerArgs{ConfigMapRef: &ConfigMapRef{Namespace: "kube-system", Name: "custom-scheduler"}}
+			p, err := New(args, newTestFrameworkWithPods(t, nil))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			cs := p.(*CustomScheduler)
+			before := cs.config()
+			err = cs.reloadArgs([]byte(tt.raw))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				if got := cs.config(); got.scoreMode != before.scoreMode || got.minAvailableLabelKey != before.minAvailableLabelKey {
+					t.Errorf("expected the config to be kept, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := cs.config()
+			if got.scoreMode != tt.want.scoreMode || len(got.resources) != 1 || got.resources[0] != tt.want.resources[0] ||
+				got.minAvailableLabelKey != tt.want.minAvailableLabelKey || got.minAvailableAnnotationKey != tt.want.minAvailableAnnotationKey ||
+				got.maxAvailableLabelKey != tt.want.maxAvailableLabelKey {
+				t.Errorf("expected config %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package plugins
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+// PodEffectiveRequests returns the resources the pod needs on a node: the
+// larger of the sum of its containers' requests and the requests of each init
+// container, since init containers run one at a time before the containers,
+// plus the pod overhead. A pod without requests needs an empty list.
+func PodEffectiveRequests(pod *v1.Pod) v1.ResourceList {
+	return podEffectiveResources(pod, func(container *v1.Container) v1.ResourceList {
+		return container.Resources.Requests
+	})
+}
+
+// podEffectiveLimits returns the limits of the pod the way
+// PodEffectiveRequests returns its requests. A container without a limit on a
+// resource counts with its request. Containers being resized in place count
+// with what the node holds for them, as podAllocatedRequests does.
+func podEffectiveLimits(pod *v1.Pod) v1.ResourceList {
+	statuses := containerStatuses(pod)
+	return podEffectiveResources(pod, func(container *v1.Container) v1.ResourceList {
+		limits := containerRequests(pod, container, statuses).DeepCopy()
+		if limits == nil {
+			limits = v1.ResourceList{}
+		}
+		for name, quantity := range containerLimits(pod, container, statuses) {
+			limits[name] = quantity
+		}
+		return limits
+	})
+}
+
+// podEffectiveResources combines the resources of the pod's containers into
+// those of the pod.
+func podEffectiveResources(pod *v1.Pod, resources func(container *v1.Container) v1.ResourceList) v1.ResourceList {
+	list := v1.ResourceList{}
+	for i := range pod.Spec.Containers {
+		addResources(list, resources(&pod.Spec.Containers[i]))
+	}
+	for i := range pod.Spec.InitContainers {
+		for name, quantity := range resources(&pod.Spec.InitContainers[i]) {
+			if current, ok := list[name]; !ok || quantity.Cmp(current) > 0 {
+				list[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	addResources(list, pod.Spec.Overhead)
+	return list
+}
+
+// addResources adds the quantities of the resources to the list.
+func addResources(list, resources v1.ResourceList) {
+	for name, quantity := range resources {
+		sum, ok := list[name]
+		if !ok {
+			list[name] = quantity.DeepCopy()
+			continue
+		}
+		sum.Add(quantity)
+		list[name] = sum
+	}
+}
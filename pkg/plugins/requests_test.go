@@ -0,0 +1,110 @@
+package plugins
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestPodEffectiveRequests(t *testing.T) {
+	makeContainers := func(requests ...v1.ResourceList) []v1.Container {
+		var containers []v1.Container
+		for _, r := range requests {
+			containers = append(containers, v1.Container{Resources: v1.ResourceRequirements{Requests: r}})
+		}
+		return containers
+	}
+	makeList := func(cpu, memory string) v1.ResourceList {
+		list := v1.ResourceList{}
+		if cpu != "" {
+			list[v1.ResourceCPU] = resource.MustParse(cpu)
+		}
+		if memory != "" {
+			list[v1.ResourceMemory] = resource.MustParse(memory)
+		}
+		return list
+	}
+	tests := []struct {
+		name       string
+		spec       v1.PodSpec
+		wantCPU    string
+		wantMemory string
+	}{
+		{
+			name:       "no requests at all",
+			spec:       v1.PodSpec{Containers: makeContainers(nil)},
+			wantCPU:    "0",
+			wantMemory: "0",
+		},
+		{
+			name:       "sum of the containers",
+			spec:       v1.PodSpec{Containers: makeContainers(makeList("500m", "1Gi"), makeList("250m", "512Mi"))},
+			wantCPU:    "750m",
+			wantMemory: "1536Mi",
+		},
+		{
+			name:       "only init containers",
+			spec:       v1.PodSpec{InitContainers: makeContainers(makeList("1", "256Mi"), makeList("500m", "1Gi"))},
+			wantCPU:    "1",
+			wantMemory: "1Gi",
+		},
+		{
+			name: "init container larger than the containers",
+			spec: v1.PodSpec{
+				InitContainers: makeContainers(makeList("", "2Gi")),
+				Containers:     makeContainers(makeList("1", "1Gi"), makeList("1", "512Mi")),
+			},
+			wantCPU:    "2",
+			wantMemory: "2Gi",
+		},
+		{
+			name: "overhead",
+			spec: v1.PodSpec{
+				Containers: makeContainers(makeList("1", "1Gi")),
+				Overhead:   makeList("100m", "128Mi"),
+			},
+			wantCPU:    "1100m",
+			wantMemory: "1152Mi",
+		},
+		{
+			name: "overhead on top of an init container",
+			spec: v1.PodSpec{
+				InitContainers: makeContainers(makeList("2", "")),
+				Containers:     makeContainers(makeList("1", "1Gi")),
+				Overhead:       makeList("100m", "128Mi"),
+			},
+			wantCPU:    "2100m",
+			wantMemory: "1152Mi",
+		},
+		{
+			name:       "only overhead",
+			spec:       v1.PodSpec{Overhead: makeList("100m", "128Mi")},
+			wantCPU:    "100m",
+			wantMemory: "128Mi",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := PodEffectiveRequests(&v1.Pod{Spec: tt.spec})
+			if want := resource.MustParse(tt.wantCPU); requests.Cpu().Cmp(want) != 0 {
+				t.Errorf("expected cpu %s, got %s", tt.wantCPU, requests.Cpu())
+			}
+			if want := resource.MustParse(tt.wantMemory); requests.Memory().Cmp(want) != 0 {
+				t.Errorf("expected memory %s, got %s", tt.wantMemory, requests.Memory())
+			}
+		})
+	}
+}
+
+func TestPodEffectiveRequests_DoesNotModifyPod(t *testing.T) {
+	pod := &v1.Pod{Spec: v1.PodSpec{
+		Containers: []v1.Container{{Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")}}}},
+		Overhead:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("128Mi")},
+	}}
+	PodEffectiveRequests(pod)
+	PodEffectiveRequests(pod)
+	if memory := pod.Spec.Containers[0].Resources.Requests[v1.ResourceMemory]; memory.Cmp(resource.MustParse("1Gi")) != 0 {
+		t.Errorf("expected the container request to stay 1Gi, got %s", memory.String())
+	}
+}
@@ -0,0 +1,113 @@
+package plugins
+
+import (
+	v1 "k8s.io/api/core/v1"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	"k8s.io/kubernetes/pkg/features"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// resizedResources returns the resources a node holds for a container that
+// may be resized in place, given those of its spec and those the kubelet
+// reports as actually allocated to it. An infeasible resize won't happen, so
+// the node keeps what is allocated. Otherwise the node holds the larger of
+// both: the old amounts until a shrink completes, and the new ones as soon as
+// a grow is accepted. Clusters not reporting the allocated resources get the
+// spec back.
+func resizedResources(pod *v1.Pod, spec, allocated v1.ResourceList) v1.ResourceList {
+	if allocated == nil {
+		return spec
+	}
+	if pod.Status.Resize == v1.PodResizeStatusInfeasible {
+		return allocated
+	}
+	resources := spec.DeepCopy()
+	if resources == nil {
+		resources = v1.ResourceList{}
+	}
+	for name, quantity := range allocated {
+		if current, ok := resources[name]; !ok || quantity.Cmp(current) > 0 {
+			resources[name] = quantity.DeepCopy()
+		}
+	}
+	return resources
+}
+
+// containerStatuses returns the statuses of the pod's containers by name.
+func containerStatuses(pod *v1.Pod) map[string]*v1.ContainerStatus {
+	statuses := make(map[string]*v1.ContainerStatus, len(pod.Status.ContainerStatuses))
+	for i := range pod.Status.ContainerStatuses {
+		statuses[pod.Status.ContainerStatuses[i].Name] = &pod.Status.ContainerStatuses[i]
+	}
+	return statuses
+}
+
+// containerRequests returns the requests the node holds for the container,
+// taking a resize in progress into account.
+func containerRequests(pod *v1.Pod, container *v1.Container, statuses map[string]*v1.ContainerStatus) v1.ResourceList {
+	status, ok := statuses[container.Name]
+	if !ok {
+		return container.Resources.Requests
+	}
+	return resizedResources(pod, container.Resources.Requests, status.AllocatedResources)
+}
+
+// containerLimits returns the limits of the container, taking a resize in
+// progress into account like containerRequests.
+func containerLimits(pod *v1.Pod, container *v1.Container, statuses map[string]*v1.ContainerStatus) v1.ResourceList {
+	status, ok := statuses[container.Name]
+	if !ok || status.Resources == nil {
+		return container.Resources.Limits
+	}
+	return resizedResources(pod, container.Resources.Limits, status.Resources.Limits)
+}
+
+// podAllocatedRequests returns the requests the node holds for the pod the
+// way PodEffectiveRequests returns those of its spec, with the containers
+// being resized in place counting as resizedResources says.
+func podAllocatedRequests(pod *v1.Pod) v1.ResourceList {
+	statuses := containerStatuses(pod)
+	return podEffectiveResources(pod, func(container *v1.Container) v1.ResourceList {
+		return containerRequests(pod, container, statuses)
+	})
+}
+
+// resizeAdjustment returns how much more the pods of the node hold than their
+// spec requests, which are all nodeInfo.Requested counts of them unless the
+// scheduler has the InPlacePodVerticalScaling feature gate enabled. It is
+// negative for pods whose infeasible grow left them with less than their
+// spec, and nil when no pod of the node is being resized.
+func resizeAdjustment(nodeInfo *framework.NodeInfo) *framework.Resource {
+	if utilfeature.DefaultFeatureGate.Enabled(features.InPlacePodVerticalScaling) {
+		return nil
+	}
+	var adjustment *framework.Resource
+	for _, podInfo := range nodeInfo.Pods {
+		pod := podInfo.Pod
+		if !hasAllocatedResources(pod) {
+			continue
+		}
+		delta := podAllocatedRequests(pod)
+		for name, quantity := range PodEffectiveRequests(pod) {
+			allocated := delta[name]
+			allocated.Sub(quantity)
+			delta[name] = allocated
+		}
+		if adjustment == nil {
+			adjustment = &framework.Resource{}
+		}
+		adjustment.Add(delta)
+	}
+	return adjustment
+}
+
+// hasAllocatedResources reports whether the kubelet reports what is allocated
+// to any container of the pod.
+func hasAllocatedResources(pod *v1.Pod) bool {
+	for i := range pod.Status.ContainerStatuses {
+		if pod.Status.ContainerStatuses[i].AllocatedResources != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package plugins
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// makeResizingPod returns a pod whose spec requests and limits memory, with
+// the memory the kubelet allocated to it and limits it to when allocated is
+// set, as during an in-place resize.
+func makeResizingPod(name string, spec, allocated int64, resize v1.PodResizeStatus) *v1.Pod {
+	p := makeLimitedPod(name, spec)
+	p.Spec.Containers[0].Name = "main"
+	p.Status.Resize = resize
+	if allocated > 0 {
+		quantity := *resource.NewQuantity(allocated, resource.BinarySI)
+		p.Status.ContainerStatuses = []v1.ContainerStatus{{
+			Name:               "main",
+			AllocatedResources: v1.ResourceList{v1.ResourceMemory: quantity},
+			Resources: &v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceMemory: quantity},
+				Limits:   v1.ResourceList{v1.ResourceMemory: quantity},
+			},
+		}}
+	}
+	return p
+}
+
+func TestCustomScheduler_NodeResources_InPlaceResize(t *testing.T) {
+	tests := []struct {
+		name string
+		pod  *v1.Pod
+		// wantHeld is the memory the node holds for the pod, both requested
+		// and limited
+		wantHeld int64
+	}{
+		{
+			name:     "no allocated resources reported",
+			pod:      makeResizingPod("pod1", 2<<30, 0, ""),
+			wantHeld: 2 << 30,
+		},
+		{
+			name:     "resized",
+			pod:      makeResizingPod("pod1", 2<<30, 2<<30, ""),
+			wantHeld: 2 << 30,
+		},
+		{
+			name:     "growing",
+			pod:      makeResizingPod("pod1", 3<<30, 1<<30, v1.PodResizeStatusInProgress),
+			wantHeld: 3 << 30,
+		},
+		{
+			name:     "shrinking",
+			pod:      makeResizingPod("pod1", 1<<30, 3<<30, v1.PodResizeStatusInProgress),
+			wantHeld: 3 << 30,
+		},
+		{
+			name:     "shrink proposed",
+			pod:      makeResizingPod("pod1", 1<<30, 3<<30, v1.PodResizeStatusProposed),
+			wantHeld: 3 << 30,
+		},
+		{
+			name:     "infeasible grow",
+			pod:      makeResizingPod("pod1", 3<<30, 1<<30, v1.PodResizeStatusInfeasible),
+			wantHeld: 1 << 30,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeInfo := makeNodeInfo("node1", 4000, 8<<30)
+			nodeInfo.AddPod(tt.pod)
+			cs := &CustomScheduler{capacityPolicy: capacityLimits}
+			r := cs.nodeResources(nodeInfo)
+			if want := int64(8<<30) - tt.wantHeld; r.free.Memory != want {
+				t.Errorf("expected %d bytes free, got %d", want, r.free.Memory)
+			}
+			if r.memoryLimit != tt.wantHeld {
+				t.Errorf("expected %d bytes limited, got %d", tt.wantHeld, r.memoryLimit)
+			}
+		})
+	}
+}
+
+func TestPodAllocatedRequests(t *testing.T) {
+	p := makeResizingPod("pod1", 1<<30, 3<<30, v1.PodResizeStatusInProgress)
+	p.Spec.Containers = append(p.Spec.Containers, v1.Container{
+		Name: "sidecar",
+		Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse("500m"),
+			v1.ResourceMemory: resource.MustParse("1Gi"),
+		}},
+	})
+	got := podAllocatedRequests(p)
+	if got.Memory().Value() != 4<<30 || got.Cpu().MilliValue() != 500 {
+		t.Errorf("expected 4Gi and 500m, got %s and %s", got.Memory(), got.Cpu())
+	}
+	// the spec requests are left alone
+	if spec := PodEffectiveRequests(p); spec.Memory().Value() != 2<<30 {
+		t.Errorf("expected the spec to request 2Gi, got %s", spec.Memory())
+	}
+}
@@ -0,0 +1,34 @@
+package plugins
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// listerAttempts bounds how often a failing lister is called within a single
+// extension point, and listerRetryDelay is the delay between the attempts
+// before jitter.
+const (
+	listerAttempts   = 3
+	listerRetryDelay = 5 * time.Millisecond
+)
+
+// retryLister calls fn until it succeeds, listerAttempts calls failed or ctx
+// is done, sleeping a jittered delay between the calls. Lister errors are
+// most likely transient, so retrying them saves a scheduling attempt. It
+// returns the error of the last call.
+func retryLister(ctx context.Context, fn func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil || attempt == listerAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait.Jitter(listerRetryDelay, 1)):
+		}
+	}
+}
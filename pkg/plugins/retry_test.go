@@ -0,0 +1,98 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// flakyIndexer fails the first failures lookups by index.
+type flakyIndexer struct {
+	cache.Indexer
+	failures int
+	calls    int
+}
+
+func (f *flakyIndexer) ByIndex(indexName, indexedValue string) ([]interface{}, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, errors.New("indexer unavailable")
+	}
+	return f.Indexer.ByIndex(indexName, indexedValue)
+}
+
+func TestCustomScheduler_PreFilter_ListerRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		cancelled bool
+		want      framework.Code
+		wantCalls int
+	}{
+		{
+			name:      "lister works",
+			want:      framework.Success,
+			wantCalls: 1,
+		},
+		{
+			name:      "lister recovers",
+			failures:  listerAttempts - 1,
+			want:      framework.Success,
+			wantCalls: listerAttempts,
+		},
+		{
+			name:      "lister keeps failing",
+			failures:  listerAttempts,
+			want:      framework.Unschedulable,
+			wantCalls: listerAttempts,
+		},
+		{
+			name:      "context is cancelled",
+			failures:  listerAttempts,
+			cancelled: true,
+			want:      framework.Unschedulable,
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pods := []*v1.Pod{makeGangPod("pod0", "g1", 2), makeGangPod("pod1", "g1", 2)}
+			fh := newTestFrameworkWithPods(t, nil)
+			cs := &CustomScheduler{
+				handle:               fh,
+				groupLabelKey:        groupNameLabel,
+				minAvailableLabelKey: minAvailableLabel,
+			}
+			informer := fh.SharedInformerFactory().Core().V1().Pods().Informer()
+			if err := cs.addGroupIndexer(informer); err != nil {
+				t.Fatalf("fail to add the group index: %v", err)
+			}
+			for _, p := range pods {
+				informer.GetStore().Add(p)
+			}
+			indexer := &flakyIndexer{Indexer: cs.podIndexer, failures: tt.failures}
+			cs.podIndexer = indexer
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+			_, status := cs.PreFilter(ctx, nil, pods[0])
+			if status.Code() != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, status)
+			}
+			if !status.IsSuccess() && !strings.Contains(status.Message(), "indexer unavailable") {
+				t.Errorf("expected the message to carry the lister error, got %q", status.Message())
+			}
+			if indexer.calls != tt.wantCalls {
+				t.Errorf("expected %d calls to the lister, got %d", tt.wantCalls, indexer.calls)
+			}
+		})
+	}
+}
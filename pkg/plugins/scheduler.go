@@ -2,183 +2,1129 @@ package plugins
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
-	"math"
+	"io"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	v1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	batchlisters "k8s.io/client-go/listers/batch/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	policylisters "k8s.io/client-go/listers/policy/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/component-base/configz"
+	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
+	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+	"k8s.io/utils/clock"
 )
 
+// CustomSchedulerArgs are the arguments of the plugin, registered as
+// scheduler.nthu.io/v1 CustomSchedulerArgs.
 type CustomSchedulerArgs struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Mode is Least or Most to score nodes on their free memory, LeastCPU or
+	// MostCPU to score them on their free CPU, or Balanced to prefer the nodes
+	// whose CPU and memory utilization stay closest to each other. LeastPods
+	// prefers the nodes running the fewest pods and MostPods packs them.
+	// Weighted combines the free fractions of the configured Resources,
+	// Shaped scores the utilization left by the pod on the Shape curve, and
+	// Composite combines the weighted ScoreComponents. The mode is
+	// case-insensitive, and LeastAllocated and MostAllocated are
+	// accepted for Least and Most. It defaults to Least.
 	Mode string `json:"mode"`
+	// ModeByQoS maps the QoS classes Guaranteed, Burstable and BestEffort to
+	// the mode their pods are scored in, so that for example Guaranteed pods
+	// spread while BestEffort pods pack. Pods of the classes it doesn't list
+	// are scored in Mode.
+	ModeByQoS map[string]string `json:"modeByQoS"`
+	// ClusterWideGroups counts pods of a group across all namespaces instead
+	// of only the namespace of the incoming pod.
+	ClusterWideGroups bool `json:"clusterWideGroups"`
+	// PermitWaitingTimeSeconds is how long gang members wait at Permit for
+	// the rest of their group before the whole group is rejected.
+	PermitWaitingTimeSeconds int64 `json:"permitWaitingTimeSeconds"`
+	// GroupBackoffSeconds is how long a group stays blocked after one of its
+	// members couldn't fit on any node.
+	GroupBackoffSeconds int64 `json:"groupBackoffSeconds"`
+	// GroupLabelKey and MinAvailableLabelKey are the pod label keys holding
+	// the group name and minAvailable. They default to "podGroup" and
+	// "minAvailable".
+	GroupLabelKey        string `json:"groupLabelKey"`
+	MinAvailableLabelKey string `json:"minAvailableLabelKey"`
+	// MinAvailableAnnotationKey is the pod annotation read when the
+	// minAvailable label is absent. It defaults to
+	// "scheduler.nthu.io/min-available".
+	MinAvailableAnnotationKey string `json:"minAvailableAnnotationKey"`
+	// MaxAvailableLabelKey is the pod label holding the largest number of
+	// members of the group that may be assigned to nodes at once. It
+	// defaults to "maxAvailable". Groups without the label are unlimited.
+	MaxAvailableLabelKey string `json:"maxAvailableLabelKey"`
+	// CoschedulingLabels also recognizes the group and minAvailable labels of
+	// the sig-scheduling coscheduling plugin. The plugin's own labels take
+	// precedence when a pod carries both.
+	CoschedulingLabels bool `json:"coschedulingLabels"`
+	// MaxMinAvailable is the largest minAvailable a pod may ask for. Larger
+	// values are rejected as invalid. It defaults to 10000.
+	MaxMinAvailable int `json:"maxMinAvailable"`
+	// EnablePodGroupCRD reads minAvailable from the spec.minMember of the
+	// PodGroup named by the group label, falling back to the pod labels when
+	// the PodGroup doesn't exist.
+	EnablePodGroupCRD bool `json:"enablePodGroupCRD"`
+	// GangCountPolicy decides which members count toward minAvailable at
+	// Permit: "Created" (default) counts every live pod of the group,
+	// "Assigned" only counts pods that are bound or waiting at Permit.
+	GangCountPolicy string `json:"gangCountPolicy"`
+	// GangTimeoutSeconds bounds how long a group may take to become ready,
+	// counted from when its first member is seen. Afterwards new members are
+	// rejected, or scheduled individually when GangTimeoutBestEffort is set.
+	// Zero disables the timeout.
+	GangTimeoutSeconds    int64 `json:"gangTimeoutSeconds"`
+	GangTimeoutBestEffort bool  `json:"gangTimeoutBestEffort"`
+	// BestEffortGraceSeconds is how long after its oldest member was created
+	// a group labeled gangPolicy: besteffort may take to reach
+	// minAvailable before its members are scheduled on their own. It
+	// defaults to 300.
+	BestEffortGraceSeconds int64 `json:"bestEffortGraceSeconds"`
+	// MaxConcurrentGroupsPerNamespace is how many complete groups of a
+	// namespace may schedule at once. The members of other groups are
+	// rejected until one of them has all of its members scheduled or is
+	// deleted, and the oldest waiting groups go first. Zero means no limit.
+	MaxConcurrentGroupsPerNamespace int `json:"maxConcurrentGroupsPerNamespace"`
+	// MaxConcurrentReleasingGroups is how many groups that reached
+	// minAvailable Permit releases at once. The other complete groups keep
+	// waiting, in the order they completed, until the released groups are
+	// bound or failed. Zero releases every group as soon as it completes.
+	MaxConcurrentReleasingGroups int `json:"maxConcurrentReleasingGroups"`
+	// MinAvailableFromOwner derives minAvailable from the Job or StatefulSet
+	// owning the pod when neither the label nor the annotation is set.
+	MinAvailableFromOwner bool `json:"minAvailableFromOwner"`
+	// ConflictPolicy resolves members of a group disagreeing on
+	// minAvailable: "Reject" (default) rejects the pod, "Max" and "Min" use
+	// the largest or smallest value.
+	ConflictPolicy string `json:"conflictPolicy"`
+	// CheckGroupResources rejects a group in PreFilter when its pending
+	// members request more CPU or memory than is free in the whole cluster.
+	CheckGroupResources bool `json:"checkGroupResources"`
+	// PriorityAdmission holds a group back in PreFilter while a group of
+	// higher priority waits to be scheduled and the pending members of both
+	// groups request more CPU or memory than is free in the cluster.
+	PriorityAdmission bool `json:"priorityAdmission"`
+	// RespectResourceQuota rejects a group in PreFilter when its members
+	// request more than a ResourceQuota of their namespace allows, or waits
+	// while its missing members don't fit in what the quota has left.
+	RespectResourceQuota bool `json:"respectResourceQuota"`
+	// EnableGroupPreemption lets PostFilter evict whole groups of lower
+	// priority to make room for a group that doesn't fit. Groups protected by
+	// a PodDisruptionBudget are spared.
+	EnableGroupPreemption bool `json:"enableGroupPreemption"`
+	// ResourceName is an extended resource, such as nvidia.com/gpu, or a
+	// hugepages resource, such as hugepages-2Mi, that the Least and Most modes
+	// score on instead of memory.
+	ResourceName string `json:"resourceName"`
+	// DefaultMemoryRequest is the memory request Score assumes for pods that
+	// don't request any memory. It defaults to 200Mi.
+	DefaultMemoryRequest string `json:"defaultMemoryRequest"`
+	// GroupAffinityWeight is the share, from 0 to 100, of the final score
+	// given to the members of the pod's group already on a node. Each member
+	// is worth GroupAffinityBonus points, up to the maximum node score. The
+	// weight defaults to 0, which disables group affinity, and the bonus to
+	// 10.
+	GroupAffinityWeight int64 `json:"groupAffinityWeight"`
+	GroupAffinityBonus  int64 `json:"groupAffinityBonus"`
+	// SpreadGroup turns the group affinity bonus into a penalty, so that the
+	// members of a group spread over the nodes. It needs a positive
+	// GroupAffinityWeight. Pods can also cap the members of their group per
+	// node with the maxMembersPerNode label.
+	SpreadGroup bool `json:"spreadGroup"`
+	// DomainTopologyKey is the node label whose values are the failure
+	// domains the members of a group with the minDomains label spread over.
+	// It defaults to topology.kubernetes.io/zone.
+	DomainTopologyKey string `json:"domainTopologyKey"`
+	// Resources are scored by the Weighted mode, by the weighted average of
+	// the fraction of each resource left on a node. They default to memory
+	// and cpu with a weight of 1.
+	Resources []ResourceSpec `json:"resources"`
+	// Shape is the utilization-to-score curve of the Shaped mode, which
+	// scores a node by interpolating the curve at the utilization of its
+	// memory, or of ResourceName, once the pod is placed. For example the
+	// points (0, 0), (70, 100) and (100, 0) prefer nodes ending up 70% full.
+	// The utilizations must increase from 0 to 100 and the scores lie
+	// between 0 and 100.
+	Shape []ShapePoint `json:"shape"`
+	// ScoreComponents are combined by the Composite mode, by the weighted
+	// average of their sub-scores between 0 and 100. A component is a free
+	// mode such as Least or MostCPU, GroupLocality, worth GroupAffinityBonus
+	// points per member of the pod's group on the node, Tier, the bonus of the
+	// node's tier, or ImageLocality, the bytes of the pod's images already on
+	// the node against the most on any node. Weights must not be negative, and
+	// a component weighing 0, like ImageLocality when it isn't listed, is
+	// disabled.
+	ScoreComponents []ScoreComponent `json:"scoreComponents"`
+	// UseActualUsage scores the memory modes on the working set reported by
+	// the metrics API instead of the requested memory. The metrics are
+	// refreshed every UsageRefreshSeconds (default 30) and ignored once older
+	// than UsageStaleSeconds (default 300), in which case the requests are
+	// used.
+	UseActualUsage      bool  `json:"useActualUsage"`
+	UsageRefreshSeconds int64 `json:"usageRefreshSeconds"`
+	UsageStaleSeconds   int64 `json:"usageStaleSeconds"`
+	// DeterministicTieBreak breaks ties between nodes with the same score by
+	// a stable hash of the pod UID and the node name.
+	DeterministicTieBreak bool `json:"deterministicTieBreak"`
+	// MemoryPressurePenalty and DiskPressurePenalty are subtracted from the
+	// normalized score of nodes reporting the condition. They default to 100
+	// and 20.
+	MemoryPressurePenalty *int64 `json:"memoryPressurePenalty"`
+	DiskPressurePenalty   *int64 `json:"diskPressurePenalty"`
+	// CapacityPolicy is what the memory modes take the pods on a node to
+	// use: their memory requests (Requests, the default) or their memory
+	// limits (Limits). Containers without a memory limit count with their
+	// request, and pods without either with DefaultMemoryRequest.
+	CapacityPolicy string `json:"capacityPolicy"`
+	// ScoreBasis is what the modes scoring on a single resource compare:
+	// the amount left on the node (Absolute, the default) or the fraction of
+	// its allocatable amount left (Fraction), so that nodes of different
+	// sizes are compared on how full they are.
+	ScoreBasis string `json:"scoreBasis"`
+	// NormalizationStrategy is how NormalizeScore maps the raw scores to the
+	// node score range: linearly from the lowest to the highest (MinMax, the
+	// default), on their distance from the mean in standard deviations,
+	// clamped at two (ZScore), or on their rank (Percentile). The last two
+	// keep a single outlier node from squeezing the others together.
+	NormalizationStrategy string `json:"normalizationStrategy"`
+	// DryRunMode is a score mode, Least or Most, that Score computes next to
+	// the active one without returning it. PostBind logs and counts whether
+	// the pod landed on the node the dry-run mode ranked first, to preview a
+	// mode before switching to it. It is unset by default.
+	DryRunMode string `json:"dryRunMode"`
+	// AnnotateMemberNodes has PostBind list the node of each scheduled
+	// member of a group in the scheduler.nthu.io/member-nodes annotation of
+	// the oldest member, and MemberNodesMetric exports the nodes of each
+	// group as the group_member_nodes_info series.
+	AnnotateMemberNodes bool `json:"annotateMemberNodes"`
+	MemberNodesMetric   bool `json:"memberNodesMetric"`
+	// TierLabelKey is the node label naming the tier of a node, and
+	// TierBonus maps the tiers to the points added to the normalized score
+	// of their nodes, between 0 and 100. Nodes without the label, or in a
+	// tier missing from the map, get no bonus. The score stays capped at 100.
+	TierLabelKey string           `json:"tierLabelKey"`
+	TierBonus    map[string]int64 `json:"tierBonus"`
+	// AllowRandomMode must be set to use the Random mode, which scores nodes
+	// at random as a control group for experiments. RandomSeed makes the
+	// scores reproducible; without it the seed is taken from the clock.
+	AllowRandomMode bool   `json:"allowRandomMode"`
+	RandomSeed      *int64 `json:"randomSeed"`
+	// ExplainScores annotates each pod at PreBind with the score of the node
+	// it is bound to and the amounts the score was computed from. It costs an
+	// API write per pod.
+	ExplainScores bool `json:"explainScores"`
+	// EnableGangFilter and EnableScoring turn the gang scheduling and the
+	// scoring of nodes on and off, so that a profile can use either on its
+	// own. Both default to true, and can't both be false.
+	EnableGangFilter *bool `json:"enableGangFilter"`
+	EnableScoring    *bool `json:"enableScoring"`
+	// ConfigMapRef names a ConfigMap whose arguments are reloaded while the
+	// scheduler runs. Only the mode, the resources and the minAvailable and
+	// maxAvailable keys are taken from it; updates that are invalid or change
+	// other arguments are logged and ignored.
+	ConfigMapRef *ConfigMapRef `json:"configMapRef"`
+	// EnableConfigz serves the effective arguments, after defaulting and
+	// reloads, under "customscheduler" on the /configz endpoint of the
+	// scheduler. Only one profile can enable it.
+	EnableConfigz bool `json:"enableConfigz"`
+	// MemorySafetyMargin is the memory Filter keeps free on every node, as a
+	// quantity such as 512Mi or a percentage of the allocatable memory such
+	// as 5%. A node is rejected when the pod would leave less than that. Pods
+	// annotated with scheduler.nthu.io/memory-safety-margin: disabled ignore
+	// it, but not the node headroom. It is off by default.
+	MemorySafetyMargin string `json:"memorySafetyMargin"`
+	// NodeHeadroomBytes is the memory kept free on every node for emergency
+	// DaemonSets and kernel caches, on top of what kubelet reserves. Score
+	// takes it off the free memory of the nodes, and Filter off their
+	// allocatable memory before checking the safety margin. Nodes annotated
+	// with scheduler.nthu.io/memory-headroom use the quantity of the
+	// annotation instead. It is 0 by default.
+	NodeHeadroomBytes int64 `json:"nodeHeadroomBytes"`
+	// EnableNodePrefiltering makes PreFilter restrict the nodes of a gang
+	// member to those with enough free memory for the largest request in its
+	// group, so that the framework skips the other nodes.
+	EnableNodePrefiltering bool `json:"enableNodePrefiltering"`
+	// UseSchedulingGates starts a controller that removes the
+	// scheduler.nthu.io/gang scheduling gate from the members of a group once
+	// the group has minAvailable pods created, so that the scheduler only
+	// sees complete gangs. Pods without the gate are scheduled as before.
+	UseSchedulingGates bool `json:"useSchedulingGates"`
+	// MaxGroupAttempts trips the circuit breaker of a group after that many
+	// failed attempts in a row, i.e. members that fit on no node. A tripped
+	// group is rejected by PreFilter for the group backoff, doubled on every
+	// trip in a row up to 30 minutes. Binding a member or adding a node
+	// resets the breaker. 0 disables it.
+	MaxGroupAttempts int `json:"maxGroupAttempts"`
+	// CacheSyncTimeoutSeconds is how long after startup PreFilter rejects
+	// gang members while the pod informer hasn't synced, since it would
+	// undercount their groups. After that the cache is used as it is. It
+	// defaults to 60, and 0 doesn't wait for the cache at all.
+	CacheSyncTimeoutSeconds *int64 `json:"cacheSyncTimeoutSeconds"`
 }
 
 type CustomScheduler struct {
-	handle    framework.Handle
-	scoreMode string
+	handle                    framework.Handle
+	scoreMode                 string
+	resourceName              v1.ResourceName
+	defaultMemoryRequest      resource.Quantity
+	groupAffinityWeight       int64
+	groupAffinityBonus        int64
+	spreadGroup               bool
+	domainTopologyKey         string
+	resources                 []ResourceSpec
+	deterministicTieBreak     bool
+	memoryPressurePenalty     int64
+	diskPressurePenalty       int64
+	capacityPolicy            string
+	scoreBasis                string
+	normalizationStrategy     string
+	dryRunMode                string
+	annotateMemberNodes       bool
+	memberNodesMetric         bool
+	tierLabelKey              string
+	shape                     []ShapePoint
+	modeByQoS                 map[string]string
+	scoreComponents           []ScoreComponent
+	tierBonus                 map[string]int64
+	allowRandomMode           bool
+	randomSeed                int64
+	explainScores             bool
+	gangFilterDisabled        bool
+	memorySafetyMargin        int64
+	memorySafetyMarginPercent int64
+	nodeHeadroomBytes         int64
+	scoringDisabled           bool
+	clusterWideGroups         bool
+	permitWaitingTime         time.Duration
+	groupBackoff              time.Duration
+	maxGroupAttempts          int
+	groupLabelKey             string
+	minAvailableLabelKey      string
+	minAvailableAnnotationKey string
+	maxAvailableLabelKey      string
+	coschedulingLabels        bool
+	maxMinAvailable           int
+	gangCountPolicy           string
+	gangTimeout               time.Duration
+	gangTimeoutBestEffort     bool
+	bestEffortGrace           time.Duration
+	maxConcurrentGroups       int
+	maxReleasingGroups        int
+	conflictPolicy            string
+	checkGroupResources       bool
+	priorityAdmission         bool
+	nodePrefiltering          bool
+	groupPreemption           bool
+	eventRecorder             events.EventRecorder
+	// podIndexer indexes pods by group under groupIndexName. It is nil when
+	// the index couldn't be added.
+	podIndexer     cache.Indexer
+	groupIndexName string
+	// groupMgr manages the members of the groups.
+	groupMgr *GroupManager
+	clock    clock.PassiveClock
+	// podListerOverride and nodeInfoListerOverride replace the listers of
+	// the handle when set with an Option.
+	podListerOverride      corelisters.PodLister
+	nodeInfoListerOverride framework.NodeInfoLister
+	minAvailableFromOwner  bool
+	jobLister              batchlisters.JobLister
+	statefulSetLister      appslisters.StatefulSetLister
+	pdbLister              policylisters.PodDisruptionBudgetLister
+	namespaceLister        corelisters.NamespaceLister
+	// quotaLister is only set when resource quotas are respected.
+	quotaLister corelisters.ResourceQuotaLister
+	// podGroupClient and podGroupLister are only set when PodGroup support
+	// is enabled.
+	podGroupClient dynamic.Interface
+	podGroupLister cache.GenericLister
+	// usage caches the node metrics. It is nil unless actual usage is used.
+	usage *usageCache
+	// admission limits the groups scheduling at once in a namespace. It is
+	// nil without a limit.
+	admission *groupAdmission
+	// releases orders the release of complete groups at Permit. It is nil
+	// without a limit.
+	releases *releaseQueue
+	// limitCache keeps the memory limits of the nodes' pods across
+	// scheduling cycles. It is nil unless the capacity policy is Limits.
+	limitCache *limitCache
+	// podsSynced is nil when PreFilter doesn't wait for the pod informer.
+	podsSynced *cacheSync
+	// gates releases gated gang members. It is nil unless scheduling gates
+	// are used.
+	gates *gateController
+	// stopCh stops the background workers of the plugin once Close closes
+	// it.
+	stopCh    chan struct{}
+	closeOnce sync.Once
+
+	// configMu guards the fields reloaded from the ConfigMap, which are read
+	// through config. args are the arguments they are reloaded against.
+	configMu sync.RWMutex
+	args     *CustomSchedulerArgs
+	// configz serves the effective arguments. It is nil unless EnableConfigz
+	// is set.
+	configz *configz.Config
+
+	// mu guards groups, which is shared between Permit and Unreserve.
+	mu     sync.Mutex
+	groups map[string]*groupState
+}
+
+// invalidMinAvailableError reports a minAvailable value that isn't a positive
+// integer within the limit or a percentage between 1% and 100%, or a
+// percentage where it can't be used.
+type invalidMinAvailableError struct {
+	value string
+	limit int
+	// percent is set for a percentage given in the label.
+	percent bool
+}
+
+func (e *invalidMinAvailableError) Error() string {
+	if e.percent {
+		return fmt.Sprintf("%q is a percentage, which is only accepted in the annotation", e.value)
+	}
+	return fmt.Sprintf("%q is not a positive integer up to %d or a percentage between 1%% and 100%%", e.value, e.limit)
 }
 
 var _ framework.PreFilterPlugin = &CustomScheduler{}
 var _ framework.ScorePlugin = &CustomScheduler{}
+var _ framework.PermitPlugin = &CustomScheduler{}
+var _ framework.ReservePlugin = &CustomScheduler{}
+var _ framework.PostFilterPlugin = &CustomScheduler{}
+var _ framework.PostBindPlugin = &CustomScheduler{}
+var _ framework.PreFilterExtensions = &CustomScheduler{}
+var _ framework.QueueSortPlugin = &CustomScheduler{}
+var _ framework.EnqueueExtensions = &CustomScheduler{}
+var _ io.Closer = &CustomScheduler{}
 
 // Name is the name of the plugin used in Registry and configurations.
 const (
 	Name              string = "CustomScheduler"
 	groupNameLabel    string = "podGroup"
 	minAvailableLabel string = "minAvailable"
-	leastMode         string = "Least"
-	mostMode          string = "Most"
+	// minAvailableAnnotation is read when the minAvailable label is absent.
+	minAvailableAnnotation string = "scheduler.nthu.io/min-available"
+	maxAvailableLabel      string = "maxAvailable"
+	maxMembersPerNodeLabel string = "maxMembersPerNode"
+	minDomainsLabel        string = "minDomains"
+	// gangAnnotation opts a pod out of the gang check. With gangDisabled the
+	// pod still counts toward its group, with gangIgnored it doesn't.
+	gangAnnotation string = "scheduler.nthu.io/gang"
+	gangDisabled   string = "disabled"
+	gangIgnored    string = "ignored"
+	leastMode      string = "Least"
+	mostMode       string = "Most"
+	leastCPUMode   string = "LeastCPU"
+	mostCPUMode    string = "MostCPU"
+	balancedMode   string = "Balanced"
+	leastPodsMode  string = "LeastPods"
+	mostPodsMode   string = "MostPods"
+	weightedMode   string = "Weighted"
+	randomMode     string = "Random"
+	shapedMode     string = "Shaped"
+	compositeMode  string = "Composite"
+
+	gangCountCreated  string = "Created"
+	gangCountAssigned string = "Assigned"
+	conflictReject    string = "Reject"
+	conflictMax       string = "Max"
+	conflictMin       string = "Min"
+	capacityRequests  string = "Requests"
+	capacityLimits    string = "Limits"
+	basisAbsolute     string = "Absolute"
+	basisFraction     string = "Fraction"
+
+	defaultPermitWaitingTimeSeconds int64 = 60
+	defaultGroupBackoffSeconds      int64 = 30
+	defaultCacheSyncTimeoutSeconds  int64 = 60
+	defaultBestEffortGraceSeconds   int64 = 300
+	defaultMaxMinAvailable          int   = 10000
+	defaultMemoryRequestValue             = "200Mi"
+	defaultGroupAffinityBonus       int64 = 10
+	defaultUsageRefreshSeconds      int64 = 30
+	defaultUsageStaleSeconds        int64 = 300
+	defaultMemoryPressurePenalty    int64 = 100
+	defaultDiskPressurePenalty      int64 = 20
 )
 
 func (cs *CustomScheduler) Name() string {
 	return Name
 }
 
+// Close stops the background workers of the plugin. It implements io.Closer,
+// which newer schedulers call on their plugins when shutting down.
+func (cs *CustomScheduler) Close() error {
+	cs.closeOnce.Do(func() {
+		if cs.stopCh != nil {
+			close(cs.stopCh)
+		}
+	})
+	return nil
+}
+
+// Register returns the name and factory of the plugin, which scheduler
+// binaries embedding it pass to app.WithPlugin.
+func Register() (string, frameworkruntime.PluginFactory) {
+	return Name, New
+}
+
 // New initializes and returns a new CustomScheduler plugin.
 func New(obj runtime.Object, h framework.Handle) (framework.Plugin, error) {
+	return NewWithOptions(obj, h)
+}
+
+// NewWithOptions is New with options replacing what the plugin takes from the
+// handle, such as its listers, clock and event recorder.
+func NewWithOptions(obj runtime.Object, h framework.Handle, opts ...Option) (framework.Plugin, error) {
+	args, err := decodeArgs(obj)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateCustomSchedulerArgs(args); err != nil {
+		return nil, err
+	}
+	mode := args.Mode
+	// validated above
+	defaultMemoryRequest, _ := resource.ParseQuantity(args.DefaultMemoryRequest)
+	randomSeed := time.Now().UnixNano()
+	if args.RandomSeed != nil {
+		randomSeed = *args.RandomSeed
+	}
+
 	cs := CustomScheduler{}
-	mode := leastMode
-	if obj != nil {
-		args := obj.(*runtime.Unknown)
-		var csArgs CustomSchedulerArgs
-		if err := json.Unmarshal(args.Raw, &csArgs); err != nil {
-			fmt.Printf("Error unmarshal: %v\n", err)
+	cs.handle = h
+	cs.args = args
+	cs.scoreMode = mode
+	cs.resourceName = v1.ResourceName(args.ResourceName)
+	cs.defaultMemoryRequest = defaultMemoryRequest
+	cs.groupAffinityWeight = args.GroupAffinityWeight
+	cs.groupAffinityBonus = args.GroupAffinityBonus
+	cs.spreadGroup = args.SpreadGroup
+	cs.domainTopologyKey = args.DomainTopologyKey
+	cs.resources = args.Resources
+	cs.deterministicTieBreak = args.DeterministicTieBreak
+	cs.memoryPressurePenalty = *args.MemoryPressurePenalty
+	cs.diskPressurePenalty = *args.DiskPressurePenalty
+	cs.capacityPolicy = args.CapacityPolicy
+	cs.scoreBasis = args.ScoreBasis
+	cs.normalizationStrategy = args.NormalizationStrategy
+	cs.dryRunMode = args.DryRunMode
+	cs.annotateMemberNodes = args.AnnotateMemberNodes
+	cs.memberNodesMetric = args.MemberNodesMetric
+	cs.tierLabelKey = args.TierLabelKey
+	cs.shape = args.Shape
+	cs.modeByQoS = args.ModeByQoS
+	cs.scoreComponents = args.ScoreComponents
+	cs.tierBonus = args.TierBonus
+	cs.allowRandomMode = args.AllowRandomMode
+	cs.randomSeed = randomSeed
+	cs.explainScores = args.ExplainScores
+	cs.gangFilterDisabled = !*args.EnableGangFilter
+	cs.scoringDisabled = !*args.EnableScoring
+	cs.memorySafetyMargin, cs.memorySafetyMarginPercent, _ = parseMemorySafetyMargin(args.MemorySafetyMargin)
+	cs.nodeHeadroomBytes = args.NodeHeadroomBytes
+	if args.AllowRandomMode {
+		klog.InfoS("Random mode allowed", "seed", randomSeed)
+	}
+	cs.clusterWideGroups = args.ClusterWideGroups
+	cs.permitWaitingTime = time.Duration(args.PermitWaitingTimeSeconds) * time.Second
+	cs.groupBackoff = time.Duration(args.GroupBackoffSeconds) * time.Second
+	cs.maxGroupAttempts = args.MaxGroupAttempts
+	cs.groupLabelKey = args.GroupLabelKey
+	cs.minAvailableLabelKey = args.MinAvailableLabelKey
+	cs.minAvailableAnnotationKey = args.MinAvailableAnnotationKey
+	cs.maxAvailableLabelKey = args.MaxAvailableLabelKey
+	cs.coschedulingLabels = args.CoschedulingLabels
+	cs.maxMinAvailable = args.MaxMinAvailable
+	cs.gangCountPolicy = args.GangCountPolicy
+	cs.gangTimeout = time.Duration(args.GangTimeoutSeconds) * time.Second
+	cs.gangTimeoutBestEffort = args.GangTimeoutBestEffort
+	cs.bestEffortGrace = time.Duration(args.BestEffortGraceSeconds) * time.Second
+	cs.maxConcurrentGroups = args.MaxConcurrentGroupsPerNamespace
+	if cs.maxConcurrentGroups > 0 {
+		cs.admission = newGroupAdmission()
+	}
+	cs.maxReleasingGroups = args.MaxConcurrentReleasingGroups
+	if cs.maxReleasingGroups > 0 {
+		cs.releases = newReleaseQueue()
+	}
+	cs.conflictPolicy = args.ConflictPolicy
+	cs.checkGroupResources = args.CheckGroupResources
+	cs.priorityAdmission = args.PriorityAdmission
+	cs.nodePrefiltering = args.EnableNodePrefiltering
+	cs.groupPreemption = args.EnableGroupPreemption
+	cs.eventRecorder = h.EventRecorder()
+	cs.clock = clock.RealClock{}
+	cs.groups = make(map[string]*groupState)
+	cs.stopCh = make(chan struct{})
+	for _, opt := range opts {
+		opt(&cs)
+	}
+	cs.minAvailableFromOwner = args.MinAvailableFromOwner
+	cs.jobLister = h.SharedInformerFactory().Batch().V1().Jobs().Lister()
+	cs.statefulSetLister = h.SharedInformerFactory().Apps().V1().StatefulSets().Lister()
+	cs.pdbLister = h.SharedInformerFactory().Policy().V1().PodDisruptionBudgets().Lister()
+	cs.namespaceLister = h.SharedInformerFactory().Core().V1().Namespaces().Lister()
+	if args.RespectResourceQuota {
+		cs.quotaLister = h.SharedInformerFactory().Core().V1().ResourceQuotas().Lister()
+	}
+	cs.groupMgr = newGroupManager(&cs, h.SharedInformerFactory())
+	if cs.podListerOverride == nil && *args.CacheSyncTimeoutSeconds > 0 {
+		cs.podsSynced = &cacheSync{
+			hasSynced: h.SharedInformerFactory().Core().V1().Pods().Informer().HasSynced,
+			deadline:  cs.now().Add(time.Duration(*args.CacheSyncTimeoutSeconds) * time.Second),
 		}
-		mode = csArgs.Mode
-		if mode != leastMode && mode != mostMode {
-			return nil, fmt.Errorf("invalid mode, got %s", mode)
+	}
+	if cs.capacityPolicy == capacityLimits {
+		cs.limitCache = newLimitCache()
+	}
+	cs.registerEventHandlers(h.SharedInformerFactory())
+	if args.UseActualUsage {
+		if err := cs.setupUsageCache(h, time.Duration(args.UsageRefreshSeconds)*time.Second, time.Duration(args.UsageStaleSeconds)*time.Second); err != nil {
+			return nil, err
 		}
 	}
-	cs.handle = h
-	cs.scoreMode = mode
-	log.Printf("Custom scheduler runs with the mode: %s.", mode)
+	if args.EnablePodGroupCRD {
+		if err := cs.setupPodGroupCRD(h); err != nil {
+			return nil, err
+		}
+	}
+	if args.ConfigMapRef != nil {
+		cs.setupConfigReload(h, args)
+	}
+	if args.UseSchedulingGates {
+		cs.setupGateController(h.SharedInformerFactory().Core().V1().Pods().Informer())
+	}
+	if args.EnableConfigz {
+		cs.setupConfigz()
+	}
+	RegisterMetrics()
+	klog.InfoS("Custom scheduler created", "mode", mode)
+	cs.publishEffectiveConfig("created")
 
 	return &cs, nil
 }
 
 // filter the pod if the pod in group is less than minAvailable
-func (cs *CustomScheduler) PreFilter(ctx context.Context, state *framework.CycleState, pod *v1.Pod) (*framework.PreFilterResult, *framework.Status) {
-	log.Printf("Pod %s is in Prefilter phase.", pod.Name)
+func (cs *CustomScheduler) PreFilter(ctx context.Context, state *framework.CycleState, pod *v1.Pod) (_ *framework.PreFilterResult, status *framework.Status) {
+	ctx, span := cs.startSpan(ctx, "PreFilter", pod)
+	defer func() { endSpan(span, status) }()
+	defer recoverPanic("PreFilter", &status)
+	logger := klog.FromContext(ctx)
+	logger.V(5).Info("PreFilter", "pod", klog.KObj(pod))
+	defer func(start time.Time) { preFilterDuration.Observe(time.Since(start).Seconds()) }(time.Now())
 	newStatus := framework.NewStatus(framework.Success, "")
+	if cs.gangFilterDisabled {
+		return nil, newStatus
+	}
 
 	// TODO
 	// 1. extract the label of the pod
 	// 2. retrieve the pod with the same group label
 	// 3. justify if the pod can be scheduled
-	podLabels := pod.ObjectMeta.Labels
-	groupLabelValue := podLabels[groupNameLabel]
-	minAvailableValue := podLabels[minAvailableLabel]
-	log.Printf("groupLabel: %s", groupLabelValue)
-	log.Printf("minAvailable: %s", minAvailableValue)
-
-	minAvailable, err := strconv.Atoi(minAvailableValue)
+	groups := cs.groupManager().ForCycle(state)
+	groupLabelValue, minAvailable, isGang, err := groups.requirement(pod)
+	if !isGang {
+		// pods outside of a gang have nothing to wait for
+		logger.V(5).Info("Pod has no gang requirement", "pod", klog.KObj(pod))
+		return nil, newStatus
+	}
+	logger.V(4).Info("Checking the group of the pod", "pod", klog.KObj(pod), "group", groupLabelValue, "minAvailable", minAvailable)
+	rejection := rejectionOther
+	defer func() {
+		if !status.IsSuccess() {
+			groupPreFilterRejections.WithLabelValues(cs.groupMetricLabels(pod.Namespace, groupLabelValue)...).Inc()
+			preFilterRejections.WithLabelValues(rejection).Inc()
+		}
+	}()
+	if !cs.podCacheSynced() {
+		rejection = rejectionCacheNotSynced
+		return nil, framework.NewStatus(framework.Unschedulable, "pod cache not synced yet")
+	}
 	if err != nil {
-		return nil, framework.NewStatus(framework.Error, fmt.Sprintf("Invalid minAvailable value: %v", err))
+		rejection = rejectionInvalidMinAvailable
+		return nil, invalidMinAvailableStatus(err)
+	}
+	key := cs.groupKey(pod.Namespace, groupLabelValue)
+	if until := cs.breakerOpenUntil(key); !until.IsZero() {
+		return nil, framework.NewStatus(framework.UnschedulableAndUnresolvable, fmt.Sprintf("group '%s' failed %d attempts in a row and is held back until %s", groupLabelValue, cs.maxGroupAttempts, until.Format(time.RFC3339)))
+	}
+	if reason := cs.blockedReason(key); reason != "" {
+		return nil, framework.NewStatus(framework.Unschedulable, fmt.Sprintf("group '%s' is blocked: %s", groupLabelValue, reason))
+	}
+	if cs.gangTimedOut(key) {
+		if cs.gangTimeoutBestEffort {
+			logger.V(2).Info("Group timed out, scheduling the pod on its own", "pod", klog.KObj(pod), "group", groupLabelValue)
+			return nil, newStatus
+		}
+		return nil, framework.NewStatus(framework.UnschedulableAndUnresolvable, fmt.Sprintf("group '%s' did not reach minAvailable %d within %v", groupLabelValue, minAvailable, cs.gangTimeout))
 	}
 
-	selector := labels.SelectorFromSet(map[string]string{groupNameLabel: groupLabelValue})
-	pods, err := cs.handle.SharedInformerFactory().Core().V1().Pods().Lister().List(selector)
+	var pods []*v1.Pod
+	err = retryLister(ctx, func() (err error) {
+		pods, err = groups.Members(pod.Namespace, groupLabelValue)
+		return err
+	})
 	if err != nil {
-		return nil, framework.NewStatus(framework.Error, fmt.Sprintf("Failed to list pods: %v", err))
+		// the pod is retried like any other rejected pod instead of
+		// counting as a scheduler error
+		rejection = rejectionListerError
+		return nil, framework.NewStatus(framework.Unschedulable, fmt.Sprintf("Failed to list pods: %v", err))
+	}
+	groupSize := len(pods)
+	if pods = sameSchedulerPods(pod, pods); len(pods) < groupSize {
+		// a group split across schedulers is most likely a manifest bug
+		logger.V(2).Info("Group has pods handed to other schedulers", "group", groupLabelValue, "pods", groupSize-len(pods), "schedulerName", pod.Spec.SchedulerName)
+		mixedSchedulerGroups.Inc()
+	}
+	// a PodGroup is the single source of minAvailable, so there is nothing to
+	// disagree on
+	if _, ok := cs.podGroupMinMember(pod.Namespace, groupLabelValue); !ok {
+		var status *framework.Status
+		if minAvailable, status = cs.resolveMinAvailable(groups, pod, groupLabelValue, minAvailable, pods); status != nil {
+			return nil, status
+		}
+	}
+	if status := cs.checkMaxAvailable(pod, groupLabelValue, pods); status != nil {
+		return nil, status
+	}
+
+	gangState := &preFilterState{
+		namespace:    pod.Namespace,
+		group:        groupLabelValue,
+		minAvailable: minAvailable,
+		members:      sets.New[string](),
+	}
+	listed := sets.New[string]()
+	for _, p := range pods {
+		listed.Insert(podKey(p))
+		if isActivePod(p) {
+			gangState.members.Insert(podKey(p))
+		}
+	}
+	// members reserved in an earlier cycle count even when the informer
+	// doesn't show them yet
+	for _, member := range cs.assumedMembers(key) {
+		if !listed.Has(member.key) {
+			gangState.members.Insert(member.key)
+		}
+	}
+	if state != nil {
+		state.Write(preFilterStateKey, gangState)
+		state.Write(GroupStateKey, newGroupState(gangState))
 	}
 
-	if len(pods) < minAvailable {
-		log.Println("pods is not available")
-		return nil, framework.NewStatus(framework.Unschedulable, fmt.Sprintf("Pod cannot be scheduled because the group '%s' has only %d pods, but needs %d", groupLabelValue, len(pods), minAvailable))
+	activePods := gangState.members.Len()
+	metricLabels := cs.groupMetricLabels(pod.Namespace, groupLabelValue)
+	groupMembers.WithLabelValues(metricLabels...).Set(float64(activePods))
+	groupMinAvailable.WithLabelValues(metricLabels...).Set(float64(minAvailable))
+	if status := cs.checkResourceQuota(pod, groupLabelValue, pods, minAvailable); status != nil {
+		rejection = rejectionQuotaExceeded
+		return nil, status
+	}
+	if activePods < minAvailable && cs.bestEffortReleased(pod, groupLabelValue) {
+		cs.recordBestEffortRelease(pod, groupLabelValue, activePods, minAvailable)
+		return nil, newStatus
+	}
+	if activePods < minAvailable {
+		logger.V(4).Info("Group has too few members", "pod", klog.KObj(pod), "group", groupLabelValue, "members", activePods, "minAvailable", minAvailable)
+		cs.recordGroupNotReady(pod, groupLabelValue, activePods, minAvailable)
+		rejection = rejectionGroupIncomplete
+		replicas, owner, hasOwner := cs.ownerReplicas(pod)
+		// retrying is pointless when the owner will never create enough pods
+		if hasOwner && replicas < minAvailable {
+			return nil, framework.NewStatus(framework.UnschedulableAndUnresolvable, fmt.Sprintf("Pod cannot be scheduled because the group '%s' needs %d pods, but %s only wants %d", groupLabelValue, minAvailable, owner, replicas))
+		}
+		msg := fmt.Sprintf("Pod cannot be scheduled because the group '%s' has only %d pods, but needs %d", groupLabelValue, activePods, minAvailable)
+		msg += cs.describeMembers(pods)
+		if hasOwner && replicas > activePods {
+			msg += fmt.Sprintf("; %s has %d pods left to create", owner, replicas-activePods)
+		}
+		return nil, framework.NewStatus(framework.Unschedulable, msg)
+	}
+	cs.activateSiblings(state, pod, pods)
+	if status := cs.checkMinResources(pod, groupLabelValue, pods); status != nil {
+		rejection = rejectionGroupIncomplete
+		return nil, status
+	}
+	cs.registerContender(pod, groupLabelValue, minAvailable)
+	if status := cs.admitByPriority(pod, groupLabelValue, pods); status != nil {
+		return nil, status
+	}
+	if status := cs.admitGroup(pod, groupLabelValue, minAvailable); status != nil {
+		return nil, status
+	}
+	if cs.checkGroupResources {
+		if status := cs.groupResourcesFit(groupLabelValue, pods); status != nil {
+			return nil, status
+		}
+	}
+	if state != nil {
+		domains, status := cs.newDomainState(state, pod)
+		if status != nil {
+			return nil, status
+		}
+		if domains != nil {
+			state.Write(domainStateKey, domains)
+		}
+	}
+	if cs.nodePrefiltering {
+		if nodes := cs.candidateNodes(state, pod, groupLabelValue, pods); nodes != nil {
+			return &framework.PreFilterResult{NodeNames: nodes}, newStatus
+		}
 	}
 	return nil, newStatus
 }
 
+// maxListedMembers bounds the number of member names in a rejection message.
+const maxListedMembers = 10
+
+// describeMembers summarizes the live members of a group for a rejection
+// message: how many are running, pending and not yet scheduled, followed by
+// their names.
+func (cs *CustomScheduler) describeMembers(pods []*v1.Pod) string {
+	var running, pending, unscheduled int
+	var names []string
+	for _, p := range pods {
+		if !isActivePod(p) {
+			continue
+		}
+		if p.Status.Phase == v1.PodRunning {
+			running++
+		} else {
+			pending++
+		}
+		if p.Spec.NodeName == "" {
+			unscheduled++
+		}
+		names = append(names, cs.memberName(p))
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	if len(names) > maxListedMembers {
+		names = append(names[:maxListedMembers], fmt.Sprintf("and %d more", len(names)-maxListedMembers))
+	}
+	return fmt.Sprintf(" (%d running, %d pending, %d unscheduled: %s)", running, pending, unscheduled, strings.Join(names, ", "))
+}
+
+// recordGroupNotReady emits a Warning event on the pod so that its owners can
+// see why it is held back.
+func (cs *CustomScheduler) recordGroupNotReady(pod *v1.Pod, group string, members, minAvailable int) {
+	if cs.eventRecorder == nil {
+		return
+	}
+	cs.eventRecorder.Eventf(pod, nil, v1.EventTypeWarning, "GroupNotReady", "Scheduling",
+		"Group '%s' has %d of the %d pods it needs", group, members, minAvailable)
+}
+
+// parseMinAvailableValue validates a minAvailable value without resolving
+// percentages. It returns either a count between 1 and limit or, when
+// isPercent is set, a percentage between 1 and 100.
+func parseMinAvailableValue(value string, limit int) (n int, isPercent bool, err error) {
+	number, isPercent := strings.CutSuffix(value, "%")
+	upper := limit
+	if isPercent {
+		upper = 100
+	}
+	n, err = strconv.Atoi(number)
+	if err != nil || n < 1 || n > upper {
+		return 0, false, &invalidMinAvailableError{value: value, limit: limit}
+	}
+	return n, isPercent, nil
+}
+
+// invalidMinAvailableStatus returns the status for a pod whose minAvailable
+// can't be used. An invalid value is reported as Unschedulable so the pod is
+// retried once the value is fixed.
+func invalidMinAvailableStatus(err error) *framework.Status {
+	code := framework.Error
+	var invalid *invalidMinAvailableError
+	if errors.As(err, &invalid) {
+		code = framework.Unschedulable
+	}
+	return framework.NewStatus(code, fmt.Sprintf("Invalid minAvailable value: %v", err))
+}
+
+// gangOptedOut reports whether the pod is excluded from the gang check by the
+// gang annotation.
+func gangOptedOut(pod *v1.Pod) bool {
+	switch pod.Annotations[gangAnnotation] {
+	case gangDisabled, gangIgnored:
+		return true
+	}
+	return false
+}
+
+// withoutIgnoredPods drops the pods that don't count toward their group.
+func withoutIgnoredPods(pods []*v1.Pod) []*v1.Pod {
+	filtered := make([]*v1.Pod, 0, len(pods))
+	for _, p := range pods {
+		if p.Annotations[gangAnnotation] != gangIgnored {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+// sameSchedulerPods returns the pods that are handed to the same scheduler as
+// pod. Members assigned to another scheduler are never co-scheduled with it
+// and so can't count toward minAvailable.
+func sameSchedulerPods(pod *v1.Pod, pods []*v1.Pod) []*v1.Pod {
+	filtered := make([]*v1.Pod, 0, len(pods))
+	for _, p := range pods {
+		if p.Spec.SchedulerName == pod.Spec.SchedulerName {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+// countActivePods returns the number of pods that are still alive. Pods left
+// over from a previous run of the group must not count toward minAvailable.
+func countActivePods(pods []*v1.Pod) int {
+	count := 0
+	for _, p := range pods {
+		if isActivePod(p) {
+			count++
+		}
+	}
+	return count
+}
+
+// isActivePod reports whether the pod is Pending or Running and not being
+// deleted. A terminating pod is never counted, however long its grace period,
+// since it won't be around when the rest of the group starts.
+func isActivePod(p *v1.Pod) bool {
+	if p.DeletionTimestamp != nil {
+		return false
+	}
+	switch p.Status.Phase {
+	case "", v1.PodPending, v1.PodRunning:
+		return true
+	}
+	return false
+}
+
 // PreFilterExtensions returns a PreFilterExtensions interface if the plugin implements one.
 func (cs *CustomScheduler) PreFilterExtensions() framework.PreFilterExtensions {
+	return cs
+}
+
+// AddPod keeps the group members counted in PreFilter up to date when the
+// framework adds a pod, e.g. while simulating preemption.
+func (cs *CustomScheduler) AddPod(ctx context.Context, state *framework.CycleState, podToSchedule *v1.Pod, podInfoToAdd *framework.PodInfo, nodeInfo *framework.NodeInfo) *framework.Status {
+	s, err := getPreFilterState(state)
+	if err != nil || s == nil {
+		return framework.AsStatus(err)
+	}
+	if p := podInfoToAdd.Pod; cs.inGroup(p, s.namespace, s.group) && isActivePod(p) {
+		s.members.Insert(podKey(p))
+	}
+	return nil
+}
+
+// RemovePod keeps the group members counted in PreFilter up to date when the
+// framework removes a pod, e.g. a preemption victim of the same group.
+func (cs *CustomScheduler) RemovePod(ctx context.Context, state *framework.CycleState, podToSchedule *v1.Pod, podInfoToRemove *framework.PodInfo, nodeInfo *framework.NodeInfo) *framework.Status {
+	s, err := getPreFilterState(state)
+	if err != nil || s == nil {
+		return framework.AsStatus(err)
+	}
+	if p := podInfoToRemove.Pod; cs.inGroup(p, s.namespace, s.group) {
+		s.members.Delete(podKey(p))
+	}
 	return nil
 }
-func RemoveSubstring(s, sep string) string {
-	if idx := strings.Index(s, sep); idx != -1 {
-		return s[:idx]
+
+const preFilterStateKey = framework.StateKey("PreFilter" + Name)
+
+// preFilterState is the group of the pod being scheduled as seen in PreFilter.
+type preFilterState struct {
+	namespace    string
+	group        string
+	minAvailable int
+	// members are the keys of the live pods counted toward minAvailable.
+	members sets.Set[string]
+}
+
+// Clone implements framework.StateData.
+func (s *preFilterState) Clone() framework.StateData {
+	return &preFilterState{
+		namespace:    s.namespace,
+		group:        s.group,
+		minAvailable: s.minAvailable,
+		members:      s.members.Clone(),
+	}
+}
+
+// getPreFilterState returns the state written by PreFilter, or nil if the pod
+// being scheduled isn't a gang member.
+func getPreFilterState(state *framework.CycleState) (*preFilterState, error) {
+	if state == nil {
+		return nil, nil
+	}
+	c, err := state.Read(preFilterStateKey)
+	if errors.Is(err, framework.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s, ok := c.(*preFilterState)
+	if !ok {
+		return nil, fmt.Errorf("%+v convert to CustomScheduler.preFilterState error", c)
 	}
-	return s
+	return s, nil
+}
+
+// podKey identifies a pod within the members of a group.
+func podKey(p *v1.Pod) string {
+	return p.Namespace + "/" + p.Name
 }
 
 // Score invoked at the score extension point.
-func (cs *CustomScheduler) Score(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) (int64, *framework.Status) {
-	log.Printf("Pod %s is in Score phase. Calculate the score of Node %s.", pod.Name, nodeName)
+func (cs *CustomScheduler) Score(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) (_ int64, status *framework.Status) {
+	ctx, span := cs.startSpan(ctx, "Score", pod, attribute.String("node", nodeName))
+	defer func() { endSpan(span, status) }()
+	defer recoverPanic("Score", &status)
+	logger := klog.FromContext(ctx)
+	start := time.Now()
 
 	// TODO
-	// 1. retrieve the node allocatable memory
+	// 1. retrieve the node info
 	// 2. return the score based on the scheduler mode
-	nodeInfo, err := cs.handle.SnapshotSharedLister().NodeInfos().Get(nodeName)
+	nodeInfo, err := cs.nodeInfoLister().Get(nodeName)
+	if err != nil {
+		// the snapshot only fails for the nodes it doesn't hold, which would
+		// abort the cycle as a failed Score; NormalizeScore gives the node
+		// the minimum score instead
+		logger.V(4).Info("Giving the node the minimum score", "pod", klog.KObj(pod), "node", nodeName, "reason", "node not found", "err", err)
+		return framework.MinNodeScore, nil
+	}
+	if reason := unscorableNode(nodeInfo); reason != "" {
+		// NormalizeScore gives the node the minimum score
+		logger.V(4).Info("Giving the node the minimum score", "pod", klog.KObj(pod), "node", nodeName, "reason", reason)
+		return framework.MinNodeScore, nil
+	}
+	s, err := cs.getPreScoreState(state, pod)
 	if err != nil {
-		log.Printf("Failed to get node info for node %s: %v", nodeName, err)
-		return 0, framework.NewStatus(framework.Error, err.Error())
-	}
-	allocatableMemory := nodeInfo.Allocatable.Memory
-	log.Printf("original allocatableMemory = %d", allocatableMemory)
-	// var hasUsed int64 = 0
-	// for _, podinNode := range nodeInfo.Pods {
-	// 	// result := RemoveSubstring(podinNode.Pod.Spec.InitContainers[0].Resources.Limits.Memory().Format, "Mi")
-	// 	hasUsed = podinNode.Pod.Spec.Containers[0].Resources.Limits.Memory().Value()
-	// 	log.Printf("Pod %s Memory Resources Limit: %d", podinNode.Pod.ObjectMeta.Name, hasUsed)
-	// }
-
-	allocatableMemory -= nodeInfo.Requested.Memory
-	log.Printf("node %s has been requested memory = %d", nodeName, nodeInfo.Requested.Memory)
-
-	mode := cs.scoreMode
-	var score int64 = 0
-	log.Printf("score Mode: %s", mode)
-	log.Printf("node %s now can be allocated memory: %d", nodeName, allocatableMemory)
-	if mode == leastMode {
-		score = 100000000000 / allocatableMemory
-	} else {
-		score = allocatableMemory
-	}
-	log.Printf("Node %s score is %d.", nodeName, score)
-	log.Println()
+		return 0, framework.AsStatus(err)
+	}
+	defer func() { scoreDuration.WithLabelValues(s.mode).Observe(time.Since(start).Seconds()) }()
+	cs.scoreDryRun(state, s, nodeInfo)
+	span.SetAttributes(attribute.String("mode", s.mode))
+	score := cs.modeScorer(s.mode, pod).subScore(s, nodeInfo)
+	logger.V(5).Info("Scored the node", "pod", klog.KObj(pod), "node", nodeName, "mode", s.mode, "score", score)
 
 	return score, nil
 }
 
 // ensure the scores are within the valid range
-func (cs *CustomScheduler) NormalizeScore(ctx context.Context, state *framework.CycleState, pod *v1.Pod, scores framework.NodeScoreList) *framework.Status {
+func (cs *CustomScheduler) NormalizeScore(ctx context.Context, state *framework.CycleState, pod *v1.Pod, scores framework.NodeScoreList) (status *framework.Status) {
+	_, span := cs.startSpan(ctx, "NormalizeScore", pod)
+	defer func() { endSpan(span, status) }()
+	defer recoverPanic("NormalizeScore", &status)
+	defer func(start time.Time) { normalizeScoreDuration.Observe(time.Since(start).Seconds()) }(time.Now())
 	// TODO
 	// find the range of the current score and map to the valid range
-	minScore := int64(math.MaxInt64)
-	maxScore := int64(math.MinInt64)
-
+	if len(scores) == 0 || scoreSkipped(state) {
+		return nil
+	}
+	s, err := cs.getPreScoreState(state, pod)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	span.SetAttributes(attribute.String("mode", s.mode))
+	var rawScores map[string]int64
+	if cs.explainScores {
+		rawScores = make(map[string]int64, len(scores))
+		for _, nodeScore := range scores {
+			rawScores[nodeScore.Name] = nodeScore.Score
+		}
+	}
+	unfit := cs.unfitNodes(s, scores)
+	fitScores := make([]int64, 0, len(scores))
 	for _, nodeScore := range scores {
-		if nodeScore.Score < minScore {
-			minScore = nodeScore.Score
+		if !unfit[nodeScore.Name] {
+			fitScores = append(fitScores, nodeScore.Score)
+		}
+	}
+	normalize := newScoreNormalizer(cs.normalizationStrategy, fitScores, invertsScores(s.mode))
+	for i := range scores {
+		if unfit[scores[i].Name] {
+			scores[i].Score = framework.MinNodeScore
+			continue
 		}
-		if nodeScore.Score > maxScore {
-			maxScore = nodeScore.Score
+		if s.mode == shapedMode || s.mode == compositeMode {
+			// the shape and the components already give scores in the
+			// node score range, which rescaling would distort
+			scores[i].Score = clampScore(scores[i].Score)
+			continue
 		}
+		scores[i].Score = normalize(scores[i].Score)
 	}
-
-	if minScore == maxScore {
-		for i := range scores {
-			scores[i].Score = framework.MaxNodeScore
+	// the Composite mode weighs the group locality and the tiers itself
+	if cs.groupAffinityWeight > 0 && s.mode != compositeMode {
+		cs.addGroupAffinity(scores, unfit, s.memberNodes)
+	}
+	// nodes are only weighted, given a bonus and penalized when PreScore ran
+	if s, _ := readPreScoreState(state); s != nil {
+		applyNodeWeights(scores, s.nodeWeights)
+		if s.mode != compositeMode {
+			applyTierBonuses(scores, unfit, s.nodeBonuses)
 		}
-		return framework.NewStatus(framework.Success, "")
+		applyNodePenalties(scores, s.nodePenalties)
 	}
-
-	scoreRange := maxScore - minScore
-	frameRange := framework.MaxNodeScore - framework.MinNodeScore
-	for i := range scores {
-		scores[i].Score = ((scores[i].Score-minScore)*frameRange)/scoreRange + framework.MinNodeScore
+	applyDomainPreferences(scores, unfit, s.domainPreferences)
+	if cs.deterministicTieBreak {
+		breakTies(pod, scores)
+	}
+	if cs.explainScores && state != nil {
+		recordScores(state, rawScores, scores)
 	}
 
 	return framework.NewStatus(framework.Success, "")
 	// return nil
 }
 
-// ScoreExtensions of the Score plugin.
+// clampScore bounds a score to the valid range.
+func clampScore(score int64) int64 {
+	if score < framework.MinNodeScore {
+		return framework.MinNodeScore
+	}
+	if score > framework.MaxNodeScore {
+		return framework.MaxNodeScore
+	}
+	return score
+}
+
+// ScoreExtensions of the Score plugin. The mode is picked per pod, so
+// NormalizeScore is always run and leaves the scores of the Shaped mode as
+// they are.
 func (cs *CustomScheduler) ScoreExtensions() framework.ScoreExtensions {
 	return cs
 }
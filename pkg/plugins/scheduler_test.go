@@ -5,15 +5,20 @@ import (
 	"fmt"
 	"reflect"
 	"math"
+	"math/rand"
 	"testing"
+	"time"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
 	clientsetfake "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/events"
 	st "k8s.io/kubernetes/pkg/scheduler/testing"
 	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	fakeframework "k8s.io/kubernetes/pkg/scheduler/framework/fake"
 	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/queuesort"
@@ -109,6 +114,8 @@ func TestCustomScheduler_PreFilter(t *testing.T) {
 			cs := &CustomScheduler{
 				handle: fh,
 				scoreMode: leastMode,
+				groupLabelKey: groupNameLabel,
+				minAvailableLabelKey: minAvailableLabel,
 			}
 			
 			podList := []*v1.Pod{}
@@ -142,6 +149,1068 @@ func TestCustomScheduler_PreFilter(t *testing.T) {
 	}
 }
 
+func TestCustomScheduler_PreFilter_PodPhase(t *testing.T) {
+	now := metav1.Now()
+	makePod := func(name string, phase v1.PodPhase, deleting bool) *v1.Pod {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{"podGroup": "g1"},
+			},
+			Status: v1.PodStatus{Phase: phase},
+		}
+		if deleting {
+			pod.DeletionTimestamp = &now
+		}
+		return pod
+	}
+	existing := []*v1.Pod{
+		makePod("succeeded", v1.PodSucceeded, false),
+		makePod("failed", v1.PodFailed, false),
+		makePod("terminating", v1.PodRunning, true),
+		makePod("running", v1.PodRunning, false),
+		makePod("pending", v1.PodPending, false),
+	}
+	tests := []struct {
+		name         string
+		minAvailable string
+		want         framework.Code
+		wantMessage  string
+	}{
+		{
+			name:         "only live pods are counted",
+			minAvailable: "2",
+			want:         framework.Success,
+		},
+		{
+			name:         "dead pods do not satisfy minAvailable",
+			minAvailable: "3",
+			want:         framework.Unschedulable,
+			wantMessage:  "Pod cannot be scheduled because the group 'g1' has only 2 pods, but needs 3 (1 running, 1 pending, 2 unscheduled: pending, running)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:               newTestFrameworkWithPods(t, existing),
+				scoreMode:            leastMode,
+				groupLabelKey:        groupNameLabel,
+				minAvailableLabelKey: minAvailableLabel,
+			}
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "incoming",
+					Labels: map[string]string{
+						"podGroup":     "g1",
+						"minAvailable": tt.minAvailable,
+					},
+				},
+			}
+			_, status := cs.PreFilter(context.Background(), nil, pod)
+			if status.Code() != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, status.Code())
+			}
+			if tt.wantMessage != "" && status.Message() != tt.wantMessage {
+				t.Errorf("expected message %q, got %q", tt.wantMessage, status.Message())
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_PreFilter_Terminating(t *testing.T) {
+	makePod := func(name string, gracePeriod *int64) *v1.Pod {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{"podGroup": "g1"},
+			},
+			Status: v1.PodStatus{Phase: v1.PodRunning},
+		}
+		if gracePeriod != nil {
+			deletion := metav1.NewTime(time.Now().Add(time.Duration(*gracePeriod) * time.Second))
+			pod.DeletionTimestamp = &deletion
+			pod.DeletionGracePeriodSeconds = gracePeriod
+		}
+		return pod
+	}
+	short, long := int64(2), int64(300)
+	tests := []struct {
+		name     string
+		existing []*v1.Pod
+		want     framework.Code
+	}{
+		{
+			name:     "healthy members alone reach minAvailable",
+			existing: []*v1.Pod{makePod("healthy0", nil), makePod("healthy1", nil), makePod("healthy2", nil), makePod("leaving", &short)},
+			want:     framework.Success,
+		},
+		{
+			name:     "pod about to be gone is not counted",
+			existing: []*v1.Pod{makePod("healthy0", nil), makePod("healthy1", nil), makePod("leaving", &short)},
+			want:     framework.Unschedulable,
+		},
+		{
+			name:     "pod with a long grace period is not counted",
+			existing: []*v1.Pod{makePod("healthy0", nil), makePod("healthy1", nil), makePod("leaving", &long)},
+			want:     framework.Unschedulable,
+		},
+		{
+			name:     "restarting group is not admitted against its old members",
+			existing: []*v1.Pod{makePod("old0", &long), makePod("old1", &long), makePod("old2", &long), makePod("new0", nil)},
+			want:     framework.Unschedulable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:               newTestFrameworkWithPods(t, tt.existing),
+				scoreMode:            leastMode,
+				groupLabelKey:        groupNameLabel,
+				minAvailableLabelKey: minAvailableLabel,
+			}
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "incoming",
+					Labels: map[string]string{
+						"podGroup":     "g1",
+						"minAvailable": "3",
+					},
+				},
+			}
+			_, status := cs.PreFilter(context.Background(), nil, pod)
+			if status.Code() != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, status.Code())
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_PreFilter_Namespace(t *testing.T) {
+	var existing []*v1.Pod
+	for i, ns := range []string{"team-a", "team-b", "team-b"} {
+		existing = append(existing, &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("pod%d", i),
+				Namespace: ns,
+				Labels:    map[string]string{"podGroup": "exp1"},
+			},
+		})
+	}
+	tests := []struct {
+		name        string
+		clusterWide bool
+		want        framework.Code
+	}{
+		{
+			name:        "only pods in the same namespace are counted",
+			clusterWide: false,
+			want:        framework.Unschedulable,
+		},
+		{
+			name:        "cluster-wide groups count every namespace",
+			clusterWide: true,
+			want:        framework.Success,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:               newTestFrameworkWithPods(t, existing),
+				scoreMode:            leastMode,
+				groupLabelKey:        groupNameLabel,
+				minAvailableLabelKey: minAvailableLabel,
+				clusterWideGroups:    tt.clusterWide,
+			}
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "incoming",
+					Namespace: "team-a",
+					Labels: map[string]string{
+						"podGroup":     "exp1",
+						"minAvailable": "3",
+					},
+				},
+			}
+			_, status := cs.PreFilter(context.Background(), nil, pod)
+			if status.Code() != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, status.Code())
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_PreFilter_SchedulerName(t *testing.T) {
+	var existing []*v1.Pod
+	for i, schedulerName := range []string{"custom-scheduler", "default-scheduler", "default-scheduler"} {
+		existing = append(existing, &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   fmt.Sprintf("pod%d", i),
+				Labels: map[string]string{"podGroup": "g1"},
+			},
+			Spec: v1.PodSpec{SchedulerName: schedulerName},
+		})
+	}
+	tests := []struct {
+		name          string
+		schedulerName string
+		minAvailable  string
+		want          framework.Code
+		wantMessage   string
+	}{
+		{
+			name:          "members of another scheduler are not counted",
+			schedulerName: "custom-scheduler",
+			minAvailable:  "2",
+			want:          framework.Unschedulable,
+			wantMessage:   "Pod cannot be scheduled because the group 'g1' has only 1 pods, but needs 2 (0 running, 1 pending, 1 unscheduled: pod0)",
+		},
+		{
+			name:          "members of the same scheduler are counted",
+			schedulerName: "default-scheduler",
+			minAvailable:  "2",
+			want:          framework.Success,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:               newTestFrameworkWithPods(t, existing),
+				scoreMode:            leastMode,
+				groupLabelKey:        groupNameLabel,
+				minAvailableLabelKey: minAvailableLabel,
+			}
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "incoming",
+					Labels: map[string]string{"podGroup": "g1", "minAvailable": tt.minAvailable},
+				},
+				Spec: v1.PodSpec{SchedulerName: tt.schedulerName},
+			}
+			_, status := cs.PreFilter(context.Background(), nil, pod)
+			if status.Code() != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, status.Code())
+			}
+			if tt.wantMessage != "" && status.Message() != tt.wantMessage {
+				t.Errorf("expected message %q, got %q", tt.wantMessage, status.Message())
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_PreFilter_NoGang(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+	}{
+		{
+			name:   "no labels",
+			labels: nil,
+		},
+		{
+			name:   "only podGroup",
+			labels: map[string]string{"podGroup": "g1"},
+		},
+		{
+			name:   "only minAvailable",
+			labels: map[string]string{"minAvailable": "3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:               newTestFrameworkWithPods(t, nil),
+				scoreMode:            leastMode,
+				groupLabelKey:        groupNameLabel,
+				minAvailableLabelKey: minAvailableLabel,
+			}
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "incoming", Labels: tt.labels},
+			}
+			_, status := cs.PreFilter(context.Background(), nil, pod)
+			if !status.IsSuccess() {
+				t.Errorf("expected success, got %v", status)
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_PreFilter_GangOptOut(t *testing.T) {
+	makePod := func(name, minAvailable, gang string) *v1.Pod {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{"podGroup": "g1"},
+			},
+		}
+		if minAvailable != "" {
+			pod.Labels["minAvailable"] = minAvailable
+		}
+		if gang != "" {
+			pod.Annotations = map[string]string{"scheduler.nthu.io/gang": gang}
+		}
+		return pod
+	}
+	existing := []*v1.Pod{
+		makePod("member", "", ""),
+		makePod("sidecar", "5", "disabled"),
+		makePod("debug", "5", "ignored"),
+	}
+	tests := []struct {
+		name string
+		pod  *v1.Pod
+		want framework.Code
+	}{
+		{
+			name: "disabled pod skips the check",
+			pod:  makePod("sidecar", "5", "disabled"),
+			want: framework.Success,
+		},
+		{
+			name: "ignored pod skips the check",
+			pod:  makePod("debug", "5", "ignored"),
+			want: framework.Success,
+		},
+		{
+			name: "disabled pod counts toward its siblings",
+			pod:  makePod("incoming", "2", ""),
+			want: framework.Success,
+		},
+		{
+			name: "ignored pod does not count toward its siblings",
+			pod:  makePod("incoming", "3", ""),
+			want: framework.Unschedulable,
+		},
+		{
+			name: "unknown annotation value keeps the check",
+			pod:  makePod("incoming", "3", "off"),
+			want: framework.Unschedulable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:               newTestFrameworkWithPods(t, existing),
+				scoreMode:            leastMode,
+				groupLabelKey:        groupNameLabel,
+				minAvailableLabelKey: minAvailableLabel,
+			}
+			_, status := cs.PreFilter(context.Background(), nil, tt.pod)
+			if status.Code() != tt.want {
+				t.Errorf("expected %v, got %v: %s", tt.want, status.Code(), status.Message())
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_PreFilter_CustomLabelKeys(t *testing.T) {
+	var existing []*v1.Pod
+	for i := 0; i < 2; i++ {
+		existing = append(existing, &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   fmt.Sprintf("pod%d", i),
+				Labels: map[string]string{"scheduling.x-k8s.io/pod-group": "g1"},
+			},
+		})
+	}
+	fh := newTestFrameworkWithPods(t, existing)
+	args := &runtime.Unknown{Raw: []byte(`{"mode": "Least", "groupLabelKey": "scheduling.x-k8s.io/pod-group", "minAvailableLabelKey": "scheduling.x-k8s.io/min-available"}`)}
+	p, err := New(args, fh)
+	if err != nil {
+		t.Fatalf("fail to create plugin: %v", err)
+	}
+	cs := p.(*CustomScheduler)
+	cs.podsSynced = nil
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   framework.Code
+	}{
+		{
+			name: "group is complete",
+			labels: map[string]string{
+				"scheduling.x-k8s.io/pod-group":     "g1",
+				"scheduling.x-k8s.io/min-available": "2",
+			},
+			want: framework.Success,
+		},
+		{
+			name: "group is incomplete",
+			labels: map[string]string{
+				"scheduling.x-k8s.io/pod-group":     "g1",
+				"scheduling.x-k8s.io/min-available": "3",
+			},
+			want: framework.Unschedulable,
+		},
+		{
+			name: "default keys are ignored",
+			labels: map[string]string{
+				"podGroup":     "g1",
+				"minAvailable": "3",
+			},
+			want: framework.Success,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "incoming", Labels: tt.labels}}
+			_, status := cs.PreFilter(context.Background(), nil, pod)
+			if status.Code() != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, status.Code())
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_PreFilter_MinAvailableAnnotation(t *testing.T) {
+	var existing []*v1.Pod
+	for i := 0; i < 2; i++ {
+		existing = append(existing, &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   fmt.Sprintf("pod%d", i),
+				Labels: map[string]string{"podGroup": "g1"},
+			},
+		})
+	}
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		want        framework.Code
+		wantMessage string
+	}{
+		{
+			name:        "annotation is used when the label is absent",
+			labels:      map[string]string{"podGroup": "g1"},
+			annotations: map[string]string{"scheduler.nthu.io/min-available": "2"},
+			want:        framework.Success,
+		},
+		{
+			name:        "group is incomplete according to the annotation",
+			labels:      map[string]string{"podGroup": "g1"},
+			annotations: map[string]string{"scheduler.nthu.io/min-available": "3"},
+			want:        framework.Unschedulable,
+			wantMessage: "Pod cannot be scheduled because the group 'g1' has only 2 pods, but needs 3 (0 running, 2 pending, 2 unscheduled: pod0, pod1)",
+		},
+		{
+			name:        "label takes precedence over the annotation",
+			labels:      map[string]string{"podGroup": "g1", "minAvailable": "3"},
+			annotations: map[string]string{"scheduler.nthu.io/min-available": "2"},
+			want:        framework.Unschedulable,
+		},
+		{
+			name:        "malformed annotation is unschedulable",
+			labels:      map[string]string{"podGroup": "g1"},
+			annotations: map[string]string{"scheduler.nthu.io/min-available": "two"},
+			want:        framework.Unschedulable,
+		},
+		{
+			name:        "malformed label is unschedulable",
+			labels:      map[string]string{"podGroup": "g1", "minAvailable": "two"},
+			want:        framework.Unschedulable,
+			wantMessage: `Invalid minAvailable value: label minAvailable "two" is not a positive integer up to 10000 or a percentage between 1% and 100%`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:                    newTestFrameworkWithPods(t, existing),
+				scoreMode:                 leastMode,
+				groupLabelKey:             groupNameLabel,
+				minAvailableLabelKey:      minAvailableLabel,
+				minAvailableAnnotationKey: minAvailableAnnotation,
+			}
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "incoming",
+					Labels:      tt.labels,
+					Annotations: tt.annotations,
+				},
+			}
+			_, status := cs.PreFilter(context.Background(), nil, pod)
+			if status.Code() != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, status.Code())
+			}
+			if tt.wantMessage != "" && status.Message() != tt.wantMessage {
+				t.Errorf("expected message %q, got %q", tt.wantMessage, status.Message())
+			}
+		})
+	}
+}
+
+func TestParseMinAvailableValue(t *testing.T) {
+	tests := []struct {
+		value       string
+		want        int
+		wantPercent bool
+		wantErr     bool
+	}{
+		{value: "1", want: 1},
+		{value: "3", want: 3},
+		{value: "100", want: 100},
+		{value: "1%", want: 1, wantPercent: true},
+		{value: "100%", want: 100, wantPercent: true},
+		{value: "+5", want: 5},
+		{value: "101", wantErr: true},
+		{value: "0", wantErr: true},
+		{value: "-3", wantErr: true},
+		{value: "999999999999999999999", wantErr: true},
+		{value: "0%", wantErr: true},
+		{value: "101%", wantErr: true},
+		{value: "-5%", wantErr: true},
+		{value: "%", wantErr: true},
+		{value: "", wantErr: true},
+		{value: "two", wantErr: true},
+		{value: "1.5", wantErr: true},
+		{value: " 3", wantErr: true},
+		{value: "3%%", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, isPercent, err := parseMinAvailableValue(tt.value, 100)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want || isPercent != tt.wantPercent {
+				t.Errorf("expected %d (percent %v), got %d (percent %v)", tt.want, tt.wantPercent, got, isPercent)
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_PreFilter_InvalidMinAvailable(t *testing.T) {
+	existing := []*v1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "pod0", Labels: map[string]string{"podGroup": "g1"}}}}
+	tests := []struct {
+		name            string
+		maxMinAvailable int
+		value           string
+		want            framework.Code
+		wantMessage     string
+	}{
+		{
+			name:        "zero is rejected",
+			value:       "0",
+			want:        framework.Unschedulable,
+			wantMessage: `Invalid minAvailable value: label minAvailable "0" is not a positive integer up to 10000 or a percentage between 1% and 100%`,
+		},
+		{
+			name:  "negative value is rejected",
+			value: "-3",
+			want:  framework.Unschedulable,
+		},
+		{
+			name:  "overflowing value is rejected",
+			value: "999999999999999999999",
+			want:  framework.Unschedulable,
+		},
+		{
+			name:  "value above the default limit is rejected",
+			value: "10001",
+			want:  framework.Unschedulable,
+		},
+		{
+			name:            "value above a configured limit is rejected",
+			maxMinAvailable: 5,
+			value:           "6",
+			want:            framework.Unschedulable,
+			wantMessage:     `Invalid minAvailable value: label minAvailable "6" is not a positive integer up to 5 or a percentage between 1% and 100%`,
+		},
+		{
+			name:            "value within a configured limit is used",
+			maxMinAvailable: 5,
+			value:           "1",
+			want:            framework.Success,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:               newTestFrameworkWithPods(t, existing),
+				scoreMode:            leastMode,
+				groupLabelKey:        groupNameLabel,
+				minAvailableLabelKey: minAvailableLabel,
+				maxMinAvailable:      tt.maxMinAvailable,
+			}
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "incoming",
+					Labels: map[string]string{"podGroup": "g1", "minAvailable": tt.value},
+				},
+			}
+			_, status := cs.PreFilter(context.Background(), nil, pod)
+			if status.Code() != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, status.Code())
+			}
+			if tt.wantMessage != "" && status.Message() != tt.wantMessage {
+				t.Errorf("expected message %q, got %q", tt.wantMessage, status.Message())
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_PreFilterExtensions(t *testing.T) {
+	var existing []*v1.Pod
+	for i := 0; i < 2; i++ {
+		existing = append(existing, &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("pod%d", i),
+				Namespace: "default",
+				Labels:    map[string]string{"podGroup": "g1"},
+			},
+		})
+	}
+	cs := &CustomScheduler{
+		handle:               newTestFrameworkWithPods(t, existing),
+		scoreMode:            leastMode,
+		groupLabelKey:        groupNameLabel,
+		minAvailableLabelKey: minAvailableLabel,
+	}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "incoming",
+			Namespace: "default",
+			Labels:    map[string]string{"podGroup": "g1", "minAvailable": "2"},
+		},
+	}
+	state := framework.NewCycleState()
+	if _, status := cs.PreFilter(context.Background(), state, pod); !status.IsSuccess() {
+		t.Fatalf("expected success, got %v", status)
+	}
+
+	sibling := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod2", Namespace: "default", Labels: map[string]string{"podGroup": "g1"}}}
+	stranger := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod3", Namespace: "default", Labels: map[string]string{"podGroup": "g2"}}}
+	steps := []struct {
+		name        string
+		run         func(state *framework.CycleState) *framework.Status
+		wantMembers []string
+	}{
+		{
+			name:        "after PreFilter",
+			run:         func(*framework.CycleState) *framework.Status { return nil },
+			wantMembers: []string{"default/pod0", "default/pod1"},
+		},
+		{
+			name: "add a pod of the group",
+			run: func(state *framework.CycleState) *framework.Status {
+				return cs.AddPod(context.Background(), state, pod, mustNewPodInfo(t, sibling), nil)
+			},
+			wantMembers: []string{"default/pod0", "default/pod1", "default/pod2"},
+		},
+		{
+			name: "add a pod of another group",
+			run: func(state *framework.CycleState) *framework.Status {
+				return cs.AddPod(context.Background(), state, pod, mustNewPodInfo(t, stranger), nil)
+			},
+			wantMembers: []string{"default/pod0", "default/pod1", "default/pod2"},
+		},
+		{
+			name: "remove a victim of the same group",
+			run: func(state *framework.CycleState) *framework.Status {
+				return cs.RemovePod(context.Background(), state, pod, mustNewPodInfo(t, existing[0]), nil)
+			},
+			wantMembers: []string{"default/pod1", "default/pod2"},
+		},
+	}
+	for _, step := range steps {
+		if status := step.run(state); !status.IsSuccess() {
+			t.Fatalf("%s: unexpected error: %v", step.name, status)
+		}
+		s, err := getPreFilterState(state)
+		if err != nil {
+			t.Fatalf("%s: fail to read state: %v", step.name, err)
+		}
+		if got := sets.List(s.members); !reflect.DeepEqual(got, step.wantMembers) {
+			t.Errorf("%s: expected members %v, got %v", step.name, step.wantMembers, got)
+		}
+	}
+}
+
+func TestCustomScheduler_PreFilter_Events(t *testing.T) {
+	existing := []*v1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "pod0", Labels: map[string]string{"podGroup": "g1"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "pod1", Labels: map[string]string{"podGroup": "g1"}}},
+	}
+	tests := []struct {
+		name         string
+		minAvailable string
+		wantEvents   []string
+	}{
+		{
+			name:         "rejected pod gets a warning",
+			minAvailable: "3",
+			wantEvents:   []string{"Warning GroupNotReady Group 'g1' has 2 of the 3 pods it needs"},
+		},
+		{
+			name:         "accepted pod gets no event",
+			minAvailable: "2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := events.NewFakeRecorder(10)
+			cs := &CustomScheduler{
+				handle:               newTestFrameworkWithPods(t, existing),
+				scoreMode:            leastMode,
+				groupLabelKey:        groupNameLabel,
+				minAvailableLabelKey: minAvailableLabel,
+				eventRecorder:        recorder,
+			}
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "incoming",
+					Labels: map[string]string{"podGroup": "g1", "minAvailable": tt.minAvailable},
+				},
+			}
+			cs.PreFilter(context.Background(), nil, pod)
+			close(recorder.Events)
+			var got []string
+			for event := range recorder.Events {
+				got = append(got, event)
+			}
+			if !reflect.DeepEqual(got, tt.wantEvents) {
+				t.Errorf("expected events %q, got %q", tt.wantEvents, got)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    string
+		wantErr bool
+	}{
+		{
+			name: "default label keys",
+			args: `{"mode": "Most"}`,
+		},
+		{
+			name: "custom label keys",
+			args: `{"mode": "Most", "groupLabelKey": "scheduling.x-k8s.io/pod-group", "minAvailableLabelKey": "min-available"}`,
+		},
+		{
+			name:    "invalid group label key",
+			args:    `{"mode": "Most", "groupLabelKey": "pod group"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid minAvailable label key",
+			args:    `{"mode": "Most", "minAvailableLabelKey": "-min/available"}`,
+			wantErr: true,
+		},
+		{
+			name: "cpu mode",
+			args: `{"mode": "MostCPU"}`,
+		},
+		{
+			name: "balanced mode",
+			args: `{"mode": "Balanced"}`,
+		},
+		{
+			name: "extended resource",
+			args: `{"mode": "Most", "resourceName": "nvidia.com/gpu"}`,
+		},
+		{
+			name: "hugepages resource",
+			args: `{"mode": "Least", "resourceName": "hugepages-2Mi"}`,
+		},
+		{
+			name: "pod count mode",
+			args: `{"mode": "LeastPods"}`,
+		},
+		{
+			name: "group affinity",
+			args: `{"mode": "Most", "groupAffinityWeight": 30, "groupAffinityBonus": 25}`,
+		},
+		{
+			name:    "group affinity weight above 100",
+			args:    `{"mode": "Most", "groupAffinityWeight": 101}`,
+			wantErr: true,
+		},
+		{
+			name: "spread group",
+			args: `{"mode": "Most", "groupAffinityWeight": 30, "spreadGroup": true}`,
+		},
+		{
+			name:    "spread group without a weight",
+			args:    `{"mode": "Most", "spreadGroup": true}`,
+			wantErr: true,
+		},
+		{
+			name: "weighted mode",
+			args: `{"mode": "Weighted", "resources": [{"name": "memory", "weight": 2}, {"name": "nvidia.com/gpu", "weight": 5}]}`,
+		},
+		{
+			name:    "weighted mode with a zero weight",
+			args:    `{"mode": "Weighted", "resources": [{"name": "memory", "weight": 0}]}`,
+			wantErr: true,
+		},
+		{
+			name: "limits capacity policy",
+			args: `{"mode": "Most", "capacityPolicy": "Limits"}`,
+		},
+		{
+			name:    "unknown capacity policy",
+			args:    `{"mode": "Most", "capacityPolicy": "Usage"}`,
+			wantErr: true,
+		},
+		{
+			name: "random mode with a seed",
+			args: `{"mode": "Random", "allowRandomMode": true, "randomSeed": 42}`,
+		},
+		{
+			name:    "random mode without allowing it",
+			args:    `{"mode": "Random", "randomSeed": 42}`,
+			wantErr: true,
+		},
+		{
+			name: "memory safety margin",
+			args: `{"mode": "Most", "memorySafetyMargin": "512Mi"}`,
+		},
+		{
+			name: "memory safety margin in percent",
+			args: `{"mode": "Most", "memorySafetyMargin": "5%"}`,
+		},
+		{
+			name:    "negative memory safety margin",
+			args:    `{"mode": "Most", "memorySafetyMargin": "-1Mi"}`,
+			wantErr: true,
+		},
+		{
+			name:    "memory safety margin of all the memory",
+			args:    `{"mode": "Most", "memorySafetyMargin": "100%"}`,
+			wantErr: true,
+		},
+		{
+			name: "scheduling gates",
+			args: `{"mode": "Most", "useSchedulingGates": true}`,
+		},
+		{
+			name: "group circuit breaker",
+			args: `{"mode": "Most", "maxGroupAttempts": 3}`,
+		},
+		{
+			name:    "negative maxGroupAttempts",
+			args:    `{"mode": "Most", "maxGroupAttempts": -1}`,
+			wantErr: true,
+		},
+		{
+			name:    "negative cacheSyncTimeoutSeconds",
+			args:    `{"mode": "Most", "cacheSyncTimeoutSeconds": -1}`,
+			wantErr: true,
+		},
+		{
+			name: "fraction score basis",
+			args: `{"mode": "Most", "scoreBasis": "Fraction"}`,
+		},
+		{
+			name:    "unknown score basis",
+			args:    `{"mode": "Most", "scoreBasis": "Relative"}`,
+			wantErr: true,
+		},
+		{
+			name: "tier bonus",
+			args: `{"mode": "Most", "tierLabelKey": "tier", "tierBonus": {"gold": 20, "silver": 10}}`,
+		},
+		{
+			name:    "tier bonus without a label key",
+			args:    `{"mode": "Most", "tierBonus": {"gold": 20}}`,
+			wantErr: true,
+		},
+		{
+			name:    "tier bonus above the maximum score",
+			args:    `{"mode": "Most", "tierLabelKey": "tier", "tierBonus": {"gold": 101}}`,
+			wantErr: true,
+		},
+		{
+			name: "shaped mode",
+			args: `{"mode": "Shaped", "shape": [{"utilization": 0, "score": 0}, {"utilization": 70, "score": 100}, {"utilization": 100, "score": 0}]}`,
+		},
+		{
+			name:    "shaped mode without a shape",
+			args:    `{"mode": "Shaped"}`,
+			wantErr: true,
+		},
+		{
+			name:    "shape with decreasing utilizations",
+			args:    `{"mode": "Shaped", "shape": [{"utilization": 70, "score": 100}, {"utilization": 30, "score": 0}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "shape with repeated utilizations",
+			args:    `{"mode": "Shaped", "shape": [{"utilization": 70, "score": 100}, {"utilization": 70, "score": 0}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "shape with a utilization above 100",
+			args:    `{"mode": "Shaped", "shape": [{"utilization": 0, "score": 0}, {"utilization": 120, "score": 100}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "shape with a score above the maximum",
+			args:    `{"mode": "Shaped", "shape": [{"utilization": 0, "score": 0}, {"utilization": 100, "score": 1000}]}`,
+			wantErr: true,
+		},
+		{
+			name: "composite mode",
+			args: `{"mode": "Composite", "scoreComponents": [{"name": "most", "weight": 3}, {"name": "GroupLocality", "weight": 1}]}`,
+		},
+		{
+			name:    "composite mode without components",
+			args:    `{"mode": "Composite"}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown score component",
+			args:    `{"mode": "Composite", "scoreComponents": [{"name": "Balanced", "weight": 1}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "score components without weight",
+			args:    `{"mode": "Composite", "scoreComponents": [{"name": "Most"}]}`,
+			wantErr: true,
+		},
+		{
+			name: "disabled score component",
+			args: `{"mode": "Composite", "scoreComponents": [{"name": "Most", "weight": 1}, {"name": "imagelocality", "weight": 0}]}`,
+		},
+		{
+			name:    "score component with a negative weight",
+			args:    `{"mode": "Composite", "scoreComponents": [{"name": "Most", "weight": 2}, {"name": "Tier", "weight": -1}]}`,
+			wantErr: true,
+		},
+		{
+			name: "zscore normalization",
+			args: `{"normalizationStrategy": "ZScore"}`,
+		},
+		{
+			name: "percentile normalization",
+			args: `{"normalizationStrategy": "Percentile"}`,
+		},
+		{
+			name:    "invalid normalization strategy",
+			args:    `{"normalizationStrategy": "Rank"}`,
+			wantErr: true,
+		},
+		{
+			name:    "negative best-effort grace period",
+			args:    `{"bestEffortGraceSeconds": -1}`,
+			wantErr: true,
+		},
+		{
+			name: "concurrent groups per namespace",
+			args: `{"maxConcurrentGroupsPerNamespace": 2}`,
+		},
+		{
+			name:    "negative concurrent groups per namespace",
+			args:    `{"maxConcurrentGroupsPerNamespace": -1}`,
+			wantErr: true,
+		},
+		{
+			name: "concurrent releasing groups",
+			args: `{"maxConcurrentReleasingGroups": 1}`,
+		},
+		{
+			name:    "negative concurrent releasing groups",
+			args:    `{"maxConcurrentReleasingGroups": -1}`,
+			wantErr: true,
+		},
+		{
+			name: "priority admission",
+			args: `{"priorityAdmission": true}`,
+		},
+		{
+			name: "dry-run mode",
+			args: `{"dryRunMode": "mostallocated"}`,
+		},
+		{
+			name:    "unsupported dry-run mode",
+			args:    `{"dryRunMode": "Balanced"}`,
+			wantErr: true,
+		},
+		{
+			name: "member nodes",
+			args: `{"annotateMemberNodes": true, "memberNodesMetric": true}`,
+		},
+		{
+			name: "node headroom",
+			args: `{"nodeHeadroomBytes": 2147483648}`,
+		},
+		{
+			name:    "negative node headroom",
+			args:    `{"nodeHeadroomBytes": -1}`,
+			wantErr: true,
+		},
+		{
+			name: "respect resource quota",
+			args: `{"respectResourceQuota": true}`,
+		},
+		{
+			name: "domain topology key",
+			args: `{"domainTopologyKey": "topology.kubernetes.io/region"}`,
+		},
+		{
+			name:    "invalid domain topology key",
+			args:    `{"domainTopologyKey": "not a key"}`,
+			wantErr: true,
+		},
+		{
+			name: "pressure penalties",
+			args: `{"mode": "Most", "memoryPressurePenalty": 0, "diskPressurePenalty": 50}`,
+		},
+		{
+			name:    "pressure penalty above the maximum score",
+			args:    `{"mode": "Most", "memoryPressurePenalty": 101}`,
+			wantErr: true,
+		},
+		{
+			name: "default memory request",
+			args: `{"mode": "Most", "defaultMemoryRequest": "1Gi"}`,
+		},
+		{
+			name:    "invalid default memory request",
+			args:    `{"mode": "Most", "defaultMemoryRequest": "-1Gi"}`,
+			wantErr: true,
+		},
+		{
+			name:    "resource that isn't extended",
+			args:    `{"mode": "Most", "resourceName": "memory"}`,
+			wantErr: true,
+		},
+		{
+			name:    "extended resource with cpu mode",
+			args:    `{"mode": "LeastCPU", "resourceName": "nvidia.com/gpu"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid mode",
+			args:    `{"mode": "Fastest"}`,
+			wantErr: true,
+		},
+		{
+			name: "omitted mode",
+			args: `{"conflictPolicy": "Max"}`,
+		},
+		{
+			name: "empty mode",
+			args: `{"mode": ""}`,
+		},
+		{
+			name:    "negative maxMinAvailable",
+			args:    `{"mode": "Most", "maxMinAvailable": -1}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(&runtime.Unknown{Raw: []byte(tt.args)}, newTestFrameworkWithPods(t, nil))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
 func TestCustomScheduler_Score(t *testing.T) {
 	type TestScoreInput struct {
 		ctx      	context.Context
@@ -209,17 +1278,24 @@ func TestCustomScheduler_Score(t *testing.T) {
 				scoreMode: tt.mode,
 			}
 
-			highest := int64(math.MinInt64)
-			bestNode := ""
+			var scores framework.NodeScoreList
 			for _, nodeName := range tt.args.nodeNames {
 				got, status := cs.Score(tt.args.ctx, tt.args.state, tt.args.pod, nodeName)
 				if !status.IsSuccess() {
 					t.Errorf("unexpected error: %v", status)
 				}
+				scores = append(scores, framework.NodeScore{Name: nodeName, Score: got})
+			}
+			if status := cs.NormalizeScore(tt.args.ctx, tt.args.state, tt.args.pod, scores); !status.IsSuccess() {
+				t.Errorf("unexpected error: %v", status)
+			}
 
-				if got > highest {
-					highest = got
-					bestNode = nodeName
+			highest := int64(math.MinInt64)
+			bestNode := ""
+			for _, nodeScore := range scores {
+				if nodeScore.Score > highest {
+					highest = nodeScore.Score
+					bestNode = nodeScore.Name
 				}
 			}
 			
@@ -317,6 +1393,529 @@ func TestCustomScheduler_NormalizeScore(t *testing.T) {
 	}
 }
 
+func TestCustomScheduler_ScoreCPU(t *testing.T) {
+	// cpu1 has little CPU but plenty of memory, cpu2 the other way around
+	nodeInfos := []*framework.NodeInfo{makeNodeInfo("cpu1", 1000, 400), makeNodeInfo("cpu2", 4000, 100)}
+	tests := []struct {
+		name     string
+		mode     string
+		wantBest string
+	}{
+		{
+			name:     "least CPU mode",
+			mode:     "LeastCPU",
+			wantBest: "cpu1",
+		},
+		{
+			name:     "most CPU mode",
+			mode:     "MostCPU",
+			wantBest: "cpu2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:    newTestFrameworkWithNodes(t, nil, nodeInfos),
+				scoreMode: tt.mode,
+			}
+			pod := &v1.Pod{}
+			scores := scoreNodes(t, cs, pod, nodeInfos)
+			for _, score := range scores {
+				want := framework.MinNodeScore
+				if score.Name == tt.wantBest {
+					want = framework.MaxNodeScore
+				}
+				if score.Score != want {
+					t.Errorf("expected node %s to score %d, got %d", score.Name, want, score.Score)
+				}
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_Score_ExtendedResource(t *testing.T) {
+	const gpu v1.ResourceName = "nvidia.com/gpu"
+	makeGPUNode := func(name string, gpus, used int64) *framework.NodeInfo {
+		nodeInfo := makeNodeInfo(name, 4000, 1<<30)
+		nodeInfo.Node().Status.Allocatable[gpu] = *resource.NewQuantity(gpus, resource.DecimalSI)
+		nodeInfo.SetNode(nodeInfo.Node())
+		if used > 0 {
+			nodeInfo.AddPod(&v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name + "-user"},
+				Spec: v1.PodSpec{Containers: []v1.Container{{
+					Resources: v1.ResourceRequirements{Requests: v1.ResourceList{gpu: *resource.NewQuantity(used, resource.DecimalSI)}},
+				}}},
+			})
+		}
+		return nodeInfo
+	}
+	nodeInfos := []*framework.NodeInfo{
+		makeGPUNode("gpu8", 8, 2),
+		makeGPUNode("gpu4", 4, 1),
+		// the plain node has more memory than any GPU node
+		makeNodeInfo("plain", 4000, 1<<40),
+	}
+	tests := []struct {
+		name     string
+		mode     string
+		wantBest string
+	}{
+		{
+			name:     "least mode packs GPUs",
+			mode:     "Least",
+			wantBest: "gpu4",
+		},
+		{
+			name:     "most mode spreads GPUs",
+			mode:     "Most",
+			wantBest: "gpu8",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:       newTestFrameworkWithNodes(t, nil, nodeInfos),
+				scoreMode:    tt.mode,
+				resourceName: gpu,
+			}
+			pod := &v1.Pod{}
+			scores := scoreNodes(t, cs, pod, nodeInfos)
+			best := scores[0]
+			for _, score := range scores {
+				if score.Score > best.Score {
+					best = score
+				}
+				if score.Name == "plain" && score.Score != framework.MinNodeScore {
+					t.Errorf("expected the node without GPUs to score %d, got %d", framework.MinNodeScore, score.Score)
+				}
+			}
+			if best.Name != tt.wantBest {
+				t.Errorf("expected node %s to score best, got %v", tt.wantBest, scores)
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_Score_HugePages(t *testing.T) {
+	const hugePages v1.ResourceName = "hugepages-2Mi"
+	makeHugePagesNode := func(name string, allocatable, used string) *framework.NodeInfo {
+		nodeInfo := makeNodeInfo(name, 4000, 1<<30)
+		nodeInfo.Node().Status.Allocatable[hugePages] = resource.MustParse(allocatable)
+		nodeInfo.SetNode(nodeInfo.Node())
+		nodeInfo.AddPod(&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name + "-user"},
+			Spec: v1.PodSpec{Containers: []v1.Container{{
+				Resources: v1.ResourceRequirements{Requests: v1.ResourceList{hugePages: resource.MustParse(used)}},
+			}}},
+		})
+		return nodeInfo
+	}
+	nodeInfos := []*framework.NodeInfo{
+		makeHugePagesNode("large", "2Gi", "512Mi"),
+		makeHugePagesNode("small", "1Gi", "512Mi"),
+		makeHugePagesNode("full", "1Gi", "1Gi"),
+		// the plain node has more memory than any hugepages node
+		makeNodeInfo("plain", 4000, 1<<40),
+	}
+	pod := &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{{
+		Resources: v1.ResourceRequirements{Requests: v1.ResourceList{hugePages: resource.MustParse("256Mi")}},
+	}}}}
+	tests := []struct {
+		name string
+		mode string
+		want map[string]int64
+	}{
+		{
+			name: "least mode packs hugepages",
+			mode: "Least",
+			want: map[string]int64{"large": 0, "small": 100, "full": 0, "plain": 0},
+		},
+		{
+			name: "most mode spreads hugepages",
+			mode: "Most",
+			want: map[string]int64{"large": 100, "small": 0, "full": 0, "plain": 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:       newTestFrameworkWithNodes(t, nil, nodeInfos),
+				scoreMode:    tt.mode,
+				resourceName: hugePages,
+			}
+			for _, score := range scoreNodes(t, cs, pod, nodeInfos) {
+				if score.Score != tt.want[score.Name] {
+					t.Errorf("expected node %s to score %d, got %d", score.Name, tt.want[score.Name], score.Score)
+				}
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_Score_ExhaustedNode(t *testing.T) {
+	makeNode := func(name string, requested int64) *framework.NodeInfo {
+		nodeInfo := makeNodeInfo(name, 1000, 1000)
+		nodeInfo.Requested.Memory = requested
+		return nodeInfo
+	}
+	nodeInfos := []*framework.NodeInfo{
+		makeNode("full", 1000),
+		makeNode("overcommitted", 1500),
+		makeNode("half", 500),
+	}
+	tests := []struct {
+		name string
+		mode string
+		want map[string]int64
+	}{
+		{
+			name: "least mode packs full nodes first",
+			mode: "Least",
+			want: map[string]int64{"full": 100, "overcommitted": 0, "half": 0},
+		},
+		{
+			name: "most mode avoids full nodes",
+			mode: "Most",
+			want: map[string]int64{"full": 0, "overcommitted": 0, "half": 100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:    newTestFrameworkWithNodes(t, nil, nodeInfos),
+				scoreMode: tt.mode,
+			}
+			scores := scoreNodes(t, cs, &v1.Pod{}, nodeInfos)
+			for _, score := range scores {
+				if score.Score != tt.want[score.Name] {
+					t.Errorf("expected node %s to score %d, got %d", score.Name, tt.want[score.Name], score.Score)
+				}
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_Score_Monotonic(t *testing.T) {
+	// 30GiB, 45GiB and 60GiB of memory left
+	nodeInfos := []*framework.NodeInfo{
+		makeNodeInfo("n30", 1000, 30<<30),
+		makeNodeInfo("n45", 1000, 45<<30),
+		makeNodeInfo("n60", 1000, 60<<30),
+	}
+	for _, mode := range []string{"Least", "Most"} {
+		t.Run(mode, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:    newTestFrameworkWithNodes(t, nil, nodeInfos),
+				scoreMode: mode,
+			}
+			scores := scoreNodes(t, cs, &v1.Pod{}, nodeInfos)
+			// the nodes are ordered by increasing free memory
+			for i := 1; i < len(scores); i++ {
+				more := scores[i].Score > scores[i-1].Score
+				if mode == "Least" {
+					more = scores[i].Score < scores[i-1].Score
+				}
+				if !more {
+					t.Errorf("expected the scores to strictly follow free memory in %s mode, got %v", mode, scores)
+				}
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_Score_PodRequest(t *testing.T) {
+	makePod := func(memory string) *v1.Pod {
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "incoming"}, Spec: v1.PodSpec{Containers: []v1.Container{{}}}}
+		if memory != "" {
+			pod.Spec.Containers[0].Resources.Requests = v1.ResourceList{v1.ResourceMemory: resource.MustParse(memory)}
+		}
+		return pod
+	}
+	// 100Mi and 300Mi of memory left
+	nodeInfos := []*framework.NodeInfo{makeNodeInfo("small", 1000, 100<<20), makeNodeInfo("large", 1000, 300<<20)}
+	tests := []struct {
+		name                 string
+		pod                  *v1.Pod
+		defaultMemoryRequest string
+		wantBest             string
+	}{
+		{
+			name:     "pod fits both nodes",
+			pod:      makePod("50Mi"),
+			wantBest: "small",
+		},
+		{
+			name:     "pod only fits the large node",
+			pod:      makePod("150Mi"),
+			wantBest: "large",
+		},
+		{
+			name:     "init container request counts",
+			pod:      func() *v1.Pod {
+				pod := makePod("50Mi")
+				pod.Spec.InitContainers = []v1.Container{{Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceMemory: resource.MustParse("150Mi")}}}}
+				return pod
+			}(),
+			wantBest: "large",
+		},
+		{
+			name:     "pod overhead counts",
+			pod:      func() *v1.Pod {
+				pod := makePod("50Mi")
+				pod.Spec.Overhead = v1.ResourceList{v1.ResourceMemory: resource.MustParse("100Mi")}
+				return pod
+			}(),
+			wantBest: "large",
+		},
+		{
+			name:                 "pod without a request uses the default",
+			pod:                  makePod(""),
+			defaultMemoryRequest: "200Mi",
+			wantBest:             "large",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:    newTestFrameworkWithNodes(t, nil, nodeInfos),
+				scoreMode: "Least",
+			}
+			if tt.defaultMemoryRequest != "" {
+				cs.defaultMemoryRequest = resource.MustParse(tt.defaultMemoryRequest)
+			}
+			scores := scoreNodes(t, cs, tt.pod, nodeInfos)
+			for _, score := range scores {
+				want := framework.MinNodeScore
+				if score.Name == tt.wantBest {
+					want = framework.MaxNodeScore
+				}
+				if score.Score != want {
+					t.Errorf("expected node %s to score %d, got %d", score.Name, want, score.Score)
+				}
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_Score_PodCount(t *testing.T) {
+	makeNode := func(name string, memory int64, pods int) *framework.NodeInfo {
+		nodeInfo := makeNodeInfo(name, 4000, memory)
+		nodeInfo.Node().Status.Allocatable[v1.ResourcePods] = *resource.NewQuantity(10, resource.DecimalSI)
+		nodeInfo.SetNode(nodeInfo.Node())
+		for i := 0; i < pods; i++ {
+			nodeInfo.AddPod(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-%d", name, i)}})
+		}
+		return nodeInfo
+	}
+	// the busy node has more memory left but runs more pods
+	nodeInfos := []*framework.NodeInfo{makeNode("busy", 8<<30, 6), makeNode("quiet", 2<<30, 1)}
+	tests := []struct {
+		name     string
+		mode     string
+		wantBest string
+	}{
+		{
+			name:     "most mode follows memory",
+			mode:     "Most",
+			wantBest: "busy",
+		},
+		{
+			name:     "least pods mode prefers the emptier node",
+			mode:     "LeastPods",
+			wantBest: "quiet",
+		},
+		{
+			name:     "most pods mode packs",
+			mode:     "MostPods",
+			wantBest: "busy",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:    newTestFrameworkWithNodes(t, nil, nodeInfos),
+				scoreMode: tt.mode,
+			}
+			scores := scoreNodes(t, cs, &v1.Pod{}, nodeInfos)
+			for _, score := range scores {
+				want := framework.MinNodeScore
+				if score.Name == tt.wantBest {
+					want = framework.MaxNodeScore
+				}
+				if score.Score != want {
+					t.Errorf("expected node %s to score %d, got %d", score.Name, want, score.Score)
+				}
+			}
+		})
+	}
+}
+
+// scoreNodes runs PreScore, scores the pod on the nodes and normalizes the
+// scores.
+func scoreNodes(t *testing.T, cs *CustomScheduler, pod *v1.Pod, nodeInfos []*framework.NodeInfo) framework.NodeScoreList {
+	t.Helper()
+	state := framework.NewCycleState()
+	var nodes []*v1.Node
+	for _, nodeInfo := range nodeInfos {
+		nodes = append(nodes, nodeInfo.Node())
+	}
+	if status := cs.PreScore(context.Background(), state, pod, nodes); !status.IsSuccess() {
+		t.Fatalf("unexpected error: %v", status)
+	}
+	var scores framework.NodeScoreList
+	for _, nodeInfo := range nodeInfos {
+		score, status := cs.Score(context.Background(), state, pod, nodeInfo.Node().Name)
+		if !status.IsSuccess() {
+			t.Fatalf("unexpected error: %v", status)
+		}
+		scores = append(scores, framework.NodeScore{Name: nodeInfo.Node().Name, Score: score})
+	}
+	if status := cs.NormalizeScore(context.Background(), state, pod, scores); !status.IsSuccess() {
+		t.Fatalf("unexpected error: %v", status)
+	}
+	return scores
+}
+
+func TestCustomScheduler_NormalizeScore_Extremes(t *testing.T) {
+	extremes := []int64{math.MaxInt64, math.MinInt64, 0, -1, 1, 4 << 40, -(4 << 40)}
+	rng := rand.New(rand.NewSource(1))
+	var lists []framework.NodeScoreList
+	lists = append(lists, nil, framework.NodeScoreList{{Name: "m0", Score: math.MaxInt64}, {Name: "m1", Score: math.MaxInt64}})
+	for i := 0; i < 200; i++ {
+		var scores framework.NodeScoreList
+		for j := 0; j < 1+rng.Intn(8); j++ {
+			score := rng.Int63() - rng.Int63()
+			if rng.Intn(2) == 0 {
+				score = extremes[rng.Intn(len(extremes))]
+			}
+			scores = append(scores, framework.NodeScore{Name: fmt.Sprintf("m%d", j), Score: score})
+		}
+		lists = append(lists, scores)
+	}
+	for _, mode := range []string{"Least", "Most", "Balanced"} {
+		cs := &CustomScheduler{handle: newTestFrameworkWithNodes(t, nil, nil), scoreMode: mode}
+		for _, scores := range lists {
+			raw := append(framework.NodeScoreList(nil), scores...)
+			if status := cs.NormalizeScore(context.Background(), framework.NewCycleState(), &v1.Pod{}, scores); !status.IsSuccess() {
+				t.Fatalf("unexpected error: %v", status)
+			}
+			for i := range scores {
+				if scores[i].Score < framework.MinNodeScore || scores[i].Score > framework.MaxNodeScore {
+					t.Fatalf("%s mode normalized %v to %v, out of range", mode, raw, scores)
+				}
+				for j := range scores {
+					// the order of the raw scores is kept, or reversed in Least mode
+					higher := raw[i].Score > raw[j].Score
+					if mode == "Least" {
+						higher = raw[i].Score < raw[j].Score
+					}
+					if higher && scores[i].Score < scores[j].Score {
+						t.Fatalf("%s mode normalized %v to %v, out of order", mode, raw, scores)
+					}
+				}
+			}
+		}
+	}
+}
+
+// newTestFrameworkWithPods returns a framework handle whose pod informer
+// already contains the given pods.
+func newTestFrameworkWithPods(t testing.TB, pods []*v1.Pod) framework.Handle {
+	t.Helper()
+	return newTestFrameworkWithNodes(t, pods, nil)
+}
+
+// newTestFrameworkWithNodes returns a framework handle whose pod informer
+// already contains the given pods and whose snapshot holds the given nodes.
+func newTestFrameworkWithNodes(t testing.TB, pods []*v1.Pod, nodeInfos []*framework.NodeInfo) framework.Handle {
+	t.Helper()
+	client := clientsetfake.NewSimpleClientset()
+	informerFactory := informers.NewSharedInformerFactory(client, 0)
+	podInformer := informerFactory.Core().V1().Pods()
+	registeredPlugins := []st.RegisterPluginFunc{
+		st.RegisterBindPlugin(defaultbinder.Name, defaultbinder.New),
+		st.RegisterQueueSortPlugin(queuesort.Name, queuesort.New),
+	}
+	fh, err := st.NewFramework(
+		registeredPlugins,
+		"default-scheduler",
+		wait.NeverStop,
+		frameworkruntime.WithClientSet(client),
+		frameworkruntime.WithInformerFactory(informerFactory),
+		frameworkruntime.WithSnapshotSharedLister(&fakeSharedLister{nodes: nodeInfos}),
+	)
+	if err != nil {
+		t.Fatalf("fail to create framework: %s", err)
+	}
+	for _, p := range pods {
+		podInformer.Informer().GetStore().Add(p)
+	}
+	return fh
+}
+
+func TestCustomScheduler_DescribeMembers(t *testing.T) {
+	makePods := func(n int) []*v1.Pod {
+		var pods []*v1.Pod
+		for i := 0; i < n; i++ {
+			pods = append(pods, &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("pod%02d", i), Namespace: "default"},
+				Spec:       v1.PodSpec{NodeName: "node1"},
+				Status:     v1.PodStatus{Phase: v1.PodRunning},
+			})
+		}
+		return pods
+	}
+	tests := []struct {
+		name        string
+		clusterWide bool
+		pods        []*v1.Pod
+		want        string
+	}{
+		{
+			name: "no members",
+			want: "",
+		},
+		{
+			name: "members are counted by state",
+			pods: []*v1.Pod{
+				{ObjectMeta: metav1.ObjectMeta{Name: "b"}, Spec: v1.PodSpec{NodeName: "node1"}, Status: v1.PodStatus{Phase: v1.PodRunning}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "a"}, Spec: v1.PodSpec{NodeName: "node1"}, Status: v1.PodStatus{Phase: v1.PodPending}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "c"}, Status: v1.PodStatus{Phase: v1.PodPending}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "dead"}, Status: v1.PodStatus{Phase: v1.PodFailed}},
+			},
+			want: " (1 running, 2 pending, 1 unscheduled: a, b, c)",
+		},
+		{
+			name:        "cluster-wide groups list namespaces",
+			clusterWide: true,
+			pods:        makePods(1),
+			want:        " (1 running, 0 pending, 0 unscheduled: default/pod00)",
+		},
+		{
+			name: "long lists are truncated",
+			pods: makePods(12),
+			want: " (12 running, 0 pending, 0 unscheduled: pod00, pod01, pod02, pod03, pod04, pod05, pod06, pod07, pod08, pod09, and 2 more)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{clusterWideGroups: tt.clusterWide}
+			if got := cs.describeMembers(tt.pods); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func mustNewPodInfo(t *testing.T, pod *v1.Pod) *framework.PodInfo {
+	t.Helper()
+	podInfo, err := framework.NewPodInfo(pod)
+	if err != nil {
+		t.Fatalf("fail to create pod info: %v", err)
+	}
+	return podInfo
+}
+
 func makeNodeInfo(node string, milliCPU, memory int64) *framework.NodeInfo {
 	ni := framework.NewNodeInfo()
 	ni.SetNode(&v1.Node{
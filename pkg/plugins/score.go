@@ -0,0 +1,187 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// freeModes maps the modes scoring nodes by the amount of a resource left
+// after placing the pod to that resource. The modes preferring the nodes with
+// the least left score nodes like the others, and NormalizeScore inverts their
+// scores.
+var freeModes = map[string]v1.ResourceName{
+	leastMode:     v1.ResourceMemory,
+	mostMode:      v1.ResourceMemory,
+	leastCPUMode:  v1.ResourceCPU,
+	mostCPUMode:   v1.ResourceCPU,
+	leastPodsMode: v1.ResourcePods,
+	mostPodsMode:  v1.ResourcePods,
+}
+
+// scoredResource returns the resource the free mode scores on. The configured
+// resource name only replaces the memory of the Least and Most modes.
+func (cs *CustomScheduler) scoredResource(mode string) v1.ResourceName {
+	if cs.resourceName != "" && (mode == leastMode || mode == mostMode) {
+		return cs.resourceName
+	}
+	if resourceName, ok := freeModes[mode]; ok {
+		return resourceName
+	}
+	return v1.ResourceMemory
+}
+
+// modeScorer returns the scorer of the mode for the pod.
+func (cs *CustomScheduler) modeScorer(mode string, pod *v1.Pod) subScorer {
+	switch mode {
+	case balancedMode:
+		return balancedSubScorer{}
+	case weightedMode:
+		return weightedSubScorer{cs: cs}
+	case randomMode:
+		return randomSubScorer{cs: cs, pod: pod}
+	case shapedMode:
+		return shapedSubScorer{cs: cs}
+	case compositeMode:
+		return compositeSubScorer{cs: cs}
+	}
+	return freeModeSubScorer{cs: cs}
+}
+
+// freeModeSubScorer scores the nodes in the free modes by the amount of the
+// mode's resource left, which NormalizeScore rescales. Nodes the pod doesn't
+// fit on score the minimum, which NormalizeScore keeps out of the range.
+type freeModeSubScorer struct {
+	cs *CustomScheduler
+}
+
+func (f freeModeSubScorer) subScore(s *preScoreState, nodeInfo *framework.NodeInfo) int64 {
+	score, fits := f.cs.nodeFree(s, nodeInfo, f.cs.scoredResource(s.mode))
+	if !fits {
+		return framework.MinNodeScore
+	}
+	return score
+}
+
+// invertsScores reports whether the mode prefers the nodes with the least
+// left, so that NormalizeScore has to invert the scores. MostPods packs pods
+// onto the nodes with the fewest free pod slots.
+func invertsScores(mode string) bool {
+	return mode == leastMode || mode == leastCPUMode || mode == mostPodsMode
+}
+
+// podRequests returns the effective requests of the pod. A pod without a
+// memory request is taken to request defaultMemoryRequest.
+func (cs *CustomScheduler) podRequests(pod *v1.Pod) v1.ResourceList {
+	requests := PodEffectiveRequests(pod)
+	if requests.Memory().IsZero() && !cs.defaultMemoryRequest.IsZero() {
+		requests[v1.ResourceMemory] = cs.defaultMemoryRequest
+	}
+	return requests
+}
+
+// ErrInsufficientResource is returned by ComputeNodeScore when the node
+// doesn't have enough of the resource left for the pod.
+var ErrInsufficientResource = errors.New("insufficient resource")
+
+// ComputeNodeScore returns the raw score of a node in one of the free modes:
+// the amount of the resource left once the pod's request is placed next to
+// what is already requested. The modes preferring the nodes with the least
+// left get the same raw score, as NormalizeScore inverts it. It returns
+// ErrInsufficientResource when the pod doesn't fit, and an error for the modes
+// that don't score on a single resource and for negative amounts.
+func ComputeNodeScore(mode string, allocatable, requested, podRequest int64) (int64, error) {
+	if _, ok := freeModes[mode]; !ok {
+		return 0, fmt.Errorf("mode %q doesn't score on a single resource", mode)
+	}
+	if allocatable < 0 || requested < 0 || podRequest < 0 {
+		return 0, fmt.Errorf("negative amount, got allocatable %d, requested %d, pod request %d", allocatable, requested, podRequest)
+	}
+	// neither subtraction can overflow, as all amounts are non-negative
+	free := allocatable - requested
+	if podRequest > free {
+		return 0, ErrInsufficientResource
+	}
+	return free - podRequest, nil
+}
+
+// nodeAmounts returns the allocatable and requested amounts of the resource
+// on the node, and the amount the pod requests, in the unit of the resource:
+// millicores, bytes, pod slots or units of a scalar resource. It reports false
+// when the node doesn't offer an extended or hugepages resource at all.
+func nodeAmounts(requests v1.ResourceList, nodeInfo *framework.NodeInfo, resourceName v1.ResourceName) (allocatable, requested, podRequest int64, ok bool) {
+	switch resourceName {
+	case v1.ResourceCPU:
+		return nodeInfo.Allocatable.MilliCPU, nodeInfo.Requested.MilliCPU, requests.Cpu().MilliValue(), true
+	case v1.ResourceMemory:
+		return nodeInfo.Allocatable.Memory, nodeInfo.Requested.Memory, requests.Memory().Value(), true
+	case v1.ResourcePods:
+		return int64(nodeInfo.Allocatable.AllowedPodNumber), int64(len(nodeInfo.Pods)), 1, true
+	}
+	allocatable, ok = nodeInfo.Allocatable.ScalarResources[resourceName]
+	if !ok {
+		return 0, 0, 0, false
+	}
+	request := requests[resourceName]
+	return allocatable, nodeInfo.Requested.ScalarResources[resourceName], request.Value(), true
+}
+
+// freeAfter returns the amount of the resource left on the node once the
+// requests of the pod are placed on it. It reports false when the node doesn't
+// have enough of the resource for the pod, or doesn't offer an extended or
+// hugepages resource at all. Score can't skip such nodes, so NormalizeScore
+// leaves them out of the range and gives them the minimum score.
+func freeAfter(requests v1.ResourceList, nodeInfo *framework.NodeInfo, resourceName v1.ResourceName) (int64, bool) {
+	allocatable, requested, podRequest, ok := nodeAmounts(requests, nodeInfo, resourceName)
+	if !ok {
+		return 0, false
+	}
+	free := allocatable - requested - podRequest
+	return free, free >= 0
+}
+
+// unscorableNode returns why the node can't be scored, or "" when it can: it
+// is still registering and reports no allocatable memory yet. Such a node
+// gets the minimum score rather than failing the cycle.
+func unscorableNode(nodeInfo *framework.NodeInfo) string {
+	if nodeInfo.Allocatable == nil || nodeInfo.Allocatable.Memory <= 0 {
+		return "node has no allocatable memory"
+	}
+	return ""
+}
+
+// unfitNodes returns the scored nodes the pod doesn't fit on in the free
+// modes and the Shaped mode, along with the nodes that can't be scored. They
+// get the minimum score whatever the mode.
+func (cs *CustomScheduler) unfitNodes(s *preScoreState, scores framework.NodeScoreList) map[string]bool {
+	if _, ok := freeModes[s.mode]; !ok && s.mode != shapedMode {
+		// Score gives unscorable nodes the minimum raw score, which the
+		// other modes don't invert
+		return nil
+	}
+	resourceName := cs.scoredResource(s.mode)
+	unfit := make(map[string]bool)
+	for _, nodeScore := range scores {
+		nodeInfo, err := cs.nodeInfoLister().Get(nodeScore.Name)
+		if err != nil {
+			// Score gave the node missing from the snapshot the minimum
+			// raw score
+			unfit[nodeScore.Name] = true
+			continue
+		}
+		var fits bool
+		switch {
+		case unscorableNode(nodeInfo) != "":
+		case s.mode == shapedMode:
+			_, fits = cs.shapedScore(s.requests, nodeInfo)
+		default:
+			_, fits = cs.nodeFree(s, nodeInfo, resourceName)
+		}
+		if !fits {
+			unfit[nodeScore.Name] = true
+		}
+	}
+	return unfit
+}
@@ -0,0 +1,320 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/apis/config"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestComputeNodeScore(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        string
+		allocatable int64
+		requested   int64
+		podRequest  int64
+		want        int64
+		wantErr     error
+		// wantAnyErr is set for errors other than ErrInsufficientResource
+		wantAnyErr bool
+	}{
+		{
+			name:        "free memory after the pod",
+			mode:        mostMode,
+			allocatable: 4 << 30,
+			requested:   1 << 30,
+			podRequest:  1 << 30,
+			want:        2 << 30,
+		},
+		{
+			name:        "least mode has the same raw score",
+			mode:        leastMode,
+			allocatable: 4 << 30,
+			requested:   1 << 30,
+			podRequest:  1 << 30,
+			want:        2 << 30,
+		},
+		{
+			name:        "pod fills the node exactly",
+			mode:        leastCPUMode,
+			allocatable: 4000,
+			requested:   3000,
+			podRequest:  1000,
+			want:        0,
+		},
+		{
+			name:        "pod without a request",
+			mode:        mostCPUMode,
+			allocatable: 4000,
+			requested:   1000,
+			want:        3000,
+		},
+		{
+			name: "zero allocatable",
+			mode: mostMode,
+			want: 0,
+		},
+		{
+			name:       "zero allocatable with a request",
+			mode:       mostMode,
+			podRequest: 1,
+			wantErr:    ErrInsufficientResource,
+		},
+		{
+			name:        "node already overcommitted",
+			mode:        leastPodsMode,
+			allocatable: 110,
+			requested:   111,
+			podRequest:  1,
+			wantErr:     ErrInsufficientResource,
+		},
+		{
+			name:        "huge node",
+			mode:        mostMode,
+			allocatable: math.MaxInt64,
+			podRequest:  1,
+			want:        math.MaxInt64 - 1,
+		},
+		{
+			name:        "huge request",
+			mode:        mostPodsMode,
+			allocatable: 110,
+			requested:   10,
+			podRequest:  math.MaxInt64,
+			wantErr:     ErrInsufficientResource,
+		},
+		{
+			name:        "negative amount",
+			mode:        mostMode,
+			allocatable: 1 << 30,
+			requested:   -1,
+			wantAnyErr:  true,
+		},
+		{
+			name:        "mode scoring several resources",
+			mode:        balancedMode,
+			allocatable: 1 << 30,
+			wantAnyErr:  true,
+		},
+		{
+			name:        "unknown mode",
+			mode:        "Random",
+			allocatable: 1 << 30,
+			wantAnyErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ComputeNodeScore(tt.mode, tt.allocatable, tt.requested, tt.podRequest)
+			if tt.wantAnyErr {
+				if err == nil || errors.Is(err, ErrInsufficientResource) {
+					t.Fatalf("expected an invalid argument error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected score %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func FuzzComputeNodeScore(f *testing.F) {
+	f.Add(int64(4<<30), int64(1<<30), int64(1<<30))
+	f.Add(int64(0), int64(0), int64(0))
+	f.Add(int64(math.MaxInt64), int64(0), int64(math.MaxInt64))
+	f.Add(int64(110), int64(111), int64(1))
+	f.Fuzz(func(t *testing.T, allocatable, requested, podRequest int64) {
+		for mode := range freeModes {
+			score, err := ComputeNodeScore(mode, allocatable, requested, podRequest)
+			if allocatable < 0 || requested < 0 || podRequest < 0 {
+				if err == nil || errors.Is(err, ErrInsufficientResource) {
+					t.Fatalf("expected an invalid argument error for %d, %d, %d, got %v", allocatable, requested, podRequest, err)
+				}
+				continue
+			}
+			fits := requested <= allocatable && podRequest <= allocatable-requested
+			if !fits {
+				if !errors.Is(err, ErrInsufficientResource) {
+					t.Fatalf("expected %v for %d, %d, %d, got %d, %v", ErrInsufficientResource, allocatable, requested, podRequest, score, err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("unexpected error for %d, %d, %d: %v", allocatable, requested, podRequest, err)
+			}
+			if score < 0 || score > allocatable || score+requested+podRequest != allocatable {
+				t.Fatalf("score %d out of range for %d, %d, %d", score, allocatable, requested, podRequest)
+			}
+		}
+	})
+}
+
+func TestCustomScheduler_Score_ScoreBasis(t *testing.T) {
+	// the large node has more memory left, but is far fuller
+	large := makeNodeInfo("large", 64000, 512<<30)
+	large.AddPod(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "big"}, Spec: v1.PodSpec{Containers: []v1.Container{{
+		Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceMemory: *resource.NewQuantity(512<<30/10*8, resource.BinarySI)}},
+	}}}})
+	small := makeNodeInfo("small", 8000, 64<<30)
+	small.AddPod(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "little"}, Spec: v1.PodSpec{Containers: []v1.Container{{
+		Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceMemory: *resource.NewQuantity(64<<30/10, resource.BinarySI)}},
+	}}}})
+	nodeInfos := []*framework.NodeInfo{large, small}
+	tests := []struct {
+		mode     string
+		basis    string
+		wantBest string
+	}{
+		{mode: mostMode, basis: basisAbsolute, wantBest: "large"},
+		{mode: mostMode, basis: basisFraction, wantBest: "small"},
+		{mode: leastMode, basis: basisAbsolute, wantBest: "small"},
+		{mode: leastMode, basis: basisFraction, wantBest: "large"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode+"/"+tt.basis, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:     newTestFrameworkWithNodes(t, nil, nodeInfos),
+				scoreMode:  tt.mode,
+				scoreBasis: tt.basis,
+			}
+			scores := scoreNodes(t, cs, &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod"}}, nodeInfos)
+			best := scores[0]
+			for _, score := range scores {
+				if score.Score > best.Score {
+					best = score
+				}
+			}
+			if best.Name != tt.wantBest {
+				t.Errorf("expected node %s to score best, got %v", tt.wantBest, scores)
+			}
+		})
+	}
+}
+
+func TestFreeFraction(t *testing.T) {
+	tests := []struct {
+		free, allocatable, want int64
+	}{
+		{free: 0, allocatable: 100, want: 0},
+		{free: 25, allocatable: 100, want: fractionScale / 4},
+		{free: 512 << 30, allocatable: 512 << 30, want: fractionScale},
+		{free: 10, allocatable: 0, want: 0},
+	}
+	for _, tt := range tests {
+		if got := freeFraction(tt.free, tt.allocatable); got != tt.want {
+			t.Errorf("freeFraction(%d, %d): expected %d, got %d", tt.free, tt.allocatable, tt.want, got)
+		}
+	}
+}
+
+// nodeInfoMapLister lists the NodeInfos of a map by name, including those
+// without a Node, which the snapshot would hide.
+type nodeInfoMapLister map[string]*framework.NodeInfo
+
+func (l nodeInfoMapLister) List() ([]*framework.NodeInfo, error) {
+	var nodeInfos []*framework.NodeInfo
+	for _, nodeInfo := range l {
+		nodeInfos = append(nodeInfos, nodeInfo)
+	}
+	return nodeInfos, nil
+}
+
+func (l nodeInfoMapLister) HavePodsWithAffinityList() ([]*framework.NodeInfo, error) {
+	return nil, nil
+}
+
+func (l nodeInfoMapLister) HavePodsWithRequiredAntiAffinityList() ([]*framework.NodeInfo, error) {
+	return nil, nil
+}
+
+func (l nodeInfoMapLister) Get(nodeName string) (*framework.NodeInfo, error) {
+	if nodeInfo, ok := l[nodeName]; ok {
+		return nodeInfo, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func TestCustomScheduler_Score_UnscorableNodes(t *testing.T) {
+	nodeInfos := nodeInfoMapLister{
+		"ready": makeNodeInfo("ready", 4000, 4<<30),
+		// a node registering without allocatable resources yet
+		"registering": makeNodeInfo("registering", 0, 0),
+	}
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod"}}
+	for _, mode := range []string{leastMode, mostMode, balancedMode} {
+		t.Run(mode, func(t *testing.T) {
+			cs := &CustomScheduler{scoreMode: mode, nodeInfoListerOverride: nodeInfos}
+			state := framework.NewCycleState()
+			var scores framework.NodeScoreList
+			// the lister doesn't hold a node deleted while the cycle runs
+			for _, name := range []string{"ready", "registering", "deleted"} {
+				score, status := cs.Score(context.Background(), state, pod, name)
+				if !status.IsSuccess() {
+					t.Fatalf("node %s: expected success, got %v", name, status)
+				}
+				scores = append(scores, framework.NodeScore{Name: name, Score: score})
+			}
+			if status := cs.NormalizeScore(context.Background(), state, pod, scores); !status.IsSuccess() {
+				t.Fatalf("unexpected error: %v", status)
+			}
+			for _, score := range scores {
+				want := int64(framework.MinNodeScore)
+				if score.Name == "ready" {
+					want = framework.MaxNodeScore
+				}
+				if score.Score != want {
+					t.Errorf("expected node %s to score %d, got %d", score.Name, want, score.Score)
+				}
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_Score_NodeMissingFromSnapshot(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var cs *CustomScheduler
+	newPlugin := func(obj runtime.Object, h framework.Handle) (framework.Plugin, error) {
+		p, err := New(obj, h)
+		cs, _ = p.(*CustomScheduler)
+		return p, err
+	}
+	// the scheduler's snapshot stays empty until it runs a cycle
+	newTestScheduler(ctx, t, clientsetfake.NewSimpleClientset(), &schedulerapi.Plugins{
+		Score: schedulerapi.PluginSet{Enabled: []schedulerapi.Plugin{{Name: Name, Weight: 1}}},
+	}, newPlugin)
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod"}}
+	state := framework.NewCycleState()
+	if status := cs.PreScore(ctx, state, pod, nil); !status.IsSuccess() {
+		t.Fatalf("unexpected error: %v", status)
+	}
+	score, status := cs.Score(ctx, state, pod, "node1")
+	if !status.IsSuccess() || score != framework.MinNodeScore {
+		t.Fatalf("expected the minimum score for a node missing from the snapshot, got %d and %v", score, status)
+	}
+	scores := framework.NodeScoreList{{Name: "node1", Score: score}}
+	if status := cs.NormalizeScore(ctx, state, pod, scores); !status.IsSuccess() || scores[0].Score != framework.MinNodeScore {
+		t.Errorf("expected NormalizeScore to keep the minimum score, got %d and %v", scores[0].Score, status)
+	}
+}
+
+func TestCustomScheduler_Filter_NodeNotFound(t *testing.T) {
+	cs := &CustomScheduler{memorySafetyMargin: 1 << 30}
+	status := cs.Filter(context.Background(), framework.NewCycleState(), &v1.Pod{}, framework.NewNodeInfo())
+	if status.Code() != framework.UnschedulableAndUnresolvable {
+		t.Errorf("expected %v, got %v", framework.UnschedulableAndUnresolvable, status)
+	}
+}
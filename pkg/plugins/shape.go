@@ -0,0 +1,77 @@
+package plugins
+
+import (
+	"fmt"
+	"math"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// ShapePoint is a point of the utilization-to-score curve of the Shaped mode:
+// a node whose utilization after placing the pod is Utilization percent
+// scores Score.
+type ShapePoint struct {
+	Utilization int64 `json:"utilization"`
+	Score       int64 `json:"score"`
+}
+
+// validateShape checks that the utilizations of the points increase strictly
+// within 0 to 100 and that the scores are valid node scores.
+func validateShape(shape []ShapePoint) error {
+	for i, p := range shape {
+		if p.Utilization < 0 || p.Utilization > 100 {
+			return fmt.Errorf("utilization of point %d must be between 0 and 100, got %d", i, p.Utilization)
+		}
+		if p.Score < framework.MinNodeScore || p.Score > framework.MaxNodeScore {
+			return fmt.Errorf("score of point %d must be between %d and %d, got %d", i, framework.MinNodeScore, framework.MaxNodeScore, p.Score)
+		}
+		if i > 0 && p.Utilization <= shape[i-1].Utilization {
+			return fmt.Errorf("utilizations must increase, got %d after %d", p.Utilization, shape[i-1].Utilization)
+		}
+	}
+	return nil
+}
+
+// shapedScore scores a node by interpolating the shape at the utilization of
+// the scored resource once the pod's requests are placed. It reports false
+// when the pod doesn't fit the node.
+func (cs *CustomScheduler) shapedScore(requests v1.ResourceList, nodeInfo *framework.NodeInfo) (int64, bool) {
+	resourceName := cs.scoredResource(leastMode)
+	free, fits := freeAfter(requests, nodeInfo, resourceName)
+	allocatable := allocatableAmount(nodeInfo, resourceName)
+	if !fits || allocatable <= 0 {
+		return framework.MinNodeScore, false
+	}
+	return interpolateShape(cs.shape, float64(allocatable-free)*100/float64(allocatable)), true
+}
+
+// interpolateShape returns the score of the utilization on the piecewise
+// linear curve through the points. Utilizations outside of the points score
+// like the nearest one.
+func interpolateShape(shape []ShapePoint, utilization float64) int64 {
+	if len(shape) == 0 {
+		return framework.MinNodeScore
+	}
+	if utilization <= float64(shape[0].Utilization) {
+		return shape[0].Score
+	}
+	for i := 1; i < len(shape); i++ {
+		lo, hi := shape[i-1], shape[i]
+		if utilization <= float64(hi.Utilization) {
+			ratio := (utilization - float64(lo.Utilization)) / float64(hi.Utilization-lo.Utilization)
+			return lo.Score + int64(math.Round(ratio*float64(hi.Score-lo.Score)))
+		}
+	}
+	return shape[len(shape)-1].Score
+}
+
+// shapedSubScorer scores the nodes in the Shaped mode.
+type shapedSubScorer struct {
+	cs *CustomScheduler
+}
+
+func (sh shapedSubScorer) subScore(s *preScoreState, nodeInfo *framework.NodeInfo) int64 {
+	score, _ := sh.cs.shapedScore(s.requests, nodeInfo)
+	return score
+}
@@ -0,0 +1,82 @@
+package plugins
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// targetShape prefers the nodes ending up 70% full.
+var targetShape = []ShapePoint{{Utilization: 0, Score: 0}, {Utilization: 70, Score: 100}, {Utilization: 100, Score: 0}}
+
+func TestInterpolateShape(t *testing.T) {
+	tests := []struct {
+		name        string
+		shape       []ShapePoint
+		utilization float64
+		want        int64
+	}{
+		{name: "first point", shape: targetShape, utilization: 0, want: 0},
+		{name: "rising edge", shape: targetShape, utilization: 35, want: 50},
+		{name: "inner point", shape: targetShape, utilization: 70, want: 100},
+		{name: "falling edge", shape: targetShape, utilization: 85, want: 50},
+		{name: "last point", shape: targetShape, utilization: 100, want: 0},
+		{name: "below the first point", shape: []ShapePoint{{Utilization: 20, Score: 40}, {Utilization: 80, Score: 100}}, utilization: 5, want: 40},
+		{name: "above the last point", shape: []ShapePoint{{Utilization: 20, Score: 40}, {Utilization: 80, Score: 100}}, utilization: 95, want: 100},
+		{name: "single point", shape: []ShapePoint{{Utilization: 50, Score: 60}}, utilization: 10, want: 60},
+		{name: "no shape", utilization: 50, want: framework.MinNodeScore},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := interpolateShape(tt.shape, tt.utilization); got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_Score_ShapedMode(t *testing.T) {
+	makeNode := func(name string, requested int64) *framework.NodeInfo {
+		ni := makeNodeInfo(name, 4000, 10<<30)
+		ni.AddPod(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name + "-pod"}, Spec: v1.PodSpec{Containers: []v1.Container{{
+			Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceMemory: *resource.NewQuantity(requested, resource.BinarySI)}},
+		}}}})
+		return ni
+	}
+	// the pod takes another 10% of every node
+	nodeInfos := []*framework.NodeInfo{
+		makeNode("empty", 0),
+		makeNode("quarter", 10<<30/100*25),
+		makeNode("target", 10<<30/100*60),
+		makeNode("full", 10<<30/100*90),
+		makeNode("overcommitted", 10<<30/100*95),
+	}
+	cs := &CustomScheduler{
+		handle:    newTestFrameworkWithNodes(t, nil, nodeInfos),
+		scoreMode: shapedMode,
+		shape:     targetShape,
+	}
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod"}, Spec: v1.PodSpec{Containers: []v1.Container{{
+		Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceMemory: *resource.NewQuantity(10<<30/10, resource.BinarySI)}},
+	}}}}
+	// NormalizeScore leaves the shaped scores as they are
+	want := map[string]int64{"empty": 14, "quarter": 50, "target": 100, "full": 0, "overcommitted": 0}
+	for _, score := range scoreNodes(t, cs, pod, nodeInfos) {
+		if score.Score != want[score.Name] {
+			t.Errorf("expected node %s to score %d, got %d", score.Name, want[score.Name], score.Score)
+		}
+	}
+}
+
+func TestCustomScheduler_PodScoreMode_Shaped(t *testing.T) {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{scoreModeAnnotation: "shaped"}}}
+	if mode := (&CustomScheduler{scoreMode: leastMode}).podScoreMode(pod); mode != leastMode {
+		t.Errorf("expected pods not to pick the Shaped mode without a shape, got %s", mode)
+	}
+	if mode := (&CustomScheduler{scoreMode: leastMode, shape: targetShape}).podScoreMode(pod); mode != shapedMode {
+		t.Errorf("expected pods to pick the Shaped mode with a shape, got %s", mode)
+	}
+}
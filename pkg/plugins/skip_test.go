@@ -0,0 +1,96 @@
+package plugins
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/defaultbinder"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/queuesort"
+	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+	st "k8s.io/kubernetes/pkg/scheduler/testing"
+)
+
+func TestCustomScheduler_SkipScoring(t *testing.T) {
+	nodeInfos := []*framework.NodeInfo{makeNodeInfo("node1", 4000, 4<<30), makeNodeInfo("node2", 4000, 2<<30)}
+	client := clientsetfake.NewSimpleClientset()
+	fh, err := st.NewFramework(
+		[]st.RegisterPluginFunc{
+			st.RegisterBindPlugin(defaultbinder.Name, defaultbinder.New),
+			st.RegisterQueueSortPlugin(queuesort.Name, queuesort.New),
+			st.RegisterPluginAsExtensions(Name, New, "PreScore", "Score"),
+		},
+		"default-scheduler",
+		wait.NeverStop,
+		frameworkruntime.WithClientSet(client),
+		frameworkruntime.WithInformerFactory(informers.NewSharedInformerFactory(client, 0)),
+		frameworkruntime.WithSnapshotSharedLister(&fakeSharedLister{nodes: nodeInfos}),
+	)
+	if err != nil {
+		t.Fatalf("fail to create framework: %s", err)
+	}
+	nodes := []*v1.Node{nodeInfos[0].Node(), nodeInfos[1].Node()}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantScored  bool
+	}{
+		{
+			name:       "pod is scored",
+			wantScored: true,
+		},
+		{
+			name:        "pod opted out",
+			annotations: map[string]string{scoringAnnotation: disabledValue},
+		},
+		{
+			name:        "unknown value is ignored",
+			annotations: map[string]string{scoringAnnotation: "off"},
+			wantScored:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Annotations: tt.annotations}}
+			ctx := context.Background()
+			state := framework.NewCycleState()
+			if status := fh.RunPreScorePlugins(ctx, state, pod, nodes); !status.IsSuccess() {
+				t.Fatalf("unexpected PreScore status: %v", status)
+			}
+			if skipped := state.SkipScorePlugins.Has(Name); skipped == tt.wantScored {
+				t.Fatalf("expected the plugin to be skipped: %v, got %v", !tt.wantScored, skipped)
+			}
+			scores, status := fh.RunScorePlugins(ctx, state, pod, nodes)
+			if !status.IsSuccess() {
+				t.Fatalf("unexpected Score status: %v", status)
+			}
+			for _, nodeScores := range scores {
+				if scored := len(nodeScores.Scores) == 1 && nodeScores.Scores[0].Name == Name; scored != tt.wantScored {
+					t.Errorf("expected node %s to be scored by the plugin: %v, got %v", nodeScores.Name, tt.wantScored, nodeScores.Scores)
+				}
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_NormalizeScoreAfterSkip(t *testing.T) {
+	cs := &CustomScheduler{
+		handle:    newTestFrameworkWithNodes(t, nil, []*framework.NodeInfo{makeNodeInfo("node1", 4000, 4<<30)}),
+		scoreMode: leastMode,
+	}
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Annotations: map[string]string{scoringAnnotation: disabledValue}}}
+	state := framework.NewCycleState()
+	if status := cs.PreScore(context.Background(), state, pod, nil); !status.IsSkip() {
+		t.Fatalf("expected PreScore to skip the pod, got %v", status)
+	}
+	scores := framework.NodeScoreList{{Name: "node1", Score: 42}}
+	if status := cs.NormalizeScore(context.Background(), state, pod, scores); !status.IsSuccess() || scores[0].Score != 42 {
+		t.Errorf("expected NormalizeScore to leave the scores alone, got %v and %v", status, scores)
+	}
+}
@@ -0,0 +1,53 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+var _ framework.FilterPlugin = &CustomScheduler{}
+
+// Filter rejects the node when it already runs as many members of the pod's
+// group as the maxMembersPerNode label allows, when placing the pod there
+// would keep the group from spreading over minDomains domains, or when it
+// would be left with less memory than the safety margin.
+func (cs *CustomScheduler) Filter(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
+	if nodeInfo == nil || nodeInfo.Node() == nil {
+		klog.FromContext(ctx).V(4).Info("Node is gone from the snapshot", "pod", klog.KObj(pod))
+		return framework.NewStatus(framework.UnschedulableAndUnresolvable, "node not found")
+	}
+	if status := cs.checkMaxMembersPerNode(pod, nodeInfo); status != nil {
+		return status
+	}
+	if status := cs.checkMinDomains(state, pod, nodeInfo); status != nil {
+		return status
+	}
+	return cs.checkMemoryMargin(pod, nodeInfo)
+}
+
+// checkMaxMembersPerNode rejects the node when it already runs as many
+// members of the pod's group as the maxMembersPerNode label allows. Pods
+// without the label are never limited.
+func (cs *CustomScheduler) checkMaxMembersPerNode(pod *v1.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
+	value, ok := pod.Labels[maxMembersPerNodeLabel]
+	if !ok {
+		return nil
+	}
+	group, ok := cs.podGroupName(pod)
+	if !ok || gangOptedOut(pod) {
+		return nil
+	}
+	maxPerNode, err := strconv.Atoi(value)
+	if err != nil || maxPerNode < 1 {
+		return framework.NewStatus(framework.UnschedulableAndUnresolvable, fmt.Sprintf("Invalid maxMembersPerNode value: label %s %q is not a positive integer", maxMembersPerNodeLabel, value))
+	}
+	if members := cs.countNodeMembers(pod, group, nodeInfo); members >= maxPerNode {
+		return framework.NewStatus(framework.Unschedulable, fmt.Sprintf("Node already runs %d pods of the group '%s', and the group allows at most %d per node", members, group, maxPerNode))
+	}
+	return nil
+}
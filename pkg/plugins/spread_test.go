@@ -0,0 +1,115 @@
+package plugins
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_Filter_MaxMembersPerNode(t *testing.T) {
+	makePod := func(name, maxPerNode string) *v1.Pod {
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"podGroup": "g1"}}}
+		if maxPerNode != "" {
+			pod.Labels["maxMembersPerNode"] = maxPerNode
+		}
+		return pod
+	}
+	nodeInfo := makeNodeInfo("node1", 1000, 1<<30)
+	nodeInfo.AddPod(makePod("member0", ""))
+	nodeInfo.AddPod(makePod("member1", ""))
+
+	tests := []struct {
+		name        string
+		pod         *v1.Pod
+		want        framework.Code
+		wantMessage string
+	}{
+		{
+			name: "no cap",
+			pod:  makePod("incoming", ""),
+			want: framework.Success,
+		},
+		{
+			name: "node below the cap",
+			pod:  makePod("incoming", "3"),
+			want: framework.Success,
+		},
+		{
+			name:        "node at the cap",
+			pod:         makePod("incoming", "2"),
+			want:        framework.Unschedulable,
+			wantMessage: "Node already runs 2 pods of the group 'g1', and the group allows at most 2 per node",
+		},
+		{
+			name:        "invalid cap",
+			pod:         makePod("incoming", "0"),
+			want:        framework.UnschedulableAndUnresolvable,
+			wantMessage: `Invalid maxMembersPerNode value: label maxMembersPerNode "0" is not a positive integer`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{groupLabelKey: groupNameLabel}
+			status := cs.Filter(context.Background(), nil, tt.pod, nodeInfo)
+			if status.Code() != tt.want {
+				t.Fatalf("expected %v, got %v: %s", tt.want, status.Code(), status.Message())
+			}
+			if tt.wantMessage != "" && status.Message() != tt.wantMessage {
+				t.Errorf("expected message %q, got %q", tt.wantMessage, status.Message())
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_Score_SpreadGroup(t *testing.T) {
+	makePod := func(name string) *v1.Pod {
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"podGroup": "g1"}}}
+	}
+	// all nodes have the same memory left, and node1 already runs two
+	// members, node2 one and node3 none
+	newNodeInfos := func() []*framework.NodeInfo {
+		nodeInfos := []*framework.NodeInfo{makeNodeInfo("node1", 1000, 1<<30), makeNodeInfo("node2", 1000, 1<<30), makeNodeInfo("node3", 1000, 1<<30)}
+		nodeInfos[0].AddPod(makePod("member0"))
+		nodeInfos[0].AddPod(makePod("member1"))
+		nodeInfos[1].AddPod(makePod("member2"))
+		return nodeInfos
+	}
+	tests := []struct {
+		name  string
+		bonus int64
+		want  map[string]int64
+	}{
+		{
+			name:  "members are a penalty",
+			bonus: 10,
+			want:  map[string]int64{"node1": 90, "node2": 95, "node3": 100},
+		},
+		{
+			// the penalty is capped, so crowded nodes still get scored
+			name:  "penalty saturates",
+			bonus: 100,
+			want:  map[string]int64{"node1": 50, "node2": 50, "node3": 100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeInfos := newNodeInfos()
+			cs := &CustomScheduler{
+				handle:              newTestFrameworkWithNodes(t, nil, nodeInfos),
+				scoreMode:           mostMode,
+				groupLabelKey:       groupNameLabel,
+				groupAffinityWeight: 50,
+				groupAffinityBonus:  tt.bonus,
+				spreadGroup:         true,
+			}
+			for _, score := range scoreNodes(t, cs, makePod("incoming"), nodeInfos) {
+				if score.Score != tt.want[score.Name] {
+					t.Errorf("expected node %s to score %d, got %d", score.Name, tt.want[score.Name], score.Score)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package plugins
+
+import (
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// The states below are written to the CycleState for other plugins to read.
+// They are a stable contract: fields are only ever added to them.
+const (
+	// GroupStateKey holds the GroupState PreFilter writes for pods in a
+	// group.
+	GroupStateKey = framework.StateKey(Name + "/Group")
+	// ScoreInputsStateKey holds the ScoreInputs PreScore writes.
+	ScoreInputsStateKey = framework.StateKey(Name + "/ScoreInputs")
+)
+
+// GroupState is the group of the pod being scheduled as seen in PreFilter.
+type GroupState struct {
+	Namespace    string
+	Name         string
+	MinAvailable int
+	// Members are the namespace/name keys of the live pods counted toward
+	// MinAvailable, sorted.
+	Members []string
+}
+
+// Clone implements framework.StateData.
+func (s *GroupState) Clone() framework.StateData {
+	c := *s
+	c.Members = append([]string(nil), s.Members...)
+	return &c
+}
+
+// ScoreInputs are what Score computes the scores of the pod from.
+type ScoreInputs struct {
+	// Mode is the score mode of the pod.
+	Mode string
+	// Resource is the resource the mode scores on, or memory for the modes
+	// scoring several resources.
+	Resource v1.ResourceName
+	// Requests are the effective requests of the pod.
+	Requests v1.ResourceList
+	// Nodes are the inputs of each node PreScore was given.
+	Nodes map[string]NodeScoreInputs
+}
+
+// NodeScoreInputs are the amounts of the scored resource on a node, in the
+// unit of the resource, and the members of the pod's group on it.
+type NodeScoreInputs struct {
+	Allocatable int64
+	Requested   int64
+	PodRequest  int64
+	// Free is what is left of the resource once the pod is placed. It is
+	// only meaningful when Fits is set.
+	Free         int64
+	Fits         bool
+	GroupMembers int
+}
+
+// Clone implements framework.StateData.
+func (s *ScoreInputs) Clone() framework.StateData {
+	c := *s
+	c.Requests = s.Requests.DeepCopy()
+	c.Nodes = make(map[string]NodeScoreInputs, len(s.Nodes))
+	for name, inputs := range s.Nodes {
+		c.Nodes[name] = inputs
+	}
+	return &c
+}
+
+// newGroupState returns the exported copy of the PreFilter state.
+func newGroupState(s *preFilterState) *GroupState {
+	members := s.members.UnsortedList()
+	sort.Strings(members)
+	return &GroupState{
+		Namespace:    s.namespace,
+		Name:         s.group,
+		MinAvailable: s.minAvailable,
+		Members:      members,
+	}
+}
+
+// newScoreInputs returns the score inputs of the pod on the nodes.
+func (cs *CustomScheduler) newScoreInputs(s *preScoreState, nodes []*v1.Node) *ScoreInputs {
+	resourceName := cs.scoredResource(s.mode)
+	inputs := &ScoreInputs{
+		Mode:     s.mode,
+		Resource: resourceName,
+		Requests: s.requests.DeepCopy(),
+		Nodes:    make(map[string]NodeScoreInputs, len(nodes)),
+	}
+	for _, node := range nodes {
+		nodeInfo, err := cs.nodeInfoLister().Get(node.Name)
+		if err != nil {
+			continue
+		}
+		var n NodeScoreInputs
+		n.Allocatable, n.Requested, n.PodRequest, _ = nodeAmounts(s.requests, nodeInfo, resourceName)
+		n.Free, n.Fits = cs.nodeFree(s, nodeInfo, resourceName)
+		n.GroupMembers = s.memberNodes[node.Name]
+		inputs.Nodes[node.Name] = n
+	}
+	return inputs
+}
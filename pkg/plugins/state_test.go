@@ -0,0 +1,99 @@
+package plugins_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"my-scheduler-plugins/pkg/plugins"
+)
+
+// TestCycleStateContract reads the states the plugin writes the way another
+// plugin would, through their exported keys and types.
+func TestCycleStateContract(t *testing.T) {
+	member := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "member",
+			Namespace: "default",
+			Labels:    map[string]string{"podGroup": "g1", "minAvailable": "2"},
+		},
+		Spec: v1.PodSpec{NodeName: "node1"},
+	}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "incoming",
+			Namespace: "default",
+			Labels:    map[string]string{"podGroup": "g1", "minAvailable": "2"},
+		},
+		Spec: v1.PodSpec{Containers: []v1.Container{{
+			Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")}},
+		}}},
+	}
+	nodeInfos := []*framework.NodeInfo{plugins.MakeNodeInfo("node1", 4000, 4<<30), plugins.MakeNodeInfo("node2", 4000, 512<<20)}
+	nodeInfos[0].AddPod(member)
+	fh := plugins.NewTestFrameworkWithNodes(t, []*v1.Pod{member, pod}, nodeInfos)
+	p, err := plugins.New(&runtime.Unknown{Raw: []byte(`{"mode": "Most"}`)}, fh)
+	if err != nil {
+		t.Fatalf("fail to create plugin: %v", err)
+	}
+	cs := p.(*plugins.CustomScheduler)
+	plugins.SkipPodCacheSync(cs)
+
+	state := framework.NewCycleState()
+	if _, status := cs.PreFilter(context.Background(), state, pod); !status.IsSuccess() {
+		t.Fatalf("unexpected error: %v", status)
+	}
+	if status := cs.PreScore(context.Background(), state, pod, []*v1.Node{nodeInfos[0].Node(), nodeInfos[1].Node()}); !status.IsSuccess() {
+		t.Fatalf("unexpected error: %v", status)
+	}
+
+	c, err := state.Read(plugins.GroupStateKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	group, ok := c.(*plugins.GroupState)
+	if !ok {
+		t.Fatalf("expected a *GroupState, got %T", c)
+	}
+	wantGroup := &plugins.GroupState{Namespace: "default", Name: "g1", MinAvailable: 2, Members: []string{"default/incoming", "default/member"}}
+	if !reflect.DeepEqual(group, wantGroup) {
+		t.Errorf("expected group %+v, got %+v", wantGroup, group)
+	}
+
+	c, err = state.Read(plugins.ScoreInputsStateKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inputs, ok := c.(*plugins.ScoreInputs)
+	if !ok {
+		t.Fatalf("expected a *ScoreInputs, got %T", c)
+	}
+	if inputs.Mode != "Most" || inputs.Resource != v1.ResourceMemory {
+		t.Errorf("expected the Most mode on memory, got %s on %s", inputs.Mode, inputs.Resource)
+	}
+	wantNodes := map[string]plugins.NodeScoreInputs{
+		"node1": {Allocatable: 4 << 30, PodRequest: 1 << 30, Free: 3 << 30, Fits: true, GroupMembers: 1},
+		"node2": {Allocatable: 512 << 20, PodRequest: 1 << 30},
+	}
+	if !reflect.DeepEqual(inputs.Nodes, wantNodes) {
+		t.Errorf("expected node inputs %+v, got %+v", wantNodes, inputs.Nodes)
+	}
+
+	// the states are cloned along with the CycleState
+	cloned := state.Clone()
+	c, err = cloned.Read(plugins.ScoreInputsStateKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	clonedInputs := c.(*plugins.ScoreInputs)
+	clonedInputs.Nodes["node1"] = plugins.NodeScoreInputs{}
+	if inputs.Nodes["node1"].Free != 3<<30 {
+		t.Errorf("expected the clone not to share the node inputs")
+	}
+}
@@ -0,0 +1,125 @@
+// Package testing provides fakes to build the custom scheduler plugin with in
+// unit tests, without a running scheduler.
+package testing
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	fakeframework "k8s.io/kubernetes/pkg/scheduler/framework/fake"
+)
+
+// Handle is a framework.Handle good enough to build the plugin and run its
+// extension points: the snapshot holds the given nodes, the informers and the
+// pod lister hold the given pods, and the events go to a fake recorder. No
+// pod is ever waiting on Permit. The methods the plugin doesn't use panic.
+type Handle struct {
+	framework.Handle
+
+	client          *fake.Clientset
+	informerFactory informers.SharedInformerFactory
+	nodes           []*framework.NodeInfo
+	recorder        *events.FakeRecorder
+}
+
+// NewHandle returns a handle whose snapshot holds the nodes and whose
+// informers hold the pods. The informers are never started, so the pods
+// added later with AddPod are the only changes they see.
+func NewHandle(nodes []*framework.NodeInfo, pods []*v1.Pod) *Handle {
+	client := fake.NewSimpleClientset()
+	h := &Handle{
+		client:          client,
+		informerFactory: informers.NewSharedInformerFactory(client, 0),
+		nodes:           nodes,
+		recorder:        events.NewFakeRecorder(100),
+	}
+	for _, pod := range pods {
+		h.AddPod(pod)
+	}
+	return h
+}
+
+// AddPod adds the pod to the pod informer.
+func (h *Handle) AddPod(pod *v1.Pod) {
+	h.podInformer().GetStore().Add(pod)
+}
+
+// PodLister returns the lister of the pods of the handle, to be passed to
+// plugins.WithPodLister.
+func (h *Handle) PodLister() corelisters.PodLister {
+	return h.informerFactory.Core().V1().Pods().Lister()
+}
+
+// NodeInfoLister returns the lister of the nodes of the snapshot, to be passed
+// to plugins.WithNodeInfoLister.
+func (h *Handle) NodeInfoLister() framework.NodeInfoLister {
+	return fakeframework.NodeInfoLister(h.nodes)
+}
+
+// Events returns the events the plugin recorded.
+func (h *Handle) Events() <-chan string {
+	return h.recorder.Events
+}
+
+func (h *Handle) podInformer() cache.SharedIndexInformer {
+	return h.informerFactory.Core().V1().Pods().Informer()
+}
+
+// ClientSet implements framework.Handle.
+func (h *Handle) ClientSet() clientset.Interface {
+	return h.client
+}
+
+// SharedInformerFactory implements framework.Handle.
+func (h *Handle) SharedInformerFactory() informers.SharedInformerFactory {
+	return h.informerFactory
+}
+
+// SnapshotSharedLister implements framework.Handle.
+func (h *Handle) SnapshotSharedLister() framework.SharedLister {
+	return sharedLister{nodes: h.nodes}
+}
+
+// EventRecorder implements framework.Handle.
+func (h *Handle) EventRecorder() events.EventRecorder {
+	return h.recorder
+}
+
+// IterateOverWaitingPods implements framework.Handle.
+func (h *Handle) IterateOverWaitingPods(callback func(framework.WaitingPod)) {}
+
+// GetWaitingPod implements framework.Handle.
+func (h *Handle) GetWaitingPod(uid types.UID) framework.WaitingPod {
+	return nil
+}
+
+// RejectWaitingPod implements framework.Handle.
+func (h *Handle) RejectWaitingPod(uid types.UID) bool {
+	return false
+}
+
+// sharedLister is the snapshot of the handle.
+type sharedLister struct {
+	nodes []*framework.NodeInfo
+}
+
+func (s sharedLister) NodeInfos() framework.NodeInfoLister {
+	return fakeframework.NodeInfoLister(s.nodes)
+}
+
+func (s sharedLister) StorageInfos() framework.StorageInfoLister {
+	return storageInfoLister{}
+}
+
+// storageInfoLister reports every PVC as unused.
+type storageInfoLister struct{}
+
+func (storageInfoLister) IsPVCUsedByPods(key string) bool {
+	return false
+}
@@ -0,0 +1,57 @@
+package plugins
+
+import (
+	"hash/fnv"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// breakTies makes a single node win each group of nodes with the same score,
+// picked by a stable hash of the pod UID and the node name. The winner keeps
+// the score and the others lose a point, or the winner gains one, whichever
+// doesn't collide with another score. Groups with no free neighbouring score
+// are left tied, so nodes with different scores never swap.
+func breakTies(pod *v1.Pod, scores framework.NodeScoreList) {
+	groups := make(map[int64][]int)
+	for i := range scores {
+		groups[scores[i].Score] = append(groups[scores[i].Score], i)
+	}
+	taken := make(map[int64]bool, len(groups))
+	levels := make([]int64, 0, len(groups))
+	for score := range groups {
+		taken[score] = true
+		levels = append(levels, score)
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i] > levels[j] })
+
+	for _, score := range levels {
+		tied := groups[score]
+		if len(tied) < 2 {
+			continue
+		}
+		sort.Slice(tied, func(i, j int) bool {
+			return tieBreakHash(pod, scores[tied[i]].Name) < tieBreakHash(pod, scores[tied[j]].Name)
+		})
+		switch {
+		case score > framework.MinNodeScore && !taken[score-1]:
+			for _, i := range tied[1:] {
+				scores[i].Score--
+			}
+			taken[score-1] = true
+		case score < framework.MaxNodeScore && !taken[score+1]:
+			scores[tied[0]].Score++
+			taken[score+1] = true
+		}
+	}
+}
+
+// tieBreakHash orders the nodes for a pod.
+func tieBreakHash(pod *v1.Pod, nodeName string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(pod.UID))
+	h.Write([]byte{'/'})
+	h.Write([]byte(nodeName))
+	return h.Sum64()
+}
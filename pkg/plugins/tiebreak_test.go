@@ -0,0 +1,86 @@
+package plugins
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestBreakTies(t *testing.T) {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "incoming", UID: types.UID("uid-1")}}
+	tests := []struct {
+		name   string
+		scores framework.NodeScoreList
+	}{
+		{
+			name:   "two tied nodes",
+			scores: framework.NodeScoreList{{Name: "node1", Score: 50}, {Name: "node2", Score: 50}},
+		},
+		{
+			name:   "tie next to other scores",
+			scores: framework.NodeScoreList{{Name: "node1", Score: 50}, {Name: "node2", Score: 50}, {Name: "node3", Score: 49}, {Name: "node4", Score: 100}},
+		},
+		{
+			name:   "tie at the minimum score",
+			scores: framework.NodeScoreList{{Name: "node1", Score: 0}, {Name: "node2", Score: 0}, {Name: "node3", Score: 0}},
+		},
+		{
+			name:   "tie with no room",
+			scores: framework.NodeScoreList{{Name: "node1", Score: 51}, {Name: "node2", Score: 50}, {Name: "node3", Score: 50}, {Name: "node4", Score: 49}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := append(framework.NodeScoreList(nil), tt.scores...)
+			breakTies(pod, first)
+			for run := 0; run < 10; run++ {
+				again := append(framework.NodeScoreList(nil), tt.scores...)
+				breakTies(pod, again)
+				for i := range again {
+					if again[i] != first[i] {
+						t.Fatalf("expected the same scores in every run, got %v and %v", first, again)
+					}
+				}
+			}
+			for i := range tt.scores {
+				for j := range tt.scores {
+					if tt.scores[i].Score > tt.scores[j].Score && first[i].Score <= first[j].Score {
+						t.Errorf("expected %s to stay above %s, got %v", tt.scores[i].Name, tt.scores[j].Name, first)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_NormalizeScore_TieBreak(t *testing.T) {
+	nodeInfos := []*framework.NodeInfo{makeNodeInfo("node1", 1000, 1<<30), makeNodeInfo("node2", 1000, 1<<30)}
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "incoming", UID: types.UID("uid-1")}}
+	cs := &CustomScheduler{
+		handle:                newTestFrameworkWithNodes(t, nil, nodeInfos),
+		scoreMode:             mostMode,
+		deterministicTieBreak: true,
+	}
+	winner := ""
+	for run := 0; run < 10; run++ {
+		scores := framework.NodeScoreList{{Name: "node1", Score: 1 << 30}, {Name: "node2", Score: 1 << 30}}
+		if status := cs.NormalizeScore(context.Background(), framework.NewCycleState(), pod, scores); !status.IsSuccess() {
+			t.Fatalf("unexpected error: %v", status)
+		}
+		if scores[0].Score == scores[1].Score {
+			t.Fatalf("expected the tie to be broken, got %v", scores)
+		}
+		best := scores[0].Name
+		if scores[1].Score > scores[0].Score {
+			best = scores[1].Name
+		}
+		if winner != "" && best != winner {
+			t.Fatalf("expected %s to win every run, got %s", winner, best)
+		}
+		winner = best
+	}
+}
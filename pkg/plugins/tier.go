@@ -0,0 +1,36 @@
+package plugins
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// tierBonuses returns the bonus of each node whose tier label has one. Nodes
+// without the label, or with a tier missing from the map, get none.
+func (cs *CustomScheduler) tierBonuses(nodes []*v1.Node) map[string]int64 {
+	if cs.tierLabelKey == "" {
+		return nil
+	}
+	var bonuses map[string]int64
+	for _, node := range nodes {
+		bonus := cs.tierBonus[node.Labels[cs.tierLabelKey]]
+		if bonus == 0 {
+			continue
+		}
+		if bonuses == nil {
+			bonuses = make(map[string]int64)
+		}
+		bonuses[node.Name] = bonus
+	}
+	return bonuses
+}
+
+// applyTierBonuses adds the bonuses to the normalized scores of the nodes the
+// pod fits on.
+func applyTierBonuses(scores framework.NodeScoreList, unfit map[string]bool, bonuses map[string]int64) {
+	for i := range scores {
+		if bonus, ok := bonuses[scores[i].Name]; ok && !unfit[scores[i].Name] {
+			scores[i].Score = clampScore(scores[i].Score + bonus)
+		}
+	}
+}
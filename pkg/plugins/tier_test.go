@@ -0,0 +1,85 @@
+package plugins
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_TierBonus(t *testing.T) {
+	makeNode := func(name string, memory int64, tier string) *framework.NodeInfo {
+		ni := makeNodeInfo(name, 4000, memory)
+		if tier != "" {
+			ni.Node().Labels = map[string]string{"tier": tier}
+		}
+		return ni
+	}
+	// in Most mode the nodes normalize to 100, 90, 85 and 0
+	nodeInfos := []*framework.NodeInfo{
+		makeNode("largest", 100<<20, "gold"),
+		makeNode("larger", 90<<20, ""),
+		makeNode("large", 85<<20, "gold"),
+		makeNode("empty", 100<<20, "bronze"),
+	}
+	// the empty node has no memory left
+	nodeInfos[3].Requested.Memory = 100 << 20
+	tests := []struct {
+		name  string
+		bonus map[string]int64
+		want  map[string]int64
+	}{
+		{
+			name: "no bonus",
+			want: map[string]int64{"largest": 100, "larger": 90, "large": 85, "empty": 0},
+		},
+		{
+			name:  "bonus overcomes the memory score difference",
+			bonus: map[string]int64{"gold": 10, "silver": 5},
+			want:  map[string]int64{"largest": 100, "larger": 90, "large": 95, "empty": 0},
+		},
+		{
+			name:  "bonus doesn't overcome the memory score difference",
+			bonus: map[string]int64{"gold": 3, "bronze": 50},
+			want:  map[string]int64{"largest": 100, "larger": 90, "large": 88, "empty": 50},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CustomScheduler{
+				handle:       newTestFrameworkWithNodes(t, nil, nodeInfos),
+				scoreMode:    mostMode,
+				tierLabelKey: "tier",
+				tierBonus:    tt.bonus,
+			}
+			scores := scoreNodes(t, cs, &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod"}}, nodeInfos)
+			for _, score := range scores {
+				if score.Score != tt.want[score.Name] {
+					t.Errorf("expected node %s to score %d, got %d", score.Name, tt.want[score.Name], score.Score)
+				}
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_TierBonus_UnfitNode(t *testing.T) {
+	full := makeNodeInfo("full", 4000, 1<<20)
+	full.Node().Labels = map[string]string{"tier": "gold"}
+	nodeInfos := []*framework.NodeInfo{full, makeNodeInfo("free", 4000, 4<<30)}
+	cs := &CustomScheduler{
+		handle:       newTestFrameworkWithNodes(t, nil, nodeInfos),
+		scoreMode:    mostMode,
+		tierLabelKey: "tier",
+		tierBonus:    map[string]int64{"gold": 50},
+	}
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod"}, Spec: v1.PodSpec{Containers: []v1.Container{{
+		Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")}},
+	}}}}
+	for _, score := range scoreNodes(t, cs, pod, nodeInfos) {
+		if score.Name == "full" && score.Score != framework.MinNodeScore {
+			t.Errorf("expected the node the pod doesn't fit on to get no bonus, got %d", score.Score)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package plugins
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// tracerName is the instrumentation scope of the plugin's spans.
+const tracerName = "my-scheduler-plugins/pkg/plugins"
+
+// startSpan starts the span of an extension point as a child of the span in
+// ctx. The spans go to the global tracer provider, so they cost next to
+// nothing when tracing is off.
+func (cs *CustomScheduler) startSpan(ctx context.Context, phase string, pod *v1.Pod, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("pod", pod.Namespace+"/"+pod.Name))
+	if group, ok := cs.podGroupName(pod); ok {
+		attrs = append(attrs, attribute.String("group", group))
+	}
+	return otel.Tracer(tracerName).Start(ctx, Name+"/"+phase, trace.WithAttributes(attrs...))
+}
+
+// endSpan records the status of the extension point and ends the span.
+// Errors fail the span, while rejections are recorded as an event, since the
+// plugin worked as intended.
+func endSpan(span trace.Span, status *framework.Status) {
+	span.SetAttributes(attribute.String("status", status.Code().String()))
+	switch {
+	case status.IsSuccess():
+		span.SetStatus(codes.Ok, "")
+	case status.Code() == framework.Error:
+		span.SetStatus(codes.Error, status.Message())
+	default:
+		span.AddEvent(status.Code().String(), trace.WithAttributes(attribute.String("reason", status.Message())))
+	}
+	span.End()
+}
@@ -0,0 +1,105 @@
+package plugins
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_Tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(trace.NewNoopTracerProvider()) })
+
+	existing := []*v1.Pod{makeGangPod("pod0", "g1", 3), makeGangPod("pod1", "g1", 3), makeGangPod("pod2", "g2", 1)}
+	nodeInfos := []*framework.NodeInfo{makeNodeInfo("node1", 4000, 4<<30)}
+	cs := &CustomScheduler{
+		handle:               newTestFrameworkWithNodes(t, existing, nodeInfos),
+		scoreMode:            mostMode,
+		groupLabelKey:        groupNameLabel,
+		minAvailableLabelKey: minAvailableLabel,
+	}
+	// the framework's span of the scheduling cycle
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "schedulingCycle")
+	state := framework.NewCycleState()
+	cs.PreFilter(ctx, state, existing[0])
+	cs.PreFilter(ctx, state, existing[2])
+	scores := framework.NodeScoreList{{Name: "node1"}}
+	scores[0].Score, _ = cs.Score(ctx, state, existing[2], "node1")
+	cs.Score(ctx, state, existing[2], "missing")
+	cs.NormalizeScore(ctx, state, existing[2], scores)
+	parent.End()
+
+	tests := []struct {
+		name       string
+		attrs      map[string]string
+		wantStatus codes.Code
+		wantEvent  string
+	}{
+		{
+			name:       "CustomScheduler/PreFilter",
+			attrs:      map[string]string{"pod": "/pod0", "group": "g1", "status": "Unschedulable"},
+			wantStatus: codes.Unset,
+			wantEvent:  "Unschedulable",
+		},
+		{
+			name:       "CustomScheduler/PreFilter",
+			attrs:      map[string]string{"pod": "/pod2", "group": "g2", "status": "Success"},
+			wantStatus: codes.Ok,
+		},
+		{
+			name:       "CustomScheduler/Score",
+			attrs:      map[string]string{"pod": "/pod2", "group": "g2", "node": "node1", "mode": mostMode, "status": "Success"},
+			wantStatus: codes.Ok,
+		},
+		{
+			name:       "CustomScheduler/Score",
+			attrs:      map[string]string{"pod": "/pod2", "node": "missing", "status": "Success"},
+			wantStatus: codes.Ok,
+		},
+		{
+			name:       "CustomScheduler/NormalizeScore",
+			attrs:      map[string]string{"pod": "/pod2", "mode": mostMode, "status": "Success"},
+			wantStatus: codes.Ok,
+		},
+	}
+	spans := recorder.Ended()
+	if len(spans) != len(tests)+1 {
+		t.Fatalf("expected %d spans, got %d", len(tests)+1, len(spans))
+	}
+	for i, tt := range tests {
+		span := spans[i]
+		if span.Name() != tt.name {
+			t.Errorf("span %d: expected name %s, got %s", i, tt.name, span.Name())
+		}
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("span %d: expected a child of the scheduling cycle span", i)
+		}
+		attrs := make(map[string]string)
+		for _, kv := range span.Attributes() {
+			attrs[string(kv.Key)] = kv.Value.AsString()
+		}
+		for key, want := range tt.attrs {
+			if attrs[key] != want {
+				t.Errorf("span %d: expected attribute %s=%q, got %q", i, key, want, attrs[key])
+			}
+		}
+		if span.Status().Code != tt.wantStatus {
+			t.Errorf("span %d: expected status %v, got %v", i, tt.wantStatus, span.Status().Code)
+		}
+		var events []string
+		for _, event := range span.Events() {
+			events = append(events, event.Name)
+		}
+		if tt.wantEvent != "" && (len(events) != 1 || events[0] != tt.wantEvent) {
+			t.Errorf("span %d: expected event %s, got %v", i, tt.wantEvent, events)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// nodeMetricsGVR is the NodeMetrics resource of the metrics API. Its
+// usage.memory is the working set of the node.
+var nodeMetricsGVR = schema.GroupVersionResource{
+	Group:    "metrics.k8s.io",
+	Version:  "v1beta1",
+	Resource: "nodes",
+}
+
+// nodeUsage is the memory a node actually uses, and when it was measured.
+type nodeUsage struct {
+	memory    int64
+	timestamp time.Time
+}
+
+// usageCache caches the node metrics, which a background worker lists again
+// every refresh interval. Scheduling cycles only read the cached metrics.
+type usageCache struct {
+	client     dynamic.Interface
+	refresh    time.Duration
+	staleAfter time.Duration
+
+	mu    sync.Mutex
+	nodes map[string]nodeUsage
+	// stale is set once the fallback to requests has been logged, until
+	// fresh metrics come back. Only the refresh worker uses it.
+	stale bool
+}
+
+// setupUsageCache creates the metrics API client read by Score, and starts
+// refreshing the metrics until the plugin is closed.
+func (cs *CustomScheduler) setupUsageCache(h framework.Handle, refresh, staleAfter time.Duration) error {
+	client, err := dynamic.NewForConfig(h.KubeConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create metrics client: %w", err)
+	}
+	cs.usage = &usageCache{client: client, refresh: refresh, staleAfter: staleAfter}
+	go wait.UntilWithContext(wait.ContextForChannel(cs.stopCh), cs.refreshUsage, refresh)
+	return nil
+}
+
+// refreshUsage lists the node metrics again. Failures keep the previous
+// metrics, which turn stale over time.
+func (cs *CustomScheduler) refreshUsage(ctx context.Context) {
+	c := cs.usage
+	if c == nil {
+		return
+	}
+	logger := klog.FromContext(ctx)
+	defer c.logStale(logger, cs.now())
+	list, err := c.client.Resource(nodeMetricsGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		logger.Error(err, "Failed to list node metrics")
+		return
+	}
+	nodes := make(map[string]nodeUsage, len(list.Items))
+	for _, item := range list.Items {
+		usage, err := parseNodeUsage(&item)
+		if err != nil {
+			logger.V(2).Info("Ignoring the metrics of the node", "node", item.GetName(), "err", err)
+			continue
+		}
+		nodes[item.GetName()] = usage
+	}
+	c.mu.Lock()
+	c.nodes = nodes
+	c.mu.Unlock()
+}
+
+// logStale logs once that some nodes are scored on requests, because there
+// are no metrics or they are stale, until fresh metrics come back for all of
+// them.
+func (c *usageCache) logStale(logger klog.Logger, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stale := len(c.nodes) == 0
+	for _, usage := range c.nodes {
+		if now.Sub(usage.timestamp) > c.staleAfter {
+			stale = true
+			break
+		}
+	}
+	if stale && !c.stale {
+		logger.V(2).Info("Node metrics are unavailable or stale, scoring on requests instead")
+	}
+	c.stale = stale
+}
+
+// parseNodeUsage reads the memory usage and timestamp of a NodeMetrics.
+func parseNodeUsage(u *unstructured.Unstructured) (nodeUsage, error) {
+	value, found, err := unstructured.NestedString(u.Object, "usage", "memory")
+	if err != nil || !found {
+		return nodeUsage{}, fmt.Errorf("no usage.memory")
+	}
+	memory, err := resource.ParseQuantity(value)
+	if err != nil {
+		return nodeUsage{}, fmt.Errorf("invalid usage.memory %q", value)
+	}
+	stamp, _, _ := unstructured.NestedString(u.Object, "timestamp")
+	timestamp, err := time.Parse(time.RFC3339, stamp)
+	if err != nil {
+		return nodeUsage{}, fmt.Errorf("invalid timestamp %q", stamp)
+	}
+	return nodeUsage{memory: memory.Value(), timestamp: timestamp}, nil
+}
+
+// usageSnapshot returns the memory the nodes actually use, leaving out the
+// nodes whose metrics are stale. It is nil unless actual usage is used.
+func (cs *CustomScheduler) usageSnapshot() map[string]int64 {
+	c := cs.usage
+	if c == nil {
+		return nil
+	}
+	now := cs.now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	used := make(map[string]int64, len(c.nodes))
+	for name, usage := range c.nodes {
+		if now.Sub(usage.timestamp) <= c.staleAfter {
+			used[name] = usage.memory
+		}
+	}
+	return used
+}
+
+// nodeFree returns the amount of the resource left on the node once the pod's
+// requests are placed on it, as nodeLeft computes it for the pod's mode. With
+// the Fraction score basis it returns the fraction left instead.
+func (cs *CustomScheduler) nodeFree(s *preScoreState, nodeInfo *framework.NodeInfo, resourceName v1.ResourceName) (int64, bool) {
+	free, allocatable, fits := cs.nodeLeft(s, nodeInfo, s.mode, resourceName)
+	if !fits {
+		return 0, false
+	}
+	if cs.scoreBasis == basisFraction {
+		free = freeFraction(free, allocatable)
+	}
+	return free, true
+}
+
+// nodeLeft returns the amount of the resource left on the node once the pod's
+// requests are placed on it, like freeAfter, and the node's allocatable
+// amount. The free mode checks the amounts. With the Limits capacity policy,
+// the memory limits of the pods replace their memory requests, and with
+// actual usage enabled, the memory in use replaces what the node's pods
+// request. The requests of the node's pods come from the nodeResources
+// PreScore cached when there are any. The node's headroom is taken off the
+// memory left.
+func (cs *CustomScheduler) nodeLeft(s *preScoreState, nodeInfo *framework.NodeInfo, mode string, resourceName v1.ResourceName) (free, allocatable int64, fits bool) {
+	allocatable, requested, podRequest, ok := nodeAmounts(s.requests, nodeInfo, resourceName)
+	if !ok {
+		return 0, 0, false
+	}
+	if r, ok := s.nodeResources[nodeInfo.Node().Name]; ok {
+		requested = allocatable - resourceAmount(r.free, resourceName)
+	}
+	if resourceName == v1.ResourceMemory && s.nodeMemoryLimits != nil {
+		requested, podRequest = s.nodeMemoryLimits[nodeInfo.Node().Name], s.memoryLimit
+	}
+	if resourceName == v1.ResourceMemory && s.nodeUsage != nil {
+		if used, ok := s.nodeUsage[nodeInfo.Node().Name]; ok {
+			requested = used
+		}
+	}
+	free, err := ComputeNodeScore(mode, allocatable, requested, podRequest)
+	if err != nil {
+		return 0, allocatable, false
+	}
+	if resourceName == v1.ResourceMemory {
+		free = cs.withoutHeadroom(free, nodeInfo.Node())
+	}
+	return free, allocatable, true
+}
+
+// fractionScale is the raw score of a node with all of the resource left
+// under the Fraction basis.
+const fractionScale = 1000000
+
+// freeFraction scales the amount left on the node to the fraction of its
+// allocatable amount, in millionths. The division is done in float64, as the
+// product of two byte counts can overflow int64.
+func freeFraction(free, allocatable int64) int64 {
+	if allocatable <= 0 {
+		return 0
+	}
+	return int64(float64(free) / float64(allocatable) * fractionScale)
+}
@@ -0,0 +1,136 @@
+package plugins
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+func TestCustomScheduler_Score_ActualUsage(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	// "requested" looks nearly full but is mostly idle, "used" has fewer
+	// requests but uses most of its memory
+	newNodeInfos := func() []*framework.NodeInfo {
+		requested, used := makeNodeInfo("requested", 1000, 8<<30), makeNodeInfo("used", 1000, 8<<30)
+		requested.Requested.Memory = 7 << 30
+		used.Requested.Memory = 2 << 30
+		return []*framework.NodeInfo{requested, used}
+	}
+	tests := []struct {
+		name     string
+		metrics  []*unstructured.Unstructured
+		wantBest string
+	}{
+		{
+			name: "fresh metrics",
+			metrics: []*unstructured.Unstructured{
+				makeNodeMetrics("requested", "1Gi", now.Add(-time.Minute)),
+				makeNodeMetrics("used", "6Gi", now.Add(-time.Minute)),
+			},
+			wantBest: "requested",
+		},
+		{
+			name: "stale metrics fall back to requests",
+			metrics: []*unstructured.Unstructured{
+				makeNodeMetrics("requested", "1Gi", now.Add(-time.Hour)),
+				makeNodeMetrics("used", "6Gi", now.Add(-time.Hour)),
+			},
+			wantBest: "used",
+		},
+		{
+			name:     "missing metrics fall back to requests",
+			wantBest: "used",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeInfos := newNodeInfos()
+			client := newMetricsClient(t, tt.metrics...)
+			cs := &CustomScheduler{
+				handle:    newTestFrameworkWithNodes(t, nil, nodeInfos),
+				scoreMode: mostMode,
+				clock:     testingclock.NewFakeClock(now),
+				usage:     &usageCache{client: client, refresh: time.Minute, staleAfter: 5 * time.Minute},
+			}
+			cs.refreshUsage(context.Background())
+			for _, score := range scoreNodes(t, cs, &v1.Pod{}, nodeInfos) {
+				want := framework.MinNodeScore
+				if score.Name == tt.wantBest {
+					want = framework.MaxNodeScore
+				}
+				if score.Score != want {
+					t.Errorf("expected node %s to score %d, got %d", score.Name, want, score.Score)
+				}
+			}
+		})
+	}
+}
+
+func TestCustomScheduler_RefreshUsage(t *testing.T) {
+	now := time.Now()
+	client := newMetricsClient(t, makeNodeMetrics("node1", "1Gi", now))
+	client.ClearActions()
+	cs := &CustomScheduler{
+		clock:  testingclock.NewFakeClock(now),
+		stopCh: make(chan struct{}),
+		usage:  &usageCache{client: client, refresh: 10 * time.Millisecond, staleAfter: 5 * time.Minute},
+	}
+	go wait.UntilWithContext(wait.ContextForChannel(cs.stopCh), cs.refreshUsage, cs.usage.refresh)
+
+	// the metrics are refreshed in the background
+	if err := wait.PollImmediate(10*time.Millisecond, time.Second, func() (bool, error) {
+		return len(client.Actions()) >= 2, nil
+	}); err != nil {
+		t.Fatalf("expected the metrics to be listed every refresh interval, got %d lists", len(client.Actions()))
+	}
+	cs.Close()
+	time.Sleep(20 * time.Millisecond)
+
+	// scheduling cycles only read the cached metrics
+	listed := len(client.Actions())
+	s, err := cs.newPreScoreState(&v1.Pod{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if used := s.nodeUsage["node1"]; used != 1<<30 {
+		t.Errorf("expected node1 to use %d, got %d", 1<<30, used)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := len(client.Actions()); got != listed {
+		t.Errorf("expected no lists after Close or in PreScore, got %d more", got-listed)
+	}
+}
+
+// newMetricsClient returns a fake metrics API client holding the given
+// NodeMetrics.
+func newMetricsClient(t *testing.T, metrics ...*unstructured.Unstructured) *dynamicfake.FakeDynamicClient {
+	t.Helper()
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{nodeMetricsGVR: "NodeMetricsList"})
+	for _, m := range metrics {
+		if _, err := client.Resource(nodeMetricsGVR).Create(context.Background(), m, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("fail to create NodeMetrics: %v", err)
+		}
+	}
+	return client
+}
+
+func makeNodeMetrics(name, memory string, timestamp time.Time) *unstructured.Unstructured {
+	m := &unstructured.Unstructured{}
+	m.SetAPIVersion(nodeMetricsGVR.GroupVersion().String())
+	m.SetKind("NodeMetrics")
+	m.SetName(name)
+	_ = unstructured.SetNestedField(m.Object, timestamp.Format(time.RFC3339), "timestamp")
+	_ = unstructured.SetNestedField(m.Object, memory, "usage", "memory")
+	return m
+}
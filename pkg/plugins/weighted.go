@@ -0,0 +1,72 @@
+package plugins
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// ResourceSpec is a resource the Weighted mode scores on and its weight.
+type ResourceSpec struct {
+	Name   string `json:"name"`
+	Weight int64  `json:"weight"`
+}
+
+// defaultResources are scored by the Weighted mode when none are configured.
+var defaultResources = []ResourceSpec{
+	{Name: string(v1.ResourceMemory), Weight: 1},
+	{Name: string(v1.ResourceCPU), Weight: 1},
+}
+
+// weightedScore scores a node by the weighted average of the fractions of
+// each resource left on it once the pod's requests are placed. A node that
+// doesn't offer a scalar resource has none of it left.
+func (cs *CustomScheduler) weightedScore(requests v1.ResourceList, nodeInfo *framework.NodeInfo) int64 {
+	var score, weights float64
+	for _, r := range cs.config().resources {
+		weights += float64(r.Weight)
+		resourceName := v1.ResourceName(r.Name)
+		free, _ := freeAfter(requests, nodeInfo, resourceName)
+		allocatable := allocatableAmount(nodeInfo, resourceName)
+		if allocatable <= 0 {
+			klog.V(5).InfoS("Node has none of the resource", "node", klog.KObj(nodeInfo.Node()), "resource", resourceName)
+			continue
+		}
+		if free > 0 {
+			score += float64(r.Weight) * float64(free) / float64(allocatable)
+		}
+	}
+	if weights == 0 {
+		return 0
+	}
+	return int64(score / weights * float64(framework.MaxNodeScore))
+}
+
+// allocatableAmount returns the allocatable amount of a resource of the node,
+// in the unit freeAfter uses.
+func allocatableAmount(nodeInfo *framework.NodeInfo, resourceName v1.ResourceName) int64 {
+	return resourceAmount(nodeInfo.Allocatable, resourceName)
+}
+
+// resourceAmount returns the amount of a resource held in r, in the unit
+// freeAfter uses.
+func resourceAmount(r *framework.Resource, resourceName v1.ResourceName) int64 {
+	switch resourceName {
+	case v1.ResourceCPU:
+		return r.MilliCPU
+	case v1.ResourceMemory:
+		return r.Memory
+	case v1.ResourcePods:
+		return int64(r.AllowedPodNumber)
+	}
+	return r.ScalarResources[resourceName]
+}
+
+// weightedSubScorer scores the nodes in the Weighted mode.
+type weightedSubScorer struct {
+	cs *CustomScheduler
+}
+
+func (w weightedSubScorer) subScore(s *preScoreState, nodeInfo *framework.NodeInfo) int64 {
+	return w.cs.weightedScore(s.requests, nodeInfo)
+}
@@ -0,0 +1,63 @@
+package plugins
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestCustomScheduler_Score_Weighted(t *testing.T) {
+	const gpu = "nvidia.com/gpu"
+	newNodeInfos := func() []*framework.NodeInfo {
+		// cpu-rich has 3/4 of its CPU and 1/4 of its memory left, mem-rich
+		// the other way around, and only mem-rich has GPUs
+		cpuRich, memRich := makeNodeInfo("cpu-rich", 4000, 4<<30), makeNodeInfo("mem-rich", 4000, 4<<30)
+		cpuRich.Requested.MilliCPU, cpuRich.Requested.Memory = 1000, 3<<30
+		memRich.Requested.MilliCPU, memRich.Requested.Memory = 3000, 1<<30
+		memRich.Node().Status.Allocatable[gpu] = *resource.NewQuantity(4, resource.DecimalSI)
+		memRich.SetNode(memRich.Node())
+		return []*framework.NodeInfo{cpuRich, memRich}
+	}
+	tests := []struct {
+		name      string
+		resources []ResourceSpec
+		wantBest  string
+	}{
+		{
+			name:      "memory weighs more",
+			resources: []ResourceSpec{{Name: "memory", Weight: 3}, {Name: "cpu", Weight: 1}},
+			wantBest:  "mem-rich",
+		},
+		{
+			name:      "cpu weighs more",
+			resources: []ResourceSpec{{Name: "memory", Weight: 1}, {Name: "cpu", Weight: 3}},
+			wantBest:  "cpu-rich",
+		},
+		{
+			name:      "missing gpus count as none left",
+			resources: []ResourceSpec{{Name: "memory", Weight: 1}, {Name: "cpu", Weight: 3}, {Name: gpu, Weight: 4}},
+			wantBest:  "mem-rich",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeInfos := newNodeInfos()
+			cs := &CustomScheduler{
+				handle:    newTestFrameworkWithNodes(t, nil, nodeInfos),
+				scoreMode: weightedMode,
+				resources: tt.resources,
+			}
+			for _, score := range scoreNodes(t, cs, &v1.Pod{}, nodeInfos) {
+				want := framework.MinNodeScore
+				if score.Name == tt.wantBest {
+					want = framework.MaxNodeScore
+				}
+				if score.Score != want {
+					t.Errorf("expected node %s to score %d, got %d", score.Name, want, score.Score)
+				}
+			}
+		})
+	}
+}